@@ -1,29 +1,67 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
-	"io/fs"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/internal/audit"
 	"github.com/loop-hub/code-on-rails/internal/config"
 	"github.com/loop-hub/code-on-rails/internal/detector"
+	"github.com/loop-hub/code-on-rails/internal/engine"
+	"github.com/loop-hub/code-on-rails/internal/fixer"
+	"github.com/loop-hub/code-on-rails/internal/github"
 	"github.com/loop-hub/code-on-rails/internal/matcher"
 	"github.com/loop-hub/code-on-rails/internal/reporter"
+	"github.com/loop-hub/code-on-rails/internal/server"
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
 	"github.com/spf13/cobra"
 )
 
+// version is the released version of cr
+const version = "0.1.0"
+
 var (
 	verbose   bool
+	quiet     bool
 	aiModel   string
 	threshold float64
 	format    string
 	repoURL   string
 	commitSHA string
+
+	patternFilter []string
+	strictVersion bool
+	postComment   bool
+	prNumber      int
+	includeTests  bool
+	includeGen    bool
+	outputPath    string
+	reportExpired bool
+	changedOnly   bool
+	metricsPath   string
+	baselinePath  string
+	referencePath string
+	referenceRev  string
+	configFlag    string
+	breakdown     bool
+	groupDevs     bool
+	profiling     bool
+	cpuProfile    string
+	branchName    string
+	compactJSON   bool
+	maxDevs       int
+	refreshRemote bool
+	histogram     bool
+	strictImports bool
+	ignore        []string
 )
 
 func main() {
@@ -32,18 +70,30 @@ func main() {
 		Short: "Code on Rails - Pattern enforcement for AI-generated code",
 		Long: `Code on Rails learns your codebase patterns and ensures every AI-generated 
 change fits your architecture. Works locally and in CI/CD.`,
+		PersistentPreRunE: useConfigDir,
 	}
 
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&configFlag, "config", "", "config file (default: the nearest "+config.ConfigFileName+" or "+config.AltConfigFileName+" from here up to the git root)")
 
 	// Add commands
 	rootCmd.AddCommand(initCmd())
 	rootCmd.AddCommand(checkCmd())
 	rootCmd.AddCommand(learnCmd())
 	rootCmd.AddCommand(blessCmd())
+	rootCmd.AddCommand(markAICmd())
 	rootCmd.AddCommand(feedbackCmd())
+	rootCmd.AddCommand(fixCmd())
+	rootCmd.AddCommand(migrateCmd())
+	rootCmd.AddCommand(similarityCmd())
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(listCmd())
+	rootCmd.AddCommand(patternCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(serveCmd())
 	rootCmd.AddCommand(versionCmd())
+	rootCmd.AddCommand(introspectCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -53,16 +103,83 @@ change fits your architecture. Works locally and in CI/CD.`,
 
 func initCmd() *cobra.Command {
 	var language string
+	var languageOverrides map[string]string
+	var force bool
+	var appendLang bool
+	var minExamples int
+	var strict bool
+	var interactive bool
+	var mergeMissing bool
 
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Bootstrap patterns from existing codebase",
-		Long:  `Analyze your codebase and automatically extract common patterns.`,
+		Long: `Analyze your codebase and automatically extract common patterns.
+
+With --append, patterns for another language are learned into an existing
+config, keeping its patterns and settings, e.g. for a TypeScript frontend in
+a Go repo:
+  cr init --append --language typescript
+
+With --merge-missing, an existing config only gains the pattern types it
+lacks, e.g. for new modules, and new example files for the patterns it has;
+their goldens, blessings and settings are left as they are.
+
+With --interactive, each learned pattern is shown with sample files to keep,
+rename or discard before the config is written.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Check if config already exists
+			if minExamples < 0 {
+				return fmt.Errorf("--min-examples must not be negative")
+			}
+			if appendLang && force {
+				return fmt.Errorf("--append and --force can't be combined")
+			}
+			if mergeMissing && (force || appendLang) {
+				return fmt.Errorf("--merge-missing can't be combined with --force or --append")
+			}
+			if (appendLang || mergeMissing) && interactive {
+				return fmt.Errorf("--interactive only applies to a new config, not --append or --merge-missing")
+			}
+
+			stopProfiling, err := startProfiling()
+			if err != nil {
+				return err
+			}
+			defer stopProfiling()
+
+			unlock, err := config.Lock("")
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			if appendLang {
+				return appendLanguage(language, minExamples, strict)
+			}
+			if mergeMissing {
+				return mergeMissingPatterns(minExamples, strict)
+			}
+
+			// Check if config already exists. Overwriting keeps hand-written
+			// patterns, and disabled patterns stay disabled.
+			var declared []patterns.Pattern
+			disabled := make(map[string]bool)
 			if config.Exists("") {
-				fmt.Println("Configuration file already exists. Use --force to overwrite.")
-				return nil
+				if !force {
+					fmt.Println("Configuration file already exists. Use --merge-missing to add what it lacks, or --force to overwrite.")
+					return nil
+				}
+				existing, err := config.Load("")
+				if err != nil {
+					return fmt.Errorf("failed to load existing config: %w", err)
+				}
+				for _, p := range existing.Patterns {
+					if p.IsDeclared() {
+						declared = append(declared, p)
+					} else if !p.IsEnabled() {
+						disabled[p.ID] = true
+					}
+				}
 			}
 
 			// Auto-detect language if not specified
@@ -73,18 +190,32 @@ func initCmd() *cobra.Command {
 			fmt.Println("Initializing Code on Rails...")
 			fmt.Printf("Language: %s\n\n", language)
 
-			// Create analyzer
-			a := analyzer.New(language)
-
-			// Extract patterns
-			patterns, err := a.ExtractPatterns(".")
+			// Extract patterns, per directory when languages are overridden
+			settings := config.Settings{LanguageOverrides: languageOverrides, MinExamples: minExamples}
+			patterns, err := extractPatterns(language, settings, includeTests, nil)
 			if err != nil {
 				return fmt.Errorf("failed to extract patterns: %w", err)
 			}
 
 			// Create configuration
 			cfg := config.NewDefault(language)
-			cfg.Patterns = patterns
+			if interactive {
+				patterns = config.CuratePatterns(os.Stdin, os.Stdout, patterns, &cfg.Settings)
+			}
+			cfg.Patterns = withDeclaredPatterns(patterns, declared)
+			for i := range cfg.Patterns {
+				if disabled[cfg.Patterns[i].ID] {
+					cfg.Patterns[i].Enabled = new(bool)
+				}
+			}
+			if len(languageOverrides) > 0 {
+				cfg.Settings.LanguageOverrides = languageOverrides
+			}
+			cfg.Settings.MinExamples = minExamples
+			cfg.Settings.IncludeGenerated = includeGen
+			if err := validateGoldens(cfg, strict); err != nil {
+				return err
+			}
 
 			// Save configuration
 			if err := config.Save(cfg, ""); err != nil {
@@ -98,46 +229,198 @@ func initCmd() *cobra.Command {
 			}
 
 			// Report results
-			rep := reporter.New(verbose)
+			rep := newReporter()
+			if len(languageOverrides) > 0 {
+				language = "" // Files span several languages
+			}
 			rep.ReportInit(patterns, totalFiles, language)
+			if len(declared) > 0 {
+				fmt.Printf("→ Kept %d hand-written pattern(s)\n", len(declared))
+			}
 
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&language, "language", "l", "", "programming language (auto-detected if not specified)")
+	cmd.Flags().IntVar(&minExamples, "min-examples", 0, "files needed to learn a pattern (default 3 for Go, 2 for TypeScript)")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config, keeping hand-written patterns")
+	cmd.Flags().BoolVar(&appendLang, "append", false, "learn --language patterns into the existing config")
+	cmd.Flags().StringToStringVar(&languageOverrides, "language-override", nil, "language for a directory, e.g. web=typescript (repeatable)")
+	cmd.Flags().BoolVar(&includeTests, "include-tests", false, "learn test file conventions as a test pattern")
+	cmd.Flags().BoolVar(&includeGen, "include-generated", false, "also learn from generated Go files (remembered in settings.include_generated)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail instead of warning (with --verbose) when a golden example doesn't follow its pattern")
+	cmd.Flags().BoolVar(&mergeMissing, "merge-missing", false, "add only pattern types and example files the existing config lacks, leaving its patterns as they are")
+	cmd.Flags().BoolVar(&interactive, "interactive", false, "review each learned pattern, keeping, renaming or discarding it, and the auto-approve threshold before writing the config")
+	addProfileFlags(cmd)
 
 	return cmd
 }
 
+// appendLanguage learns patterns for language into the existing config.
+// Patterns previously appended for the language are replaced; everything
+// else, including settings, is kept.
+func appendLanguage(language string, minExamples int, strict bool) error {
+	if language == "" {
+		return fmt.Errorf("--append needs --language")
+	}
+	if !config.Exists("") {
+		return fmt.Errorf("no configuration to append to (run 'cr init' first)")
+	}
+	cfg, err := config.Load("")
+	if err != nil {
+		return fmt.Errorf("failed to load existing config: %w", err)
+	}
+
+	fmt.Printf("Appending %s patterns...\n\n", language)
+	settings := config.Settings{
+		MinExamples:         minExamples,
+		MaxFileBytes:        cfg.Settings.MaxFileBytes,
+		IncludeGenerated:    cfg.Settings.IncludeGenerated,
+		CurrentPlatformOnly: cfg.Settings.CurrentPlatformOnly,
+	}
+	if minExamples == 0 {
+		settings.MinExamples = cfg.Settings.MinExamples
+	}
+	found, err := extractPatterns(language, settings, includeTests, nil)
+	if err != nil {
+		return fmt.Errorf("failed to extract patterns: %w", err)
+	}
+
+	if err := cfg.AppendLanguage(language, found); err != nil {
+		return err
+	}
+	if err := validateGoldens(cfg, strict); err != nil {
+		return err
+	}
+
+	if err := config.Save(cfg, ""); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	totalFiles := 0
+	for _, p := range found {
+		totalFiles += p.SeenCount
+	}
+	newReporter().ReportInit(found, totalFiles, language)
+	fmt.Printf("→ Config now covers %s\n", strings.Join(cfg.Languages, ", "))
+	return nil
+}
+
 func checkCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "check [files...]",
+		Use:   "check [files... | @argfile]",
 		Short: "Check files against established patterns",
 		Long:  `Validate AI-generated code against your codebase patterns.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			// Load configuration
+			config.RefreshRemote = refreshRemote
 			cfg, err := config.Load("")
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
 			}
 
+			// Validate pattern filter
+			typeFilter, err := parsePatternFilter(patternFilter)
+			if err != nil {
+				return err
+			}
+
+			if postComment && format != "github" {
+				return fmt.Errorf("--post requires --format github")
+			}
+			if referencePath != "" {
+				if _, err := os.Stat(referencePath); err != nil {
+					return fmt.Errorf("--reference: %w", err)
+				}
+			}
+			// Read the baseline before this run's metrics may be appended to it
+			var baseline *reporter.MetricsRecord
+			if baselinePath != "" {
+				if baseline, err = reporter.LoadBaselineMetrics(baselinePath); err != nil {
+					return fmt.Errorf("--compare-baseline-metrics: %w", err)
+				}
+			}
+
+			// Write reports to --output when given
+			out, err := openOutput(outputPath)
+			if err != nil {
+				return err
+			}
+			defer closeOutput(out, &err)
+
+			stopProfiling, err := startProfiling()
+			if err != nil {
+				return err
+			}
+			defer stopProfiling()
+
 			// Override threshold if specified
 			if threshold > 0 {
 				cfg.Settings.AutoApproveThreshold = threshold
 			}
+			if err := cfg.Settings.ExitCodes.Validate(); err != nil {
+				return fmt.Errorf("invalid exit_codes in config: %w", err)
+			}
 
 			// Detect language if not configured
-			lang := cfg.Language
-			if lang == "" {
-				lang = detectLanguage(".")
-			}
+			lang := configLanguage(cfg)
 
-			// Get files to check
-			files := args
-			if len(files) == 0 {
+			// Get files to check, expanding @argfiles and directories
+			listed := len(args) > 0
+			if args, err = detector.ExpandArgFiles(args, argRebase); err != nil {
+				return err
+			}
+			if listed && len(args) == 0 {
+				if (format == "" || format == "text") && !quiet {
+					fmt.Fprintln(out, "No files to check.")
+				}
+				return recordMetrics(nil)
+			}
+			walkStart := time.Now()
+			eng := engine.New(cfg)
+			eng.Language = lang
+			eng.Branch = branchName
+			eng.IncludeTests = includeTests
+			eng.TypeFilter = typeFilter
+			eng.StrictVersion = strictVersion
+			eng.StrictImports = eng.StrictImports || strictImports
+			eng.Ignore = ignore
+			eng.ReferencePath = referencePath
+			warnDetection(cfg)
+			det := eng.Detector()
+			files, err := eng.Files(args)
+			if err != nil {
+				return err
+			}
+			if reportExpired {
+				if len(args) == 0 {
+					if files, err = det.FilesInDir("."); err != nil {
+						return fmt.Errorf("failed to scan repository: %w", err)
+					}
+				}
+				rep := newReporter()
+				rep.Out = out
+				rep.ReportExpiredSuppressions(analyzer.NewAnnotationParser().FindExpiredAllows(files, time.Now()))
+				return nil
+			}
+			if changedOnly {
+				if len(args) > 0 {
+					return fmt.Errorf("--changed-only doesn't take file arguments")
+				}
+				if _, err := detector.GitRoot("."); err != nil {
+					return fmt.Errorf("--changed-only needs a git repository")
+				}
+				files, err = det.ChangedFiles(".")
+				if err != nil {
+					return err
+				}
+				if len(files) == 0 && (format == "" || format == "text") && !quiet {
+					fmt.Fprintln(out, "No changed files to check.")
+					return recordMetrics(nil)
+				}
+			} else if len(args) == 0 {
 				// Detect AI-generated files
-				det := detector.NewWithLanguage(&cfg.Detection, lang)
 				files, err = det.DetectFiles(".")
 				if err != nil {
 					return fmt.Errorf("failed to detect AI files: %w", err)
@@ -145,35 +428,75 @@ func checkCmd() *cobra.Command {
 
 				if len(files) == 0 {
 					if format == "json" {
-						fmt.Println(`{"summary":{"total_files":0},"auto_approved":[],"needs_review":[]}`)
+						fmt.Fprintln(out, `{"summary":{"total_files":0},"auto_approved":[],"needs_review":[]}`)
+					} else if format == "agent" {
+						fmt.Fprintln(out, newReporter().FormatAgentSummary(nil, lang))
 					} else if format == "github" {
-						fmt.Println("## 🤖 Code on Rails\n\n✨ No AI-generated code detected in this PR.")
-					} else {
-						fmt.Println("No AI-generated files found.")
-						fmt.Printf("Detected language: %s\n", lang)
+						fmt.Fprintln(out, "## 🤖 Code on Rails\n\n✨ No AI-generated code detected in this PR.")
+					} else if format == "markdown" {
+						fmt.Fprint(out, newReporter().ReportMarkdown(nil, lang))
+					} else if format == "shield" {
+						fmt.Fprintln(out, newReporter().ReportShield(nil))
+					} else if !quiet {
+						fmt.Fprintln(out, "No AI-generated files found.")
+						fmt.Fprintf(out, "Detected language: %s\n", lang)
 					}
-					return nil
+					return recordMetrics(nil)
 				}
 			}
 
-			// Create matcher
-			m := matcher.New(cfg.Patterns, cfg.Settings.AutoApproveThreshold)
+			prof.Stage("walk", walkStart)
 
-			// Match each file
-			matches := []patterns.PatternMatch{}
-			for _, file := range files {
-				match, err := m.MatchFile(file)
+			// Configure the matcher
+			if err := eng.Err(); err != nil {
+				return err
+			}
+			m := eng.Matcher()
+			m.Profile = prof
+			m.OnDegraded = warnDegraded
+			if referenceRev != "" {
+				commit, err := detector.ResolveRevision(".", referenceRev)
 				if err != nil {
-					if verbose {
-						fmt.Printf("Warning: failed to match %s: %v\n", file, err)
-					}
-					continue
+					return fmt.Errorf("--reference-ref: %w", err)
 				}
-				matches = append(matches, *match)
+				m.ReadReference = detector.RevisionReader(".", commit)
 			}
 
 			// Report results based on format
-			rep := reporter.New(verbose)
+			rep := newReporter()
+			rep.Explicit = len(args) > 0 || changedOnly
+			rep.Quiet = quiet
+			rep.ScoreBreakdown = breakdown || verbose
+			rep.GroupDeviations = groupDevs
+			rep.Compact = compactJSON
+			rep.MaxDeviations = maxDevs
+			rep.Histogram = histogram
+			rep.SystemicThreshold = cfg.Settings.SystemicThreshold
+			rep.ReviewLinesPerMinute = cfg.Settings.ReviewLinesPerMinute
+			rep.Baseline = baseline
+			rep.ApprovalDropThreshold = cfg.Settings.ApprovalDropThreshold
+			rep.Out = out
+
+			// Match each file
+			eng.Progress = newProgress("Matching")
+			eng.OnError = func(file string, err error) {
+				if verbose {
+					fmt.Fprintf(os.Stderr, "Warning: failed to match %s: %v\n", file, err)
+				}
+			}
+			if format == "agent" {
+				// Stream files to fix as soon as they're matched
+				eng.OnMatch = func(match patterns.PatternMatch) {
+					if line, ok := rep.FormatAgentFile(match); ok {
+						fmt.Fprintln(out, line)
+					}
+				}
+			}
+			matches, err := eng.CheckFiles(files)
+			if err != nil {
+				return err
+			}
+			rep.NewPatterns = eng.PatternCandidates(matches)
 
 			// Get GitHub context from environment if not specified
 			if repoURL == "" {
@@ -188,23 +511,38 @@ func checkCmd() *cobra.Command {
 
 			switch format {
 			case "json":
-				fmt.Println(rep.ReportJSON(matches, lang))
+				fmt.Fprintln(out, rep.ReportJSON(matches, lang))
 			case "github":
-				fmt.Println(rep.FormatForGitHub(matches, repoURL, commitSHA))
+				body := rep.FormatForGitHub(matches, repoURL, commitSHA)
+				fmt.Fprintln(out, body)
+				if postComment {
+					if err := postGitHubComment(body); err != nil {
+						return fmt.Errorf("failed to post PR comment: %w", err)
+					}
+					fmt.Fprintln(os.Stderr, "Posted review comment to pull request")
+				}
+			case "agent":
+				fmt.Fprintln(out, rep.FormatAgentSummary(matches, lang))
+			case "markdown":
+				fmt.Fprint(out, rep.ReportMarkdown(matches, lang))
+			case "shield":
+				fmt.Fprintln(out, rep.ReportShield(matches))
 			default:
 				rep.Report(matches)
 			}
 
-			// Exit with error if any files failed (only in default mode)
+			if err := recordMetrics(matches); err != nil {
+				return err
+			}
+
+			// Exit with settings.exit_codes for the worst outcome (only in default mode)
 			if format == "" {
-				for _, match := range matches {
-					if !match.AutoApprove {
-						for _, dev := range match.Deviations {
-							if dev.Severity == patterns.SeverityError {
-								os.Exit(1)
-							}
-						}
+				if code := cfg.Settings.ExitCodes.For(matches); code != 0 {
+					stopProfiling()
+					if err := out.Close(); err != nil {
+						return fmt.Errorf("failed to write output file: %w", err)
 					}
+					os.Exit(code)
 				}
 			}
 
@@ -213,10 +551,33 @@ func checkCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&aiModel, "ai-model", "a", "", "filter by AI model (claude, copilot, cursor, any)")
-	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json, github, or default (text)")
+	cmd.Flags().StringVar(&branchName, "branch", "", "branch name for the branch detection method, e.g. in CI with a detached HEAD (default: GITHUB_HEAD_REF, GITHUB_REF_NAME or git)")
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json, github, markdown, shield (shields.io badge JSON), agent (NDJSON stream), or default (text)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "write the report to this file instead of stdout")
+	cmd.Flags().BoolVar(&compactJSON, "compact", false, "write the json report on a single line instead of indented, e.g. for CI logs")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "print only files needing review and a one-line summary (text format)")
 	cmd.Flags().StringVar(&repoURL, "repo-url", "", "GitHub repository URL (for github format links)")
 	cmd.Flags().StringVar(&commitSHA, "sha", "", "Git commit SHA (for github format links)")
+	cmd.Flags().BoolVar(&postComment, "post", false, "post the github format comment to the PR (needs GITHUB_TOKEN and GITHUB_REPOSITORY)")
+	cmd.Flags().IntVar(&prNumber, "pr", 0, "pull request number for --post (defaults to GITHUB_REF)")
 	cmd.Flags().Float64VarP(&threshold, "threshold", "t", 0, "auto-approve threshold (0-100)")
+	cmd.Flags().BoolVar(&strictVersion, "strict-version", false, "treat files matching an old pattern version as needs-review")
+	cmd.Flags().StringSliceVar(&ignore, "ignore", nil, "drop deviations of this element, e.g. import_alias, on top of settings.ignore_deviations (repeatable)")
+	cmd.Flags().BoolVar(&strictImports, "strict-imports", false, "note imports the reference doesn't have, and warn about ones settings.forbidden_imports rules out (e.g. net/http in a repository)")
+	cmd.Flags().StringSliceVar(&patternFilter, "pattern", nil, "only check files matching these pattern types (e.g. api,http_handler)")
+	cmd.Flags().BoolVar(&includeTests, "include-tests", false, "also check test files against the test pattern")
+	cmd.Flags().StringVar(&metricsPath, "append-metrics", "", "append a JSON summary of this run to a JSONL file for trend tracking")
+	cmd.Flags().StringVar(&baselinePath, "compare-baseline-metrics", "", "flag patterns whose approval rate dropped since the last run recorded in this metrics file")
+	cmd.Flags().BoolVar(&histogram, "histogram", false, "add how many files of each pattern type score 95-100, 80-95, 60-80 and below 60 (text and json formats)")
+	cmd.Flags().IntVar(&maxDevs, "max-deviations", 0, "list at most this many deviations per file, most severe first, noting how many more there are (0 for all; exit codes still count every one)")
+	cmd.Flags().BoolVar(&groupDevs, "group-deviations", true, "fold a file's deviations of the same kind, e.g. missing imports, into one entry (text and github formats)")
+	cmd.Flags().BoolVar(&breakdown, "score-breakdown", false, "include each file's score components in JSON output (implied by --verbose)")
+	cmd.Flags().StringVar(&referencePath, "reference", "", "match files against this reference file only, bypassing the configured patterns")
+	cmd.Flags().StringVar(&referenceRev, "reference-ref", "", "read reference examples as of this git revision (e.g. main) instead of the working tree")
+	cmd.Flags().BoolVar(&refreshRemote, "refresh-remote", false, "fetch the patterns_url library even if the cached copy is recent")
+	cmd.Flags().BoolVar(&changedOnly, "changed-only", false, "check only files with uncommitted changes (staged, unstaged or untracked)")
+	cmd.Flags().BoolVar(&reportExpired, "report-expired-suppressions", false, "list allow annotations past their expires date instead of checking")
+	addProfileFlags(cmd)
 
 	return cmd
 }
@@ -225,6 +586,9 @@ func learnCmd() *cobra.Command {
 	var days int
 	var updateSkills bool
 	var skillsFile string
+	var sinceTag, untilTag string
+	var inferAnti bool
+	var strict bool
 
 	cmd := &cobra.Command{
 		Use:   "learn",
@@ -236,11 +600,32 @@ The --update-skills flag generates a portable skills file that can be:
   - Stored in a central skills repository for enterprise use
   - Used as input for AI assistants to understand codebase conventions
 
+The --since-tag flag learns only from files changed between two releases
+instead, capturing how code is written now. Changed files are read from the
+working tree.
+
+The --infer-anti flag also lists code from the same period that was removed
+or mostly rewritten within two weeks of being added. These are only
+suggestions; confirm one with 'cr bless --anti <file>' to record it.
+
 Examples:
   cr learn                              # Update local patterns
+  cr learn --infer-anti                 # Also list possible anti-patterns
+  cr learn --since-tag v1.2.0           # Learn from changes since v1.2.0
+  cr learn --since-tag v1.2.0 --until-tag v1.3.0
   cr learn --update-skills              # Generate .code-on-rails-skills.json
   cr learn --update-skills -s custom.json  # Custom output file`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if untilTag != "" && sinceTag == "" {
+				return fmt.Errorf("--until-tag requires --since-tag")
+			}
+
+			unlock, err := config.Lock("")
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
 			// Load configuration
 			cfg, err := config.Load("")
 			if err != nil {
@@ -248,22 +633,47 @@ Examples:
 			}
 
 			// Detect language if not configured
-			lang := cfg.Language
-			if lang == "" {
-				lang = detectLanguage(".")
-			}
+			lang := configLanguage(cfg)
 
-			fmt.Printf("Analyzing merged code from last %d days...\n", days)
-
-			// Get recently merged files
 			det := detector.New(&cfg.Detection)
-			files, err := det.GetRecentAIFiles(".", days)
-			if err != nil {
-				return fmt.Errorf("failed to get recent files: %w", err)
+			var files []string
+			if sinceTag != "" {
+				until := untilTag
+				if until == "" {
+					until = "HEAD"
+				}
+				fmt.Printf("Analyzing code changed between %s and %s...\n", sinceTag, until)
+				files, err = det.FilesBetweenTags(".", sinceTag, untilTag)
+				if err != nil {
+					return fmt.Errorf("failed to get release files: %w", err)
+				}
+			} else {
+				fmt.Printf("Analyzing merged code from last %d days...\n", days)
+
+				// Get recently merged files
+				files, err = det.GetRecentAIFiles(".", days)
+				if err != nil {
+					return fmt.Errorf("failed to get recent files: %w", err)
+				}
+			}
+
+			var candidates []patterns.AntiPatternCandidate
+			if inferAnti {
+				candidates, err = det.FindRewrittenCode(".", days)
+				if err != nil {
+					return fmt.Errorf("failed to infer anti-patterns: %w", err)
+				}
 			}
 
 			if len(files) == 0 {
-				fmt.Println("No recently merged AI-generated files found.")
+				if sinceTag != "" {
+					fmt.Println("No supported files changed in that range.")
+				} else {
+					fmt.Println("No recently merged AI-generated files found.")
+				}
+				if inferAnti {
+					newReporter().ReportAntiCandidates(candidates)
+				}
 
 				// Still generate skills file if requested
 				if updateSkills {
@@ -272,26 +682,22 @@ Examples:
 				return nil
 			}
 
-			// Re-analyze to find new patterns
-			a := analyzer.New(cfg.Language)
-			newPatterns, err := a.ExtractPatterns(".")
+			// Re-analyze to find new patterns, from just the release's
+			// files when a range was given
+			var learnFrom []string
+			if sinceTag != "" {
+				learnFrom = files
+			}
+			newPatterns, err := extractConfigPatterns(cfg, lang, false, learnFrom)
 			if err != nil {
 				return fmt.Errorf("failed to extract patterns: %w", err)
 			}
 
-			// Merge new patterns with existing (updates cfg.Patterns in-place)
-			updated := mergePatterns(cfg.Patterns, newPatterns)
+			// Merge new patterns with existing ones, adding those the config lacks
+			updated := cfg.MergeLearned(newPatterns)
 
-			// Add any new patterns that weren't in existing config
-			existingIDs := make(map[string]bool)
-			for _, p := range cfg.Patterns {
-				existingIDs[p.ID] = true
-			}
-			for _, newPat := range newPatterns {
-				if !existingIDs[newPat.ID] {
-					cfg.Patterns = append(cfg.Patterns, newPat)
-					updated++
-				}
+			if err := validateGoldens(cfg, strict); err != nil {
+				return err
 			}
 
 			// Save
@@ -300,8 +706,11 @@ Examples:
 			}
 
 			// Report
-			rep := reporter.New(verbose)
+			rep := newReporter()
 			rep.ReportLearn([]patterns.Pattern{}, updated)
+			if inferAnti {
+				rep.ReportAntiCandidates(candidates)
+			}
 
 			// Generate skills file if requested
 			if updateSkills {
@@ -313,6 +722,11 @@ Examples:
 	}
 
 	cmd.Flags().IntVarP(&days, "days", "d", 7, "number of days to look back")
+	cmd.Flags().StringVar(&sinceTag, "since-tag", "", "learn from files changed since this release tag")
+	cmd.Flags().StringVar(&untilTag, "until-tag", "", "end of the --since-tag range (default HEAD)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail instead of warning (with --verbose) when a golden example doesn't follow its pattern")
+	cmd.Flags().BoolVar(&inferAnti, "infer-anti", false, "list code removed or rewritten soon after it was added as possible anti-patterns")
+	cmd.Flags().BoolVar(&includeGen, "include-generated", false, "also learn from generated Go files this run")
 	cmd.Flags().BoolVar(&updateSkills, "update-skills", false, "generate portable skills file")
 	cmd.Flags().StringVarP(&skillsFile, "skills-file", "s", ".code-on-rails-skills.json", "skills file output path")
 
@@ -320,7 +734,7 @@ Examples:
 }
 
 func generateSkillsFile(patternList []patterns.Pattern, language, outputPath string) error {
-	rep := reporter.New(verbose)
+	rep := newReporter()
 	skills := rep.FormatSkillFile(patternList, language)
 
 	if err := os.WriteFile(outputPath, []byte(skills), 0644); err != nil {
@@ -340,20 +754,58 @@ func generateSkillsFile(patternList []patterns.Pattern, language, outputPath str
 func blessCmd() *cobra.Command {
 	var reason string
 	var weight float64
+	var anti bool
+	var allIn string
+	var minScore float64
+	var dryRun bool
+	var yes bool
 
 	cmd := &cobra.Command{
-		Use:   "bless <file>",
+		Use:   "bless <file> | --all-in <pattern>",
 		Short: "Mark a file as a blessed pattern example",
 		Long: `Bless a file to elevate it as a high-quality pattern reference.
-Blessed files have higher weight (1.5x by default) when matching patterns.`,
-		Args: cobra.ExactArgs(1),
+Blessed files have higher weight when matching patterns: settings.weights.blessed
+(1.5x by default) unless --weight is given.
+
+With --anti the file is recorded as an anti-pattern of the pattern it matches
+instead, e.g. to confirm a candidate listed by 'cr learn --infer-anti'. It is
+removed from that pattern's references.
+
+With --all-in every file matching the given pattern type (or pattern ID) at
+--min-score or above is blessed at once, e.g. to elevate a pattern's best
+examples after 'cr init'. Files already blessed or golden are skipped. The
+files are listed for confirmation first; --dry-run only lists them and --yes
+skips the prompt.`,
+		Example: `  cr bless internal/handlers/user_handler.go --reason "Template for handlers"
+  cr bless --all-in service --min-score 95 --dry-run`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if allIn != "" && len(args) > 0 {
+				return fmt.Errorf("--all-in blesses files itself; drop the file arguments")
+			}
+			if allIn != "" {
+				return nil
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filePath := args[0]
+			if allIn != "" && anti {
+				return fmt.Errorf("--anti can't be combined with --all-in")
+			}
+			var filePath string
+			if allIn == "" {
+				filePath = args[0]
+
+				// Verify file exists
+				if _, err := os.Stat(filePath); os.IsNotExist(err) {
+					return fmt.Errorf("file not found: %s", filePath)
+				}
+			}
 
-			// Verify file exists
-			if _, err := os.Stat(filePath); os.IsNotExist(err) {
-				return fmt.Errorf("file not found: %s", filePath)
+			unlock, err := config.Lock("")
+			if err != nil {
+				return err
 			}
+			defer unlock()
 
 			// Load configuration
 			cfg, err := config.Load("")
@@ -361,32 +813,42 @@ Blessed files have higher weight (1.5x by default) when matching patterns.`,
 				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
 			}
 
-			// Find which pattern this file belongs to
-			m := matcher.New(cfg.Patterns, cfg.Settings.AutoApproveThreshold)
-			match, err := m.MatchFile(filePath)
-			if err != nil {
-				return fmt.Errorf("failed to analyze file: %w", err)
+			if cfg.Settings.RequireBlessReason && strings.TrimSpace(reason) == "" {
+				return fmt.Errorf("--reason is required to bless files (settings.require_bless_reason)")
 			}
 
-			if match.Pattern == nil {
-				return fmt.Errorf("no matching pattern found for %s", filePath)
+			// Find which pattern the files belong to
+			eng := engine.New(cfg)
+			eng.Language = configLanguage(cfg)
+			if err := eng.Err(); err != nil {
+				return err
+			}
+			if weight == 0 {
+				weight = cfg.Settings.Weights.OrDefault().Blessed
+			}
+			if allIn != "" {
+				return blessAllIn(cfg, eng, allIn, minScore, reason, weight, dryRun, yes)
+			}
+
+			user := audit.GitUser()
+			if anti {
+				pattern, err := eng.BlessAnti(filePath, reason)
+				if err != nil {
+					return err
+				}
+				return saveAnti(cfg, pattern, filePath, user, reason)
 			}
 
 			// Add to config_blessed for the matched pattern
-			blessed := patterns.BlessedExample{
+			pattern, err := eng.Bless(patterns.BlessedExample{
 				Path:        filePath,
-				BlessedBy:   "config",
+				BlessedBy:   user,
 				BlessedDate: time.Now(),
 				Reason:      reason,
 				Weight:      weight,
-			}
-
-			// Find and update the pattern
-			for i, p := range cfg.Patterns {
-				if p.ID == match.Pattern.ID {
-					cfg.Patterns[i].ConfigBlessed = append(cfg.Patterns[i].ConfigBlessed, blessed)
-					break
-				}
+			})
+			if err != nil {
+				return err
 			}
 
 			// Save configuration
@@ -394,8 +856,19 @@ Blessed files have higher weight (1.5x by default) when matching patterns.`,
 				return fmt.Errorf("failed to save config: %w", err)
 			}
 
+			// Record the decision for later review
+			if err := audit.Append("", audit.Entry{
+				Action:  "bless",
+				Path:    filePath,
+				Pattern: pattern.ID,
+				User:    user,
+				Reason:  reason,
+			}); err != nil {
+				return err
+			}
+
 			fmt.Printf("✓ Blessed %s\n", filePath)
-			fmt.Printf("  Pattern: %s\n", match.Pattern.Name)
+			fmt.Printf("  Pattern: %s\n", pattern.Name)
 			fmt.Printf("  Weight: %.1fx\n", weight)
 			if reason != "" {
 				fmt.Printf("  Reason: %s\n", reason)
@@ -406,7 +879,140 @@ Blessed files have higher weight (1.5x by default) when matching patterns.`,
 	}
 
 	cmd.Flags().StringVarP(&reason, "reason", "r", "", "reason for blessing this file")
-	cmd.Flags().Float64VarP(&weight, "weight", "w", 1.5, "weight multiplier for pattern matching")
+	cmd.Flags().Float64VarP(&weight, "weight", "w", 0, "weight multiplier for pattern matching (default settings.weights.blessed, 1.5)")
+	cmd.Flags().BoolVar(&anti, "anti", false, "record the file as an anti-pattern instead")
+	cmd.Flags().StringVar(&allIn, "all-in", "", "bless every file matching this pattern type or ID at --min-score or above")
+	cmd.Flags().Float64Var(&minScore, "min-score", 90, "lowest match score (0-100) --all-in blesses")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "with --all-in, list the files that would be blessed without blessing them")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "with --all-in, bless without asking for confirmation")
+
+	return cmd
+}
+
+// blessAllIn blesses the files matching patterns of type (or ID) selector
+// with a score of at least minScore, leaving out the pattern's golden and
+// blessed examples so running it again blesses nothing new. Unless yes is
+// set it lists the files and asks for confirmation first.
+func blessAllIn(cfg *config.Config, eng *engine.Engine, selector string, minScore float64, reason string, weight float64, dryRun, yes bool) error {
+	selected, err := eng.BlessCandidates(selector, minScore)
+	if err != nil {
+		return err
+	}
+
+	if len(selected) == 0 {
+		fmt.Printf("No %s files scoring %.0f or more left to bless\n", selector, minScore)
+		return nil
+	}
+	fmt.Printf("%d %s file(s) scoring %.0f or more:\n", len(selected), selector, minScore)
+	for _, c := range selected {
+		fmt.Printf("  %s (%.0f%% match)\n", c.Path, c.Score)
+	}
+	if dryRun {
+		fmt.Println("\nDry run: nothing blessed")
+		return nil
+	}
+	if !yes {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("not a terminal: pass --yes to bless without confirmation")
+		}
+		fmt.Print("\nBless them? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Println("Nothing blessed")
+			return nil
+		}
+	}
+
+	user := audit.GitUser()
+	if err := eng.BlessAll(selected, patterns.BlessedExample{
+		BlessedBy:   user,
+		BlessedDate: time.Now(),
+		Reason:      reason,
+		Weight:      weight,
+	}); err != nil {
+		return err
+	}
+	if err := config.Save(cfg, ""); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	for _, c := range selected {
+		if err := audit.Append("", audit.Entry{
+			Action:  "bless",
+			Path:    c.Path,
+			Pattern: c.Pattern,
+			User:    user,
+			Reason:  reason,
+		}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\n✓ Blessed %d file(s)\n", len(selected))
+	fmt.Printf("  Weight: %.1fx\n", weight)
+	if reason != "" {
+		fmt.Printf("  Reason: %s\n", reason)
+	}
+	return nil
+}
+
+// saveAnti saves the config after cr bless --anti recorded an anti-pattern
+// on pattern, auditing the decision
+func saveAnti(cfg *config.Config, pattern *patterns.Pattern, filePath, user, reason string) error {
+	if err := config.Save(cfg, ""); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := audit.Append("", audit.Entry{
+		Action:  "bless-anti",
+		Path:    filePath,
+		Pattern: pattern.ID,
+		User:    user,
+		Reason:  reason,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Recorded %s as an anti-pattern\n", filePath)
+	fmt.Printf("  Pattern: %s\n", pattern.Name)
+	if reason != "" {
+		fmt.Printf("  Reason: %s\n", reason)
+	}
+	return nil
+}
+
+func markAICmd() *cobra.Command {
+	var source string
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "mark-ai [commits...]",
+		Short: "Record AI provenance for commits as git notes",
+		Long: `Attach a code-on-rails git note marking commits as AI-generated, without
+touching their messages. Files changed by marked commits are detected when
+detection.method is git_notes (or all). Defaults to HEAD.
+
+Notes are not pushed or fetched by default; share them with:
+  git push origin refs/notes/code-on-rails
+  git fetch origin refs/notes/code-on-rails:refs/notes/code-on-rails
+
+Examples:
+  cr mark-ai --source claude
+  cr mark-ai abc123 def456 --source copilot --reason "generated handlers"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"HEAD"}
+			}
+			for _, commit := range args {
+				if err := detector.AddAINote(".", commit, source, reason); err != nil {
+					return err
+				}
+				fmt.Printf("✓ Marked %s as AI-generated\n", commit)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&source, "source", "", "AI tool that generated the code (e.g. claude, copilot)")
+	cmd.Flags().StringVarP(&reason, "reason", "r", "", "note to record alongside the marker")
 
 	return cmd
 }
@@ -439,17 +1045,17 @@ Examples:
 			}
 
 			// Detect language if not configured
-			lang := cfg.Language
-			if lang == "" {
-				lang = detectLanguage(".")
-			}
+			lang := configLanguage(cfg)
 
-			// Get files to check
-			files := args
-			if len(files) == 0 {
+			// Get files to check, expanding directories
+			eng := newEngine(cfg, lang)
+			files, err := eng.Files(args)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
 				// Detect AI-generated files
-				det := detector.NewWithLanguage(&cfg.Detection, lang)
-				files, err = det.DetectFiles(".")
+				files, err = eng.Detect(".")
 				if err != nil {
 					return fmt.Errorf("failed to detect AI files: %w", err)
 				}
@@ -471,25 +1077,16 @@ Examples:
 				}
 			}
 
-			// Create matcher
-			m := matcher.New(cfg.Patterns, cfg.Settings.AutoApproveThreshold)
-
 			// Match each file
-			matches := []patterns.PatternMatch{}
-			for _, file := range files {
-				match, err := m.MatchFile(file)
-				if err != nil {
-					if verbose {
-						fmt.Fprintf(os.Stderr, "Warning: failed to match %s: %v\n", file, err)
-					}
-					continue
-				}
-				matches = append(matches, *match)
+			matches, err := eng.CheckFiles(files)
+			if err != nil {
+				return err
 			}
 
 			// Generate AI feedback
-			rep := reporter.New(verbose)
-			feedback := rep.FormatAIFeedback(matches, lang, cfg.Patterns)
+			rep := newReporter()
+			rep.Explicit = len(args) > 0
+			feedback := eng.Feedback(rep, matches)
 
 			// Output to file or stdout
 			if outputFile != "" {
@@ -520,50 +1117,555 @@ Examples:
 	return cmd
 }
 
-func versionCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "version",
-		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Code on Rails v0.1.0 (PoC)")
-		},
-	}
-}
-
-// Helper functions
+func fixCmd() *cobra.Command {
+	var dryRun bool
 
-func detectLanguage(path string) string {
-	// Detect language based on project files
-	if path == "" {
-		path = "."
-	}
+	cmd := &cobra.Command{
+		Use:   "fix [files...]",
+		Short: "Automatically apply safe fixes for pattern deviations",
+		Long: `Apply mechanical fixes for deviations, such as inserting missing imports.
 
-	// Check for Go
-	if fileExists(path + "/go.mod") {
-		return "go"
-	}
+Only the files passed explicitly (or AI-detected files when none are given)
+are touched. Deviations that can't be fixed mechanically are left as-is and
+reported. Inserted imports still need to be used by the code.
 
-	// Check for TypeScript/React
-	if fileExists(path + "/tsconfig.json") {
-		// Check if it's a React project
-		if fileExists(path+"/package.json") && fileContains(path+"/package.json", "react") {
-			return "react"
-		}
-		return "typescript"
-	}
+Examples:
+  cr fix                          # Fix AI-generated files
+  cr fix internal/services/       # Fix files under a directory
+  cr fix --dry-run main.go        # Print a diff without writing`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load configuration
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
+			}
 
-	// Check for JavaScript/React
-	if fileExists(path + "/package.json") {
-		if fileContains(path+"/package.json", "react") {
-			return "react"
-		}
-		return "javascript"
-	}
+			// Detect language if not configured
+			lang := configLanguage(cfg)
+
+			// Get files to fix, expanding directories
+			eng := newEngine(cfg, lang)
+			files, err := eng.Files(args)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				files, err = eng.Detect(".")
+				if err != nil {
+					return fmt.Errorf("failed to detect AI files: %w", err)
+				}
+			}
+			if len(files) == 0 {
+				fmt.Println("No files to fix.")
+				return nil
+			}
+
+			results, err := eng.Fix(files)
+			if err != nil {
+				return err
+			}
+			f := fixer.New()
+
+			fixedFiles := 0
+			for _, result := range results {
+				file := result.Path
+				if result.Changed() {
+					fixedFiles++
+					if dryRun {
+						fmt.Print(fixer.Diff(result))
+					} else if err := f.Write(result); err != nil {
+						return fmt.Errorf("failed to write %s: %w", file, err)
+					}
+					fmt.Printf("✓ %s: fixed %d deviation(s)\n", file, len(result.Applied))
+				}
+
+				if len(result.Remaining) > 0 {
+					fmt.Printf("⚠ %s: %d deviation(s) need manual fixes\n", file, len(result.Remaining))
+					for _, dev := range result.Remaining {
+						fmt.Printf("    • %s", dev.Element)
+						if dev.Suggestion != "" {
+							fmt.Printf(": %s", dev.Suggestion)
+						}
+						fmt.Println()
+					}
+				}
+			}
+
+			if dryRun {
+				fmt.Printf("\n%d file(s) would be fixed (dry run)\n", fixedFiles)
+			} else {
+				fmt.Printf("\n%d file(s) fixed\n", fixedFiles)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print a diff instead of writing files")
+
+	return cmd
+}
+
+func migrateCmd() *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate [paths...]",
+		Short: "Plan migrations for files that follow anti-patterns",
+		Long: `Scan for files resembling annotated anti-patterns and print a migration
+plan per file, referencing each anti-pattern's migration guide and the golden
+example to migrate towards.
+
+Scans the whole repository unless files or directories are given.
+
+Examples:
+  cr migrate                      # Print a migration plan
+  cr migrate --format json        # Emit a task list for an agent
+  cr migrate --apply              # Also apply mechanical fixes (like cr fix)`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			// Load configuration
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
+			}
+
+			// Detect language if not configured
+			lang := configLanguage(cfg)
+
+			if len(args) == 0 {
+				args = []string{"."}
+			}
+			eng := newEngine(cfg, lang)
+			hits, err := eng.Migrate(args)
+			if err != nil {
+				return err
+			}
+
+			out, err := openOutput(outputPath)
+			if err != nil {
+				return err
+			}
+			defer closeOutput(out, &err)
+
+			rep := newReporter()
+			rep.Out = out
+			if format == "json" {
+				fmt.Fprintln(out, rep.FormatMigrationJSON(hits))
+			} else {
+				rep.ReportMigration(hits)
+			}
+
+			if apply {
+				return applyMigrationFixes(eng, hits)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json or default (text)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "write the plan to this file instead of stdout")
+	cmd.Flags().BoolVar(&apply, "apply", false, "apply mechanical fixes (e.g. missing imports) to migrated files")
+
+	return cmd
+}
+
+// applyMigrationFixes runs the cr fix logic on files with anti-pattern hits
+func applyMigrationFixes(eng *engine.Engine, hits []patterns.AntiPatternMatch) error {
+	files := []string{}
+	for _, hit := range hits {
+		files = append(files, hit.FilePath)
+	}
+	results, err := eng.Fix(files)
+	if err != nil {
+		return err
+	}
+	f := fixer.New()
+	fixed := 0
+	for _, result := range results {
+		if !result.Changed() {
+			continue
+		}
+		if err := f.Write(result); err != nil {
+			return fmt.Errorf("failed to write %s: %w", result.Path, err)
+		}
+		fixed++
+	}
+	fmt.Fprintf(os.Stderr, "Applied mechanical fixes to %d file(s)\n", fixed)
+	return nil
+}
+
+func similarityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "similarity <fileA> <fileB>",
+		Short: "Show how similar two files are to the matcher",
+		Long: `Compare two files the way the matcher does: structural similarity, plus
+the imports and exported functions/types they share or don't. Useful for
+understanding why a file scored as it did. No configuration is needed.
+
+Examples:
+  cr similarity handlers/user.go handlers/order.go
+  cr similarity --format json a.ts b.ts`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m := matcher.New(nil, 0)
+			cmp, err := m.Compare(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			rep := newReporter()
+			if format == "json" {
+				fmt.Println(rep.FormatSimilarityJSON(cmp))
+				return nil
+			}
+			rep.ReportSimilarity(cmp)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json or default (text)")
+
+	return cmd
+}
+
+func configCmd() *cobra.Command {
+	var showPatterns bool
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the effective configuration",
+		Long: `Print the configuration cr actually runs with, after defaults are applied,
+with each value labelled by where it came from: default, file or flag.
+Works without a config file, showing pure defaults. Nothing is written.
+
+Examples:
+  cr config
+  cr config --threshold 90
+  cr config --format json --patterns`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Defaults()
+			if config.Exists("") {
+				loaded, err := config.Load("")
+				if err != nil {
+					return err
+				}
+				cfg = loaded
+			}
+
+			cfg = effectiveConfig(cfg)
+			var flagKeys []string
+			if cmd.Flags().Changed("threshold") {
+				cfg.Settings.AutoApproveThreshold = threshold
+				flagKeys = append(flagKeys, "settings.auto_approve_threshold")
+			}
+			omitted := 0
+			if !showPatterns {
+				omitted, cfg.Patterns = len(cfg.Patterns), nil
+			}
+
+			explained, err := config.Explain(cfg, "", flagKeys)
+			if err != nil {
+				return err
+			}
+			if omitted > 0 {
+				explained.Sources["patterns"] = fmt.Sprintf("%s, %d omitted (use --patterns)", config.SourceFile, omitted)
+			}
+
+			var data []byte
+			switch format {
+			case "json":
+				data, err = explained.JSON()
+			case "", "yaml":
+				data, err = explained.YAML()
+			default:
+				return fmt.Errorf("unknown format %q (valid: yaml, json)", format)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println(strings.TrimSuffix(string(data), "\n"))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json or default (yaml)")
+	cmd.Flags().Float64VarP(&threshold, "threshold", "t", 0, "auto-approve threshold, as passed to cr check")
+	cmd.Flags().BoolVar(&showPatterns, "patterns", false, "include the learned patterns")
+
+	return cmd
+}
+
+func listCmd() *cobra.Command {
+	var full bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the learned patterns",
+		Long: `List the patterns in the config with their type, example count and
+confidence. --full --format json adds each pattern's detection rules,
+structure and example paths (dates as RFC3339), for dashboards that render
+the learned architecture without parsing YAML. Only the config, and the
+pattern library it names with patterns_url, is read; library patterns are
+marked remote.
+
+Examples:
+  cr list
+  cr list --format json
+  cr list --full --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
+			}
+
+			rep := newReporter()
+			switch format {
+			case "json":
+				fmt.Println(rep.FormatPatterns(cfg.AllPatterns(), cfg.Language, full))
+			case "":
+				if full {
+					return fmt.Errorf("--full requires --format json")
+				}
+				rep.ReportPatterns(cfg.AllPatterns())
+			default:
+				return fmt.Errorf("unknown format %q (valid: json)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json or default (text)")
+	cmd.Flags().BoolVar(&full, "full", false, "include detection rules, structure and example paths (with --format json)")
+
+	return cmd
+}
+
+func exportCmd() *cobra.Command {
+	var rules bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export learned conventions for other tools",
+		Long: `Export the enforceable parts of learned patterns for other tools.
+
+--rules writes a JSON rules file: file naming, package paths, declaration
+regexes, required imports and test conventions per pattern, each with the
+pattern's confidence. It is a structured dump for custom linters and
+scripts, not generated code.
+
+Examples:
+  cr export --rules
+  cr export --rules -o conventions.json`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if !rules {
+				return fmt.Errorf("nothing to export: pass --rules")
+			}
+
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
+			}
+
+			out, err := openOutput(outputPath)
+			if err != nil {
+				return err
+			}
+			defer closeOutput(out, &err)
+
+			rep := newReporter()
+			fmt.Fprintln(out, rep.FormatRules(cfg.Patterns, cfg.Language))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&rules, "rules", false, "export conventions as a JSON rules file")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "write the export to this file instead of stdout")
+
+	return cmd
+}
+
+func serveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Answer editor check requests over stdio (JSON-RPC)",
+		Long: `Run a JSON-RPC 2.0 server on stdin/stdout for editor integrations, one
+JSON object per line. The config is loaded once and reloaded when it changes.
+
+Methods:
+  check     {"path": "...", "text": "..."}  match a file; text, if given, is
+            checked instead of the file on disk (unsaved buffers)
+  shutdown  stop the server
+
+Example:
+  {"jsonrpc":"2.0","id":1,"method":"check","params":{"path":"handlers/user.go"}}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return server.New("", newMatcher).Serve(os.Stdin, os.Stdout)
+		},
+	}
+}
+
+func versionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("Code on Rails v%s (PoC)\n", version)
+		},
+	}
+}
+
+func introspectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "introspect",
+		Short: "List the pattern types, severities and other values cr understands",
+		Long: `List the values cr accepts regardless of any config: pattern types (for
+--type), severities, deviation types, detection methods and check names.
+Wrapper scripts and shell completions can validate arguments against
+--format json, whose schema_version only changes on incompatible changes.
+
+Examples:
+  cr introspect
+  cr introspect --format json | jq -r '.pattern_types[]'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := engine.Introspect(version)
+
+			switch format {
+			case "json":
+				data, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+			case "":
+				fmt.Printf("Pattern types:     %s\n", strings.Join(info.PatternTypes, ", "))
+				fmt.Printf("Severities:        %s\n", strings.Join(info.Severities, ", "))
+				fmt.Printf("Deviation types:   %s\n", strings.Join(info.DeviationTypes, ", "))
+				fmt.Printf("Detection methods: %s\n", strings.Join(info.DetectionMethods, ", "))
+				fmt.Printf("Checks:            %s\n", strings.Join(info.Checks, ", "))
+			default:
+				return fmt.Errorf("unknown format %q (valid: json)", format)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: json or default (text)")
+	return cmd
+}
+
+// Helper functions
+
+// pathArgCommands take file paths as arguments
+var pathArgCommands = map[string]bool{
+	"check": true, "feedback": true, "fix": true, "migrate": true, "bless": true, "similarity": true,
+}
+
+// pathFlags name files relative to where cr was run
+var pathFlags = []string{"output", "reference", "append-metrics", "compare-baseline-metrics", "skills-file", "cpuprofile"}
+
+// useConfigDir switches to the directory of the config in use, --config or
+// the nearest one found upward from the working directory, so the paths in
+// it resolve as they do from there. File arguments and path flags given
+// relative to the original directory are rebased to match.
+func useConfigDir(cmd *cobra.Command, args []string) error {
+	path := configFlag
+	if path == "" {
+		if cmd.Name() == "init" {
+			return nil // init creates the config here
+		}
+		found, err := config.Find(".")
+		if err != nil {
+			return nil // Commands report the missing config themselves
+		}
+		path = found
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(abs)
+	config.DefaultPath = filepath.Base(abs)
+	if dir == cwd {
+		return nil
+	}
+
+	rebase := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		if rel, err := filepath.Rel(dir, filepath.Join(cwd, p)); err == nil {
+			return rel
+		}
+		return p
+	}
+	if pathArgCommands[cmd.Name()] {
+		for i := range args {
+			if strings.HasPrefix(args[i], "@") {
+				args[i] = "@" + rebase(args[i][1:])
+			} else {
+				args[i] = rebase(args[i])
+			}
+		}
+		argRebase = rebase
+	}
+	for _, name := range pathFlags {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			if err := f.Value.Set(rebase(f.Value.String())); err != nil {
+				return err
+			}
+		}
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Using config %s\n", abs)
+	}
+	return os.Chdir(dir)
+}
+
+func detectLanguage(path string) string {
+	// Detect language based on project files
+	if path == "" {
+		path = "."
+	}
+
+	// Check for Go
+	if fileExists(path + "/go.mod") {
+		return "go"
+	}
+
+	// Check for .NET
+	counter := detector.New(&config.DetectionConfig{})
+	if counter.CountFiles(path, ".sln", ".csproj") > 0 {
+		return "csharp"
+	}
+
+	// Check for TypeScript/React
+	if fileExists(path + "/tsconfig.json") {
+		// Check if it's a React project
+		if fileExists(path+"/package.json") && fileContains(path+"/package.json", "react") {
+			return "react"
+		}
+		return "typescript"
+	}
+
+	// Check for JavaScript/React
+	if fileExists(path + "/package.json") {
+		if fileContains(path+"/package.json", "react") {
+			return "react"
+		}
+		return "javascript"
+	}
 
 	// Default to detecting based on file prevalence
-	goCount := countFilesWithExtension(path, ".go")
-	tsCount := countFilesWithExtension(path, ".ts") + countFilesWithExtension(path, ".tsx")
-	jsCount := countFilesWithExtension(path, ".js") + countFilesWithExtension(path, ".jsx")
+	goCount := counter.CountFiles(path, ".go")
+	tsCount := counter.CountFiles(path, ".ts", ".tsx")
+	jsCount := counter.CountFiles(path, ".js", ".jsx")
 
 	if goCount >= tsCount && goCount >= jsCount && goCount > 0 {
 		return "go"
@@ -591,37 +1693,403 @@ func fileContains(path string, substr string) bool {
 	return strings.Contains(string(content), substr)
 }
 
-func countFilesWithExtension(dir string, ext string) int {
-	count := 0
-	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+// argRebase rebases a path listed in an @argfile the way useConfigDir
+// rebased the command line, as listed paths are relative to where cr ran
+var argRebase = func(p string) string { return p }
+
+// recordMetrics appends a summary of a check run to --append-metrics, if set
+func recordMetrics(matches []patterns.PatternMatch) error {
+	if metricsPath == "" {
+		return nil
+	}
+	return appendMetrics(metricsPath, newReporter().Metrics(matches))
+}
+
+// appendMetrics completes a run's metrics record with its commit, cr
+// version and config fingerprint, and appends it to a JSONL file
+func appendMetrics(path string, record reporter.MetricsRecord) error {
+	record.Version = version
+	record.Commit = commitSHA
+	if record.Commit == "" {
+		record.Commit = os.Getenv("GITHUB_SHA")
+	}
+	if record.Commit == "" {
+		record.Commit, _ = detector.HeadCommit(".")
+	}
+	fingerprint, err := config.Fingerprint("")
+	if err != nil {
+		return err
+	}
+	record.ConfigFingerprint = fingerprint
+
+	return reporter.AppendMetrics(path, record)
+}
+
+// openOutput opens the report destination, creating parent directories as
+// needed. An empty path means stdout.
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return f, nil
+}
+
+// closeOutput closes the report destination, reporting a failed close
+// unless the command already failed
+func closeOutput(out io.Closer, err *error) {
+	if closeErr := out.Close(); closeErr != nil && *err == nil {
+		*err = fmt.Errorf("failed to write output file: %w", closeErr)
+	}
+}
+
+// nopWriteCloser lets stdout stand in for an output file
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newReporter creates a reporter that prints paths relative to the repo root
+func newReporter() *reporter.Reporter {
+	rep := reporter.New(verbose)
+	if root, err := detector.GitRoot("."); err == nil {
+		rep.Root = root
+	} else if cwd, err := os.Getwd(); err == nil {
+		rep.Root = cwd
+	}
+	return rep
+}
+
+// newEngine creates an engine for the repo's language that warns about
+// files it fails to match with --verbose and skips them
+func newEngine(cfg *config.Config, lang string) *engine.Engine {
+	eng := engine.New(cfg)
+	eng.Language = lang
+	eng.Branch = branchName
+	eng.OnError = func(file string, err error) {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Warning: failed to match %s: %v\n", file, err)
+		}
+	}
+	if m := eng.Matcher(); m != nil {
+		m.Profile = prof
+		m.OnDegraded = warnDegraded
+	}
+	warnDetection(cfg)
+	return eng
+}
+
+// warnDetection warns about detection settings that can't find anything
+func warnDetection(cfg *config.Config) {
+	if err := cfg.Detection.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// validateGoldens reports the golden examples that don't follow the
+// pattern they are annotated for. With strict they are an error; otherwise
+// they are warned about with --verbose.
+func validateGoldens(cfg *config.Config, strict bool) error {
+	if !strict && !verbose {
+		return nil
+	}
+	misfits, err := engine.GoldenMisfits(cfg)
+	if err != nil || len(misfits) == 0 {
+		return err
+	}
+	if strict {
+		lines := make([]string, len(misfits))
+		for i, misfit := range misfits {
+			lines[i] = misfit.String()
+		}
+		return fmt.Errorf("%d golden example(s) don't follow the pattern they are annotated for:\n  %s", len(misfits), strings.Join(lines, "\n  "))
+	}
+	for _, misfit := range misfits {
+		fmt.Fprintf(os.Stderr, "Warning: golden example %s; check its @pattern\n", misfit)
+	}
+	return nil
+}
+
+// extractPatterns learns patterns from the current directory, or from just
+// files when non-nil. With language
+// overrides, each language gets its own analyzer over the files in its
+// directories, and the resulting patterns are tagged with their language.
+func extractPatterns(language string, settings config.Settings, withTests bool, files []string) ([]patterns.Pattern, error) {
+	newAnalyzer := func(lang, label string) *analyzer.Analyzer {
+		a := analyzer.New(lang)
+		a.IncludeTests = withTests
+		a.MinExamples = settings.MinExamples
+		a.MaxFileBytes = settings.MaxFileBytes
+		a.IncludeGenerated = includeGen || settings.IncludeGenerated
+		a.CurrentPlatformOnly = settings.CurrentPlatformOnly
+		a.Weights = settings.Weights
+		a.Progress = newProgress(label)
+		a.Profile = prof
+		return a
+	}
+	extract := func(a *analyzer.Analyzer) ([]patterns.Pattern, error) {
+		if files != nil {
+			return a.ExtractPatternsFromFiles(files)
+		}
+		return a.ExtractPatterns(".")
+	}
+
+	overrides := settings.LanguageOverrides
+	if len(overrides) == 0 {
+		return extract(newAnalyzer(language, "Parsing"))
+	}
+
+	prefixes := make([]string, 0, len(overrides))
+	for prefix := range overrides {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	languages := []string{language}
+	seen := map[string]bool{language: true}
+	for _, prefix := range prefixes {
+		if lang := overrides[prefix]; !seen[lang] {
+			languages = append(languages, lang)
+			seen[lang] = true
+		}
+	}
+
+	all := []patterns.Pattern{}
+	ids := make(map[string]bool)
+	for _, lang := range languages {
+		lang := lang
+		a := newAnalyzer(lang, "Parsing "+lang)
+		a.Owns = func(path string) bool {
+			return config.ResolveLanguage(overrides, language, path) == lang
+		}
+
+		found, err := extract(a)
 		if err != nil {
-			return nil
+			return nil, fmt.Errorf("%s: %w", lang, err)
 		}
-		if !d.IsDir() && strings.HasSuffix(path, ext) {
-			count++
+		for _, p := range found {
+			p.Language = lang
+			if ids[p.ID] {
+				// Both languages learned this type; keep the IDs distinct
+				p.ID = p.ID + "_" + lang
+			}
+			ids[p.ID] = true
+			all = append(all, p)
+		}
+	}
+	return all, nil
+}
+
+// configLanguage returns the language whose files a command works on: the
+// configured one, detected when unset, or "" (every supported language) when
+// the config holds patterns for several
+func configLanguage(cfg *config.Config) string {
+	if len(cfg.Languages) > 1 {
+		return ""
+	}
+	if cfg.Language != "" {
+		return cfg.Language
+	}
+	return detectLanguage(".")
+}
+
+// extractConfigPatterns learns patterns for each language in cfg: just lang,
+// or every language once several were appended with init --append
+func extractConfigPatterns(cfg *config.Config, lang string, withTests bool, files []string) ([]patterns.Pattern, error) {
+	if len(cfg.Languages) < 2 {
+		return extractPatterns(lang, cfg.Settings, withTests, files)
+	}
+
+	all := []patterns.Pattern{}
+	ids := make(map[string]bool)
+	for i, l := range cfg.Languages {
+		settings := cfg.Settings
+		if i > 0 {
+			settings.LanguageOverrides = nil // Overrides belong to the original language
 		}
-		// Limit depth to avoid scanning too deep
-		if d.IsDir() && strings.Count(path, string(os.PathSeparator)) > 5 {
-			return filepath.SkipDir
+		found, err := extractPatterns(l, settings, withTests, files)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l, err)
 		}
+		all = append(all, config.TagLanguage(found, l, ids)...)
+	}
+	return all, nil
+}
+
+// mergeMissingPatterns learns patterns into the existing config for cr init
+// --merge-missing: pattern types it lacks are added, and patterns it has
+// gain the example files newly discovered for them. Nothing else about
+// existing patterns changes.
+func mergeMissingPatterns(minExamples int, strict bool) error {
+	if !config.Exists("") {
+		return fmt.Errorf("no configuration to merge into (run 'cr init' first)")
+	}
+	cfg, err := config.Load("")
+	if err != nil {
+		return fmt.Errorf("failed to load existing config: %w", err)
+	}
+
+	fmt.Println("Looking for patterns the config lacks...")
+	fmt.Println()
+	learnCfg := *cfg
+	if minExamples > 0 {
+		learnCfg.Settings.MinExamples = minExamples
+	}
+	found, err := extractConfigPatterns(&learnCfg, configLanguage(cfg), includeTests, nil)
+	if err != nil {
+		return fmt.Errorf("failed to extract patterns: %w", err)
+	}
+
+	added, examples := cfg.MergeMissing(found)
+
+	if len(added) == 0 && examples == 0 {
+		fmt.Println("✓ The config already covers every pattern found")
 		return nil
-	})
-	return count
-}
-
-func mergePatterns(existing, new []patterns.Pattern) int {
-	// Simple merge: count how many existing patterns got updated
-	// In real implementation, would intelligently merge patterns
-	updated := 0
-	for i := range existing {
-		for _, newPat := range new {
-			if existing[i].ID == newPat.ID {
-				existing[i].SeenCount = newPat.SeenCount
-				existing[i].Confidence = newPat.Confidence
-				updated++
-				break
-			}
+	}
+	if err := validateGoldens(cfg, strict); err != nil {
+		return err
+	}
+	if err := config.Save(cfg, ""); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if len(added) > 0 {
+		fmt.Printf("→ Added %d pattern(s):\n", len(added))
+		for _, p := range added {
+			fmt.Printf("  • %s (%d file(s))\n", p.Name, p.SeenCount)
+		}
+	}
+	if examples > 0 {
+		fmt.Printf("→ Added %d example file(s) to existing patterns\n", examples)
+	}
+	fmt.Println("✓ Existing patterns otherwise unchanged")
+	return nil
+}
+
+// withDeclaredPatterns appends hand-written patterns to learned ones. A
+// declared pattern replaces a learned one with the same ID.
+func withDeclaredPatterns(learned, declared []patterns.Pattern) []patterns.Pattern {
+	ids := make(map[string]bool)
+	for _, p := range declared {
+		ids[p.ID] = true
+	}
+
+	result := []patterns.Pattern{}
+	for _, p := range learned {
+		if !ids[p.ID] {
+			result = append(result, p)
+		}
+	}
+	return append(result, declared...)
+}
+
+// newMatcher creates a matcher configured from settings, profiled with
+// --profile
+func newMatcher(cfg *config.Config) (*matcher.Matcher, error) {
+	m, err := engine.NewMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.Profile = prof
+	m.OnDegraded = warnDegraded
+	return m, nil
+}
+
+// warnDegraded warns, with --verbose, about comparisons that fell back to
+// a default score, e.g. against a reference that doesn't parse
+func warnDegraded(err error) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// effectiveConfig fills in the defaults the matcher and analyzers apply to
+// unset settings, so cr config shows what actually runs
+func effectiveConfig(cfg *config.Config) *config.Config {
+	eff := *cfg
+	s := &eff.Settings
+
+	scoring := engine.Scoring(s.Scoring)
+	s.Scoring = config.ScoringSettings{
+		MissingImportPenalty:        &scoring.MissingImportPenalty,
+		MissingErrorHandlingPenalty: &scoring.MissingErrorHandlingPenalty,
+		ErrorPenalty:                &scoring.ErrorPenalty,
+		WarningPenalty:              &scoring.WarningPenalty,
+		StructureWeight:             &scoring.StructureWeight,
+		TieEpsilon:                  &scoring.TieEpsilon,
+		PathSpecificityBonus:        &scoring.PathSpecificityBonus,
+	}
+	if s.SimilarityMethod == "" {
+		s.SimilarityMethod = scoring.SimilarityMethod
+	}
+	if s.MatchMode == "" {
+		s.MatchMode = matcher.MatchBest
+	}
+	if s.MinExamples == 0 {
+		switch eff.Language {
+		case "go":
+			s.MinExamples = analyzer.DefaultMinExamplesGo
+		case "typescript":
+			s.MinExamples = analyzer.DefaultMinExamplesTypeScript
+		case "csharp":
+			s.MinExamples = analyzer.DefaultMinExamplesCSharp
+		}
+	}
+	if s.MaxFileBytes == 0 {
+		s.MaxFileBytes = analyzer.DefaultMaxFileBytes
+	}
+	if s.FileTimeout == 0 {
+		s.FileTimeout = matcher.DefaultFileTimeout
+	}
+	if s.SystemicThreshold == 0 {
+		s.SystemicThreshold = reporter.DefaultSystemicThreshold
+	}
+	if s.ApprovalDropThreshold == 0 {
+		s.ApprovalDropThreshold = reporter.DefaultApprovalDropThreshold
+	}
+	if s.ReviewLinesPerMinute == 0 {
+		s.ReviewLinesPerMinute = reporter.DefaultReviewLinesPerMinute
+	}
+	if s.SecretEntropy == 0 {
+		s.SecretEntropy = matcher.DefaultSecretEntropy
+	}
+	if s.TodoDensity == 0 {
+		s.TodoDensity = matcher.DefaultTodoDensity
+	}
+	s.Weights = s.Weights.OrDefault()
+	return &eff
+}
+
+func parsePatternFilter(names []string) ([]patterns.PatternType, error) {
+	types := []patterns.PatternType{}
+	for _, name := range names {
+		t, err := patterns.ParsePatternType(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func postGitHubComment(body string) error {
+	client, err := github.NewFromEnv()
+	if err != nil {
+		return err
+	}
+
+	pr := prNumber
+	if pr == 0 {
+		pr, err = github.PRNumberFromRef(os.Getenv("GITHUB_REF"))
+		if err != nil {
+			return err
 		}
 	}
-	return updated
+
+	return client.PostComment(pr, body)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/loop-hub/code-on-rails/internal/audit"
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func patternCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pattern",
+		Short: "Manage learned patterns",
+		Long: `Manage individual patterns in the config.
+
+Disabling a noisy or wrong pattern mutes it without deleting it: files are no
+longer matched against it, while its examples and counts are kept for when
+it is enabled again. cr list marks disabled patterns.
+
+Examples:
+  cr pattern disable repository_pattern
+  cr pattern enable repository_pattern`,
+	}
+	cmd.AddCommand(setPatternEnabledCmd("disable", "Disable", false))
+	cmd.AddCommand(setPatternEnabledCmd("enable", "Enable", true))
+	return cmd
+}
+
+// setPatternEnabledCmd creates the enable or disable subcommand
+func setPatternEnabledCmd(name, title string, enabled bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   name + " <id>",
+		Short: title + " a pattern by ID",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			unlock, err := config.Lock("")
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w (run 'cr init' first)", err)
+			}
+
+			changed, err := cfg.SetPatternEnabled(id, enabled)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				fmt.Printf("Pattern %s is already %sd\n", id, name)
+				return nil
+			}
+
+			if err := config.Save(cfg, ""); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			if err := audit.Append("", audit.Entry{
+				Action:  "pattern-" + name,
+				Pattern: id,
+				User:    audit.GitUser(),
+			}); err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ %sd pattern %s\n", title, id)
+			return nil
+		},
+	}
+}
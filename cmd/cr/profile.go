@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+
+	"github.com/loop-hub/code-on-rails/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+// prof is the running command's timing profile, nil unless --profile is on.
+// Analyzers and matchers created while it is set record into it.
+var prof *profile.Profile
+
+// startProfiling starts the --profile timing profile, unless the format is
+// meant for machines, and a --cpuprofile pprof profile. The returned stop
+// function prints the timings to stderr and finishes the pprof file; it is
+// safe to call more than once.
+func startProfiling() (stop func(), err error) {
+	if profiling && (format == "" || format == "text") {
+		prof = profile.New()
+	}
+
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		if cpuFile, err = os.Create(cpuProfile); err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	stopped := false
+	return func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		prof.Print(os.Stderr)
+	}, nil
+}
+
+// addProfileFlags adds --profile and --cpuprofile to a command
+func addProfileFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&profiling, "profile", false, "print time spent walking, parsing and matching, per pattern and for the slowest files, to stderr")
+	cmd.Flags().StringVar(&cpuProfile, "cpuprofile", "", "write a pprof CPU profile to this file")
+}
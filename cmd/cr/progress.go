@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// progressInterval limits how often the progress line is redrawn
+const progressInterval = 100 * time.Millisecond
+
+// newProgress returns a callback that redraws a "label N/M files" line on
+// stderr, or nil when progress shouldn't be shown: stdout isn't a terminal,
+// verbose or quiet output is on, or the format is meant for machines.
+func newProgress(label string) func(done, total int) {
+	if verbose || quiet || (format != "" && format != "text") || !isTerminal(os.Stdout) {
+		return nil
+	}
+
+	var last time.Time
+	return func(done, total int) {
+		if done < total && time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		fmt.Fprintf(os.Stderr, "\r%s %d/%d files", label, done, total)
+		if done == total {
+			// Clear the line so results start on a clean one
+			fmt.Fprint(os.Stderr, "\r\033[K")
+		}
+	}
+}
+
+// isTerminal checks if a file is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -3,19 +3,47 @@ package analyzer
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/loop-hub/code-on-rails/internal/profile"
+	"github.com/loop-hub/code-on-rails/internal/walk"
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
 )
 
 // Analyzer extracts patterns from codebases
 type Analyzer struct {
-	Language string
+	Language     string
+	IncludeTests bool  // Learn test file conventions as a test pattern
+	MinExamples  int   // Files needed to learn a pattern; 0 uses the language default
+	MaxFileBytes int64 // Larger files are skipped; 0 uses DefaultMaxFileBytes, negative means no limit
+
+	// IncludeGenerated learns from generated Go files too, which are
+	// skipped by default
+	IncludeGenerated bool
+	// CurrentPlatformOnly skips Go files whose build constraints or
+	// _GOOS/_GOARCH name exclude the platform cr runs on
+	CurrentPlatformOnly bool
+	// Weights are the tier weights given to golden and discovered examples;
+	// unset weights use patterns.DefaultTierWeights
+	Weights patterns.TierWeights
+
+	// Progress, when set, is called after each file is parsed
+	Progress func(done, total int)
+	// Profile, when set, records the time spent walking, parsing and
+	// extracting patterns
+	Profile *profile.Profile
+
+	// Owns restricts analysis to the files it accepts, so several analyzers
+	// can split a polyglot repo by directory. Nil accepts every file.
+	Owns func(path string) bool
 }
 
 // New creates a new analyzer
@@ -23,6 +51,28 @@ func New(language string) *Analyzer {
 	return &Analyzer{Language: language}
 }
 
+// DefaultMaxFileBytes is the size above which files are too large to analyze,
+// typically generated code
+const DefaultMaxFileBytes = 512 << 10
+
+// TooLarge checks if a file exceeds the size limit. A zero limit uses
+// DefaultMaxFileBytes and a negative one disables the check.
+func TooLarge(path string, limit int64) bool {
+	info, err := os.Stat(path)
+	return err == nil && OverLimit(info.Size(), limit)
+}
+
+// OverLimit checks a size against a limit as TooLarge does
+func OverLimit(size, limit int64) bool {
+	if limit < 0 {
+		return false
+	}
+	if limit == 0 {
+		limit = DefaultMaxFileBytes
+	}
+	return size > limit
+}
+
 // ExtractPatterns analyzes a codebase and extracts common patterns
 func (a *Analyzer) ExtractPatterns(rootPath string) ([]patterns.Pattern, error) {
 	switch a.Language {
@@ -30,16 +80,114 @@ func (a *Analyzer) ExtractPatterns(rootPath string) ([]patterns.Pattern, error)
 		return a.extractGoPatterns(rootPath)
 	case "typescript", "ts", "javascript", "js", "react":
 		return a.extractTypeScriptPatterns(rootPath)
+	case "csharp":
+		start := time.Now()
+		files, err := findCSharpFiles(rootPath, a.IncludeTests)
+		a.Profile.Stage("walk", start)
+		if err != nil {
+			return nil, err
+		}
+		return a.learnCSharpPatterns(a.ownedFiles(files)), nil
+	default:
+		return nil, fmt.Errorf("unsupported language: %s", a.Language)
+	}
+}
+
+// ExtractPatternsFromFiles extracts patterns from just the given files, e.g.
+// those changed in a release range. Files in other languages, test files
+// (unless IncludeTests) and files the analyzer doesn't own are ignored, and
+// only annotations within the given files are honored.
+func (a *Analyzer) ExtractPatternsFromFiles(files []string) ([]patterns.Pattern, error) {
+	selected := []string{}
+	for _, file := range a.ownedFiles(files) {
+		if !a.IncludeTests && patterns.IsTestFile(file) {
+			continue
+		}
+		selected = append(selected, file)
+	}
+
+	switch a.Language {
+	case "go":
+		goFiles := []string{}
+		for _, file := range selected {
+			if strings.HasSuffix(file, ".go") && !strings.Contains("/"+filepath.ToSlash(file), "/vendor/") {
+				goFiles = append(goFiles, file)
+			}
+		}
+		goldenExamples, antiPatterns := a.annotationParser().FindAnnotatedExamples(goFiles)
+		return a.learnGoPatterns(goFiles, goldenExamples, antiPatterns), nil
+	case "typescript", "ts", "javascript", "js", "react":
+		tsFiles := []string{}
+		for _, file := range selected {
+			if isTypeScriptFile(file) {
+				tsFiles = append(tsFiles, file)
+			}
+		}
+		return a.learnTypeScriptPatterns(tsFiles), nil
+	case "csharp":
+		csFiles := []string{}
+		for _, file := range selected {
+			if isCSharpFile(file) && !strings.Contains("/"+filepath.ToSlash(file), "/bin/") && !strings.Contains("/"+filepath.ToSlash(file), "/obj/") {
+				csFiles = append(csFiles, file)
+			}
+		}
+		return a.learnCSharpPatterns(csFiles), nil
 	default:
 		return nil, fmt.Errorf("unsupported language: %s", a.Language)
 	}
 }
 
+// Default minimum group sizes for learning a pattern
+const (
+	DefaultMinExamplesGo         = 3
+	DefaultMinExamplesTypeScript = 2
+)
+
+// minExamples returns the group size needed to learn a pattern
+func (a *Analyzer) minExamples(languageDefault int) int {
+	if a.MinExamples > 0 {
+		return a.MinExamples
+	}
+	return languageDefault
+}
+
+// progress reports parsing progress if a callback is set
+func (a *Analyzer) progress(done, total int) {
+	if a.Progress != nil {
+		a.Progress(done, total)
+	}
+}
+
+// annotationParser creates a parser giving golden examples the golden tier weight
+func (a *Analyzer) annotationParser() *AnnotationParser {
+	return &AnnotationParser{GoldenWeight: a.Weights.OrDefault().Golden}
+}
+
+// owns checks if a file belongs to this analyzer
+func (a *Analyzer) owns(path string) bool {
+	return a.Owns == nil || a.Owns(path)
+}
+
+// ownedFiles filters a file list down to the files this analyzer owns
+func (a *Analyzer) ownedFiles(files []string) []string {
+	if a.Owns == nil {
+		return files
+	}
+	owned := []string{}
+	for _, file := range files {
+		if a.Owns(file) {
+			owned = append(owned, file)
+		}
+	}
+	return owned
+}
+
 // extractGoPatterns extracts patterns from Go codebases
 func (a *Analyzer) extractGoPatterns(rootPath string) ([]patterns.Pattern, error) {
 
 	// Step 1: Find annotated golden examples
-	parser := NewAnnotationParser()
+	start := time.Now()
+	parser := a.annotationParser()
 	goldenExamples, err := parser.FindGoldenExamples(rootPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find golden examples: %w", err)
@@ -50,49 +198,78 @@ func (a *Analyzer) extractGoPatterns(rootPath string) ([]patterns.Pattern, error
 	if err != nil {
 		return nil, fmt.Errorf("failed to find anti-patterns: %w", err)
 	}
+	a.Profile.Stage("annotations", start)
 
 	// Step 2: Find all Go files for discovery
-	files, err := findGoFiles(rootPath)
+	start = time.Now()
+	files, err := findGoFiles(rootPath, a.IncludeTests)
+	a.Profile.Stage("walk", start)
 	if err != nil {
 		return nil, err
 	}
+	return a.learnGoPatterns(a.ownedFiles(files), goldenExamples, antiPatterns), nil
+}
 
+// learnGoPatterns groups Go files into patterns, attaching annotated
+// golden examples and anti-patterns
+func (a *Analyzer) learnGoPatterns(files []string, goldenExamples []patterns.GoldenExample, antiPatterns []patterns.AntiPattern) []patterns.Pattern {
 	// Parse all files
 	fileInfos := make([]patterns.FileInfo, 0, len(files))
-	for _, file := range files {
+	for i, file := range files {
+		a.progress(i+1, len(files))
+		if TooLarge(file, a.MaxFileBytes) || a.skipGoFile(file) {
+			continue
+		}
+		start := time.Now()
 		info, err := parseGoFile(file)
+		a.Profile.File("parse", file, start)
 		if err != nil {
 			// Skip files that can't be parsed
 			continue
 		}
 		fileInfos = append(fileInfos, *info)
 	}
+	defer a.Profile.Stage("extract", time.Now())
 
 	// Group files by structural similarity
 	groups := groupByStructure(fileInfos)
+	return a.annotatedPatterns(groups, a.minExamples(DefaultMinExamplesGo), extractPattern, goldenExamples, antiPatterns)
+}
 
+// annotatedPatterns turns groups of files into patterns, attaching annotated
+// golden examples and anti-patterns. A group smaller than minExamples only
+// becomes a pattern if it has a golden example.
+func (a *Analyzer) annotatedPatterns(groups map[patterns.PatternType][]patterns.FileInfo, minExamples int,
+	extract func(patterns.PatternType, []patterns.FileInfo) patterns.Pattern,
+	goldenExamples []patterns.GoldenExample, antiPatterns []patterns.AntiPattern) []patterns.Pattern {
 	// Extract patterns from groups
 	extractedPatterns := []patterns.Pattern{}
 
 	// Organize golden examples by pattern
 	goldenByPattern := make(map[string][]patterns.GoldenExample)
 	for _, golden := range goldenExamples {
+		if !a.owns(golden.Path) {
+			continue
+		}
 		goldenByPattern[golden.Pattern] = append(goldenByPattern[golden.Pattern], golden)
 	}
 
 	// Organize anti-patterns by pattern
 	antiByPattern := make(map[string][]patterns.AntiPattern)
 	for _, anti := range antiPatterns {
+		if !a.owns(anti.Path) {
+			continue
+		}
 		antiByPattern[anti.Pattern] = append(antiByPattern[anti.Pattern], anti)
 	}
 
 	for patternType, group := range groups {
-		if len(group) < 3 && len(goldenByPattern[string(patternType)]) == 0 {
-			// Need at least 3 examples to call it a pattern, unless we have golden examples
+		if len(group) < minExamples && len(goldenByPattern[string(patternType)]) == 0 {
+			// Need enough examples to call it a pattern, unless we have golden examples
 			continue
 		}
 
-		pattern := extractPattern(patternType, group)
+		pattern := extract(patternType, group)
 
 		// Add golden examples if they exist for this pattern
 		if goldens, ok := goldenByPattern[string(patternType)]; ok {
@@ -119,7 +296,7 @@ func (a *Analyzer) extractGoPatterns(rootPath string) ([]patterns.Pattern, error
 				pattern.Discovered = append(pattern.Discovered, patterns.Example{
 					Path:            file.Path,
 					SimilarityScore: 0.9, // Placeholder
-					Weight:          1.0,
+					Weight:          a.Weights.OrDefault().Discovered,
 				})
 			}
 		}
@@ -127,76 +304,83 @@ func (a *Analyzer) extractGoPatterns(rootPath string) ([]patterns.Pattern, error
 		extractedPatterns = append(extractedPatterns, pattern)
 	}
 
-	return extractedPatterns, nil
+	return extractedPatterns
 }
 
 // extractTypeScriptPatterns extracts patterns from TypeScript/JavaScript codebases
 func (a *Analyzer) extractTypeScriptPatterns(rootPath string) ([]patterns.Pattern, error) {
 	// Find all TypeScript/JavaScript files
-	files, err := findTypeScriptFiles(rootPath)
+	start := time.Now()
+	files, err := findTypeScriptFiles(rootPath, a.IncludeTests)
+	a.Profile.Stage("walk", start)
 	if err != nil {
 		return nil, err
 	}
+	return a.learnTypeScriptPatterns(a.ownedFiles(files)), nil
+}
 
+// learnTypeScriptPatterns groups TypeScript/JavaScript files into patterns
+func (a *Analyzer) learnTypeScriptPatterns(files []string) []patterns.Pattern {
 	// Parse all files
 	fileInfos := make([]patterns.FileInfo, 0, len(files))
-	for _, file := range files {
-		info, err := parseTypeScriptFile(file)
+	for i, file := range files {
+		a.progress(i+1, len(files))
+		if TooLarge(file, a.MaxFileBytes) {
+			continue
+		}
+		start := time.Now()
+		info, err := ParseTypeScriptFile(file)
+		a.Profile.File("parse", file, start)
 		if err != nil {
 			continue
 		}
 		fileInfos = append(fileInfos, *info)
 	}
+	defer a.Profile.Stage("extract", time.Now())
 
 	// Group files by pattern type
 	groups := groupTypeScriptByPattern(fileInfos)
 
 	// Extract patterns from groups
 	extractedPatterns := []patterns.Pattern{}
+	minExamples := a.minExamples(DefaultMinExamplesTypeScript)
 	for patternType, group := range groups {
-		if len(group) < 2 {
-			continue // Need at least 2 examples
+		if len(group) < minExamples {
+			continue // Need enough examples to call it a pattern
 		}
 
 		pattern := extractTypeScriptPattern(patternType, group)
+		if patternType == patterns.PatternTest {
+			pattern.Structure.Elements = append(pattern.Structure.Elements,
+				extractTestConventions(group, typeScriptTestConventions)...)
+		}
 		pattern.Discovered = make([]patterns.Example, 0, len(group))
 		for _, file := range group {
 			pattern.Discovered = append(pattern.Discovered, patterns.Example{
 				Path:            file.Path,
 				SimilarityScore: 0.9,
-				Weight:          1.0,
+				Weight:          a.Weights.OrDefault().Discovered,
 			})
 		}
 
 		extractedPatterns = append(extractedPatterns, pattern)
 	}
 
-	return extractedPatterns, nil
+	return extractedPatterns
 }
 
 // findTypeScriptFiles recursively finds all TypeScript/JavaScript files
-func findTypeScriptFiles(root string) ([]string, error) {
+func findTypeScriptFiles(root string, includeTests bool) ([]string, error) {
 	var files []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			// Skip node_modules and common non-source directories
-			if info.Name() == "node_modules" || info.Name() == "dist" ||
-				info.Name() == "build" || info.Name() == ".next" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
+	// Skip node_modules and common non-source directories
+	skipDir := func(name string) bool {
+		return name == "node_modules" || name == "dist" || name == "build" || name == ".next"
+	}
+	err := walk.Files(root, skipDir, func(path string) error {
 		// Include TypeScript and JavaScript files
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".ts" || ext == ".tsx" || ext == ".js" || ext == ".jsx" {
-			// Skip test files
-			name := strings.ToLower(filepath.Base(path))
-			if !strings.Contains(name, ".test.") && !strings.Contains(name, ".spec.") &&
-				!strings.Contains(path, "__tests__") && !strings.Contains(path, "__mocks__") {
+		if isTypeScriptFile(path) {
+			// Skip test files unless requested
+			if includeTests || !patterns.IsTestFile(path) {
 				files = append(files, path)
 			}
 		}
@@ -205,38 +389,60 @@ func findTypeScriptFiles(root string) ([]string, error) {
 	return files, err
 }
 
-// parseTypeScriptFile parses a TypeScript/JavaScript file using text analysis
-func parseTypeScriptFile(filePath string) (*patterns.FileInfo, error) {
+// isTypeScriptFile checks for a TypeScript or JavaScript extension
+func isTypeScriptFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ts", ".tsx", ".js", ".jsx":
+		return true
+	}
+	return false
+}
+
+// ParseTypeScriptFile parses a TypeScript/JavaScript file using text analysis
+func ParseTypeScriptFile(filePath string) (*patterns.FileInfo, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	return ParseTypeScriptSource(filePath, content), nil
+}
 
+// ParseTypeScriptSource parses TypeScript/JavaScript contents as if read
+// from filePath, e.g. an unsaved editor buffer
+func ParseTypeScriptSource(filePath string, content []byte) *patterns.FileInfo {
 	code := string(content)
 	lines := strings.Split(code, "\n")
 
+	imports, importLines := extractTypeScriptImports(code)
 	info := &patterns.FileInfo{
-		Path:      filePath,
-		Package:   filepath.Base(filepath.Dir(filePath)),
-		Imports:   extractTypeScriptImports(code),
-		Functions: extractTypeScriptFunctions(lines),
-		Types:     extractTypeScriptTypes(lines),
+		Path:        filePath,
+		Package:     filepath.Base(filepath.Dir(filePath)),
+		Imports:     imports,
+		ImportLines: importLines,
+		Functions:   extractTypeScriptFunctions(lines),
+		Types:       extractTypeScriptTypes(lines),
 	}
 
-	return info, nil
+	return info
 }
 
 // extractTypeScriptImports extracts import statements from TypeScript/JavaScript
-func extractTypeScriptImports(code string) []string {
+// along with the line each one starts on
+func extractTypeScriptImports(code string) ([]string, map[string]int) {
 	imports := []string{}
+	lines := make(map[string]int)
 	importRegex := regexp.MustCompile(`import\s+(?:{[^}]+}|[\w\s,*]+)\s+from\s+['"]([^'"]+)['"]`)
-	matches := importRegex.FindAllStringSubmatch(code, -1)
+	matches := importRegex.FindAllStringSubmatchIndex(code, -1)
 	for _, match := range matches {
-		if len(match) > 1 {
-			imports = append(imports, match[1])
+		if len(match) > 3 {
+			path := code[match[2]:match[3]]
+			imports = append(imports, path)
+			if _, seen := lines[path]; !seen {
+				lines[path] = strings.Count(code[:match[0]], "\n") + 1
+			}
 		}
 	}
-	return imports
+	return imports, lines
 }
 
 // extractTypeScriptFunctions extracts function declarations
@@ -251,12 +457,14 @@ func extractTypeScriptFunctions(lines []string) []patterns.FunctionInfo {
 		regexp.MustCompile(`(?:export\s+)?const\s+(\w+)\s*=\s*(?:async\s+)?\([^)]*\)\s*=>`),
 	}
 
-	for _, line := range lines {
+	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		for _, pattern := range funcPatterns {
 			if matches := pattern.FindStringSubmatch(trimmed); len(matches) > 1 {
 				functions = append(functions, patterns.FunctionInfo{
-					Name: matches[1],
+					Name:     matches[1],
+					Line:     i + 1,
+					Exported: strings.HasPrefix(trimmed, "export "),
 				})
 				break
 			}
@@ -277,14 +485,16 @@ func extractTypeScriptTypes(lines []string) []patterns.TypeInfo {
 		regexp.MustCompile(`(?:export\s+)?enum\s+(\w+)`),
 	}
 
-	for _, line := range lines {
+	for lineNum, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		for i, pattern := range typePatterns {
 			if matches := pattern.FindStringSubmatch(trimmed); len(matches) > 1 {
 				kind := []string{"interface", "type", "class", "enum"}[i]
 				types = append(types, patterns.TypeInfo{
-					Name: matches[1],
-					Kind: kind,
+					Name:     matches[1],
+					Kind:     kind,
+					Line:     lineNum + 1,
+					Exported: strings.HasPrefix(trimmed, "export "),
 				})
 				break
 			}
@@ -300,6 +510,9 @@ func groupTypeScriptByPattern(files []patterns.FileInfo) map[patterns.PatternTyp
 
 	for _, file := range files {
 		patternType := inferTypeScriptPatternType(file)
+		if patterns.IsTestFile(file.Path) {
+			patternType = patterns.PatternTest
+		}
 		groups[patternType] = append(groups[patternType], file)
 	}
 
@@ -388,39 +601,42 @@ func extractTypeScriptPattern(patternType patterns.PatternType, files []patterns
 	}
 
 	// Build pattern structure
+	lines := sourceLines{}
 	elements := make([]patterns.StructureElement, 0, len(commonImports))
 	for _, imp := range commonImports {
 		elements = append(elements, patterns.StructureElement{
-			Name:    imp,
-			Type:    patterns.ElementImport,
-			Pattern: regexp.QuoteMeta(imp),
+			Name:     imp,
+			Type:     patterns.ElementImport,
+			Pattern:  regexp.QuoteMeta(imp),
+			Examples: importExamples(imp, files, lines),
 		})
 	}
 
+	structure := patterns.CodeStructure{
+		Elements: elements,
+		Required: commonImports,
+	}
+
 	return patterns.Pattern{
-		ID:       string(patternType) + "_pattern",
-		Name:     string(patternType),
-		Type:     patternType,
-		Version:  "1.0",
-		Structure: patterns.CodeStructure{
-			Elements: elements,
-			Required: commonImports,
-		},
-		Confidence: 0.8,
-		SeenCount:  len(files),
+		ID:          string(patternType) + "_pattern",
+		Name:        string(patternType),
+		Type:        patternType,
+		Version:     "1.0",
+		Structure:   structure,
+		Fingerprint: structure.Fingerprint(),
+		Confidence:  0.8,
+		SeenCount:   len(files),
 	}
 }
 
 // findGoFiles recursively finds all .go files
-func findGoFiles(root string) ([]string, error) {
+func findGoFiles(root string, includeTests bool) ([]string, error) {
 	var files []string
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			// Skip test files and vendor
-			if !strings.HasSuffix(path, "_test.go") && !strings.Contains(path, "/vendor/") {
+	err := walk.Files(root, nil, func(path string) error {
+		if strings.HasSuffix(path, ".go") {
+			// Skip vendor, and test files unless requested
+			isTest := strings.HasSuffix(path, "_test.go")
+			if (includeTests || !isTest) && !strings.Contains(path, "/vendor/") {
 				files = append(files, path)
 			}
 		}
@@ -429,26 +645,60 @@ func findGoFiles(root string) ([]string, error) {
 	return files, err
 }
 
+// skipGoFile checks if a Go file should be left out of learning, as
+// generated code (unless IncludeGenerated) or, with CurrentPlatformOnly,
+// code built only for other platforms
+func (a *Analyzer) skipGoFile(path string) bool {
+	if a.CurrentPlatformOnly {
+		if match, err := build.Default.MatchFile(filepath.Dir(path), filepath.Base(path)); err == nil && !match {
+			return true
+		}
+	}
+	return !a.IncludeGenerated && IsGenerated(path)
+}
+
+// IsGenerated checks if a Go file carries the standard
+// "// Code generated ... DO NOT EDIT." marker ahead of its package clause
+func IsGenerated(path string) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	return err == nil && ast.IsGenerated(file)
+}
+
 // parseGoFile parses a Go file and extracts structure
 func parseGoFile(filePath string) (*patterns.FileInfo, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGoSource(filePath, src)
+}
+
+// ParseGoSource extracts structure from Go source read from filePath
+func ParseGoSource(filePath string, src []byte) (*patterns.FileInfo, error) {
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+	file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
 
 	info := &patterns.FileInfo{
-		Path:      filePath,
-		Package:   file.Name.Name,
-		Imports:   []string{},
-		Functions: []patterns.FunctionInfo{},
-		Types:     []patterns.TypeInfo{},
+		Path:          filePath,
+		Package:       file.Name.Name,
+		Imports:       []string{},
+		ImportLines:   make(map[string]int),
+		ImportAliases: make(map[string]string),
+		Functions:     []patterns.FunctionInfo{},
+		Types:         []patterns.TypeInfo{},
 	}
 
 	// Extract imports
 	for _, imp := range file.Imports {
 		path := strings.Trim(imp.Path.Value, `"`)
 		info.Imports = append(info.Imports, path)
+		info.ImportLines[path] = fset.Position(imp.Pos()).Line
+		if imp.Name != nil {
+			info.ImportAliases[path] = imp.Name.Name
+		}
 	}
 
 	// Extract functions and types
@@ -456,21 +706,30 @@ func parseGoFile(filePath string) (*patterns.FileInfo, error) {
 		switch node := n.(type) {
 		case *ast.FuncDecl:
 			funcInfo := patterns.FunctionInfo{
-				Name: node.Name.Name,
+				Name:       node.Name.Name,
+				Parameters: FieldTypes(node.Type.Params),
+				Returns:    FieldTypes(node.Type.Results),
+				Line:       fset.Position(node.Pos()).Line,
+				Exported:   node.Name.IsExported(),
 			}
 			if node.Recv != nil && len(node.Recv.List) > 0 {
 				// It's a method
-				if starExpr, ok := node.Recv.List[0].Type.(*ast.StarExpr); ok {
-					if ident, ok := starExpr.X.(*ast.Ident); ok {
-						funcInfo.Receiver = ident.Name
-					}
+				funcInfo.Receiver = receiverName(node.Recv.List[0].Type)
+			}
+			if node.Body != nil {
+				start := fset.Position(node.Body.Lbrace).Offset
+				end := fset.Position(node.Body.Rbrace).Offset
+				if start >= 0 && end < len(src) && start <= end {
+					funcInfo.Body = string(src[start : end+1])
 				}
 			}
 			info.Functions = append(info.Functions, funcInfo)
 
 		case *ast.TypeSpec:
 			typeInfo := patterns.TypeInfo{
-				Name: node.Name.Name,
+				Name:     node.Name.Name,
+				Line:     fset.Position(node.Pos()).Line,
+				Exported: node.Name.IsExported(),
 			}
 			if structType, ok := node.Type.(*ast.StructType); ok {
 				typeInfo.Kind = "struct"
@@ -478,6 +737,9 @@ func parseGoFile(filePath string) (*patterns.FileInfo, error) {
 					for _, name := range field.Names {
 						typeInfo.Fields = append(typeInfo.Fields, name.Name)
 					}
+					if len(field.Names) == 0 {
+						typeInfo.Embeds = append(typeInfo.Embeds, embeddedName(field.Type))
+					}
 				}
 			} else if _, ok := node.Type.(*ast.InterfaceType); ok {
 				typeInfo.Kind = "interface"
@@ -490,12 +752,51 @@ func parseGoFile(filePath string) (*patterns.FileInfo, error) {
 	return info, nil
 }
 
+// receiverName extracts the type name from a method receiver, handling
+// pointer and generic receivers like *Repo[T]
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// FieldTypes lists the types in a field list as source strings, repeating
+// the type for each name in grouped parameters like (a, b int)
+func FieldTypes(fields *ast.FieldList) []string {
+	result := []string{}
+	if fields == nil {
+		return result
+	}
+	for _, field := range fields.List {
+		typ := types.ExprString(field.Type)
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			result = append(result, typ)
+		}
+	}
+	return result
+}
+
 // groupByStructure groups files by their structural patterns
 func groupByStructure(files []patterns.FileInfo) map[patterns.PatternType][]patterns.FileInfo {
 	groups := make(map[patterns.PatternType][]patterns.FileInfo)
 
 	for _, file := range files {
 		patternType := inferPatternType(file)
+		if strings.HasSuffix(file.Path, "_test.go") {
+			patternType = patterns.PatternTest
+		}
 		groups[patternType] = append(groups[patternType], file)
 	}
 
@@ -611,10 +912,22 @@ func extractPattern(patternType patterns.PatternType, group []patterns.FileInfo)
 			StructPattern: "type.*Repository.*interface",
 			PackagePath:   "*/repository",
 		}
+	case patterns.PatternTest:
+		pattern.Detection = patterns.DetectionRule{
+			FilePattern: "*_test.go",
+		}
 	}
 
 	// Extract common structure elements
 	pattern.Structure = extractCommonStructure(group)
+	if patternType == patterns.PatternTest {
+		pattern.Structure.Elements = append(pattern.Structure.Elements,
+			extractTestConventions(group, goTestConventions)...)
+	}
+	if patternType == patterns.PatternModel {
+		pattern.Structure.Elements = append(pattern.Structure.Elements, extractCommonFields(group)...)
+	}
+	pattern.Fingerprint = pattern.Structure.Fingerprint()
 
 	return pattern
 }
@@ -632,19 +945,26 @@ func extractCommonStructure(group []patterns.FileInfo) patterns.CodeStructure {
 	importCounts := make(map[string]int)
 	for _, file := range group {
 		for _, imp := range file.Imports {
-			importCounts[imp]++
+			// A blank import runs a package's init once per program, e.g.
+			// to register a database driver; files needn't each repeat it
+			if file.ImportAliases[imp] != "_" {
+				importCounts[imp]++
+			}
 		}
 	}
 
 	// Imports present in >80% of files are "required"
 	threshold := int(float64(len(group)) * 0.8)
+	lines := sourceLines{}
 	for imp, count := range importCounts {
 		if count >= threshold {
 			structure.Required = append(structure.Required, imp)
 			structure.Elements = append(structure.Elements, patterns.StructureElement{
-				Name:    imp,
-				Type:    patterns.ElementImport,
-				Pattern: regexp.QuoteMeta(imp),
+				Name:       imp,
+				Type:       patterns.ElementImport,
+				Pattern:    regexp.QuoteMeta(imp),
+				Examples:   importExamples(imp, group, lines),
+				Importance: float64(count) / float64(len(group)),
 			})
 		}
 	}
@@ -652,6 +972,50 @@ func extractCommonStructure(group []patterns.FileInfo) patterns.CodeStructure {
 	return structure
 }
 
+// goTestConventions are the Go test styles a test pattern can establish
+var goTestConventions = []patterns.StructureElement{
+	{Name: "subtests", Type: patterns.ElementTestConvention, Pattern: `\bt\.Run\(`},
+	{Name: "table_driven", Type: patterns.ElementTestConvention, Pattern: `\[\]struct\s*\{`},
+}
+
+// typeScriptTestConventions are the TS/JS test styles a test pattern can establish
+var typeScriptTestConventions = []patterns.StructureElement{
+	{Name: "describe_blocks", Type: patterns.ElementTestConvention, Pattern: `\bdescribe\(`},
+	{Name: "it_blocks", Type: patterns.ElementTestConvention, Pattern: `\bit\(`},
+}
+
+// extractTestConventions returns the conventions followed by >80% of test files
+func extractTestConventions(group []patterns.FileInfo, conventions []patterns.StructureElement) []patterns.StructureElement {
+	counts := make([]int, len(conventions))
+	examples := make([][]string, len(conventions))
+	for _, file := range group {
+		src, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		for i, convention := range conventions {
+			line := matchLine(regexp.MustCompile(convention.Pattern), src)
+			if line == "" {
+				continue
+			}
+			counts[i]++
+			if len(examples[i]) < maxElementExamples {
+				examples[i] = addExample(examples[i], line)
+			}
+		}
+	}
+
+	threshold := int(float64(len(group)) * 0.8)
+	established := []patterns.StructureElement{}
+	for i, convention := range conventions {
+		if counts[i] > 0 && counts[i] >= threshold {
+			convention.Examples = examples[i]
+			established = append(established, convention)
+		}
+	}
+	return established
+}
+
 // generatePatternID creates a unique ID for a pattern
 func generatePatternID(patternType patterns.PatternType) string {
 	return fmt.Sprintf("%s_pattern", patternType)
@@ -0,0 +1,519 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// parseFunctions parses Go source, failing the test on a syntax error, and
+// indexes its functions by name
+func parseFunctions(t *testing.T, src string) map[string]patterns.FunctionInfo {
+	t.Helper()
+	info, err := ParseGoSource("src.go", []byte(src))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	funcs := make(map[string]patterns.FunctionInfo)
+	for _, fn := range info.Functions {
+		funcs[fn.Name] = fn
+	}
+	return funcs
+}
+
+func TestParseGoSourceSignatures(t *testing.T) {
+	funcs := parseFunctions(t, `package p
+
+import "context"
+
+type Repo[T any] struct{}
+
+func Plain() {}
+
+func Single(id string) error { return nil }
+
+func Grouped(a, b int, name string) (int, error) { return 0, nil }
+
+func Unnamed(int, string) (bool) { return false }
+
+func NamedResults(x int) (n int, err error) { return }
+
+func GroupedResults() (a, b string) { return }
+
+func Variadic(format string, args ...any) {}
+
+func Generic[K comparable, V any](m map[K]V, keys ...K) []V { return nil }
+
+func Funcs(fn func(int) error, ch <-chan struct{}) {}
+
+func (r *Repo[T]) Find(ctx context.Context, id string) (*T, error) { return nil, nil }
+
+func (Repo[T]) Count() int { return 0 }
+`)
+
+	tests := []struct {
+		name     string
+		receiver string
+		params   []string
+		returns  []string
+	}{
+		{"Plain", "", []string{}, []string{}},
+		{"Single", "", []string{"string"}, []string{"error"}},
+		{"Grouped", "", []string{"int", "int", "string"}, []string{"int", "error"}},
+		{"Unnamed", "", []string{"int", "string"}, []string{"bool"}},
+		{"NamedResults", "", []string{"int"}, []string{"int", "error"}},
+		{"GroupedResults", "", []string{}, []string{"string", "string"}},
+		{"Variadic", "", []string{"string", "...any"}, []string{}},
+		{"Generic", "", []string{"map[K]V", "...K"}, []string{"[]V"}},
+		{"Funcs", "", []string{"func(int) error", "<-chan struct{}"}, []string{}},
+		{"Find", "Repo", []string{"context.Context", "string"}, []string{"*T", "error"}},
+		{"Count", "Repo", []string{}, []string{"int"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, ok := funcs[tt.name]
+			if !ok {
+				t.Fatalf("function %s not found", tt.name)
+			}
+			if fn.Receiver != tt.receiver {
+				t.Errorf("Receiver = %q, want %q", fn.Receiver, tt.receiver)
+			}
+			if !reflect.DeepEqual(fn.Parameters, tt.params) {
+				t.Errorf("Parameters = %q, want %q", fn.Parameters, tt.params)
+			}
+			if !reflect.DeepEqual(fn.Returns, tt.returns) {
+				t.Errorf("Returns = %q, want %q", fn.Returns, tt.returns)
+			}
+		})
+	}
+}
+
+func TestParseGoSourceBody(t *testing.T) {
+	funcs := parseFunctions(t, `package p
+
+func Add(a, b int) int {
+	return a + b
+}
+`)
+	if got, want := funcs["Add"].Body, "{\n\treturn a + b\n}"; got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+	if got := funcs["Add"].Line; got != 3 {
+		t.Errorf("Line = %d, want 3", got)
+	}
+}
+
+func TestFieldTypesNil(t *testing.T) {
+	if got := FieldTypes(nil); got == nil || len(got) != 0 {
+		t.Errorf("FieldTypes(nil) = %#v, want an empty list", got)
+	}
+}
+
+// writeTree creates files, keyed by slash-separated path, under a temporary
+// directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// findPattern returns the learned pattern of a type, or nil
+func findPattern(pats []patterns.Pattern, patternType patterns.PatternType) *patterns.Pattern {
+	for i := range pats {
+		if pats[i].Type == patternType {
+			return &pats[i]
+		}
+	}
+	return nil
+}
+
+const tableDrivenTest = `package services
+
+import "testing"
+
+func TestX(t *testing.T) {
+	tests := []struct{ in string }{{"a"}}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {})
+	}
+}
+`
+
+func TestIncludeTestsLearnsTestConventions(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/a_test.go": tableDrivenTest,
+		"services/b_test.go": tableDrivenTest,
+		"services/c_test.go": tableDrivenTest,
+		"services/d_test.go": strings.Replace(tableDrivenTest, "[]struct", "map[string]struct", 1),
+		"services/e_test.go": strings.Replace(tableDrivenTest, "[]struct", "map[string]struct", 1),
+	})
+
+	a := New("go")
+	pats, err := a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternTest); p != nil {
+		t.Fatalf("learned a test pattern without IncludeTests: %+v", p)
+	}
+
+	a.IncludeTests = true
+	pats, err = a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := findPattern(pats, patterns.PatternTest)
+	if p == nil {
+		t.Fatalf("no test pattern learned from %d patterns", len(pats))
+	}
+	if p.Detection.FilePattern != "*_test.go" {
+		t.Errorf("test pattern detection = %+v", p.Detection)
+	}
+	conventions := []string{}
+	for _, elem := range p.Structure.Elements {
+		if elem.Type == patterns.ElementTestConvention {
+			conventions = append(conventions, elem.Name)
+		}
+	}
+	// Every file uses subtests; 3 of 5 (below 80%) are table-driven
+	if len(conventions) != 1 || conventions[0] != "subtests" {
+		t.Errorf("conventions = %v, want just subtests", conventions)
+	}
+}
+
+func TestParseTypeScriptSourceLines(t *testing.T) {
+	src := `import React from 'react';
+import {
+  useState,
+} from 'react';
+import { api } from './api';
+
+interface Props {
+  id: string;
+}
+export function UserCard({ id }: Props) {
+  const [user] = useState(null);
+  return null;
+}
+
+export const useUser = async (id: string) => api.get(id);
+type Id = string;
+`
+	info := ParseTypeScriptSource("components/UserCard.tsx", []byte(src))
+
+	if got := info.ImportLines; got["react"] != 1 || got["./api"] != 5 {
+		t.Errorf("ImportLines = %v, want react on 1 and ./api on 5", got)
+	}
+	funcs := map[string]int{}
+	for _, fn := range info.Functions {
+		funcs[fn.Name] = fn.Line
+	}
+	if funcs["UserCard"] != 10 || funcs["useUser"] != 15 {
+		t.Errorf("function lines = %v, want UserCard on 10 and useUser on 15", funcs)
+	}
+	types := map[string]int{}
+	for _, typ := range info.Types {
+		types[typ.Name] = typ.Line
+	}
+	if types["Props"] != 7 || types["Id"] != 16 {
+		t.Errorf("type lines = %v, want Props on 7 and Id on 16", types)
+	}
+}
+
+const serviceSource = `package services
+
+import "context"
+
+type UserService struct{}
+
+func (s *UserService) Get(ctx context.Context, id string) error { return nil }
+`
+
+func TestMinExamples(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/a.go": serviceSource,
+		"services/b.go": serviceSource,
+	})
+
+	a := New("go")
+	pats, err := a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p != nil {
+		t.Fatalf("learned a service pattern from 2 files with the default minimum of %d", DefaultMinExamplesGo)
+	}
+
+	a.MinExamples = 2
+	pats, err = a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p == nil {
+		t.Error("no service pattern learned from 2 files with MinExamples 2")
+	}
+}
+
+func TestMinExamplesGolden(t *testing.T) {
+	golden := "// @code-on-rails: golden-example\n// @pattern: service\n" + serviceSource
+	root := writeTree(t, map[string]string{"services/a.go": golden})
+
+	a := New("go")
+	a.MinExamples = 5
+	pats, err := a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p == nil {
+		t.Error("golden example not learned below the minimum")
+	}
+}
+
+func TestMaxFileBytes(t *testing.T) {
+	big := serviceSource + "\nvar table = []string{" + strings.Repeat(`"row", `, 200) + "}\n"
+	root := writeTree(t, map[string]string{
+		"services/a.go":   serviceSource,
+		"services/b.go":   serviceSource,
+		"services/big.go": big,
+	})
+	if !TooLarge(filepath.Join(root, "services/big.go"), int64(len(serviceSource)+100)) {
+		t.Error("big.go not too large for a limit below its size")
+	}
+	if TooLarge(filepath.Join(root, "services/big.go"), -1) {
+		t.Error("big.go too large with the limit disabled")
+	}
+	if TooLarge(filepath.Join(root, "services/big.go"), 0) {
+		t.Errorf("big.go too large for DefaultMaxFileBytes %d", DefaultMaxFileBytes)
+	}
+
+	// With big.go skipped, two service files are too few for a pattern
+	a := New("go")
+	a.MaxFileBytes = int64(len(serviceSource) + 100)
+	pats, err := a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p != nil {
+		t.Errorf("learned a service pattern counting an oversized file: %+v", p.Discovered)
+	}
+
+	a.MaxFileBytes = 0
+	pats, err = a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p == nil {
+		t.Error("no service pattern learned from 3 files within the default limit")
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := map[string]bool{
+		"user_service.pb.go":      true,
+		"mock_service.go":         true,
+		"handwritten.go":          false,
+		"service_plan9.go":        false,
+		"service_constrained.go":  false,
+		"missing_file_service.go": false,
+	}
+	for name, want := range tests {
+		if got := IsGenerated(filepath.Join("testdata", "generated", name)); got != want {
+			t.Errorf("IsGenerated(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSkipGoFile(t *testing.T) {
+	dir := filepath.Join("testdata", "generated")
+	tests := []struct {
+		name                string
+		includeGenerated    bool
+		currentPlatformOnly bool
+		want                bool
+	}{
+		{"user_service.pb.go", false, false, true},
+		{"user_service.pb.go", true, false, false},
+		{"handwritten.go", false, true, false},
+		{"service_plan9.go", false, false, false},
+		{"service_plan9.go", false, true, true},
+		{"service_constrained.go", true, false, false},
+		{"service_constrained.go", true, true, true},
+	}
+	for _, tt := range tests {
+		a := New("go")
+		a.IncludeGenerated = tt.includeGenerated
+		a.CurrentPlatformOnly = tt.currentPlatformOnly
+		if got := a.skipGoFile(filepath.Join(dir, tt.name)); got != tt.want {
+			t.Errorf("skipGoFile(%s) with IncludeGenerated %v, CurrentPlatformOnly %v = %v, want %v",
+				tt.name, tt.includeGenerated, tt.currentPlatformOnly, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeGenerated(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/a.go":     serviceSource,
+		"services/b.go":     serviceSource,
+		"services/mock.go":  "// Code generated by mockgen. DO NOT EDIT.\n\n" + serviceSource,
+		"services/other.go": "//go:build plan9\n\n" + serviceSource,
+	})
+
+	// Only two hand-written files for this platform: too few for a pattern
+	a := New("go")
+	a.CurrentPlatformOnly = true
+	pats, err := a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p != nil {
+		t.Errorf("learned a service pattern counting generated or other-platform files: %+v", p.Discovered)
+	}
+
+	a.IncludeGenerated = true
+	pats, err = a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p == nil {
+		t.Error("no service pattern learned with IncludeGenerated")
+	}
+
+	// Other-platform files are learned from unless CurrentPlatformOnly
+	a = New("go")
+	pats, err = a.ExtractPatterns(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := findPattern(pats, patterns.PatternService); p == nil {
+		t.Error("no service pattern learned with other-platform files included")
+	}
+}
+
+func TestGoldenWeight(t *testing.T) {
+	golden := "// @code-on-rails: golden-example\n// @pattern: service\n" + serviceSource
+	root := writeTree(t, map[string]string{
+		"services/a.go": golden,
+		"services/b.go": serviceSource,
+		"services/c.go": serviceSource,
+	})
+
+	for _, tt := range []struct {
+		weights                  patterns.TierWeights
+		wantGolden, wantDiscover float64
+	}{
+		{patterns.TierWeights{}, 2.0, 1.0},
+		{patterns.TierWeights{Golden: 1.0, Discovered: 1.5}, 1.0, 1.5},
+	} {
+		a := New("go")
+		a.Weights = tt.weights
+		pats, err := a.ExtractPatterns(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := findPattern(pats, patterns.PatternService)
+		if p == nil || len(p.AnnotatedGolden) != 1 || len(p.Discovered) == 0 {
+			t.Fatalf("weights %+v: service pattern = %+v, want a golden and discovered examples", tt.weights, p)
+		}
+		if got := p.AnnotatedGolden[0].Weight; got != tt.wantGolden {
+			t.Errorf("weights %+v: golden weight = %v, want %v", tt.weights, got, tt.wantGolden)
+		}
+		if got := p.Discovered[0].Weight; got != tt.wantDiscover {
+			t.Errorf("weights %+v: discovered weight = %v, want %v", tt.weights, got, tt.wantDiscover)
+		}
+	}
+}
+
+func TestParseGoSourceImportAliases(t *testing.T) {
+	info, err := ParseGoSource("src.go", []byte(`package p
+
+import (
+	"fmt"
+	router "github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+	. "github.com/onsi/gomega"
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"github.com/gorilla/mux": "router",
+		"github.com/lib/pq":      "_",
+		"github.com/onsi/gomega": ".",
+	}
+	if !reflect.DeepEqual(info.ImportAliases, want) {
+		t.Errorf("ImportAliases = %v, want %v", info.ImportAliases, want)
+	}
+	if len(info.Imports) != 4 {
+		t.Errorf("Imports = %v, want all 4", info.Imports)
+	}
+}
+
+func TestCommonStructureSkipsBlankImports(t *testing.T) {
+	group := []patterns.FileInfo{}
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		info, err := ParseGoSource(name, []byte(`package repository
+
+import (
+	"database/sql"
+	_ "github.com/lib/pq"
+)
+
+var _ *sql.DB
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		group = append(group, *info)
+	}
+
+	structure := extractCommonStructure(group)
+	if !reflect.DeepEqual(structure.Required, []string{"database/sql"}) {
+		t.Errorf("required imports = %v, want database/sql without the blank driver import", structure.Required)
+	}
+}
+
+func TestCommonStructureImportance(t *testing.T) {
+	group := []patterns.FileInfo{}
+	for i := 0; i < 5; i++ {
+		imports := `"database/sql"`
+		if i < 4 {
+			imports += "\n\t\"fmt\""
+		}
+		if i < 2 {
+			imports += "\n\t\"strings\""
+		}
+		info, err := ParseGoSource(fmt.Sprintf("repo%d.go", i), []byte("package repository\n\nimport (\n\t"+imports+"\n)\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		group = append(group, *info)
+	}
+
+	structure := extractCommonStructure(group)
+	importance := map[string]float64{}
+	for _, elem := range structure.Elements {
+		if elem.Type == patterns.ElementImport {
+			importance[elem.Name] = elem.Importance
+		}
+	}
+	// strings is in too few examples to be required
+	want := map[string]float64{"database/sql": 1, "fmt": 0.8}
+	if !reflect.DeepEqual(importance, want) {
+		t.Errorf("import importance = %v, want %v", importance, want)
+	}
+}
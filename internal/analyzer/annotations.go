@@ -3,22 +3,24 @@ package analyzer
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/loop-hub/code-on-rails/internal/walk"
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
 )
 
 // Annotation represents a code-on-rails annotation in source code
 type Annotation struct {
-	Type           string    // golden-example, anti-pattern, generated-from
+	Type           string    // golden-example, anti-pattern, generated-from, allow
 	Pattern        string    // Pattern ID
 	Version        string    // Pattern version
 	Reason         string    // Why this is golden/anti-pattern
-	Author         string    // GitHub handle
+	Author         string    // GitHub handles, comma-separated
+	Authors        []string  // Author split into handles
 	BlessedDate    time.Time // When it was blessed
 	QualityScore   int       // 0-100
 	Supersedes     string    // Version this supersedes
@@ -27,12 +29,33 @@ type Annotation struct {
 	GoldenExample  string // For generated-from
 	GeneratedBy    string // AI tool that generated
 	GeneratedDate  time.Time
-	FunctionName   string // Function this annotation applies to
-	LineNumber     int    // Line where annotation starts
+	Element        string     // Deviation element an allow annotation suppresses
+	Expires        *time.Time // Last day an allow annotation applies
+	FunctionName   string     // Function this annotation applies to
+	LineNumber     int        // Line where annotation starts
+	Trailing       bool       // An allow annotation ending a line of code, covering that line
 }
 
+// allowAnnotation matches the inline form: allow <element> reason="..."
+// expires=YYYY-MM-DD. The attributes may come in either order.
+var (
+	allowAnnotation = regexp.MustCompile(`^allow\s+(\S+)(.*)`)
+	allowReason     = regexp.MustCompile(`\breason="([^"]*)"`)
+	allowExpires    = regexp.MustCompile(`\bexpires=(\S+)`)
+)
+
+// continuation matches an indented comment line wrapping a field's value
+var continuation = regexp.MustCompile(`^//(\s{2,}|\t)\S`)
+
+// tsFunctionDecl matches TypeScript/JavaScript function and arrow function declarations
+var tsFunctionDecl = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(?:function\s+(\w+)|const\s+(\w+)\s*(?::[^=]+)?=)`)
+
 // AnnotationParser parses code-on-rails annotations from source files
-type AnnotationParser struct{}
+type AnnotationParser struct {
+	// GoldenWeight is the weight given to golden examples; 0 uses
+	// patterns.DefaultTierWeights.Golden
+	GoldenWeight float64
+}
 
 // NewAnnotationParser creates a new annotation parser
 func NewAnnotationParser() *AnnotationParser {
@@ -46,12 +69,17 @@ func (p *AnnotationParser) ParseFile(filePath string) ([]Annotation, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return p.Parse(file)
+}
 
+// Parse parses all annotations in source read from r
+func (p *AnnotationParser) Parse(r io.Reader) ([]Annotation, error) {
 	annotations := []Annotation{}
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	lineNum := 0
 	inAnnotation := false
 	currentAnnotation := Annotation{}
+	var field, value string // Last field, extended by continuation lines
 
 	for scanner.Scan() {
 		lineNum++
@@ -62,18 +90,28 @@ func (p *AnnotationParser) ParseFile(filePath string) ([]Annotation, error) {
 		if strings.HasPrefix(trimmed, "// @code-on-rails:") {
 			inAnnotation = true
 			currentAnnotation = Annotation{LineNumber: lineNum}
+			field, value = "", ""
 
 			// Parse the type
 			parts := strings.SplitN(trimmed, ":", 2)
 			if len(parts) == 2 {
 				currentAnnotation.Type = strings.TrimSpace(parts[1])
 			}
+			p.parseAllow(&currentAnnotation)
 			continue
 		}
 
 		// If we're in an annotation block, parse fields
 		if inAnnotation && strings.HasPrefix(trimmed, "// @") {
-			p.parseAnnotationField(&currentAnnotation, trimmed)
+			field, value = p.parseAnnotationField(&currentAnnotation, trimmed)
+			continue
+		}
+
+		// An indented comment line continues the previous field's value;
+		// unindented ones, such as a doc comment, are left alone
+		if inAnnotation && field != "" && continuation.MatchString(trimmed) {
+			value += " " + strings.TrimSpace(strings.TrimPrefix(trimmed, "//"))
+			p.setAnnotationField(&currentAnnotation, field, value)
 			continue
 		}
 
@@ -82,29 +120,75 @@ func (p *AnnotationParser) ParseFile(filePath string) ([]Annotation, error) {
 			// Check if next line is a function declaration
 			if strings.HasPrefix(trimmed, "func ") {
 				currentAnnotation.FunctionName = p.extractFunctionName(trimmed)
+			} else if m := tsFunctionDecl.FindStringSubmatch(trimmed); m != nil {
+				currentAnnotation.FunctionName = m[1] + m[2]
 			}
 			annotations = append(annotations, currentAnnotation)
 			inAnnotation = false
 		}
+
+		if ann, ok := p.trailingAllow(line, lineNum); ok {
+			annotations = append(annotations, ann)
+		}
 	}
 
 	return annotations, scanner.Err()
 }
 
-// parseAnnotationField parses a single annotation field
-func (p *AnnotationParser) parseAnnotationField(ann *Annotation, line string) {
+// parseAllow fills in an allow annotation's element, reason and expiry
+// from its inline form, leaving other annotation types alone
+func (p *AnnotationParser) parseAllow(ann *Annotation) bool {
+	m := allowAnnotation.FindStringSubmatch(ann.Type)
+	if m == nil {
+		return false
+	}
+	ann.Type = "allow"
+	ann.Element = m[1]
+	if r := allowReason.FindStringSubmatch(m[2]); r != nil {
+		ann.Reason = r[1]
+	}
+	if e := allowExpires.FindStringSubmatch(m[2]); e != nil {
+		p.parseAnnotationField(ann, "// @expires: "+e[1])
+	}
+	return true
+}
+
+// trailingAllow parses an allow annotation trailing a line of code, as in
+// x := f() // @code-on-rails: allow element reason="...". Only allow
+// annotations may trail code.
+func (p *AnnotationParser) trailingAllow(line string, lineNum int) (Annotation, bool) {
+	i := strings.Index(line, "// @code-on-rails:")
+	if i < 0 || strings.TrimSpace(line[:i]) == "" {
+		return Annotation{}, false
+	}
+	ann := Annotation{
+		Type:       strings.TrimSpace(line[i+len("// @code-on-rails:"):]),
+		LineNumber: lineNum,
+		Trailing:   true,
+	}
+	return ann, p.parseAllow(&ann)
+}
+
+// parseAnnotationField parses a single annotation field, returning its key
+// and value so continuation lines can extend it
+func (p *AnnotationParser) parseAnnotationField(ann *Annotation, line string) (key, value string) {
 	// Remove leading "// @"
 	line = strings.TrimPrefix(line, "// @")
 
 	// Split on first colon
 	parts := strings.SplitN(line, ":", 2)
 	if len(parts) != 2 {
-		return
+		return "", ""
 	}
 
-	key := strings.TrimSpace(parts[0])
-	value := strings.TrimSpace(parts[1])
+	key = strings.TrimSpace(parts[0])
+	value = strings.TrimSpace(parts[1])
+	p.setAnnotationField(ann, key, value)
+	return key, value
+}
 
+// setAnnotationField sets the annotation field named key
+func (p *AnnotationParser) setAnnotationField(ann *Annotation, key, value string) {
 	switch key {
 	case "pattern":
 		ann.Pattern = value
@@ -113,7 +197,8 @@ func (p *AnnotationParser) parseAnnotationField(ann *Annotation, line string) {
 	case "reason":
 		ann.Reason = value
 	case "author":
-		ann.Author = value
+		ann.Authors = splitList(value)
+		ann.Author = strings.Join(ann.Authors, ", ")
 	case "blessed":
 		if t, err := time.Parse("2006-01-02", value); err == nil {
 			ann.BlessedDate = t
@@ -138,7 +223,22 @@ func (p *AnnotationParser) parseAnnotationField(ann *Annotation, line string) {
 		if t, err := time.Parse("2006-01-02", value); err == nil {
 			ann.Deprecated = &t
 		}
+	case "expires":
+		if t, err := time.Parse("2006-01-02", value); err == nil {
+			ann.Expires = &t
+		}
+	}
+}
+
+// splitList splits a comma-separated field value, dropping empty entries
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 // extractFunctionName extracts function name from function declaration
@@ -156,13 +256,9 @@ func (p *AnnotationParser) extractFunctionName(line string) string {
 func (p *AnnotationParser) FindGoldenExamples(rootPath string) ([]patterns.GoldenExample, error) {
 	goldenExamples := []patterns.GoldenExample{}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
+	err := walk.Files(rootPath, nil, func(path string) error {
 		// Skip non-Go files
-		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+		if !strings.HasSuffix(path, ".go") {
 			return nil
 		}
 
@@ -180,17 +276,7 @@ func (p *AnnotationParser) FindGoldenExamples(rootPath string) ([]patterns.Golde
 		// Look for golden examples
 		for _, ann := range annotations {
 			if ann.Type == "golden-example" {
-				goldenExamples = append(goldenExamples, patterns.GoldenExample{
-					Path:         path,
-					Function:     ann.FunctionName,
-					Pattern:      ann.Pattern,
-					Version:      ann.Version,
-					BlessedBy:    ann.Author,
-					BlessedDate:  ann.BlessedDate,
-					Reason:       ann.Reason,
-					QualityScore: ann.QualityScore,
-					Weight:       2.0, // Golden examples get highest weight
-				})
+				goldenExamples = append(goldenExamples, p.goldenExample(path, ann))
 			}
 		}
 
@@ -204,12 +290,8 @@ func (p *AnnotationParser) FindGoldenExamples(rootPath string) ([]patterns.Golde
 func (p *AnnotationParser) FindAntiPatterns(rootPath string) ([]patterns.AntiPattern, error) {
 	antiPatterns := []patterns.AntiPattern{}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+	err := walk.Files(rootPath, nil, func(path string) error {
+		if !strings.HasSuffix(path, ".go") {
 			return nil
 		}
 
@@ -220,14 +302,7 @@ func (p *AnnotationParser) FindAntiPatterns(rootPath string) ([]patterns.AntiPat
 
 		for _, ann := range annotations {
 			if ann.Type == "anti-pattern" {
-				antiPatterns = append(antiPatterns, patterns.AntiPattern{
-					Path:           path,
-					Function:       ann.FunctionName,
-					Pattern:        ann.Pattern,
-					Reason:         ann.Reason,
-					Deprecated:     ann.Deprecated,
-					MigrationGuide: ann.MigrationGuide,
-				})
+				antiPatterns = append(antiPatterns, antiPattern(path, ann))
 			}
 		}
 
@@ -236,3 +311,90 @@ func (p *AnnotationParser) FindAntiPatterns(rootPath string) ([]patterns.AntiPat
 
 	return antiPatterns, err
 }
+
+// FindAnnotatedExamples collects golden examples and anti-patterns from the
+// given files. Golden examples in test files are skipped, as in
+// FindGoldenExamples.
+func (p *AnnotationParser) FindAnnotatedExamples(files []string) ([]patterns.GoldenExample, []patterns.AntiPattern) {
+	goldenExamples := []patterns.GoldenExample{}
+	antiPatterns := []patterns.AntiPattern{}
+	for _, path := range files {
+		annotations, err := p.ParseFile(path)
+		if err != nil {
+			continue
+		}
+		for _, ann := range annotations {
+			switch {
+			case ann.Type == "golden-example" && !strings.HasSuffix(path, "_test.go"):
+				goldenExamples = append(goldenExamples, p.goldenExample(path, ann))
+			case ann.Type == "anti-pattern":
+				antiPatterns = append(antiPatterns, antiPattern(path, ann))
+			}
+		}
+	}
+	return goldenExamples, antiPatterns
+}
+
+// FindExpiredAllows collects the allow annotations in files whose expiry
+// date is before now
+func (p *AnnotationParser) FindExpiredAllows(files []string, now time.Time) []patterns.ExpiredSuppression {
+	expired := []patterns.ExpiredSuppression{}
+	for _, path := range files {
+		annotations, err := p.ParseFile(path)
+		if err != nil {
+			continue
+		}
+		for _, ann := range annotations {
+			if ann.Type != "allow" || !ann.Lapsed(now) {
+				continue
+			}
+			expired = append(expired, patterns.ExpiredSuppression{
+				Path:       path,
+				LineNumber: ann.LineNumber,
+				Element:    ann.Element,
+				Function:   ann.FunctionName,
+				Reason:     ann.Reason,
+				Expires:    *ann.Expires,
+			})
+		}
+	}
+	return expired
+}
+
+// Lapsed reports whether an annotation's expiry date is before now. The
+// expiry date itself is the last day the annotation applies.
+func (a Annotation) Lapsed(now time.Time) bool {
+	return a.Expires != nil && !now.Before(a.Expires.AddDate(0, 0, 1))
+}
+
+// goldenExample converts a golden-example annotation found in path
+func (p *AnnotationParser) goldenExample(path string, ann Annotation) patterns.GoldenExample {
+	weight := p.GoldenWeight
+	if weight == 0 {
+		weight = patterns.DefaultTierWeights.Golden
+	}
+	return patterns.GoldenExample{
+		Path:         path,
+		Function:     ann.FunctionName,
+		Pattern:      ann.Pattern,
+		Version:      ann.Version,
+		Supersedes:   ann.Supersedes,
+		BlessedBy:    ann.Author,
+		BlessedDate:  ann.BlessedDate,
+		Reason:       ann.Reason,
+		QualityScore: ann.QualityScore,
+		Weight:       weight,
+	}
+}
+
+// antiPattern converts an anti-pattern annotation found in path
+func antiPattern(path string, ann Annotation) patterns.AntiPattern {
+	return patterns.AntiPattern{
+		Path:           path,
+		Function:       ann.FunctionName,
+		Pattern:        ann.Pattern,
+		Reason:         ann.Reason,
+		Deprecated:     ann.Deprecated,
+		MigrationGuide: ann.MigrationGuide,
+	}
+}
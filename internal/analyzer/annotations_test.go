@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAllowAnnotations(t *testing.T) {
+	src := `package p
+
+// @code-on-rails: allow error_handling reason="handled upstream" expires=2030-01-31
+func Get() {
+	x := f() // @code-on-rails: allow context_propagation reason="fire and forget"
+	s := "// @code-on-rails: golden-example"
+	_ = g(x, s)
+}
+`
+	annotations, err := NewAnnotationParser().Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("got %d annotations, want 2: %+v", len(annotations), annotations)
+	}
+
+	fn := annotations[0]
+	if fn.Type != "allow" || fn.Element != "error_handling" || fn.Reason != "handled upstream" ||
+		fn.FunctionName != "Get" || fn.Trailing || fn.Expires == nil || fn.Expires.Format("2006-01-02") != "2030-01-31" {
+		t.Errorf("function allow = %+v", fn)
+	}
+
+	trailing := annotations[1]
+	if trailing.Type != "allow" || trailing.Element != "context_propagation" || trailing.Reason != "fire and forget" ||
+		!trailing.Trailing || trailing.LineNumber != 5 || trailing.FunctionName != "" {
+		t.Errorf("trailing allow = %+v", trailing)
+	}
+}
+
+const expiringAllows = `package p
+
+// @code-on-rails: allow error_handling reason="legacy" expires=2020-01-31
+func Past() {}
+
+// @code-on-rails: allow error_handling reason="migrating" expires=2099-12-31
+func Future() {}
+
+// @code-on-rails: allow error_handling reason="by design"
+func Never() {}
+`
+
+func TestAnnotationLapsed(t *testing.T) {
+	expires := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expires *time.Time
+		now     time.Time
+		want    bool
+	}{
+		{"no expiry", nil, expires.AddDate(10, 0, 0), false},
+		{"before", &expires, expires.AddDate(0, 0, -1), false},
+		{"on the expiry date", &expires, expires.Add(23 * time.Hour), false},
+		{"day after", &expires, expires.AddDate(0, 0, 1), true},
+	}
+	for _, tt := range tests {
+		if got := (Annotation{Expires: tt.expires}).Lapsed(tt.now); got != tt.want {
+			t.Errorf("%s: Lapsed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindExpiredAllows(t *testing.T) {
+	root := writeTree(t, map[string]string{"p/allows.go": expiringAllows})
+	path := filepath.Join(root, "p/allows.go")
+
+	expired := NewAnnotationParser().FindExpiredAllows([]string{path}, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	if len(expired) != 1 {
+		t.Fatalf("got %d expired allows, want only the past one: %+v", len(expired), expired)
+	}
+	got := expired[0]
+	if got.Path != path || got.LineNumber != 3 || got.Element != "error_handling" || got.Function != "Past" ||
+		got.Reason != "legacy" || got.Expires.Format("2006-01-02") != "2020-01-31" {
+		t.Errorf("expired allow = %+v", got)
+	}
+}
+
+func TestParseWrappedFields(t *testing.T) {
+	src := `package p
+
+// @code-on-rails: golden-example
+// @pattern: http_handler
+// @reason: validates input and wraps errors the way
+//   every handler should,
+//	even the internal ones
+// @author: alice, bob ,, @carol
+// @migration-guide: see docs/handlers.md
+//   for the v2 changes
+// GetUser returns a user.
+//
+// It is a doc comment, not part of the guide.
+func GetUser() {}
+`
+	annotations, err := NewAnnotationParser().Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 1 {
+		t.Fatalf("got %d annotations, want 1: %+v", len(annotations), annotations)
+	}
+	ann := annotations[0]
+
+	if want := "validates input and wraps errors the way every handler should, even the internal ones"; ann.Reason != want {
+		t.Errorf("Reason = %q, want %q", ann.Reason, want)
+	}
+	if want := "see docs/handlers.md for the v2 changes"; ann.MigrationGuide != want {
+		t.Errorf("MigrationGuide = %q, want %q", ann.MigrationGuide, want)
+	}
+	if want := []string{"alice", "bob", "@carol"}; !reflect.DeepEqual(ann.Authors, want) {
+		t.Errorf("Authors = %q, want %q", ann.Authors, want)
+	}
+	if ann.Author != "alice, bob, @carol" {
+		t.Errorf("Author = %q", ann.Author)
+	}
+	if ann.Pattern != "http_handler" || ann.FunctionName != "GetUser" {
+		t.Errorf("pattern %q, function %q", ann.Pattern, ann.FunctionName)
+	}
+}
+
+func TestParseWrappedAuthors(t *testing.T) {
+	src := `// @code-on-rails: golden-example
+// @author: alice,
+//   bob, carol
+package p
+`
+	annotations, err := NewAnnotationParser().Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 1 {
+		t.Fatalf("got %d annotations, want 1", len(annotations))
+	}
+	if want := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(annotations[0].Authors, want) {
+		t.Errorf("Authors = %q, want %q", annotations[0].Authors, want)
+	}
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// PatternCandidates groups files no pattern matched the way learning would,
+// returning the groups with enough files to learn a pattern from, largest
+// first. Files that can't be parsed or are in another language are left out.
+func (a *Analyzer) PatternCandidates(files []string) []patterns.PatternCandidate {
+	var parse func(string) (*patterns.FileInfo, error)
+	var group func([]patterns.FileInfo) map[patterns.PatternType][]patterns.FileInfo
+	var minExamples int
+	switch a.Language {
+	case "go":
+		parse, group, minExamples = parseGoFile, groupByStructure, DefaultMinExamplesGo
+	case "typescript", "ts", "javascript", "js", "react":
+		parse, group, minExamples = ParseTypeScriptFile, groupTypeScriptByPattern, DefaultMinExamplesTypeScript
+	case "csharp":
+		parse, group, minExamples = ParseCSharpFile, groupCSharpByPattern, DefaultMinExamplesCSharp
+	default:
+		return nil
+	}
+
+	infos := []patterns.FileInfo{}
+	for _, file := range files {
+		if !a.candidateFile(file) || TooLarge(file, a.MaxFileBytes) {
+			continue
+		}
+		if info, err := parse(file); err == nil {
+			infos = append(infos, *info)
+		}
+	}
+
+	candidates := []patterns.PatternCandidate{}
+	for patternType, members := range group(infos) {
+		if len(members) < a.minExamples(minExamples) {
+			continue
+		}
+		candidate := patterns.PatternCandidate{Type: patternType}
+		for _, info := range members {
+			candidate.Files = append(candidate.Files, info.Path)
+		}
+		sort.Strings(candidate.Files)
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if len(candidates[i].Files) != len(candidates[j].Files) {
+			return len(candidates[i].Files) > len(candidates[j].Files)
+		}
+		return candidates[i].Type < candidates[j].Type
+	})
+	return candidates
+}
+
+// candidateFile checks if a file is in the analyzer's language
+func (a *Analyzer) candidateFile(file string) bool {
+	switch a.Language {
+	case "go":
+		return strings.HasSuffix(file, ".go")
+	case "csharp":
+		return isCSharpFile(file)
+	default:
+		return isTypeScriptFile(file)
+	}
+}
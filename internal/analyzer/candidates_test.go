@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const handlerSource = `package handlers
+
+import "net/http"
+
+func GetUserHandler(w http.ResponseWriter, r *http.Request) {}
+`
+
+func TestPatternCandidates(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/user.go":   serviceSource,
+		"services/order.go":  serviceSource,
+		"services/cart.go":   serviceSource,
+		"handlers/user.go":   handlerSource,
+		"handlers/order.go":  handlerSource,
+		"services/broken.go": "package services\n\nfunc {\n",
+		"services/user.ts":   "export class UserService {}\n",
+	})
+	files := []string{}
+	for _, path := range []string{
+		"services/user.go", "services/order.go", "services/cart.go",
+		"handlers/user.go", "handlers/order.go",
+		"services/broken.go", "services/user.ts",
+	} {
+		files = append(files, filepath.Join(root, filepath.FromSlash(path)))
+	}
+
+	// Two handlers are too few to learn from; the broken and TypeScript
+	// files are left out
+	got := New("go").PatternCandidates(files)
+	want := []patterns.PatternCandidate{{Type: patterns.PatternService, Files: []string{
+		filepath.Join(root, "services", "cart.go"),
+		filepath.Join(root, "services", "order.go"),
+		filepath.Join(root, "services", "user.go"),
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("PatternCandidates = %+v, want %+v", got, want)
+	}
+
+	// With a lower minimum both groups are candidates, largest first
+	a := New("go")
+	a.MinExamples = 2
+	got = a.PatternCandidates(files)
+	if len(got) != 2 || got[0].Type != patterns.PatternService || got[1].Type != patterns.PatternHTTPHandler {
+		t.Errorf("PatternCandidates with MinExamples 2 = %+v, want services then handlers", got)
+	}
+
+	if got := New("ruby").PatternCandidates(files); len(got) != 0 {
+		t.Errorf("PatternCandidates for an unsupported language = %+v", got)
+	}
+}
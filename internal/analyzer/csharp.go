@@ -0,0 +1,305 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/walk"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// DefaultMinExamplesCSharp is the group size needed to learn a C# pattern
+const DefaultMinExamplesCSharp = 3
+
+// C# declarations recognized by the text-based parser
+var (
+	csUsing     = regexp.MustCompile(`^(?:global\s+)?using\s+(?:static\s+)?(?:\w+\s*=\s*)?([\w.]+)\s*;`)
+	csNamespace = regexp.MustCompile(`^namespace\s+([\w.]+)`)
+	csType      = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)*((?:(?:public|internal|private|protected|static|sealed|abstract|partial|readonly|file|unsafe|new)\s+)*)(class|interface|record(?:\s+(?:class|struct))?|struct|enum)\s+(\w+)[^:{]*(?::\s*([^{]+))?`)
+	csMethod    = regexp.MustCompile(`^(?:\[[^\]]*\]\s*)*((?:(?:public|private|protected|internal|static|virtual|override|async|abstract|sealed|new|extern|partial)\s+)+)[\w<>\[\],.?]+(?:\s*<[^>]*>)?\s+(\w+)\s*(?:<[^>]*>)?\s*\(`)
+	csAttribute = regexp.MustCompile(`^\[([^\]]+)\]`)
+	csAttrName  = regexp.MustCompile(`(?:^|,)\s*(?:\w+\s*:\s*)?([\w.]+)`)
+	csWhere     = regexp.MustCompile(`\swhere\s+\w+\s*:`)
+)
+
+// learnCSharpPatterns groups C# files into patterns, attaching annotated
+// golden examples and anti-patterns found in them
+func (a *Analyzer) learnCSharpPatterns(files []string) []patterns.Pattern {
+	fileInfos := make([]patterns.FileInfo, 0, len(files))
+	for i, file := range files {
+		a.progress(i+1, len(files))
+		if TooLarge(file, a.MaxFileBytes) {
+			continue
+		}
+		start := time.Now()
+		info, err := ParseCSharpFile(file)
+		a.Profile.File("parse", file, start)
+		if err != nil {
+			continue
+		}
+		fileInfos = append(fileInfos, *info)
+	}
+	defer a.Profile.Stage("extract", time.Now())
+
+	groups := groupCSharpByPattern(fileInfos)
+	goldenExamples, antiPatterns := a.annotationParser().FindAnnotatedExamples(files)
+	return a.annotatedPatterns(groups, a.minExamples(DefaultMinExamplesCSharp), extractCSharpPattern, goldenExamples, antiPatterns)
+}
+
+// groupCSharpByPattern groups C# files by inferred pattern type
+func groupCSharpByPattern(files []patterns.FileInfo) map[patterns.PatternType][]patterns.FileInfo {
+	groups := make(map[patterns.PatternType][]patterns.FileInfo)
+	for _, file := range files {
+		patternType := inferCSharpPatternType(file)
+		groups[patternType] = append(groups[patternType], file)
+	}
+	return groups
+}
+
+// findCSharpFiles recursively finds all C# files, skipping build output
+func findCSharpFiles(root string, includeTests bool) ([]string, error) {
+	var files []string
+	skipDir := func(name string) bool { return name == "bin" || name == "obj" }
+	err := walk.Files(root, skipDir, func(path string) error {
+		if isCSharpFile(path) && (includeTests || !patterns.IsTestFile(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// isCSharpFile checks for a C# extension
+func isCSharpFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".cs"
+}
+
+// ParseCSharpFile parses a C# file using text analysis
+func ParseCSharpFile(filePath string) (*patterns.FileInfo, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCSharpSource(filePath, content), nil
+}
+
+// ParseCSharpSource parses C# contents as if read from filePath: using
+// directives become imports, the namespace the package, and class,
+// interface, record, struct and enum declarations the types. Base types are
+// recorded as embeds.
+func ParseCSharpSource(filePath string, content []byte) *patterns.FileInfo {
+	info := &patterns.FileInfo{
+		Path:        filePath,
+		Package:     filepath.Base(filepath.Dir(filePath)),
+		Imports:     []string{},
+		ImportLines: map[string]int{},
+		Functions:   []patterns.FunctionInfo{},
+		Types:       []patterns.TypeInfo{},
+		Attributes:  []string{},
+	}
+
+	seenAttributes := map[string]bool{}
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := csUsing.FindStringSubmatch(trimmed); m != nil {
+			if _, seen := info.ImportLines[m[1]]; !seen {
+				info.Imports = append(info.Imports, m[1])
+				info.ImportLines[m[1]] = i + 1
+			}
+			continue
+		}
+		if m := csNamespace.FindStringSubmatch(trimmed); m != nil {
+			info.Package = m[1]
+			continue
+		}
+
+		if m := csAttribute.FindStringSubmatch(trimmed); m != nil {
+			for _, name := range csAttrName.FindAllStringSubmatch(stripArguments(m[1]), -1) {
+				attr := strings.TrimSuffix(name[1], "Attribute")
+				if !seenAttributes[attr] {
+					seenAttributes[attr] = true
+					info.Attributes = append(info.Attributes, attr)
+				}
+			}
+		}
+
+		// Generic constraints would read as base types
+		decl := trimmed
+		if loc := csWhere.FindStringIndex(decl); loc != nil {
+			decl = decl[:loc[0]]
+		}
+		if m := csType.FindStringSubmatch(decl); m != nil {
+			t := patterns.TypeInfo{
+				Name:     m[3],
+				Kind:     strings.Fields(m[2])[0],
+				Line:     i + 1,
+				Exported: strings.Contains(m[1], "public"),
+			}
+			for _, base := range strings.Split(m[4], ",") {
+				if base = strings.TrimSpace(stripArguments(base)); base != "" {
+					t.Embeds = append(t.Embeds, base)
+				}
+			}
+			info.Types = append(info.Types, t)
+			continue
+		}
+		if m := csMethod.FindStringSubmatch(trimmed); m != nil {
+			info.Functions = append(info.Functions, patterns.FunctionInfo{
+				Name:     m[2],
+				Line:     i + 1,
+				Exported: strings.Contains(m[1], "public"),
+			})
+		}
+	}
+	return info
+}
+
+// stripArguments drops parenthesized and generic arguments, so
+// Route("api/[controller]") becomes Route and IRepo<User> becomes IRepo
+func stripArguments(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// inferCSharpPatternType determines the pattern type of a C# file from its
+// attributes, declared type names and location
+func inferCSharpPatternType(file patterns.FileInfo) patterns.PatternType {
+	if patterns.IsTestFile(file.Path) {
+		return patterns.PatternTest
+	}
+	for _, attr := range file.Attributes {
+		if attr == "ApiController" {
+			return patterns.PatternHTTPHandler
+		}
+	}
+	for _, t := range file.Types {
+		switch {
+		case strings.HasSuffix(t.Name, "Controller") || embeds(t, "ControllerBase", "Controller"):
+			return patterns.PatternHTTPHandler
+		case strings.HasSuffix(t.Name, "Repository"):
+			return patterns.PatternRepository
+		case strings.HasSuffix(t.Name, "Service"):
+			return patterns.PatternService
+		case strings.HasSuffix(t.Name, "Middleware"):
+			return patterns.PatternMiddleware
+		}
+	}
+
+	path := strings.ToLower(filepath.ToSlash(file.Path))
+	if strings.Contains(path, "/models/") || strings.Contains(path, "/entities/") ||
+		strings.HasSuffix(file.Package, ".Models") || strings.HasSuffix(file.Package, ".Entities") {
+		return patterns.PatternModel
+	}
+	return patterns.PatternUtil
+}
+
+// embeds checks if a type derives from any of the named base types
+func embeds(t patterns.TypeInfo, names ...string) bool {
+	for _, base := range t.Embeds {
+		for _, name := range names {
+			if base == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extractCSharpPattern creates a pattern from a group of similar C# files:
+// using directives in >80% of them are required, and attributes they share
+// are recorded as structure elements
+func extractCSharpPattern(patternType patterns.PatternType, group []patterns.FileInfo) patterns.Pattern {
+	pattern := patterns.Pattern{
+		ID:         generatePatternID(patternType),
+		Name:       string(patternType),
+		Type:       patternType,
+		Confidence: calculateConfidence(group),
+		SeenCount:  len(group),
+		Version:    "1.0",
+	}
+
+	switch patternType {
+	case patterns.PatternHTTPHandler:
+		pattern.Detection = patterns.DetectionRule{
+			FilePattern:   "*Controller.cs",
+			StructPattern: "class.*Controller",
+		}
+	case patterns.PatternService:
+		pattern.Detection = patterns.DetectionRule{
+			FilePattern:   "*Service.cs",
+			StructPattern: "class.*Service",
+		}
+	case patterns.PatternRepository:
+		pattern.Detection = patterns.DetectionRule{
+			FilePattern:   "*Repository.cs",
+			StructPattern: "class.*Repository",
+		}
+	case patterns.PatternTest:
+		pattern.Detection = patterns.DetectionRule{
+			FilePattern: "*Tests.cs",
+		}
+	}
+
+	pattern.Structure = extractCommonStructure(group)
+	pattern.Structure.Elements = append(pattern.Structure.Elements, extractCommonAttributes(group)...)
+	pattern.Fingerprint = pattern.Structure.Fingerprint()
+	return pattern
+}
+
+// extractCommonAttributes returns the attributes used by >80% of the files
+func extractCommonAttributes(group []patterns.FileInfo) []patterns.StructureElement {
+	counts := make(map[string]int)
+	order := []string{}
+	for _, file := range group {
+		for _, attr := range file.Attributes {
+			if counts[attr] == 0 {
+				order = append(order, attr)
+			}
+			counts[attr]++
+		}
+	}
+
+	threshold := int(float64(len(group)) * 0.8)
+	elements := []patterns.StructureElement{}
+	for _, attr := range order {
+		if counts[attr] < threshold {
+			continue
+		}
+		element := patterns.StructureElement{
+			Name:    attr,
+			Type:    patterns.ElementAttribute,
+			Pattern: `\[` + regexp.QuoteMeta(attr) + `\b`,
+		}
+		re := regexp.MustCompile(element.Pattern)
+		for _, file := range group {
+			src, err := os.ReadFile(file.Path)
+			if err != nil {
+				continue
+			}
+			element.Examples = addExample(element.Examples, matchLine(re, src))
+			if len(element.Examples) == maxElementExamples {
+				break
+			}
+		}
+		elements = append(elements, element)
+	}
+	return elements
+}
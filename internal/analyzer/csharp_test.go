@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// parseCSharpFixture parses a file under testdata/csharp
+func parseCSharpFixture(t *testing.T, name string) *patterns.FileInfo {
+	t.Helper()
+	info, err := ParseCSharpFile(filepath.Join("testdata", "csharp", filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+// typeNamed finds a parsed type by name
+func typeNamed(t *testing.T, info *patterns.FileInfo, name string) patterns.TypeInfo {
+	t.Helper()
+	for _, typ := range info.Types {
+		if typ.Name == name {
+			return typ
+		}
+	}
+	t.Fatalf("%s: no type %s in %+v", info.Path, name, info.Types)
+	return patterns.TypeInfo{}
+}
+
+func TestParseCSharpUsings(t *testing.T) {
+	info := parseCSharpFixture(t, "Services/UserService.cs")
+	// global, static and aliased usings import the namespace or type named
+	want := []string{"System", "System.Math", "System.Text.Json.JsonSerializer", "Shop.Repositories"}
+	if !reflect.DeepEqual(info.Imports, want) {
+		t.Errorf("Imports = %v, want %v", info.Imports, want)
+	}
+	if line := info.ImportLines["Shop.Repositories"]; line != 4 {
+		t.Errorf("Shop.Repositories imported on line %d, want 4", line)
+	}
+}
+
+func TestParseCSharpNamespaces(t *testing.T) {
+	tests := map[string]string{
+		"Services/UserService.cs":        "Shop.Services",    // Block-scoped
+		"Controllers/UsersController.cs": "Shop.Controllers", // File-scoped
+		"Models/User.cs":                 "Shop.Models",
+	}
+	for name, want := range tests {
+		if got := parseCSharpFixture(t, name).Package; got != want {
+			t.Errorf("%s: namespace %q, want %q", name, got, want)
+		}
+	}
+	// Without one, the directory stands in
+	if got := ParseCSharpSource(filepath.Join("Api", "Plain.cs"), []byte("public class Plain { }\n")).Package; got != "Api" {
+		t.Errorf("namespace without a declaration = %q, want the directory Api", got)
+	}
+}
+
+func TestParseCSharpAttributes(t *testing.T) {
+	controller := parseCSharpFixture(t, "Controllers/UsersController.cs")
+	if want := []string{"ApiController", "Route", "HttpGet"}; !reflect.DeepEqual(controller.Attributes, want) {
+		t.Errorf("controller attributes = %v, want %v", controller.Attributes, want)
+	}
+	// Several attributes in one list, one with named arguments
+	repository := parseCSharpFixture(t, "Repositories/UserRepository.cs")
+	if want := []string{"Serializable", "Obsolete"}; !reflect.DeepEqual(repository.Attributes, want) {
+		t.Errorf("repository attributes = %v, want %v", repository.Attributes, want)
+	}
+	if got := parseCSharpSourceAttributes(`[System.ObsoleteAttribute("old"), DebuggerDisplay(Name = "x")]`); !reflect.DeepEqual(got, []string{"System.Obsolete", "DebuggerDisplay"}) {
+		t.Errorf("attributes = %v, want the Attribute suffix dropped", got)
+	}
+}
+
+// parseCSharpSourceAttributes parses a single line of C# for its attributes
+func parseCSharpSourceAttributes(line string) []string {
+	return ParseCSharpSource("Attrs.cs", []byte(line+"\npublic class Attrs { }\n")).Attributes
+}
+
+func TestParseCSharpTypes(t *testing.T) {
+	service := parseCSharpFixture(t, "Services/UserService.cs")
+	iface := typeNamed(t, service, "IUsersService")
+	if iface.Kind != "interface" || !iface.Exported || iface.Line != 8 {
+		t.Errorf("IUsersService = %+v, want an exported interface on line 8", iface)
+	}
+	impl := typeNamed(t, service, "UserService")
+	if impl.Kind != "class" || impl.Exported || !reflect.DeepEqual(impl.Embeds, []string{"IUsersService", "IDisposable"}) {
+		t.Errorf("UserService = %+v, want an internal class deriving IUsersService and IDisposable", impl)
+	}
+	methods := []string{}
+	for _, fn := range service.Functions {
+		methods = append(methods, fn.Name)
+	}
+	if want := []string{"FindAsync", "Dispose"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("methods = %v, want %v without the interface's declaration", methods, want)
+	}
+
+	// Generic arguments and constraints aren't base types
+	repository := typeNamed(t, parseCSharpFixture(t, "Repositories/UserRepository.cs"), "UserRepository")
+	if !reflect.DeepEqual(repository.Embeds, []string{"IRepository"}) {
+		t.Errorf("UserRepository bases = %v, want IRepository", repository.Embeds)
+	}
+	constrained := ParseCSharpSource("Box.cs", []byte("public class Box<T> where T : class\n{\n}\n"))
+	if box := typeNamed(t, constrained, "Box"); len(box.Embeds) != 0 {
+		t.Errorf("Box bases = %v, want none", box.Embeds)
+	}
+
+	user := typeNamed(t, parseCSharpFixture(t, "Models/User.cs"), "User")
+	if user.Kind != "record" || !user.Exported {
+		t.Errorf("User = %+v, want an exported record", user)
+	}
+}
+
+func TestInferCSharpPatternType(t *testing.T) {
+	tests := map[string]patterns.PatternType{
+		"Controllers/UsersController.cs": patterns.PatternHTTPHandler,
+		"Services/UserService.cs":        patterns.PatternService,
+		"Repositories/UserRepository.cs": patterns.PatternRepository,
+		"Models/User.cs":                 patterns.PatternModel,
+	}
+	for name, want := range tests {
+		if got := inferCSharpPatternType(*parseCSharpFixture(t, name)); got != want {
+			t.Errorf("%s: type %s, want %s", name, got, want)
+		}
+	}
+
+	// [ApiController] or a controller base type make a handler whatever it's named
+	for _, src := range []string{
+		"[ApiController]\npublic class Accounts { }\n",
+		"public class Accounts : ControllerBase { }\n",
+	} {
+		if got := inferCSharpPatternType(*ParseCSharpSource("Api/Accounts.cs", []byte(src))); got != patterns.PatternHTTPHandler {
+			t.Errorf("%q: type %s, want http_handler", src, got)
+		}
+	}
+	if got := inferCSharpPatternType(*ParseCSharpSource("Tests/UserServiceTests.cs", []byte("public class UserServiceTests { }\n"))); got != patterns.PatternTest {
+		t.Errorf("test file: type %s, want test", got)
+	}
+}
+
+func TestExtractCSharpPatterns(t *testing.T) {
+	found, err := New("csharp").ExtractPatterns(filepath.Join("testdata", "csharp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the controllers are numerous enough to learn from
+	if len(found) != 1 || found[0].Type != patterns.PatternHTTPHandler {
+		t.Fatalf("patterns = %+v, want the controllers' alone", found)
+	}
+	p := found[0]
+	if p.SeenCount != 3 {
+		t.Errorf("learned from %d controllers, want 3 without bin/", p.SeenCount)
+	}
+	if p.Detection.FilePattern != "*Controller.cs" {
+		t.Errorf("file pattern = %q, want *Controller.cs", p.Detection.FilePattern)
+	}
+	if want := []string{"System.Threading.Tasks", "Microsoft.AspNetCore.Mvc", "Shop.Services"}; !reflect.DeepEqual(p.Structure.Required, want) {
+		t.Errorf("required usings = %v, want %v", p.Structure.Required, want)
+	}
+	attributes := map[string]bool{}
+	for _, elem := range p.Structure.Elements {
+		if elem.Type == patterns.ElementAttribute {
+			attributes[elem.Name] = len(elem.Examples) > 0
+		}
+	}
+	for _, attr := range []string{"ApiController", "Route", "HttpGet"} {
+		if !attributes[attr] {
+			t.Errorf("attribute elements = %v, want %s with examples", attributes, attr)
+		}
+	}
+}
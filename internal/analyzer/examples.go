@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// maxElementExamples bounds the examples kept per structure element, so
+// learned configs stay small
+const maxElementExamples = 3
+
+// sourceLines caches file contents split into lines
+type sourceLines map[string][]string
+
+// line returns the trimmed text of a 1-based line, or "" if unreadable
+func (s sourceLines) line(path string, n int) string {
+	lines, ok := s[path]
+	if !ok {
+		if src, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(src), "\n")
+		}
+		s[path] = lines
+	}
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[n-1])
+}
+
+// importExamples returns distinct import lines for imp across the group,
+// e.g. aliased and plain forms of the same package
+func importExamples(imp string, group []patterns.FileInfo, lines sourceLines) []string {
+	examples := []string{}
+	for _, file := range group {
+		n, ok := file.ImportLines[imp]
+		if !ok {
+			continue
+		}
+		examples = addExample(examples, lines.line(file.Path, n))
+		if len(examples) == maxElementExamples {
+			break
+		}
+	}
+	return examples
+}
+
+// matchLine returns the trimmed line holding the first match of re in src
+func matchLine(re *regexp.Regexp, src []byte) string {
+	loc := re.FindIndex(src)
+	if loc == nil {
+		return ""
+	}
+	start := bytes.LastIndexByte(src[:loc[0]], '\n') + 1
+	end := len(src)
+	if i := bytes.IndexByte(src[loc[0]:], '\n'); i >= 0 {
+		end = loc[0] + i
+	}
+	return strings.TrimSpace(string(src[start:end]))
+}
+
+// addExample appends example unless it is empty or already present
+func addExample(examples []string, example string) []string {
+	if example == "" {
+		return examples
+	}
+	for _, e := range examples {
+		if e == example {
+			return examples
+		}
+	}
+	return append(examples, example)
+}
@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// knownEmbeds are the fields of widely embedded base structs from outside
+// the repository
+var knownEmbeds = map[string][]string{
+	"gorm.Model": {"ID", "CreatedAt", "UpdatedAt", "DeletedAt"},
+}
+
+// embeddedName returns the type name of an embedded field, without pointer
+// or type arguments, e.g. gorm.Model for *gorm.Model
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return embeddedName(t.X) + "." + t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// TypesByName indexes the types declared across files by name
+func TypesByName(files []patterns.FileInfo) map[string]patterns.TypeInfo {
+	types := make(map[string]patterns.TypeInfo)
+	for _, file := range files {
+		for _, t := range file.Types {
+			types[t.Name] = t
+		}
+	}
+	return types
+}
+
+// StructFields returns a struct's fields including those promoted from
+// embedded structs, which are looked up in types and knownEmbeds. Embedded
+// types are resolved recursively. ok is false if any couldn't be resolved,
+// so the field list may be incomplete.
+func StructFields(t patterns.TypeInfo, types map[string]patterns.TypeInfo) (fields []string, ok bool) {
+	seen := map[string]bool{t.Name: true}
+	return collectFields(t, types, seen, fields)
+}
+
+func collectFields(t patterns.TypeInfo, types map[string]patterns.TypeInfo, seen map[string]bool, fields []string) ([]string, bool) {
+	ok := true
+	fields = append(fields, t.Fields...)
+	for _, embed := range t.Embeds {
+		// The embedded type is itself a field, named after the type
+		fields = append(fields, embed[strings.LastIndex(embed, ".")+1:])
+		if known, found := knownEmbeds[embed]; found {
+			fields = append(fields, known...)
+			continue
+		}
+		base, found := types[embed]
+		if !found || base.Kind != "struct" {
+			ok = false
+			continue
+		}
+		if seen[embed] {
+			continue
+		}
+		seen[embed] = true
+		var baseOK bool
+		fields, baseOK = collectFields(base, types, seen, fields)
+		ok = ok && baseOK
+	}
+	return fields, ok
+}
+
+// extractCommonFields returns the struct fields present in >80% of model
+// files, counting fields promoted from embedded base structs
+func extractCommonFields(group []patterns.FileInfo) []patterns.StructureElement {
+	types := TypesByName(group)
+	counts := make(map[string]int)
+	for _, file := range group {
+		seen := make(map[string]bool)
+		for _, t := range file.Types {
+			if t.Kind != "struct" || !t.Exported {
+				continue
+			}
+			fields, _ := StructFields(t, types)
+			for _, field := range fields {
+				seen[field] = true
+			}
+		}
+		for field := range seen {
+			counts[field]++
+		}
+	}
+
+	threshold := int(float64(len(group)) * 0.8)
+	common := []string{}
+	for field, count := range counts {
+		if count > 0 && count >= threshold {
+			common = append(common, field)
+		}
+	}
+	sort.Strings(common)
+
+	elements := []patterns.StructureElement{}
+	for _, field := range common {
+		elements = append(elements, patterns.StructureElement{
+			Name:    field,
+			Type:    patterns.ElementField,
+			Pattern: `\b` + regexp.QuoteMeta(field) + `\b`,
+		})
+	}
+	return elements
+}
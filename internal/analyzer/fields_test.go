@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const embeddingModels = `package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BaseEntity struct {
+	ID string
+	Timestamps
+}
+
+type Order struct {
+	*BaseEntity
+	Total int
+}
+
+type Invoice struct {
+	gorm.Model
+	Amount int
+}
+
+type Refund struct {
+	shared.Entity
+	Amount int
+}
+
+type Node struct {
+	*Node
+	Value int
+}
+`
+
+func TestStructFields(t *testing.T) {
+	info, err := ParseGoSource("models/models.go", []byte(embeddingModels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := TypesByName([]patterns.FileInfo{*info})
+
+	tests := []struct {
+		name   string
+		embeds []string
+		fields []string
+		ok     bool
+	}{
+		{"Order", []string{"BaseEntity"}, []string{"BaseEntity", "CreatedAt", "ID", "Timestamps", "Total", "UpdatedAt"}, true},
+		{"Invoice", []string{"gorm.Model"}, []string{"Amount", "CreatedAt", "DeletedAt", "ID", "Model", "UpdatedAt"}, true},
+		{"Refund", []string{"shared.Entity"}, []string{"Amount", "Entity"}, false},
+		{"Node", []string{"Node"}, []string{"Node", "Value"}, true}, // Self-embedding terminates
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := types[tt.name]
+			if !reflect.DeepEqual(typ.Embeds, tt.embeds) {
+				t.Errorf("Embeds = %v, want %v", typ.Embeds, tt.embeds)
+			}
+			fields, ok := StructFields(typ, types)
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.fields) || ok != tt.ok {
+				t.Errorf("StructFields = %v, %v; want %v, %v", fields, ok, tt.fields, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExtractCommonFieldsCountsEmbeds(t *testing.T) {
+	files := []string{`package models
+
+type BaseEntity struct {
+	ID        string
+	CreatedAt string
+}
+`, `package models
+
+type User struct {
+	BaseEntity
+	Name string
+}
+`, `package models
+
+type Order struct {
+	ID        string
+	CreatedAt string
+	Total     int
+}
+`, `package models
+
+type Coupon struct {
+	BaseEntity
+	Code string
+}
+`}
+	group := []patterns.FileInfo{}
+	for _, src := range files {
+		info, err := ParseGoSource("models/m.go", []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		group = append(group, *info)
+	}
+
+	names := []string{}
+	for _, elem := range extractCommonFields(group) {
+		names = append(names, elem.Name)
+	}
+	if want := []string{"CreatedAt", "ID"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("common fields = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,3 @@
+namespace Shop.Models;
+
+public record User(int Id, string Name);
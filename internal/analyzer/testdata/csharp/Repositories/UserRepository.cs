@@ -0,0 +1,9 @@
+using Microsoft.EntityFrameworkCore;
+
+namespace Shop.Repositories;
+
+[Serializable, Obsolete("use the generic repository")]
+public class UserRepository : IRepository<User> where User : class
+{
+    public Task<User> GetAsync(int id) => throw new NotImplementedException();
+}
@@ -0,0 +1,24 @@
+global using System;
+using static System.Math;
+using Json = System.Text.Json.JsonSerializer;
+using Shop.Repositories;
+
+namespace Shop.Services
+{
+    public interface IUsersService
+    {
+        Task<User> FindAsync(int id);
+    }
+
+    internal sealed class UserService : IUsersService, IDisposable
+    {
+        private readonly IRepository<User> _users;
+
+        public async Task<User> FindAsync(int id)
+        {
+            return await _users.GetAsync(id);
+        }
+
+        public void Dispose() { }
+    }
+}
@@ -0,0 +1,3 @@
+namespace Shop.Build;
+
+public class BuildController : ControllerBase { }
@@ -0,0 +1,5 @@
+//go:build ignore
+
+package services
+
+type IgnoredService struct{}
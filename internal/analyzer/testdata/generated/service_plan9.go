@@ -0,0 +1,3 @@
+package services
+
+type Plan9Service struct{}
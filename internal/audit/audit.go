@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const LogFileName = ".code-on-rails-audit.log"
+
+// Entry records a single governance action such as blessing a file
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"` // bless, unbless, pattern-disable, pattern-enable
+	Path    string    `json:"path"`
+	Pattern string    `json:"pattern"`
+	User    string    `json:"user"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// Append adds an entry to the audit log, one JSON object per line
+func Append(path string, entry Entry) error {
+	if path == "" {
+		path = LogFileName
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// GitUser returns "Name <email>" from git config, or "unknown" if unset
+func GitUser() string {
+	name := gitConfig("user.name")
+	email := gitConfig("user.email")
+	switch {
+	case name != "" && email != "":
+		return fmt.Sprintf("%s <%s>", name, email)
+	case name != "":
+		return name
+	case email != "":
+		return email
+	default:
+		return "unknown"
+	}
+}
+
+// gitConfig reads a single git config value
+func gitConfig(key string) string {
+	output, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
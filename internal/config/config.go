@@ -1,31 +1,202 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/loop-hub/code-on-rails/internal/remote"
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
 	"gopkg.in/yaml.v3"
 )
 
-const ConfigFileName = ".code-on-rails.yml"
+// The config file may be named either way; AltConfigFileName wins when a
+// directory has both
+const (
+	ConfigFileName    = ".code-on-rails.yml"
+	AltConfigFileName = ".code-on-rails.yaml"
+)
+
+// DefaultPath is the config used when a function is passed an empty path.
+// cr points it at --config or the config found by Find; while unset, the
+// config in the working directory is used (see FileIn).
+var DefaultPath string
+
+// ResolvePath returns path, or the default config when it is empty
+func ResolvePath(path string) string {
+	if path != "" {
+		return path
+	}
+	if DefaultPath != "" {
+		return DefaultPath
+	}
+	return FileIn(".")
+}
+
+// FileIn returns the config file of dir: .code-on-rails.yaml when it
+// exists, else .code-on-rails.yml, whether or not it exists yet
+func FileIn(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, AltConfigFileName)); err == nil {
+		return filepath.Join(dir, AltConfigFileName)
+	}
+	return filepath.Join(dir, ConfigFileName)
+}
 
 // Config represents the full configuration
 type Config struct {
 	Version   string             `yaml:"version"`
 	Language  string             `yaml:"language"`
+	Languages []string           `yaml:"languages,omitempty"` // Every learned language, first the original, once init --append added more
 	AISource  string             `yaml:"ai_source"`
 	Patterns  []patterns.Pattern `yaml:"patterns"`
 	Settings  Settings           `yaml:"settings"`
 	Detection DetectionConfig    `yaml:"detection"`
+
+	// PatternsURL names a shared pattern library, a skill file or a git
+	// repository holding one, whose patterns are matched besides the config's
+	PatternsURL string `yaml:"patterns_url,omitempty"`
+	// RemotePatterns are the library's patterns, read-only and never saved
+	RemotePatterns []patterns.Pattern `yaml:"-"`
+}
+
+// RefreshRemote makes Load fetch the patterns_url library even when its
+// cached copy is recent
+var RefreshRemote bool
+
+// AllPatterns returns the config's patterns followed by the remote ones
+// whose IDs it doesn't already use
+func (c *Config) AllPatterns() []patterns.Pattern {
+	if len(c.RemotePatterns) == 0 {
+		return c.Patterns
+	}
+	ids := make(map[string]bool, len(c.Patterns))
+	for _, p := range c.Patterns {
+		ids[p.ID] = true
+	}
+	all := append([]patterns.Pattern{}, c.Patterns...)
+	for _, p := range c.RemotePatterns {
+		if !ids[p.ID] {
+			all = append(all, p)
+		}
+	}
+	return all
 }
 
 // Settings for pattern matching behavior
 type Settings struct {
-	AutoApproveThreshold float64 `yaml:"auto_approve_threshold"`
-	LearnOnMerge         bool    `yaml:"learn_on_merge"`
+	AutoApproveThreshold  float64              `yaml:"auto_approve_threshold"`
+	LearnOnMerge          bool                 `yaml:"learn_on_merge"`
+	Checks                []string             `yaml:"checks,omitempty"`             // Custom structural checks to enable
+	LoggingPackages       []string             `yaml:"logging_packages,omitempty"`   // Extra logging imports for the logging-consistency check
+	SecretAllowlist       []string             `yaml:"secret_allowlist,omitempty"`   // Literals containing these are never flagged by hardcoded-secrets
+	SecretEntropy         float64              `yaml:"secret_entropy,omitempty"`     // Bits per character above which hardcoded-secrets flags a token (default 4.0)
+	TodoDensity           float64              `yaml:"todo_density,omitempty"`       // TODO/FIXME/not-implemented markers per 100 lines todo-density allows beyond the reference (default 1.0)
+	ValidationCalls       []string             `yaml:"validation_calls,omitempty"`   // Extra calls input-validation accepts as validating a decoded request
+	TransactionCalls      []string             `yaml:"transaction_calls,omitempty"`  // Extra methods transaction-boundaries accepts as opening a transaction
+	RouteFiles            []string             `yaml:"route_files,omitempty"`        // Globs of the files route-registration looks for handlers in (default **/routes*.go, **/router*.go)
+	CleanupCalls          map[string]string    `yaml:"cleanup_calls,omitempty"`      // Extra acquiring calls deferred-close recognizes, each with the method releasing its result
+	ConstructionCalls     []string             `yaml:"construction_calls,omitempty"` // Extra calls dependency-injection counts as constructing a dependency inline
+	EnvPackages           []string             `yaml:"env_packages,omitempty"`       // Extra packages env-access allows to read environment variables, by name (config and main are built in)
+	NamingConventions     map[string]string    `yaml:"naming_conventions,omitempty"` // Pattern type -> naming-conventions' convention for exported names: mixed_caps, pascal_case, camel_case, hook or none
+	StrictImports         bool                 `yaml:"strict_imports,omitempty"`     // Note imports the reference lacks, like cr check --strict-imports
+	ForbiddenImports      map[string][]string  `yaml:"forbidden_imports,omitempty"`  // Pattern type -> imports strict_imports warns about, replacing that type's defaults
+	IgnoreDeviations      []string             `yaml:"ignore_deviations,omitempty"`  // Deviation elements dropped before scoring, e.g. import_alias
+	IgnoreDirs            []string             `yaml:"ignore_dirs,omitempty"`        // Directory names or globs never scanned, replacing the defaults (vendor, node_modules, dist, build, .next, bin, obj, .*)
+	Scoring               ScoringSettings      `yaml:"scoring,omitempty"`
+	RequireBlessReason    bool                 `yaml:"require_bless_reason,omitempty"`    // Make cr bless --reason mandatory
+	SimilarityMethod      string               `yaml:"similarity_method,omitempty"`       // cosine (default) or cosine_normalized
+	MinExamples           int                  `yaml:"min_examples,omitempty"`            // Files needed to learn a pattern (default: 3 for Go, 2 for TypeScript)
+	MatchMode             string               `yaml:"match_mode,omitempty"`              // best (default) or consensus
+	MaxFileBytes          int64                `yaml:"max_file_bytes,omitempty"`          // Larger files are skipped (default 512KB, negative for no limit)
+	FileTimeout           time.Duration        `yaml:"file_timeout,omitempty"`            // Per-file matching limit, e.g. 30s (default 30s, negative for none)
+	SystemicThreshold     int                  `yaml:"systemic_threshold,omitempty"`      // Files sharing a deviation before it is escalated (default 5, negative for never)
+	ReviewLinesPerMinute  int                  `yaml:"review_lines_per_minute,omitempty"` // Review speed behind time-saved estimates (default 20)
+	IncludeGenerated      bool                 `yaml:"include_generated,omitempty"`       // Learn from generated Go files ("Code generated ... DO NOT EDIT."), skipped by default
+	CurrentPlatformOnly   bool                 `yaml:"current_platform_only,omitempty"`   // Don't learn from Go files whose build tags exclude the platform cr runs on
+	ApprovalDropThreshold float64              `yaml:"approval_drop_threshold,omitempty"` // Percentage points a pattern's approval rate may drop below --compare-baseline-metrics (default 20)
+	Weights               patterns.TierWeights `yaml:"weights,omitempty"`                 // Weighted-score multipliers of golden, blessed and discovered references (default 2.0, 1.5, 1.0)
+	ExitCodes             ExitCodes            `yaml:"exit_codes,omitempty"`              // cr check's exit code by worst outcome (default errors 1, warnings 0)
+
+	// LanguageOverrides maps path prefixes to languages, e.g. web: typescript
+	// in a Go repo. Files outside every prefix use the top-level language.
+	LanguageOverrides map[string]string `yaml:"language_overrides,omitempty"`
+}
+
+// ScoringSettings overrides the matcher's scoring weights. Unset fields keep
+// their defaults: missing import 5, missing error handling 10, error 10,
+// warning 5, structure weight 1.0, tie epsilon 1.0, path specificity bonus 2.0.
+type ScoringSettings struct {
+	MissingImportPenalty        *float64 `yaml:"missing_import_penalty,omitempty"`
+	MissingErrorHandlingPenalty *float64 `yaml:"missing_error_handling_penalty,omitempty"`
+	ErrorPenalty                *float64 `yaml:"error_penalty,omitempty"`
+	WarningPenalty              *float64 `yaml:"warning_penalty,omitempty"`
+	StructureWeight             *float64 `yaml:"structure_weight,omitempty"`
+	TieEpsilon                  *float64 `yaml:"tie_epsilon,omitempty"`
+	PathSpecificityBonus        *float64 `yaml:"path_specificity_bonus,omitempty"`
 }
 
+// ExitCodes are the codes cr check exits with for its worst outcome. Unset
+// fields keep their defaults, those of cr check before they could be set:
+// errors 1, warnings 0. A clean run always exits 0.
+type ExitCodes struct {
+	Errors   *int `yaml:"errors,omitempty"`   // A file has an error deviation
+	Warnings *int `yaml:"warnings,omitempty"` // Files need review, but none has an error
+}
+
+// Code returns the exit code for a run: the errors code if a file has an
+// error, otherwise the warnings code if a file needs review, otherwise 0
+func (e ExitCodes) Code(errors, review bool) int {
+	switch {
+	case errors && e.Errors != nil:
+		return *e.Errors
+	case errors:
+		return 1
+	case review && e.Warnings != nil:
+		return *e.Warnings
+	}
+	return 0
+}
+
+// For returns the exit code for a run's matches: files auto-approval left
+// for review count as warnings, unless one has an error deviation
+func (e ExitCodes) For(matches []patterns.PatternMatch) int {
+	errors, review := false, false
+	for _, match := range matches {
+		if match.AutoApprove {
+			continue
+		}
+		review = true
+		for _, dev := range match.Deviations {
+			if dev.Severity == patterns.SeverityError {
+				errors = true
+			}
+		}
+	}
+	return e.Code(errors, review)
+}
+
+// Validate checks the codes can be told apart from a shell's own: 126 and
+// up mean a command couldn't run or was killed by a signal
+func (e ExitCodes) Validate() error {
+	codes := []struct {
+		name string
+		code *int
+	}{{"errors", e.Errors}, {"warnings", e.Warnings}}
+	for _, c := range codes {
+		if c.code != nil && (*c.code < 0 || *c.code > 125) {
+			return fmt.Errorf("%s: %d is outside 0-125", c.name, *c.code)
+		}
+	}
+	return nil
+}
+
+// DetectionMethods lists the values detection.method can take
+var DetectionMethods = []string{"commit_message", "git_notes", "heuristic", "branch", "all"}
+
 // DetectionConfig for AI code detection
 type DetectionConfig struct {
 	Method         string   `yaml:"method"` // commit_message, git_notes, heuristic, branch, all
@@ -33,11 +204,23 @@ type DetectionConfig struct {
 	BranchPrefixes []string `yaml:"branch_prefixes"`
 }
 
+// Validate reports detection settings that can't work, e.g. the branch
+// method without any branch prefix to look for
+func (d DetectionConfig) Validate() error {
+	if d.Method != "branch" && d.Method != "all" {
+		return nil
+	}
+	for _, prefix := range d.BranchPrefixes {
+		if prefix != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("detection method %q has no branch_prefixes, so no branch counts as AI-generated", d.Method)
+}
+
 // Load reads configuration from file
 func Load(path string) (*Config, error) {
-	if path == "" {
-		path = ConfigFileName
-	}
+	path = ResolvePath(path)
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -48,8 +231,26 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	setDefaults(&cfg)
 
-	// Set defaults
+	if cfg.PatternsURL != "" {
+		if cfg.RemotePatterns, err = remote.Patterns(cfg.PatternsURL, RefreshRemote); err != nil {
+			return nil, fmt.Errorf("failed to load patterns_url: %w", err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// Defaults returns the configuration Load produces from an empty file
+func Defaults() *Config {
+	var cfg Config
+	setDefaults(&cfg)
+	return &cfg
+}
+
+// setDefaults fills in everything a config file leaves unset
+func setDefaults(cfg *Config) {
 	if cfg.Settings.AutoApproveThreshold == 0 {
 		cfg.Settings.AutoApproveThreshold = 95.0
 	}
@@ -65,33 +266,110 @@ func Load(path string) (*Config, error) {
 	if len(cfg.Detection.BranchPrefixes) == 0 {
 		cfg.Detection.BranchPrefixes = []string{"claude/", "ai/", "copilot/", "cursor/", "claude-", "ai-", "copilot-", "cursor-"}
 	}
+	for i := range cfg.Patterns {
+		p := &cfg.Patterns[i]
+		if !p.IsDeclared() {
+			continue
+		}
+		if p.Name == "" {
+			p.Name = string(p.Type)
+		}
+		if p.ID == "" {
+			p.ID = string(p.Type) + "_declared"
+		}
+	}
+}
 
-	return &cfg, nil
+// Fingerprint returns a short hash of the config file's contents, so results
+// produced under different configs can be told apart
+func Fingerprint(path string) (string, error) {
+	path = ResolvePath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12], nil
 }
 
-// Save writes configuration to file
+// Save writes configuration to file atomically, as an edit of the file
+// already there so unchanged parts stay byte for byte the same (see
+// marshalOver). Callers that load, modify and save should hold Lock
+// throughout.
 func Save(cfg *Config, path string) error {
-	if path == "" {
-		path = ConfigFileName
-	}
+	path = ResolvePath(path)
 
-	data, err := yaml.Marshal(cfg)
+	previous, _ := os.ReadFile(path) // A missing file is written from scratch
+	data, err := marshalOver(previous, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeAtomic(path, data); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
 }
 
+// writeAtomic writes data to a temp file beside path and renames it into
+// place, so readers and crashes never see a partially written file. The
+// directory is synced afterwards so the rename survives a crash too.
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Find looks for the nearest config from dir upward, like git does for
+// .git, stopping after the git root or at the filesystem root. It returns
+// an os.ErrNotExist error when there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := FileIn(dir)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", fmt.Errorf("no %s or %s found: %w", ConfigFileName, AltConfigFileName, os.ErrNotExist)
+}
+
 // Exists checks if config file exists
 func Exists(path string) bool {
-	if path == "" {
-		path = ConfigFileName
-	}
+	path = ResolvePath(path)
 	_, err := os.Stat(path)
 	return err == nil
 }
@@ -114,3 +392,26 @@ func NewDefault(language string) *Config {
 		},
 	}
 }
+
+// LanguageFor returns the language of a file, honoring language overrides
+func (c *Config) LanguageFor(path string) string {
+	return ResolveLanguage(c.Settings.LanguageOverrides, c.Language, path)
+}
+
+// ResolveLanguage returns the language of the longest override prefix
+// containing path, or fallback when none does
+func ResolveLanguage(overrides map[string]string, fallback, path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	language := fallback
+	longest := -1
+	for prefix, lang := range overrides {
+		prefix = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(prefix)), "/")
+		if prefix != "." && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if len(prefix) > longest {
+			language, longest = lang, len(prefix)
+		}
+	}
+	return language
+}
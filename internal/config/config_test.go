@@ -0,0 +1,451 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// configWith returns a config holding n declared patterns, so configs saved
+// by different writers differ in size
+func configWith(n int) *Config {
+	cfg := NewDefault("go")
+	for i := 0; i < n; i++ {
+		cfg.Patterns = append(cfg.Patterns, patterns.Pattern{
+			ID:        fmt.Sprintf("declared_%d", i),
+			Type:      patterns.PatternService,
+			Reference: fmt.Sprintf("services/ref_%d.go", i),
+		})
+	}
+	return cfg
+}
+
+func TestSaveInterleaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := Save(configWith(0), path); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers, saves = 4, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*saves*2)
+	for w := 1; w <= writers; w++ {
+		wg.Add(1)
+		go func(size int) {
+			defer wg.Done()
+			for i := 0; i < saves; i++ {
+				if err := Save(configWith(size*10), path); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	// Every read in between sees one writer's whole config
+	done := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			cfg, err := Load(path)
+			if err != nil {
+				errs <- fmt.Errorf("partial write: %w", err)
+				return
+			}
+			if n := len(cfg.Patterns); n%10 != 0 || n > writers*10 {
+				errs <- fmt.Errorf("read a config with %d patterns, not one writer's", n)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	<-readerDone
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != ConfigFileName {
+			t.Errorf("left behind %s", e.Name())
+		}
+	}
+}
+
+func TestAppendLanguage(t *testing.T) {
+	cfg := NewDefault("go")
+	cfg.Settings.AutoApproveThreshold = 90
+	cfg.Patterns = []patterns.Pattern{
+		{ID: "service", Type: patterns.PatternService},
+		{ID: "handler", Type: patterns.PatternHTTPHandler},
+		{ID: "repository_declared", Type: patterns.PatternRepository, Reference: "repos/user.go"},
+	}
+
+	ts := func() []patterns.Pattern {
+		return []patterns.Pattern{
+			{ID: "component", Type: patterns.PatternComponent},
+			{ID: "service", Type: patterns.PatternService},
+		}
+	}
+	if err := cfg.AppendLanguage("typescript", ts()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Go patterns are kept and tagged, declared ones untouched, and
+	// the TypeScript service gets an ID of its own
+	want := []struct{ id, language string }{
+		{"service", "go"},
+		{"handler", "go"},
+		{"repository_declared", ""},
+		{"component", "typescript"},
+		{"service_typescript", "typescript"},
+	}
+	check := func() {
+		t.Helper()
+		if len(cfg.Patterns) != len(want) {
+			t.Fatalf("got %d patterns, want %d: %+v", len(cfg.Patterns), len(want), cfg.Patterns)
+		}
+		for i, w := range want {
+			if p := cfg.Patterns[i]; p.ID != w.id || p.Language != w.language {
+				t.Errorf("pattern %d = %s (%q), want %s (%q)", i, p.ID, p.Language, w.id, w.language)
+			}
+		}
+		if got := cfg.AllLanguages(); len(got) != 2 || got[0] != "go" || got[1] != "typescript" {
+			t.Errorf("languages = %v, want go, typescript", got)
+		}
+		if cfg.Language != "go" || cfg.Settings.AutoApproveThreshold != 90 {
+			t.Errorf("language %q, threshold %g: want the original ones kept", cfg.Language, cfg.Settings.AutoApproveThreshold)
+		}
+	}
+	check()
+
+	// Appending again replaces the earlier TypeScript patterns
+	if err := cfg.AppendLanguage("typescript", ts()); err != nil {
+		t.Fatal(err)
+	}
+	check()
+
+	if err := cfg.AppendLanguage("go", nil); err == nil {
+		t.Error("appending the original language succeeded")
+	}
+}
+
+func TestAppendLanguageRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	cfg := NewDefault("go")
+	cfg.Patterns = []patterns.Pattern{{ID: "service", Type: patterns.PatternService}}
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AppendLanguage("typescript", []patterns.Pattern{{ID: "hook", Type: patterns.PatternHook}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Languages) != 2 || len(cfg.Patterns) != 2 || cfg.Patterns[0].Language != "go" || cfg.Patterns[1].Language != "typescript" {
+		t.Errorf("reloaded config: languages %v, patterns %+v", cfg.Languages, cfg.Patterns)
+	}
+}
+
+// mkTree creates dirs and empty files, keyed by slash-separated path with
+// a trailing slash for directories, under a temporary directory
+func mkTree(t *testing.T, paths ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, p := range paths {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if p[len(p)-1] == '/' {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFileIn(t *testing.T) {
+	root := mkTree(t, "both/.code-on-rails.yml", "both/.code-on-rails.yaml", "yaml/.code-on-rails.yaml", "none/")
+	tests := map[string]string{
+		"both": AltConfigFileName,
+		"yaml": AltConfigFileName,
+		"none": ConfigFileName, // Where init creates it
+	}
+	for dir, want := range tests {
+		if got := FileIn(filepath.Join(root, dir)); got != filepath.Join(root, dir, want) {
+			t.Errorf("FileIn(%s) = %s, want %s", dir, got, want)
+		}
+	}
+}
+
+// inDir runs the rest of the test from dir with no --config, so empty
+// paths resolve to the config in dir
+func inDir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	previous := DefaultPath
+	DefaultPath = ""
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		DefaultPath = previous
+	})
+}
+
+func TestDefaultPathExtensions(t *testing.T) {
+	root := mkTree(t, "yaml/", "none/")
+	yamlDir := filepath.Join(root, "yaml")
+	if err := Save(configWith(1), filepath.Join(yamlDir, AltConfigFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A .yaml config is loaded and saved back in place
+	inDir(t, yamlDir)
+	if !Exists("") {
+		t.Fatal("Exists() = false with only .code-on-rails.yaml")
+	}
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Patterns) != 1 {
+		t.Fatalf("loaded %d patterns, want 1", len(cfg.Patterns))
+	}
+	cfg.Patterns[0].Confidence = 0.5
+	if err := Save(cfg, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ConfigFileName); !os.IsNotExist(err) {
+		t.Errorf("Save created %s next to %s", ConfigFileName, AltConfigFileName)
+	}
+	if saved, err := Load(AltConfigFileName); err != nil || saved.Patterns[0].Confidence != 0.5 {
+		t.Errorf("%s wasn't saved over: %v", AltConfigFileName, err)
+	}
+
+	// Without a config, the first one is created as .yml
+	inDir(t, filepath.Join(root, "none"))
+	if Exists("") {
+		t.Fatal("Exists() = true without a config")
+	}
+	if _, err := Load(""); err == nil {
+		t.Error("Load() succeeded without a config")
+	}
+	if err := Save(configWith(1), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ConfigFileName); err != nil {
+		t.Errorf("first Save didn't create %s: %v", ConfigFileName, err)
+	}
+}
+
+func TestFindNested(t *testing.T) {
+	root := mkTree(t,
+		"repo/.git/",
+		"repo/.code-on-rails.yml",
+		"repo/internal/services/deep/",
+		"repo/web/.code-on-rails.yaml",
+		"repo/web/src/components/",
+	)
+	repo := filepath.Join(root, "repo")
+
+	tests := []struct {
+		from string
+		want string
+	}{
+		{"repo", "repo/.code-on-rails.yml"},
+		{"repo/internal", "repo/.code-on-rails.yml"},
+		{"repo/internal/services/deep", "repo/.code-on-rails.yml"},
+		{"repo/web/src/components", "repo/web/.code-on-rails.yaml"}, // The nearest wins
+	}
+	for _, tt := range tests {
+		got, err := Find(filepath.Join(root, filepath.FromSlash(tt.from)))
+		if err != nil {
+			t.Errorf("Find from %s: %v", tt.from, err)
+			continue
+		}
+		if want := filepath.Join(root, filepath.FromSlash(tt.want)); got != want {
+			t.Errorf("Find from %s = %s, want %s", tt.from, got, want)
+		}
+	}
+
+	// Relative paths are resolved from the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(repo, "internal", "services")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	got, err := Find(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(repo, ConfigFileName); !sameFile(t, got, want) {
+		t.Errorf("Find(.) = %s, want %s", got, want)
+	}
+}
+
+func TestFindStopsAtGitRoot(t *testing.T) {
+	// A config above the git root belongs to another project
+	root := mkTree(t, ".code-on-rails.yml", "repo/.git/", "repo/pkg/")
+
+	_, err := Find(filepath.Join(root, "repo", "pkg"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Find past the git root: err = %v, want os.ErrNotExist", err)
+	}
+
+	// Without a git root the search goes on up
+	root = mkTree(t, ".code-on-rails.yml", "project/pkg/")
+	got, err := Find(filepath.Join(root, "project", "pkg"))
+	if err != nil || got != filepath.Join(root, ConfigFileName) {
+		t.Errorf("Find without a git root = %s, %v", got, err)
+	}
+}
+
+// sameFile compares paths that may differ by symlinks, such as a temp
+// directory under /var on macOS
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	ia, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ia, ib)
+}
+
+func TestDetectionValidate(t *testing.T) {
+	tests := []struct {
+		method   string
+		prefixes []string
+		valid    bool
+	}{
+		{"branch", []string{"claude/"}, true},
+		{"branch", nil, false},
+		{"branch", []string{""}, false},
+		{"all", nil, false},
+		{"commit_message", nil, true},
+	}
+	for _, tt := range tests {
+		err := DetectionConfig{Method: tt.method, BranchPrefixes: tt.prefixes}.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%s, %q) = %v, want valid %v", tt.method, tt.prefixes, err, tt.valid)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	errorRun := []patterns.PatternMatch{
+		{FilePath: "a.go", AutoApprove: true},
+		{FilePath: "b.go", Deviations: []patterns.Deviation{
+			{Element: "import", Severity: patterns.SeverityWarning},
+			{Element: "error_handling", Severity: patterns.SeverityError},
+		}},
+	}
+	warningRun := []patterns.PatternMatch{
+		{FilePath: "a.go", AutoApprove: true},
+		{FilePath: "b.go", Deviations: []patterns.Deviation{{Element: "import", Severity: patterns.SeverityWarning}}},
+	}
+	cleanRun := []patterns.PatternMatch{
+		{FilePath: "a.go", AutoApprove: true},
+		// Auto-approved in spite of an error, e.g. by a low threshold
+		{FilePath: "b.go", AutoApprove: true, Deviations: []patterns.Deviation{{Element: "import", Severity: patterns.SeverityError}}},
+	}
+	code := func(n int) *int { return &n }
+
+	tests := []struct {
+		name                     string
+		codes                    ExitCodes
+		errors, warnings, passed int
+	}{
+		{"default", ExitCodes{}, 1, 0, 0},
+		{"warnings 2", ExitCodes{Warnings: code(2)}, 1, 2, 0},
+		{"errors 3 warnings 2", ExitCodes{Errors: code(3), Warnings: code(2)}, 3, 2, 0},
+		{"errors 0", ExitCodes{Errors: code(0)}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.codes.For(errorRun); got != tt.errors {
+			t.Errorf("%s: error run exits %d, want %d", tt.name, got, tt.errors)
+		}
+		if got := tt.codes.For(warningRun); got != tt.warnings {
+			t.Errorf("%s: warning run exits %d, want %d", tt.name, got, tt.warnings)
+		}
+		if got := tt.codes.For(cleanRun); got != tt.passed {
+			t.Errorf("%s: clean run exits %d, want %d", tt.name, got, tt.passed)
+		}
+		if got := tt.codes.For(nil); got != 0 {
+			t.Errorf("%s: empty run exits %d, want 0", tt.name, got)
+		}
+	}
+}
+
+func TestExitCodesLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".code-on-rails.yml")
+	src := "version: \"1.0\"\nlanguage: go\nsettings:\n    exit_codes:\n        warnings: 2\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := cfg.Settings.ExitCodes
+	if codes.Errors != nil || codes.Warnings == nil || *codes.Warnings != 2 {
+		t.Fatalf("exit_codes = %+v, want warnings 2 alone", codes)
+	}
+	if err := codes.Validate(); err != nil {
+		t.Error(err)
+	}
+	if got := codes.Code(true, true); got != 1 {
+		t.Errorf("errors exit %d, want the default 1", got)
+	}
+
+	for _, bad := range []int{-1, 126, 255} {
+		if err := (ExitCodes{Errors: &bad}).Validate(); err == nil {
+			t.Errorf("Validate accepted errors %d", bad)
+		}
+	}
+}
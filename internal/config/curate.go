@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// curateSamples is how many of a pattern's files CuratePatterns shows
+const curateSamples = 3
+
+// CuratePatterns walks the user through learned patterns for cr init
+// --interactive, reading answers from in: each is kept (the default, also
+// at end of input), renamed or discarded. Patterns have no threshold of
+// their own, so the auto-approve threshold in settings is asked for last.
+func CuratePatterns(in io.Reader, out io.Writer, learned []patterns.Pattern, settings *Settings) []patterns.Pattern {
+	r := bufio.NewReader(in)
+	ask := func(prompt string) string {
+		fmt.Fprint(out, prompt)
+		answer, _ := r.ReadString('\n')
+		return strings.TrimSpace(answer)
+	}
+
+	kept := []patterns.Pattern{}
+	for i, p := range learned {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "[%d/%d] %s (%s, %d example(s), %.0f%% confidence)\n", i+1, len(learned), p.Name, p.Type, p.SeenCount, p.Confidence*100)
+		samples := p.Discovered
+		if len(samples) > curateSamples {
+			samples = samples[:curateSamples]
+		}
+		for _, golden := range p.AnnotatedGolden {
+			fmt.Fprintf(out, "  %s (golden)\n", golden.Path)
+		}
+		for _, example := range samples {
+			fmt.Fprintf(out, "  %s\n", example.Path)
+		}
+		if more := len(p.Discovered) - len(samples); more > 0 {
+			fmt.Fprintf(out, "  ... and %d more\n", more)
+		}
+
+		for {
+			switch strings.ToLower(ask("Keep, rename or discard? [K/r/d] ")) {
+			case "", "k", "keep":
+				kept = append(kept, p)
+			case "r", "rename":
+				if name := ask(fmt.Sprintf("New name [%s]: ", p.Name)); name != "" {
+					p.Name = name
+				}
+				kept = append(kept, p)
+			case "d", "discard":
+				fmt.Fprintf(out, "Discarded %s\n", p.Name)
+			default:
+				fmt.Fprintln(out, "Answer k, r or d")
+				continue
+			}
+			break
+		}
+	}
+
+	for {
+		answer := ask(fmt.Sprintf("\nAuto-approve threshold [%.0f]: ", settings.AutoApproveThreshold))
+		if answer == "" {
+			break
+		}
+		var threshold float64
+		if _, err := fmt.Sscanf(answer, "%g", &threshold); err == nil && threshold >= 0 && threshold <= 100 {
+			settings.AutoApproveThreshold = threshold
+			break
+		}
+		fmt.Fprintln(out, "Enter a score from 0 to 100")
+	}
+	fmt.Fprintln(out)
+	return kept
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// learnedPatterns are patterns as cr init learns them, with examples
+func learnedPatterns() []patterns.Pattern {
+	examples := func(paths ...string) []patterns.Example {
+		found := []patterns.Example{}
+		for _, path := range paths {
+			found = append(found, patterns.Example{Path: path})
+		}
+		return found
+	}
+	return []patterns.Pattern{
+		{ID: "util", Name: "util", Type: patterns.PatternUtil, SeenCount: 2, Discovered: examples("pkg/a.go", "pkg/b.go")},
+		{ID: "handler", Name: "handler", Type: patterns.PatternHTTPHandler, SeenCount: 5,
+			Discovered: examples("handlers/a.go", "handlers/b.go", "handlers/c.go", "handlers/d.go", "handlers/e.go")},
+		{ID: "service", Name: "service", Type: patterns.PatternService, SeenCount: 3, Discovered: examples("services/a.go")},
+	}
+}
+
+func TestCuratePatterns(t *testing.T) {
+	var out bytes.Buffer
+	settings := Settings{AutoApproveThreshold: 95}
+	// Discard util; rename handler after a wrong answer; keep service by
+	// default; retry the threshold until it's a score
+	in := strings.NewReader("d\nx\nr\nHTTP handlers\n\nabc\n150\n90\n")
+
+	kept := CuratePatterns(in, &out, learnedPatterns(), &settings)
+	if len(kept) != 2 || kept[0].ID != "handler" || kept[0].Name != "HTTP handlers" || kept[1].ID != "service" || kept[1].Name != "service" {
+		t.Errorf("kept %+v, want the renamed handler and service", kept)
+	}
+	if settings.AutoApproveThreshold != 90 {
+		t.Errorf("threshold = %g, want 90", settings.AutoApproveThreshold)
+	}
+
+	shown := out.String()
+	for _, want := range []string{
+		"[2/3] handler (http_handler, 5 example(s)",
+		"  handlers/c.go\n  ... and 2 more\n",
+		"Discarded util\n",
+		"Answer k, r or d\n",
+		"Enter a score from 0 to 100\n",
+	} {
+		if !strings.Contains(shown, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, shown)
+		}
+	}
+	if strings.Contains(shown, "handlers/d.go") {
+		t.Errorf("prompt shows more than %d samples:\n%s", curateSamples, shown)
+	}
+}
+
+func TestCuratePatternsEndOfInput(t *testing.T) {
+	settings := Settings{AutoApproveThreshold: 95}
+	kept := CuratePatterns(strings.NewReader(""), &bytes.Buffer{}, learnedPatterns(), &settings)
+	if len(kept) != 3 || settings.AutoApproveThreshold != 95 {
+		t.Errorf("kept %d patterns at threshold %g, want all 3 at 95", len(kept), settings.AutoApproveThreshold)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where an effective setting came from
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceFlag    = "flag"
+)
+
+// sections are the mappings whose keys are labelled individually; any other
+// value (lists, language_overrides) is labelled as a whole
+var sections = map[string]bool{"": true, "settings": true, "settings.scoring": true, "detection": true}
+
+// Explained is a configuration with the source of each value
+type Explained struct {
+	Config  *Config
+	Sources map[string]string // Dotted key (e.g. settings.match_mode) → Source*
+
+	root *yaml.Node
+}
+
+// Explain labels each value of cfg as set by a flag (flagKeys), by the
+// config file at path, or by default. A missing file means all defaults.
+func Explain(cfg *Config, path string, flagKeys []string) (*Explained, error) {
+	path = ResolvePath(path)
+
+	fileKeys := map[string]bool{}
+	if data, err := os.ReadFile(path); err == nil {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+		collectKeys(raw, "", fileKeys)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	flags := map[string]bool{}
+	for _, key := range flagKeys {
+		flags[key] = true
+	}
+
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	e := &Explained{Config: cfg, Sources: map[string]string{}, root: &root}
+	walkSettings(&root, "", func(key string, k, v *yaml.Node) {
+		switch {
+		case flags[key]:
+			e.Sources[key] = SourceFlag
+		case fileKeys[key]:
+			e.Sources[key] = SourceFile
+		default:
+			e.Sources[key] = SourceDefault
+		}
+	})
+	return e, nil
+}
+
+// YAML renders the configuration with each value's source as a comment
+func (e *Explained) YAML() ([]byte, error) {
+	walkSettings(e.root, "", func(key string, k, v *yaml.Node) {
+		if v.Kind == yaml.ScalarNode || v.Style == yaml.FlowStyle || len(v.Content) == 0 {
+			v.LineComment = e.Sources[key]
+		} else {
+			k.LineComment = e.Sources[key]
+		}
+	})
+	return yaml.Marshal(e.root)
+}
+
+// JSON renders the configuration and its sources side by side
+func (e *Explained) JSON() ([]byte, error) {
+	var values map[string]interface{}
+	if err := e.root.Decode(&values); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(struct {
+		Config  map[string]interface{} `json:"config"`
+		Sources map[string]string      `json:"sources"`
+	}{values, e.Sources}, "", "  ")
+}
+
+// collectKeys records the dotted path of every key in a parsed config file
+func collectKeys(raw map[string]interface{}, prefix string, keys map[string]bool) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		keys[key] = true
+		if nested, ok := v.(map[string]interface{}); ok {
+			collectKeys(nested, key, keys)
+		}
+	}
+}
+
+// walkSettings calls fn for each labelled key/value pair under node
+func walkSettings(node *yaml.Node, prefix string, fn func(key string, k, v *yaml.Node)) {
+	if node.Kind == yaml.DocumentNode {
+		walkSettings(node.Content[0], prefix, fn)
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		key := k.Value
+		if prefix != "" {
+			key = prefix + "." + k.Value
+		}
+		if v.Kind == yaml.MappingNode && sections[key] {
+			walkSettings(v, key, fn)
+			continue
+		}
+		fn(key, k, v)
+	}
+}
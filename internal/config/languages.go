@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// AllLanguages returns every language the config learned patterns for,
+// first the original one
+func (c *Config) AllLanguages() []string {
+	if len(c.Languages) > 0 {
+		return c.Languages
+	}
+	return []string{c.Language}
+}
+
+// AppendLanguage merges patterns learned for another language into the
+// config, as cr init --append does. Patterns an earlier append learned for
+// the language are replaced; the original language's patterns are tagged
+// with it so they keep to its files, and declared patterns and settings are
+// kept as they are.
+func (c *Config) AppendLanguage(language string, found []patterns.Pattern) error {
+	languages := c.AllLanguages()
+	if language == languages[0] {
+		return fmt.Errorf("%s is the config's original language; use cr learn or cr init --force", language)
+	}
+
+	kept := []patterns.Pattern{}
+	ids := make(map[string]bool)
+	for _, p := range c.Patterns {
+		if !p.IsDeclared() {
+			if p.Language == language {
+				continue
+			}
+			if p.Language == "" {
+				p.Language = languages[0]
+			}
+		}
+		kept = append(kept, p)
+		ids[p.ID] = true
+	}
+	c.Patterns = append(kept, TagLanguage(found, language, ids)...)
+
+	for _, l := range languages {
+		if l == language {
+			c.Languages = languages
+			return nil
+		}
+	}
+	c.Languages = append(append([]string{}, languages...), language)
+	return nil
+}
+
+// TagLanguage marks patterns learned for lang with it, making IDs already in
+// ids distinct, and records the IDs used
+func TagLanguage(found []patterns.Pattern, lang string, ids map[string]bool) []patterns.Pattern {
+	for i := range found {
+		p := &found[i]
+		if p.Language == "" {
+			p.Language = lang
+		}
+		if ids[p.ID] {
+			p.ID = p.ID + "_" + lang
+		}
+		ids[p.ID] = true
+	}
+	return found
+}
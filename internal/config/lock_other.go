@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// Lock is a no-op where flock is unavailable; Save is still atomic, but
+// concurrent load-modify-save sequences can lose each other's changes.
+func Lock(path string) (func() error, error) {
+	return func() error { return nil }, nil
+}
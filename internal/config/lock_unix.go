@@ -0,0 +1,30 @@
+//go:build unix
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive advisory lock guarding a load-modify-save of the
+// config at path, waiting for any other cr process holding it. The lock is
+// held on a separate <path>.lock file, since Save replaces the config file
+// itself. Call the returned function to release it.
+func Lock(path string) (func() error, error) {
+	path = ResolvePath(path)
+
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock config: %w", err)
+	}
+	return func() error {
+		defer f.Close()
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	}, nil
+}
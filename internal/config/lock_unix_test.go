@@ -0,0 +1,63 @@
+//go:build unix
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestLockSerializesUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := Save(configWith(0), path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each writer loads, appends a pattern and saves under the lock, like
+	// cr learn and cr bless; none may lose another's pattern
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer unlock()
+			cfg, err := Load(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			cfg.Patterns = append(cfg.Patterns, patterns.Pattern{
+				ID:        fmt.Sprintf("writer_%d", w),
+				Type:      patterns.PatternService,
+				Reference: "services/ref.go",
+			})
+			if err := Save(cfg, path); err != nil {
+				errs <- err
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Patterns) != writers {
+		t.Errorf("got %d patterns after %d locked updates", len(cfg.Patterns), writers)
+	}
+}
@@ -0,0 +1,385 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultIndent is the indentation of a config written from scratch
+const DefaultIndent = 4
+
+// marshalOver encodes cfg as an edit of the config file previously at
+// path, so that saving only changes what changed: values that are equal
+// keep their node, with its comments, style, anchors and aliases, keys keep
+// their order in the file, and the file's indentation is kept. Fields the
+// file lacks are added after its existing keys in declared order. Without a
+// readable previous file cfg is encoded as is.
+func marshalOver(previous []byte, cfg *Config) ([]byte, error) {
+	var fresh yaml.Node
+	if err := fresh.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	doc := &fresh
+	indent := DefaultIndent
+	var old yaml.Node
+	if yaml.Unmarshal(previous, &old) == nil && old.Kind == yaml.DocumentNode &&
+		len(old.Content) == 1 && old.Content[0].Kind == yaml.MappingNode {
+		if unchanged(previous, cfg) {
+			return previous, nil
+		}
+		m := &merger{aliases: make(map[*yaml.Node]int)}
+		countAliases(&old, m.aliases)
+		old.Content[0] = m.node(old.Content[0], &fresh)
+		m.dropUnaliased(&old)
+		untagMergeKeys(&old)
+		doc = &old
+		indent = indentOf(previous)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unchanged checks if the previous file already loads as cfg, in which case
+// it is kept as is, blank lines and all
+func unchanged(previous []byte, cfg *Config) bool {
+	var loaded Config
+	if yaml.Unmarshal(previous, &loaded) != nil {
+		return false
+	}
+	setDefaults(&loaded)
+	a, errA := yaml.Marshal(&loaded)
+	b, errB := yaml.Marshal(cfg)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// merger edits the previous file's nodes into the new config
+type merger struct {
+	aliases map[*yaml.Node]int // How many aliases in the previous file refer to each anchored node
+	kept    []keptNode         // Nodes gone from the new config, kept for their anchors
+}
+
+// keptNode is a mapping value or sequence item the new config no longer
+// has, kept in parent because aliases referred to an anchor within it
+type keptNode struct {
+	parent, node *yaml.Node
+}
+
+// node returns the node to write where old held a value and update holds
+// the new one. Unchanged values keep old; changed mappings and sequences
+// are merged entry by entry; other changes are written over old in place,
+// so that aliases of an anchored value follow it.
+func (m *merger) node(old, update *yaml.Node) *yaml.Node {
+	if sameValue(old, update) {
+		return old
+	}
+	switch {
+	case old.Kind == yaml.AliasNode:
+		return update
+	case old.Kind == yaml.MappingNode && update.Kind == yaml.MappingNode:
+		m.mapping(old, update)
+		return old
+	case old.Kind == yaml.SequenceNode && update.Kind == yaml.SequenceNode:
+		m.sequence(old, update)
+		return old
+	}
+
+	anchor, head, line, foot := old.Anchor, old.HeadComment, old.LineComment, old.FootComment
+	*old = *update
+	old.Anchor, old.HeadComment, old.LineComment, old.FootComment = anchor, head, line, foot
+	return old
+}
+
+// mapping updates a mapping in place: keys keep their order, keys gone
+// from update are dropped, unless they define anchors still aliased
+// elsewhere, and new keys are appended. Keys a << merge key already
+// provides with the same value aren't repeated.
+func (m *merger) mapping(old, update *yaml.Node) {
+	values := make(map[string]*yaml.Node, len(update.Content)/2)
+	order := make([]string, 0, len(update.Content)/2)
+	for i := 0; i+1 < len(update.Content); i += 2 {
+		key := update.Content[i].Value
+		values[key] = update.Content[i+1]
+		order = append(order, key)
+	}
+
+	content := make([]*yaml.Node, 0, len(update.Content))
+	present := make(map[string]bool)
+	inherited := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(old.Content); i += 2 {
+		key, value := old.Content[i], old.Content[i+1]
+		if key.Tag == "!!merge" || key.Value == "<<" {
+			content = append(content, key, value)
+			for k, v := range mergedValues(value) {
+				inherited[k] = v
+			}
+			continue
+		}
+		if updated, ok := values[key.Value]; ok {
+			content = append(content, key, m.node(value, updated))
+			present[key.Value] = true
+		} else if m.stillAliased(value) {
+			content = append(content, key, value)
+			m.kept = append(m.kept, keptNode{old, value})
+		}
+	}
+	for _, key := range order {
+		if present[key] {
+			continue
+		}
+		if base, ok := inherited[key]; ok && sameValue(base, values[key]) {
+			continue
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, values[key])
+	}
+	old.Content = content
+}
+
+// sequence updates a sequence in place, in update's order. Items with an
+// id, such as patterns, are merged into the old item with that id wherever
+// it was, so removing or inserting one leaves the others' nodes alone;
+// items without one are paired up with the old id-less items in order.
+// Old items left unpaired are dropped, unless they define anchors still
+// aliased elsewhere, which stay where they were among the old items.
+func (m *merger) sequence(old, update *yaml.Node) {
+	byID := make(map[string][]*yaml.Node)
+	unnamed := []*yaml.Node{}
+	position := make(map[*yaml.Node]int, len(old.Content))
+	for i, item := range old.Content {
+		position[item] = i
+		if id := idOf(item); id != "" {
+			byID[id] = append(byID[id], item)
+		} else {
+			unnamed = append(unnamed, item)
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(update.Content))
+	paired := make(map[*yaml.Node]bool)
+	for _, item := range update.Content {
+		var match *yaml.Node
+		if id := idOf(item); id != "" {
+			if candidates := byID[id]; len(candidates) > 0 {
+				match, byID[id] = candidates[0], candidates[1:]
+			}
+		} else if len(unnamed) > 0 {
+			match, unnamed = unnamed[0], unnamed[1:]
+		}
+		if match == nil {
+			content = append(content, item)
+			continue
+		}
+		paired[match] = true
+		content = append(content, m.node(match, item))
+	}
+
+	// An alias must follow its anchor, so a kept item goes before the
+	// first item that followed it in the old sequence
+	for i, item := range old.Content {
+		if paired[item] || !m.stillAliased(item) {
+			continue
+		}
+		at := len(content)
+		for j, kept := range content {
+			if pos, ok := position[kept]; ok && pos > i {
+				at = j
+				break
+			}
+		}
+		content = append(content[:at], append([]*yaml.Node{item}, content[at:]...)...)
+		position[item] = i
+		m.kept = append(m.kept, keptNode{old, item})
+	}
+	old.Content = content
+}
+
+// idOf returns the id key of a mapping, or of the mapping an alias refers
+// to, if it has one
+func idOf(node *yaml.Node) string {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "id" && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// dropUnaliased removes kept nodes whose anchors no alias in doc refers to
+// any more, as when the aliases were themselves replaced or dropped.
+// Dropping one may free others, so it repeats until nothing changes.
+func (m *merger) dropUnaliased(doc *yaml.Node) {
+	for dropped := true; dropped; {
+		dropped = false
+		counts := make(map[*yaml.Node]int)
+		countAliases(doc, counts)
+		kept := m.kept[:0]
+		for _, k := range m.kept {
+			within := make(map[*yaml.Node]int)
+			countAliases(k.node, within)
+			if aliasedFrom(k.node, counts, within) {
+				kept = append(kept, k)
+				continue
+			}
+			remove(k.parent, k.node)
+			dropped = true
+		}
+		m.kept = kept
+	}
+}
+
+// remove takes a sequence item, or a mapping value with its key, out of
+// parent
+func remove(parent, node *yaml.Node) {
+	for i, child := range parent.Content {
+		if child != node {
+			continue
+		}
+		if parent.Kind == yaml.MappingNode {
+			parent.Content = append(parent.Content[:i-1], parent.Content[i+1:]...)
+		} else {
+			parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+		}
+		return
+	}
+}
+
+// stillAliased checks if a node or any node within it defines an anchor
+// that aliases outside the node refer to, so dropping it would leave them
+// dangling
+func (m *merger) stillAliased(node *yaml.Node) bool {
+	within := make(map[*yaml.Node]int)
+	countAliases(node, within)
+	return aliasedFrom(node, m.aliases, within)
+}
+
+// aliasedFrom checks if any anchor in node has more aliases in total than
+// within it
+func aliasedFrom(node *yaml.Node, total, within map[*yaml.Node]int) bool {
+	if node.Anchor != "" && total[node] > within[node] {
+		return true
+	}
+	for _, child := range node.Content {
+		if aliasedFrom(child, total, within) {
+			return true
+		}
+	}
+	return false
+}
+
+// countAliases counts the aliases in a node referring to each anchored node
+func countAliases(node *yaml.Node, counts map[*yaml.Node]int) {
+	if node.Kind == yaml.AliasNode {
+		counts[node.Alias]++
+		return
+	}
+	for _, child := range node.Content {
+		countAliases(child, counts)
+	}
+}
+
+// untagMergeKeys clears the tag of << merge keys, which the encoder would
+// otherwise write out as "!!merge <<"
+func untagMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Tag == "!!merge" {
+				key.Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
+
+// mergedValues lists the keys a << merge key's value provides: an aliased
+// mapping or a sequence of them, earlier ones taking precedence
+func mergedValues(node *yaml.Node) map[string]*yaml.Node {
+	values := make(map[string]*yaml.Node)
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+	for i := len(sources) - 1; i >= 0; i-- {
+		source := sources[i]
+		if source.Kind == yaml.AliasNode {
+			source = source.Alias
+		}
+		if source == nil || source.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(source.Content); j += 2 {
+			values[source.Content[j].Value] = source.Content[j+1]
+		}
+	}
+	return values
+}
+
+// sameValue checks if two nodes decode to the same value, whatever their
+// style, e.g. 95 and 95.0 or an alias and the value it refers to
+func sameValue(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalize(va), normalize(vb))
+}
+
+// normalize makes decoded values comparable: numbers become float64 and
+// times UTC strings
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalize(v[k])
+		}
+	}
+	return v
+}
+
+// indentOf guesses a YAML file's indentation from its first indented line
+func indentOf(data []byte) int {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " ")
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 {
+			if n >= 2 && n <= 8 {
+				return n
+			}
+			break
+		}
+	}
+	return DefaultIndent
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// patternYAML is a learned pattern as Save writes it, under a comment
+func patternYAML(id, confidence string) string {
+	return `    # The ` + id + ` pattern
+    - id: ` + id + `
+      name: ` + id + `
+      type: service
+      version: ""
+      detection:
+        file_pattern: '*.go'
+        func_pattern: ""
+        struct_pattern: ""
+        package_path: '*/services'
+      structure:
+        elements: []
+        ordering: []
+        required: []
+        optional: []
+      confidence: ` + confidence + `
+      seen_count: 3
+`
+}
+
+const configHead = `# Team conventions, reviewed in PRs
+version: "1.0"
+language: go
+ai_source: any
+
+patterns:
+`
+
+const configTail = `
+settings:
+    auto_approve_threshold: 95 # Raised after the Q3 review
+    learn_on_merge: true
+detection:
+    method: all
+    commit_prefixes:
+        - '[ai'
+        - '[claude'
+        - '[copilot'
+        - '[cursor'
+    branch_prefixes:
+        - claude/
+        - ai/
+        - copilot/
+        - cursor/
+        - claude-
+        - ai-
+        - copilot-
+        - cursor-
+`
+
+// savedOver writes previous as a config file, loads it, lets edit change
+// it and saves it again, returning what was written
+func savedOver(t *testing.T, previous string, edit func(*Config)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte(previous), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit(cfg)
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatalf("saved config doesn't load: %v\n%s", err, data)
+	}
+	return string(data)
+}
+
+func TestSaveNoOpNoDiff(t *testing.T) {
+	previous := configHead + patternYAML("users", "0.9") + patternYAML("orders", "0.8") + configTail
+	if got := savedOver(t, previous, func(*Config) {}); got != previous {
+		t.Errorf("no-op save changed the file:\n%s\nwant:\n%s", got, previous)
+	}
+}
+
+func TestSaveChangesOnlyWhatChanged(t *testing.T) {
+	previous := configHead + patternYAML("users", "0.9") + patternYAML("orders", "0.8") + configTail
+	got := savedOver(t, previous, func(cfg *Config) {
+		cfg.Patterns[1].Confidence = 0.85
+	})
+	want := configHead + patternYAML("users", "0.9") + patternYAML("orders", "0.85") + configTail
+	// The encoder drops blank lines once it rewrites the file
+	want = strings.Replace(want, "any\n\npatterns", "any\npatterns", 1)
+	want = strings.Replace(want, "\n\nsettings", "\nsettings", 1)
+	if got != want {
+		t.Errorf("saved:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSaveMatchesPatternsByID(t *testing.T) {
+	previous := configHead + patternYAML("users", "0.9") + patternYAML("orders", "0.8") + patternYAML("billing", "0.7") + configTail
+	got := savedOver(t, previous, func(cfg *Config) {
+		// Drop orders and put billing first
+		cfg.Patterns = append(cfg.Patterns[2:], cfg.Patterns[0])
+	})
+	want := configHead + patternYAML("billing", "0.7") + patternYAML("users", "0.9") + configTail
+	want = strings.Replace(want, "any\n\npatterns", "any\npatterns", 1)
+	want = strings.Replace(want, "\n\nsettings", "\nsettings", 1)
+	if got != want {
+		t.Errorf("saved:\n%s\nwant each pattern with its own comment:\n%s", got, want)
+	}
+}
+
+const anchoredConfig = `version: "1.0"
+language: go
+ai_source: any
+patterns:
+    - id: base
+      name: base
+      type: service
+      detection: &services
+        file_pattern: '*.go'
+        func_pattern: ""
+        struct_pattern: ""
+        package_path: '*/services'
+      confidence: 0.9
+    - id: lone
+      name: lone
+      type: model
+      detection: &models
+        file_pattern: '*.go'
+        func_pattern: ""
+        struct_pattern: ""
+        package_path: '*/models'
+      confidence: 0.8
+    - id: derived
+      name: derived
+      type: service
+      detection: *services
+      confidence: 0.7
+settings:
+    auto_approve_threshold: 95
+    learn_on_merge: true
+detection:
+    method: all
+    commit_prefixes:
+        - '[ai'
+        - '[claude'
+        - '[copilot'
+        - '[cursor'
+    branch_prefixes:
+        - claude/
+        - ai/
+        - copilot/
+        - cursor/
+        - claude-
+        - ai-
+        - copilot-
+        - cursor-
+`
+
+func TestSaveKeepsAliasedAnchors(t *testing.T) {
+	got := savedOver(t, anchoredConfig, func(cfg *Config) {
+		// Remove base, which derived's detection aliases, and lone, whose
+		// anchor nothing refers to
+		cfg.Patterns = cfg.Patterns[2:]
+		cfg.Patterns[0].Confidence = 0.75
+	})
+	if !strings.Contains(got, "detection: &services") || !strings.Contains(got, "detection: *services") {
+		t.Errorf("aliased anchor not kept:\n%s", got)
+	}
+	if strings.Contains(got, "id: lone") || strings.Contains(got, "&models") {
+		t.Errorf("unaliased anchored pattern not removed:\n%s", got)
+	}
+	if strings.Index(got, "&services") > strings.Index(got, "*services") {
+		t.Errorf("anchor moved after its alias:\n%s", got)
+	}
+	if !strings.Contains(got, "confidence: 0.75") {
+		t.Errorf("derived's change not saved:\n%s", got)
+	}
+}
+
+func TestSaveDropsAnchorsNoLongerAliased(t *testing.T) {
+	got := savedOver(t, anchoredConfig, func(cfg *Config) {
+		// derived stops aliasing base's detection, so base can go
+		cfg.Patterns = cfg.Patterns[2:]
+		cfg.Patterns[0].Detection.PackagePath = "*/billing"
+	})
+	if strings.Contains(got, "id: base") || strings.Contains(got, "services") {
+		t.Errorf("pattern kept for an anchor nothing aliases any more:\n%s", got)
+	}
+	if !strings.Contains(got, "package_path: '*/billing'") {
+		t.Errorf("derived's change not saved:\n%s", got)
+	}
+}
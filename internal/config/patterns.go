@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// SetPatternEnabled enables or disables the pattern with an ID, as cr
+// pattern enable/disable do. It reports whether anything changed; a pattern
+// already in that state is left alone. Enabled is the default, so enabling
+// clears the flag rather than writing enabled: true.
+func (c *Config) SetPatternEnabled(id string, enabled bool) (bool, error) {
+	for i := range c.Patterns {
+		p := &c.Patterns[i]
+		if p.ID != id {
+			continue
+		}
+		if p.IsEnabled() == enabled {
+			return false, nil
+		}
+		if enabled {
+			p.Enabled = nil
+		} else {
+			p.Enabled = &enabled
+		}
+		return true, nil
+	}
+	for _, p := range c.RemotePatterns {
+		if p.ID == id {
+			return false, fmt.Errorf("pattern %s comes from patterns_url and is read-only", id)
+		}
+	}
+	return false, fmt.Errorf("no pattern with ID %q (see cr list)", id)
+}
+
+// MergeMissing merges newly learned patterns into the config, as cr init
+// --merge-missing does: patterns of a language and type the config lacks
+// are added, and patterns it has gain the example files newly discovered
+// for them. Nothing else about existing patterns changes. It returns the
+// patterns added and how many examples existing patterns gained.
+func (c *Config) MergeMissing(found []patterns.Pattern) ([]patterns.Pattern, int) {
+	byID := make(map[string]int)
+	types := make(map[string]bool) // language/type of every existing pattern
+	for i, p := range c.Patterns {
+		byID[p.ID] = i
+		types[p.Language+"/"+string(p.Type)] = true
+	}
+	added := []patterns.Pattern{}
+	examples := 0
+	for _, p := range found {
+		if i, ok := byID[p.ID]; ok {
+			examples += addMissingExamples(&c.Patterns[i], p.Discovered)
+			continue
+		}
+		if types[p.Language+"/"+string(p.Type)] {
+			continue // Another pattern covers the type already
+		}
+		added = append(added, p)
+	}
+	c.Patterns = append(c.Patterns, added...)
+	return added, examples
+}
+
+// addMissingExamples adds the discovered examples a pattern doesn't have
+// under any tier, returning how many were added
+func addMissingExamples(p *patterns.Pattern, discovered []patterns.Example) int {
+	known := make(map[string]bool)
+	for _, golden := range p.AnnotatedGolden {
+		known[golden.Path] = true
+	}
+	for _, blessed := range p.ConfigBlessed {
+		known[blessed.Path] = true
+	}
+	for _, example := range p.Discovered {
+		known[example.Path] = true
+	}
+	for _, anti := range p.AntiPatterns {
+		known[anti.Path] = true
+	}
+
+	added := 0
+	for _, example := range discovered {
+		if !known[example.Path] {
+			p.Discovered = append(p.Discovered, example)
+			known[example.Path] = true
+			added++
+		}
+	}
+	p.SeenCount += added
+	return added
+}
+
+// MergeLearned merges newly learned patterns into the config, as cr learn
+// does: patterns it has take the learned counts, confidence and element
+// importance, with a new version when their required structure changed,
+// and patterns it lacks are added. It returns how many were updated or
+// added.
+func (c *Config) MergeLearned(learned []patterns.Pattern) int {
+	byID := make(map[string]int)
+	for i, p := range c.Patterns {
+		byID[p.ID] = i
+	}
+	updated := 0
+	for _, p := range learned {
+		i, ok := byID[p.ID]
+		if !ok {
+			c.Patterns = append(c.Patterns, p)
+			updated++
+			continue
+		}
+		existing := &c.Patterns[i]
+		existing.SeenCount = p.SeenCount
+		existing.Confidence = p.Confidence
+		bumpPatternVersion(existing, p.Structure)
+		updateImportance(&existing.Structure, p.Structure)
+		updated++
+	}
+	return updated
+}
+
+// bumpPatternVersion records the current structure in history and bumps the
+// version when the required structure has materially changed
+func bumpPatternVersion(p *patterns.Pattern, structure patterns.CodeStructure) {
+	if p.Fingerprint == "" {
+		p.Fingerprint = p.Structure.Fingerprint()
+	}
+	fingerprint := structure.Fingerprint()
+	if fingerprint == p.Fingerprint {
+		return
+	}
+
+	p.History = append(p.History, patterns.PatternVersion{
+		Version:     p.Version,
+		Fingerprint: p.Fingerprint,
+		Required:    p.Structure.Required,
+	})
+	p.Version = nextVersion(p.Version)
+	p.Fingerprint = fingerprint
+	p.Structure = structure
+}
+
+// updateImportance refreshes the importance of a structure's elements from
+// a newly learned one, for patterns whose required structure is unchanged
+func updateImportance(structure *patterns.CodeStructure, learned patterns.CodeStructure) {
+	for i, elem := range structure.Elements {
+		for _, l := range learned.Elements {
+			if l.Type == elem.Type && l.Name == elem.Name {
+				structure.Elements[i].Importance = l.Importance
+				break
+			}
+		}
+	}
+}
+
+// nextVersion bumps the minor component of a "major.minor" version
+func nextVersion(version string) string {
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return "1.1"
+	}
+	return fmt.Sprintf("%d.%d", major, minor+1)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestSetPatternEnabled(t *testing.T) {
+	cfg := configWith(2)
+	cfg.Patterns[1].SeenCount = 7
+	cfg.RemotePatterns = []patterns.Pattern{{ID: "shared"}}
+
+	changed, err := cfg.SetPatternEnabled("declared_1", false)
+	if err != nil || !changed {
+		t.Fatalf("disable = %v, %v, want a change", changed, err)
+	}
+	p := cfg.Patterns[1]
+	if p.IsEnabled() || p.SeenCount != 7 || p.Reference == "" {
+		t.Errorf("disabled pattern = %+v, want it muted with its counts and reference kept", p)
+	}
+	if !cfg.Patterns[0].IsEnabled() {
+		t.Error("disabling one pattern disabled another")
+	}
+	if changed, err := cfg.SetPatternEnabled("declared_1", false); err != nil || changed {
+		t.Errorf("disabling again = %v, %v, want no change", changed, err)
+	}
+
+	// The flag survives a save, and enabling clears it from the file
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Patterns[1].IsEnabled() {
+		t.Error("disabled pattern enabled after a save and load")
+	}
+	if changed, err := loaded.SetPatternEnabled("declared_1", true); err != nil || !changed {
+		t.Fatalf("enable = %v, %v, want a change", changed, err)
+	}
+	if loaded.Patterns[1].Enabled != nil {
+		t.Errorf("enabled pattern has Enabled = %v, want nil", *loaded.Patterns[1].Enabled)
+	}
+	if err := Save(loaded, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "enabled:") {
+		t.Errorf("enabled pattern saved with an enabled key:\n%s", data)
+	}
+
+	if _, err := cfg.SetPatternEnabled("shared", false); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("disabling a remote pattern: err = %v, want read-only", err)
+	}
+	if _, err := cfg.SetPatternEnabled("missing", false); err == nil {
+		t.Error("disabled a pattern that doesn't exist")
+	}
+}
+
+func TestMergeMissing(t *testing.T) {
+	cfg := &Config{
+		Version:  "1.0",
+		Language: "go",
+		Settings: Settings{AutoApproveThreshold: 90},
+		Patterns: []patterns.Pattern{
+			{
+				ID: "service", Name: "Curated services", Type: patterns.PatternService, Confidence: 0.7, SeenCount: 2,
+				AnnotatedGolden: []patterns.GoldenExample{{Path: "services/golden.go", Pattern: "service"}},
+				ConfigBlessed:   []patterns.BlessedExample{{Path: "services/blessed.go", Reason: "template"}},
+				Discovered:      []patterns.Example{{Path: "services/user.go"}},
+				AntiPatterns:    []patterns.AntiPattern{{Path: "services/legacy.go", Pattern: "service"}},
+			},
+			{ID: "api_handler", Name: "API handlers", Type: patterns.PatternHTTPHandler, SeenCount: 3},
+		},
+	}
+	found := []patterns.Pattern{
+		{ID: "service", Name: "service", Type: patterns.PatternService, Confidence: 0.9, SeenCount: 6, Discovered: []patterns.Example{
+			{Path: "services/user.go"}, {Path: "services/golden.go"}, {Path: "services/blessed.go"}, {Path: "services/legacy.go"},
+			{Path: "services/order.go"}, {Path: "services/cart.go"},
+		}},
+		// The config's api_handler already covers handlers
+		{ID: "handler", Name: "handler", Type: patterns.PatternHTTPHandler, SeenCount: 4},
+		// A new module's repositories
+		{ID: "repository", Name: "repository", Type: patterns.PatternRepository, SeenCount: 3},
+	}
+
+	added, examples := cfg.MergeMissing(found)
+	if len(added) != 1 || added[0].ID != "repository" {
+		t.Errorf("added %+v, want the repository pattern alone", added)
+	}
+	if examples != 2 {
+		t.Errorf("added %d examples, want order.go and cart.go", examples)
+	}
+	if len(cfg.Patterns) != 3 || cfg.Patterns[2].ID != "repository" {
+		t.Fatalf("patterns = %+v, want service, api_handler and repository", cfg.Patterns)
+	}
+
+	// The existing service keeps its curation, gaining only new examples
+	service := cfg.Patterns[0]
+	if service.Name != "Curated services" || service.Confidence != 0.7 || service.SeenCount != 4 {
+		t.Errorf("service = %s at %g seen %d, want its name and confidence kept and 4 seen", service.Name, service.Confidence, service.SeenCount)
+	}
+	if len(service.AnnotatedGolden) != 1 || len(service.ConfigBlessed) != 1 || len(service.AntiPatterns) != 1 {
+		t.Errorf("service tiers changed: %+v", service)
+	}
+	if len(service.Discovered) != 3 || service.Discovered[1].Path != "services/order.go" || service.Discovered[2].Path != "services/cart.go" {
+		t.Errorf("service examples = %+v, want user.go, order.go and cart.go", service.Discovered)
+	}
+	if cfg.Patterns[1].Name != "API handlers" || cfg.Settings.AutoApproveThreshold != 90 {
+		t.Errorf("api_handler or settings changed: %+v, %+v", cfg.Patterns[1], cfg.Settings)
+	}
+
+	// Merging the same patterns again finds nothing missing
+	if added, examples := cfg.MergeMissing(found); len(added) != 0 || examples != 0 {
+		t.Errorf("second merge added %+v and %d examples, want nothing", added, examples)
+	}
+}
+
+// importStructure is a structure requiring imports, each with an importance
+func importStructure(importance map[string]float64) patterns.CodeStructure {
+	s := patterns.CodeStructure{}
+	for _, imp := range []string{"errors", "fmt", "strings"} {
+		if w, ok := importance[imp]; ok {
+			s.Required = append(s.Required, imp)
+			s.Elements = append(s.Elements, patterns.StructureElement{Name: imp, Type: patterns.ElementImport, Importance: w})
+		}
+	}
+	return s
+}
+
+func TestMergeLearned(t *testing.T) {
+	cfg := &Config{Patterns: []patterns.Pattern{
+		{ID: "service", Version: "1.0", SeenCount: 3, Confidence: 0.7, Structure: importStructure(map[string]float64{"fmt": 1, "strings": 0.8})},
+		{ID: "handler", Version: "1.2", SeenCount: 4, Structure: importStructure(map[string]float64{"fmt": 1})},
+	}}
+	learned := []patterns.Pattern{
+		// The same required imports, now every service has strings
+		{ID: "service", SeenCount: 5, Confidence: 0.9, Structure: importStructure(map[string]float64{"fmt": 1, "strings": 1})},
+		// Handlers now require errors too
+		{ID: "handler", SeenCount: 6, Structure: importStructure(map[string]float64{"errors": 0.9, "fmt": 1})},
+		{ID: "repository", SeenCount: 3},
+	}
+
+	if updated := cfg.MergeLearned(learned); updated != 3 {
+		t.Errorf("MergeLearned = %d, want 3 updated or added", updated)
+	}
+	if len(cfg.Patterns) != 3 || cfg.Patterns[2].ID != "repository" {
+		t.Fatalf("patterns = %+v, want service, handler and repository", cfg.Patterns)
+	}
+
+	service := cfg.Patterns[0]
+	if service.SeenCount != 5 || service.Confidence != 0.9 {
+		t.Errorf("service seen %d at %g, want the learned 5 at 0.9", service.SeenCount, service.Confidence)
+	}
+	if service.Version != "1.0" || len(service.History) != 0 {
+		t.Errorf("service version %s with history %+v, want 1.0 unchanged", service.Version, service.History)
+	}
+	if got := service.Structure.Elements[1]; got.Name != "strings" || got.Importance != 1 {
+		t.Errorf("service strings element = %+v, want the learned importance 1", got)
+	}
+
+	handler := cfg.Patterns[1]
+	if handler.Version != "1.3" || len(handler.History) != 1 || handler.History[0].Version != "1.2" {
+		t.Errorf("handler version %s with history %+v, want 1.3 after 1.2", handler.Version, handler.History)
+	}
+	if len(handler.Structure.Elements) != 2 || handler.Structure.Elements[0].Importance != 0.9 {
+		t.Errorf("handler elements = %+v, want the learned errors and fmt", handler.Structure.Elements)
+	}
+}
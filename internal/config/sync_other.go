@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// syncDir is a no-op where directories can't be flushed; the rename itself
+// is still atomic
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package config
+
+import "os"
+
+// syncDir flushes a directory, making a rename into it durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -4,17 +4,40 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/walk"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
 )
 
+// NotesRef is the git notes ref holding AI provenance notes
+// (refs/notes/code-on-rails)
+const NotesRef = "code-on-rails"
+
 // Detector identifies AI-generated code
 type Detector struct {
-	Config   *config.DetectionConfig
-	Language string
+	Config       *config.DetectionConfig
+	Language     string
+	IncludeTests bool // Include test files, which are skipped by default
+	// Branch overrides the current branch name, e.g. in CI with a detached
+	// HEAD. Empty reads it from CI variables or git.
+	Branch string
+
+	// LanguageOverrides maps path prefixes to languages for polyglot repos
+	LanguageOverrides map[string]string
+	// IgnoreDirs are the directory names, or globs like ".*", never
+	// scanned; nil uses DefaultIgnoreDirs
+	IgnoreDirs []string
 }
 
+// DefaultIgnoreDirs are the directories never scanned out of the box:
+// dependencies, build output and hidden directories
+var DefaultIgnoreDirs = []string{"vendor", "node_modules", "dist", "build", ".next", "bin", "obj", ".*"}
+
 // New creates a new detector
 func New(cfg *config.DetectionConfig) *Detector {
 	return &Detector{Config: cfg, Language: ""}
@@ -25,45 +48,141 @@ func NewWithLanguage(cfg *config.DetectionConfig, language string) *Detector {
 	return &Detector{Config: cfg, Language: language}
 }
 
-// isSupportedFile checks if the file is a supported code file based on language
+// isSupportedFile checks if the file is a supported code file based on the
+// language of its directory
 func (d *Detector) isSupportedFile(file string) bool {
-	// If no language specified, support all
-	switch d.Language {
-	case "go":
-		return strings.HasSuffix(file, ".go")
-	case "typescript", "ts":
-		return strings.HasSuffix(file, ".ts") || strings.HasSuffix(file, ".tsx")
-	case "javascript", "js":
-		return strings.HasSuffix(file, ".js") || strings.HasSuffix(file, ".jsx")
-	case "react":
-		return strings.HasSuffix(file, ".tsx") || strings.HasSuffix(file, ".jsx") ||
-			strings.HasSuffix(file, ".ts") || strings.HasSuffix(file, ".js")
-	default:
-		// Support all code files by default
-		return strings.HasSuffix(file, ".go") ||
-			strings.HasSuffix(file, ".ts") || strings.HasSuffix(file, ".tsx") ||
-			strings.HasSuffix(file, ".js") || strings.HasSuffix(file, ".jsx")
+	return patterns.SupportsFile(config.ResolveLanguage(d.LanguageOverrides, d.Language, file), file)
+}
+
+// FilesInDir recursively lists supported source files under dir, regardless
+// of whether they were AI-generated. Ignored directories and, in a git
+// repository, files matched by .gitignore are skipped, as are test files
+// unless IncludeTests.
+func (d *Detector) FilesInDir(dir string) ([]string, error) {
+	files := []string{}
+	err := walk.Files(dir, d.isIgnoredDir, func(path string) error {
+		if d.isSupportedFile(path) && d.allowsTestFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withoutGitIgnored(dir, files), nil
+}
+
+// CountFiles counts the files under dir with any of the extensions,
+// skipping ignored directories, for guessing a project's language
+func (d *Detector) CountFiles(dir string, exts ...string) int {
+	count := 0
+	walk.Files(dir, d.isIgnoredDir, func(path string) error {
+		for _, ext := range exts {
+			if strings.HasSuffix(path, ext) {
+				count++
+				break
+			}
+		}
+		return nil
+	})
+	return count
+}
+
+// allowsTestFile checks if a file passes the test file filter
+func (d *Detector) allowsTestFile(file string) bool {
+	return d.IncludeTests || !patterns.IsTestFile(file)
+}
+
+// isIgnoredDir checks if a directory should never be scanned
+func (d *Detector) isIgnoredDir(name string) bool {
+	rules := d.IgnoreDirs
+	if rules == nil {
+		rules = DefaultIgnoreDirs
+	}
+	for _, rule := range rules {
+		if match, err := path.Match(rule, name); rule == name || (err == nil && match) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutGitIgnored drops the files .gitignore rules match, asking git about
+// them from dir. Outside a git repository the files are kept as they are.
+func withoutGitIgnored(dir string, files []string) []string {
+	if len(files) == 0 {
+		return files
+	}
+	cmd := exec.Command("git", "check-ignore", "--stdin", "-z")
+	cmd.Dir = dir
+	rel := make([]string, len(files))
+	for i, file := range files {
+		rel[i] = file
+		if r, err := filepath.Rel(dir, file); err == nil {
+			rel[i] = r
+		}
+	}
+	cmd.Stdin = strings.NewReader(strings.Join(rel, "\x00") + "\x00")
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means nothing is ignored; anything else, such as not
+		// being in a repository, leaves the files unfiltered
+		return files
+	}
+
+	ignored := make(map[string]bool)
+	for _, name := range strings.Split(string(output), "\x00") {
+		ignored[name] = true
+	}
+	kept := []string{}
+	for i, file := range files {
+		if !ignored[rel[i]] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// GitRoot returns the top-level directory of the git repository containing dir
+func GitRoot(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
 	}
+	return strings.TrimSpace(string(output)), nil
 }
 
-// isTestFile checks if file is a test file (to optionally exclude)
-func (d *Detector) isTestFile(file string) bool {
-	lower := strings.ToLower(file)
-	return strings.HasSuffix(lower, "_test.go") ||
-		strings.HasSuffix(lower, ".test.ts") ||
-		strings.HasSuffix(lower, ".test.tsx") ||
-		strings.HasSuffix(lower, ".test.js") ||
-		strings.HasSuffix(lower, ".test.jsx") ||
-		strings.HasSuffix(lower, ".spec.ts") ||
-		strings.HasSuffix(lower, ".spec.tsx") ||
-		strings.HasSuffix(lower, ".spec.js") ||
-		strings.HasSuffix(lower, ".spec.jsx") ||
-		strings.Contains(lower, "__tests__/") ||
-		strings.Contains(lower, "__mocks__/")
+// HeadCommit returns the commit checked out in the repository containing dir
+func HeadCommit(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // DetectFiles finds files that were generated by AI
 func (d *Detector) DetectFiles(gitRepo string) ([]string, error) {
+	files, err := d.detectFiles(gitRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(files))
+	for _, f := range files {
+		if d.allowsTestFile(f) {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// detectFiles runs the configured detection method
+func (d *Detector) detectFiles(gitRepo string) ([]string, error) {
 	switch d.Config.Method {
 	case "commit_message":
 		return d.detectByCommitMessage(gitRepo)
@@ -125,18 +244,8 @@ func (d *Detector) detectByCommitMessage(gitRepo string) ([]string, error) {
 				continue
 			}
 
-			// Get files changed in this commit
-			cmdFiles := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", commit)
-			cmdFiles.Dir = gitRepo
-			filesOutput, err := cmdFiles.Output()
-			if err != nil {
-				continue
-			}
-
-			for _, file := range strings.Split(strings.TrimSpace(string(filesOutput)), "\n") {
-				if file != "" && d.isSupportedFile(file) {
-					files[file] = true
-				}
+			for _, file := range d.commitFiles(gitRepo, commit) {
+				files[file] = true
 			}
 		}
 	}
@@ -148,33 +257,76 @@ func (d *Detector) detectByCommitMessage(gitRepo string) ([]string, error) {
 	return result, nil
 }
 
-// detectByBranch detects AI-generated code by checking if the current branch
-// name contains AI tool prefixes (e.g., claude/, ai/, copilot/)
-func (d *Detector) detectByBranch(gitRepo string) ([]string, error) {
-	// Get current branch name - first check GitHub Actions env var (for PRs)
-	branchName := os.Getenv("GITHUB_HEAD_REF")
+// commitFiles lists the supported files changed in a commit
+func (d *Detector) commitFiles(gitRepo, commit string) []string {
+	cmd := exec.Command("git", "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", commit)
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
 
-	// Fall back to git command if not in GitHub Actions PR context
-	if branchName == "" {
-		cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-		cmd.Dir = gitRepo
-		output, err := cmd.Output()
-		if err != nil {
-			return []string{}, nil
+	files := []string{}
+	for _, file := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if file != "" && d.isSupportedFile(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// CurrentBranch names the branch being checked: Branch when set, else the
+// PR head branch (GITHUB_HEAD_REF) or pushed branch (GITHUB_REF_NAME) in
+// GitHub Actions, else git's current branch. It is empty on a detached HEAD
+// outside CI.
+func (d *Detector) CurrentBranch(gitRepo string) string {
+	if d.Branch != "" {
+		return d.Branch
+	}
+	if branch := os.Getenv("GITHUB_HEAD_REF"); branch != "" {
+		return branch
+	}
+	if os.Getenv("GITHUB_REF_TYPE") == "branch" {
+		if branch := os.Getenv("GITHUB_REF_NAME"); branch != "" {
+			return branch
 		}
-		branchName = strings.TrimSpace(string(output))
 	}
 
-	// Check if branch name starts with any AI prefix
-	isAIBranch := false
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return "" // Detached
+	}
+	return branch
+}
+
+// IsAIBranch checks if a branch name starts with one of the configured AI
+// prefixes, possibly after a namespace such as user/claude/...
+func (d *Detector) IsAIBranch(branch string) bool {
+	if branch == "" {
+		return false
+	}
 	for _, prefix := range d.Config.BranchPrefixes {
-		if strings.HasPrefix(branchName, prefix) || strings.Contains(branchName, "/"+prefix) {
-			isAIBranch = true
-			break
+		if prefix == "" {
+			continue
+		}
+		if strings.HasPrefix(branch, prefix) || strings.Contains(branch, "/"+prefix) {
+			return true
 		}
 	}
+	return false
+}
 
-	if !isAIBranch {
+// detectByBranch detects AI-generated code by checking if the current branch
+// name contains AI tool prefixes (e.g., claude/, ai/, copilot/). Every file
+// the branch changed counts as AI-generated.
+func (d *Detector) detectByBranch(gitRepo string) ([]string, error) {
+	if !d.IsAIBranch(d.CurrentBranch(gitRepo)) {
 		return []string{}, nil
 	}
 
@@ -273,7 +425,11 @@ func (d *Detector) getAllCodeFiles(gitRepo string) ([]string, error) {
 
 // getFilePatterns returns git ls-files patterns for the configured language
 func (d *Detector) getFilePatterns() []string {
-	switch d.Language {
+	language := d.Language
+	if len(d.LanguageOverrides) > 0 {
+		language = "" // isSupportedFile routes each file by directory
+	}
+	switch language {
 	case "go":
 		return []string{"*.go", "**/*.go"}
 	case "typescript", "ts":
@@ -282,12 +438,15 @@ func (d *Detector) getFilePatterns() []string {
 		return []string{"*.js", "**/*.js", "*.jsx", "**/*.jsx"}
 	case "react":
 		return []string{"*.ts", "**/*.ts", "*.tsx", "**/*.tsx", "*.js", "**/*.js", "*.jsx", "**/*.jsx"}
+	case "csharp":
+		return []string{"*.cs", "**/*.cs"}
 	default:
 		// All supported languages
 		return []string{
 			"*.go", "**/*.go",
 			"*.ts", "**/*.ts", "*.tsx", "**/*.tsx",
 			"*.js", "**/*.js", "*.jsx", "**/*.jsx",
+			"*.cs", "**/*.cs",
 		}
 	}
 }
@@ -297,11 +456,79 @@ func (d *Detector) getAllGoFiles(gitRepo string) ([]string, error) {
 	return d.getAllCodeFiles(gitRepo)
 }
 
-// detectByGitNotes finds files with AI notes
+// detectByGitNotes finds files changed by commits whose code-on-rails git
+// note carries an AI marker. Without notes (or git notes) nothing is found.
 func (d *Detector) detectByGitNotes(gitRepo string) ([]string, error) {
-	// This would check git notes for AI markers
-	// For PoC, return empty
-	return []string{}, nil
+	// Lists "<note> <commit>" pairs; fails when git notes is unavailable
+	cmd := exec.Command("git", "notes", "--ref="+NotesRef, "list")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return []string{}, nil
+	}
+
+	files := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		commit := fields[1]
+		if !d.hasAIMarker(readNote(gitRepo, commit)) {
+			continue
+		}
+		for _, file := range d.commitFiles(gitRepo, commit) {
+			files[file] = true
+		}
+	}
+
+	result := make([]string, 0, len(files))
+	for f := range files {
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// readNote returns the code-on-rails note on a commit, or "" if it has none
+func readNote(gitRepo, commit string) string {
+	cmd := exec.Command("git", "notes", "--ref="+NotesRef, "show", commit)
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return string(output)
+}
+
+// hasAIMarker checks if a note contains one of the AI commit prefixes
+func (d *Detector) hasAIMarker(note string) bool {
+	note = strings.ToLower(note)
+	for _, prefix := range d.Config.CommitPrefixes {
+		if strings.Contains(note, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAINote records AI provenance for a commit as a git note, replacing any
+// existing code-on-rails note on it. The note reads "[ai:<source>]" plus an
+// optional reason, which the git_notes detection method recognizes.
+func AddAINote(gitRepo, commit, source, reason string) error {
+	note := "[ai]"
+	if source != "" {
+		note = "[ai:" + source + "]"
+	}
+	if reason != "" {
+		note += " " + reason
+	}
+
+	cmd := exec.Command("git", "notes", "--ref="+NotesRef, "add", "-f", "-m", note, commit)
+	cmd.Dir = gitRepo
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to add git note to %s: %s", commit, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
 
 // detectByHeuristics detects AI-generated code using multiple strategies:
@@ -599,12 +826,13 @@ func (d *Detector) IsAIGenerated(gitRepo, filePath string) (bool, error) {
 
 // GetAISource tries to determine which AI tool generated the code
 func (d *Detector) GetAISource(gitRepo, filePath string) (string, error) {
-	// First check commit message
-	cmd := exec.Command("git", "log", "-1", "--pretty=format:%s", "--", filePath)
+	// First check commit message, then any note on that commit
+	cmd := exec.Command("git", "log", "-1", "--pretty=format:%H%n%s", "--", filePath)
 	cmd.Dir = gitRepo
 	output, err := cmd.Output()
 	if err == nil {
-		msg := strings.ToLower(string(output))
+		commit, subject, _ := strings.Cut(string(output), "\n")
+		msg := strings.ToLower(subject + "\n" + readNote(gitRepo, commit))
 		if strings.Contains(msg, "claude") {
 			return "claude", nil
 		}
@@ -620,11 +848,7 @@ func (d *Detector) GetAISource(gitRepo, filePath string) (string, error) {
 	}
 
 	// Then check branch name
-	branchCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	branchCmd.Dir = gitRepo
-	branchOutput, err := branchCmd.Output()
-	if err == nil {
-		branch := strings.ToLower(strings.TrimSpace(string(branchOutput)))
+	if branch := strings.ToLower(d.CurrentBranch(gitRepo)); branch != "" {
 		if strings.Contains(branch, "claude") {
 			return "claude", nil
 		}
@@ -642,6 +866,172 @@ func (d *Detector) GetAISource(gitRepo, filePath string) (string, error) {
 	return "unknown", nil
 }
 
+// FilesBetweenTags returns the supported files added or modified between
+// two release tags that still exist at the later one. An empty until means
+// HEAD. Missing tags are an error.
+func (d *Detector) FilesBetweenTags(gitRepo, since, until string) ([]string, error) {
+	from, err := resolveTag(gitRepo, since)
+	if err != nil {
+		return nil, err
+	}
+	to := "HEAD"
+	if until != "" {
+		if to, err = resolveTag(gitRepo, until); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=ACMR", from, to)
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", since, to, err)
+	}
+
+	files := []string{}
+	for _, f := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if f != "" && d.isSupportedFile(f) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// resolveTag returns the commit a tag points to
+func resolveTag(gitRepo, tag string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/tags/"+tag+"^{commit}")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("tag %q not found", tag)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ResolveRevision returns the commit a revision such as a branch, tag or
+// SHA points to
+func ResolveRevision(gitRepo, rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("revision %q not found", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// FileAtRevision reads a file's contents as of a git revision. Relative
+// paths are resolved from gitRepo, as they would be in the working tree.
+func FileAtRevision(gitRepo, rev, path string) ([]byte, error) {
+	if filepath.IsAbs(path) {
+		dir, err := filepath.Abs(gitRepo)
+		if err != nil {
+			return nil, err
+		}
+		if path, err = filepath.Rel(dir, path); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.Command("git", "show", rev+":./"+filepath.ToSlash(path))
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s doesn't exist at %s", path, rev)
+	}
+	return output, nil
+}
+
+// RevisionReader reads files as of a commit through FileAtRevision,
+// caching them since every checked file is compared against the same
+// references
+func RevisionReader(gitRepo, commit string) func(path string) ([]byte, error) {
+	var mu sync.Mutex
+	cache := make(map[string][]byte)
+	return func(path string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if src, ok := cache[path]; ok {
+			return src, nil
+		}
+		src, err := FileAtRevision(gitRepo, commit, path)
+		if err != nil {
+			return nil, err
+		}
+		cache[path] = src
+		return src, nil
+	}
+}
+
+// ExpandArgFiles replaces each @file argument with the paths listed in the
+// file, one per line, as gofmt and compilers do for long file lists. Blank
+// lines and # comments are ignored; listed paths go through rebase, and
+// are kept whether or not they exist, for the caller to report.
+func ExpandArgFiles(args []string, rebase func(string) string) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") || arg == "@" {
+			expanded = append(expanded, arg)
+			continue
+		}
+		data, err := os.ReadFile(arg[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read argument file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			expanded = append(expanded, rebase(line))
+		}
+	}
+	return expanded, nil
+}
+
+// ChangedFiles lists supported source files with uncommitted changes: staged,
+// unstaged or untracked. Deleted files and files in ignored directories are
+// skipped; untracked files matched by .gitignore are never listed.
+func (d *Detector) ChangedFiles(gitRepo string) ([]string, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "-z", "--untracked-files=all")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	files := []string{}
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		status, path := entry[:2], entry[3:]
+		if status[0] == 'R' || status[0] == 'C' {
+			i++ // Skip the original path that follows a rename or copy
+		}
+		if strings.Contains(status, "D") {
+			continue
+		}
+		if !d.isSupportedFile(path) || !d.allowsTestFile(path) || d.inIgnoredDir(path) {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// inIgnoredDir checks if any directory in a slash-separated path is ignored
+func (d *Detector) inIgnoredDir(path string) bool {
+	dirs := strings.Split(path, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if d.isIgnoredDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRecentAIFiles gets AI files from recent commits
 func (d *Detector) GetRecentAIFiles(gitRepo string, days int) ([]string, error) {
 	cmd := exec.Command("git", "log", fmt.Sprintf("--since=%d days ago", days), "--name-only", "--pretty=format:")
@@ -0,0 +1,653 @@
+package detector
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// writeFiles creates files, with their directories, under root
+func writeFiles(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// relFiles lists files found under root relative to it, sorted
+func relFiles(t *testing.T, root string, files []string) []string {
+	t.Helper()
+	rel := []string{}
+	for _, file := range files {
+		r, err := filepath.Rel(root, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	sort.Strings(rel)
+	return rel
+}
+
+func newGoDetector() *Detector {
+	return NewWithLanguage(&config.DetectionConfig{Method: "heuristic"}, "go")
+}
+
+func TestFilesInDirDefaultIgnores(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"main.go",
+		"services/user.go",
+		"services/user_test.go",
+		"vendor/lib/lib.go",
+		"node_modules/x/x.go",
+		".cache/c.go",
+		"generated/g.go",
+		"README.md",
+	)
+
+	files, err := newGoDetector().FilesInDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"generated/g.go", "main.go", "services/user.go"}
+	if got := relFiles(t, root, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesInDir = %v, want %v", got, want)
+	}
+}
+
+func TestFilesInDirConfiguredIgnores(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"main.go",
+		"vendor/lib/lib.go",
+		"generated/g.go",
+		"gen_mocks/m.go",
+		"internal/generated/g.go",
+	)
+
+	det := newGoDetector()
+	det.IgnoreDirs = []string{"gen*"} // Replaces the defaults, so vendor is scanned
+	files, err := det.FilesInDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"main.go", "vendor/lib/lib.go"}
+	if got := relFiles(t, root, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesInDir = %v, want %v", got, want)
+	}
+}
+
+func TestFilesInDirGitIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeFiles(t, root, "main.go", "tmp/scratch.go", "api/zz_generated.go", "api/api.go")
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("tmp/\nzz_*.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := newGoDetector().FilesInDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"api/api.go", "main.go"}
+	if got := relFiles(t, root, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilesInDir = %v, want %v", got, want)
+	}
+}
+
+func TestInIgnoredDir(t *testing.T) {
+	det := newGoDetector()
+	tests := map[string]bool{
+		"main.go":               false,
+		"services/user.go":      false,
+		"vendor/lib/lib.go":     true,
+		"web/node_modules/x.js": true,
+		".github/actions/a.go":  true,
+		"cmd/build/main.go":     true,
+		"internal/builder/b.go": false,
+		"internal/.hidden/h.go": true,
+	}
+	for path, want := range tests {
+		if got := det.inIgnoredDir(path); got != want {
+			t.Errorf("inIgnoredDir(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// gitRepo initializes a repository under a temporary directory with its
+// branch named main
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	git(t, root, "init", "-q")
+	git(t, root, "symbolic-ref", "HEAD", "refs/heads/main")
+	return root
+}
+
+// git runs a git command in dir, failing the test if it fails
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+func TestFileAtRevision(t *testing.T) {
+	root := gitRepo(t)
+	golden := filepath.Join(root, "services", "golden.go")
+	writeFiles(t, root, "services/golden.go")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "golden")
+
+	// The branch edits the golden example it will be checked against
+	git(t, root, "checkout", "-q", "-b", "feature")
+	if err := os.WriteFile(golden, []byte("package x\n\nfunc Edited() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, root, "commit", "-q", "-am", "edit golden")
+
+	commit, err := ResolveRevision(root, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"services/golden.go", golden} {
+		src, err := FileAtRevision(root, commit, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(src) != "package x\n" {
+			t.Errorf("FileAtRevision(%s) = %q, want the version on main", path, src)
+		}
+	}
+
+	if _, err := FileAtRevision(root, commit, "services/missing.go"); err == nil {
+		t.Error("FileAtRevision read a file that doesn't exist at the revision")
+	}
+	if _, err := ResolveRevision(root, "no-such-branch"); err == nil {
+		t.Error("ResolveRevision resolved a missing branch")
+	}
+}
+
+func TestRevisionReaderCaches(t *testing.T) {
+	root := gitRepo(t)
+	writeFiles(t, root, "golden.go")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "golden")
+	commit, err := ResolveRevision(root, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := RevisionReader(root, commit)
+	first, err := read("golden.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cached read doesn't go back to git, so it survives the repo going away
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	second, err := read("golden.go")
+	if err != nil {
+		t.Fatalf("cached read: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("cached read = %q, want %q", second, first)
+	}
+	if _, err := read("other.go"); err == nil {
+		t.Error("uncached read succeeded without a repository")
+	}
+}
+
+func TestExpandArgFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "services/user.go", "handlers/api.go")
+	argFile := filepath.Join(root, "files.txt")
+	list := "# Changed in this PR\nservices/user.go\n\n  handlers/api.go  \n# services/skipped.go\nservices/missing.go\n"
+	if err := os.WriteFile(argFile, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rebase := func(p string) string { return filepath.Join(root, p) }
+
+	got, err := ExpandArgFiles([]string{"main.go", "@" + argFile, "@"}, rebase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The missing file is kept so matching reports it rather than it
+	// silently going unchecked
+	want := []string{
+		"main.go",
+		filepath.Join(root, "services/user.go"),
+		filepath.Join(root, "handlers/api.go"),
+		filepath.Join(root, "services/missing.go"),
+		"@",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandArgFiles = %v, want %v", got, want)
+	}
+
+	if _, err := ExpandArgFiles([]string{"@" + filepath.Join(root, "nope.txt")}, rebase); err == nil {
+		t.Error("ExpandArgFiles read a missing argument file")
+	}
+}
+
+func TestCountFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, "main.go", "web/app.ts", "web/page.tsx", "web/node_modules/x/x.ts", "api/api.go")
+	if err := os.Symlink(root, filepath.Join(root, "api", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	det := newGoDetector()
+	if got := det.CountFiles(root, ".go"); got != 2 {
+		t.Errorf("CountFiles(.go) = %d, want 2", got)
+	}
+	if got := det.CountFiles(root, ".ts", ".tsx"); got != 2 {
+		t.Errorf("CountFiles(.ts, .tsx) = %d, want 2 outside node_modules", got)
+	}
+}
+
+// clearCIBranch unsets the GitHub Actions variables naming the branch
+func clearCIBranch(t *testing.T) {
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("GITHUB_REF_NAME", "")
+	t.Setenv("GITHUB_REF_TYPE", "")
+	t.Setenv("GITHUB_BASE_REF", "")
+}
+
+func branchDetector(branch string) *Detector {
+	det := NewWithLanguage(&config.DetectionConfig{
+		Method:         "branch",
+		BranchPrefixes: []string{"claude/", "ai-"},
+	}, "go")
+	det.Branch = branch
+	return det
+}
+
+func TestIsAIBranch(t *testing.T) {
+	det := branchDetector("")
+	tests := map[string]bool{
+		"claude/fix-login":       true,
+		"ai-refactor":            true,
+		"alice/claude/fix-login": true,
+		"feature/login":          false,
+		"main":                   false,
+		"my-claude/fix":          false,
+		"":                       false,
+	}
+	for branch, want := range tests {
+		if got := det.IsAIBranch(branch); got != want {
+			t.Errorf("IsAIBranch(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	root := gitRepo(t)
+	writeFiles(t, root, "main.go")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "base")
+	clearCIBranch(t)
+
+	if got := branchDetector("").CurrentBranch(root); got != "main" {
+		t.Errorf("CurrentBranch from git = %q, want main", got)
+	}
+	git(t, root, "checkout", "-q", "--detach")
+	if got := branchDetector("").CurrentBranch(root); got != "" {
+		t.Errorf("CurrentBranch on a detached HEAD = %q, want none", got)
+	}
+
+	t.Setenv("GITHUB_REF_NAME", "claude/pushed")
+	if got := branchDetector("").CurrentBranch(root); got != "" {
+		t.Errorf("CurrentBranch used GITHUB_REF_NAME %q without GITHUB_REF_TYPE=branch", got)
+	}
+	t.Setenv("GITHUB_REF_TYPE", "branch")
+	if got := branchDetector("").CurrentBranch(root); got != "claude/pushed" {
+		t.Errorf("CurrentBranch in a push build = %q, want claude/pushed", got)
+	}
+	t.Setenv("GITHUB_HEAD_REF", "claude/pr")
+	if got := branchDetector("").CurrentBranch(root); got != "claude/pr" {
+		t.Errorf("CurrentBranch in a PR build = %q, want claude/pr", got)
+	}
+	if got := branchDetector("ai-override").CurrentBranch(root); got != "ai-override" {
+		t.Errorf("CurrentBranch with --branch = %q, want ai-override", got)
+	}
+}
+
+func TestDetectByBranch(t *testing.T) {
+	root := gitRepo(t)
+	writeFiles(t, root, "main.go", "services/user.go")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "base")
+	git(t, root, "checkout", "-q", "-b", "claude/orders")
+	writeFiles(t, root, "services/order.go", "README.md")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "orders")
+	clearCIBranch(t)
+
+	// A matching branch marks everything it changed
+	files, err := branchDetector("").DetectFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"services/order.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("DetectFiles on claude/orders = %v, want %v", files, want)
+	}
+
+	// The same commits on a branch without an AI prefix mark nothing
+	git(t, root, "checkout", "-q", "-b", "feature/orders")
+	if files, err = branchDetector("").DetectFiles(root); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("DetectFiles on feature/orders = %v, want none", files)
+	}
+
+	// --branch overrides the detached HEAD CI checks out
+	git(t, root, "checkout", "-q", "--detach")
+	if files, err = branchDetector("claude/orders").DetectFiles(root); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"services/order.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("DetectFiles with --branch claude/orders = %v, want %v", files, want)
+	}
+}
+
+// commitFiles writes files and commits them, returning the commit
+func commitFiles(t *testing.T, root, message string, paths ...string) string {
+	t.Helper()
+	writeFiles(t, root, paths...)
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", message)
+	commit, err := HeadCommit(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return commit
+}
+
+func notesDetector() *Detector {
+	return NewWithLanguage(&config.DetectionConfig{
+		Method:         "git_notes",
+		CommitPrefixes: []string{"[ai"},
+	}, "go")
+}
+
+// gitIdentity gives git commands the test doesn't run itself, like
+// AddAINote's, an identity to record
+func gitIdentity(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+func TestDetectByGitNotes(t *testing.T) {
+	root := gitRepo(t)
+	gitIdentity(t)
+	commitFiles(t, root, "base", "main.go")
+
+	// Without notes nothing is found
+	files, err := notesDetector().DetectFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("DetectFiles without notes = %v, want none", files)
+	}
+
+	generated := commitFiles(t, root, "add orders", "services/order.go", "services/order_test.go", "README.md")
+	reviewed := commitFiles(t, root, "add users", "services/user.go")
+	if err := AddAINote(root, generated, "claude", "scaffolded"); err != nil {
+		t.Fatal(err)
+	}
+	// A note without a marker isn't provenance
+	git(t, root, "notes", "--ref="+NotesRef, "add", "-m", "reviewed by hand", reviewed)
+
+	if note := readNote(root, generated); note != "[ai:claude] scaffolded\n" {
+		t.Errorf("note = %q, want [ai:claude] scaffolded", note)
+	}
+	files, err = notesDetector().DetectFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"services/order.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("DetectFiles = %v, want %v", files, want)
+	}
+
+	// Marking again replaces the note rather than failing
+	if err := AddAINote(root, reviewed, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if note := readNote(root, reviewed); note != "[ai]\n" {
+		t.Errorf("note = %q, want [ai]", note)
+	}
+	det := notesDetector()
+	det.IncludeTests = true
+	files, err = det.DetectFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if want := []string{"services/order.go", "services/order_test.go", "services/user.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("DetectFiles with tests = %v, want %v", files, want)
+	}
+
+	if err := AddAINote(root, "no-such-commit", "claude", ""); err == nil {
+		t.Error("AddAINote noted a commit that doesn't exist")
+	}
+}
+
+func TestFilesBetweenTags(t *testing.T) {
+	root := gitRepo(t)
+	commitFiles(t, root, "v1.0", "main.go", "services/user.go", "services/legacy.go")
+	git(t, root, "tag", "v1.0")
+
+	// The release adds, modifies and deletes files
+	if err := os.WriteFile(filepath.Join(root, "services", "user.go"), []byte("package x\n\nfunc New() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, "services", "legacy.go")); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(t, root, "v1.1", "services/order.go", "docs/orders.md")
+	git(t, root, "tag", "-a", "-m", "release", "v1.1")
+	commitFiles(t, root, "unreleased", "services/cart.go")
+
+	det := NewWithLanguage(&config.DetectionConfig{}, "go")
+	files, err := det.FilesBetweenTags(root, "v1.0", "v1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if want := []string{"services/order.go", "services/user.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("FilesBetweenTags(v1.0, v1.1) = %v, want %v", files, want)
+	}
+
+	// Without an until tag the range runs to HEAD
+	files, err = det.FilesBetweenTags(root, "v1.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"services/cart.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("FilesBetweenTags(v1.1, HEAD) = %v, want %v", files, want)
+	}
+
+	for _, tags := range [][2]string{{"v0.9", ""}, {"v1.0", "v2.0"}, {"main", ""}} {
+		_, err := det.FilesBetweenTags(root, tags[0], tags[1])
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("FilesBetweenTags(%s, %s) error = %v, want a missing tag", tags[0], tags[1], err)
+		}
+	}
+}
+
+// numbered is Go source with n lines, each naming prefix and its number
+func numbered(prefix string, n int) string {
+	var b strings.Builder
+	b.WriteString("package x\n")
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(&b, "var %s%d = %d\n", prefix, i, i)
+	}
+	return b.String()
+}
+
+// commitAt writes files, deleting those with empty contents, and commits
+// them dated daysAgo
+func commitAt(t *testing.T, root string, daysAgo int, files map[string]string) {
+	t.Helper()
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if src == "" {
+			if err := os.Remove(full); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	date := time.Now().Add(-time.Duration(daysAgo) * 24 * time.Hour).Format(time.RFC3339)
+	t.Setenv("GIT_AUTHOR_DATE", date)
+	t.Setenv("GIT_COMMITTER_DATE", date)
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", fmt.Sprintf("%d days ago", daysAgo))
+}
+
+func TestFindRewrittenCode(t *testing.T) {
+	root := gitRepo(t)
+	commitAt(t, root, 70, map[string]string{"old.go": numbered("old", 10)})
+	commitAt(t, root, 40, map[string]string{
+		"rewritten.go": numbered("a", 10),
+		"removed.go":   numbered("b", 10),
+		"tweaked.go":   numbered("c", 10),
+		"kept.go":      numbered("d", 10),
+		"notes.md":     "# Notes\n",
+	})
+	// Introduced before the scanned history, so not a candidate
+	commitAt(t, root, 39, map[string]string{"old.go": ""})
+	commitAt(t, root, 37, map[string]string{
+		"rewritten.go": numbered("x", 10),
+		"tweaked.go":   strings.Replace(numbered("c", 10), "c9 = 9", "c9 = 90", 1),
+		"notes.md":     "",
+	})
+	commitAt(t, root, 36, map[string]string{"rewritten.go": numbered("y", 10)})
+	commitAt(t, root, 35, map[string]string{"removed.go": ""})
+	// Removed after RewriteWindow
+	commitAt(t, root, 10, map[string]string{"kept.go": ""})
+
+	candidates, err := NewWithLanguage(&config.DetectionConfig{}, "go").FindRewrittenCode(root, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]patterns.AntiPatternCandidate{}
+	for _, c := range candidates {
+		got[c.Path] = c
+	}
+	if len(candidates) != 2 || len(got) != 2 {
+		t.Fatalf("candidates = %+v, want rewritten.go and removed.go once each", candidates)
+	}
+
+	rewritten, removed := got["rewritten.go"], got["removed.go"]
+	if rewritten.Removed || rewritten.Churn < RewriteChurn {
+		t.Errorf("rewritten.go = %+v, want a rewrite with most lines replaced", rewritten)
+	}
+	if days := rewritten.Replaced.Sub(rewritten.Introduced).Hours() / 24; days < 2.9 || days > 3.1 {
+		t.Errorf("rewritten.go replaced %.1f days after it was introduced, want 3", days)
+	}
+	if !removed.Removed || removed.Churn != 1 {
+		t.Errorf("removed.go = %+v, want a removal", removed)
+	}
+
+	// Each candidate's commit holds the code before it was replaced
+	for path, want := range map[string]string{"rewritten.go": numbered("a", 10), "removed.go": numbered("b", 10)} {
+		src, err := FileAtRevision(root, got[path].Commit, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(src) != want {
+			t.Errorf("%s at %s = %q, want the original", path, got[path].Commit, src)
+		}
+	}
+
+	// A shorter scan misses the introductions
+	if candidates, err := NewWithLanguage(&config.DetectionConfig{}, "go").FindRewrittenCode(root, 20); err != nil || len(candidates) != 0 {
+		t.Errorf("FindRewrittenCode over 20 days = %+v, %v; want none", candidates, err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	root := gitRepo(t)
+	commitFiles(t, root, "base", "main.go", "services/user.go", "services/old.go", "services/legacy.go", "README.md")
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("tmp/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unstaged, staged, untracked, renamed and deleted changes
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, "services/order.go", "services/cart.go", "services/cart_test.go", "tmp/scratch.go", "vendor/lib/lib.go", "docs/guide.md")
+	git(t, root, "add", "services/order.go")
+	git(t, root, "mv", "services/old.go", "services/renamed.go")
+	git(t, root, "rm", "-q", "services/legacy.go")
+
+	files, err := newGoDetector().ChangedFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := []string{"main.go", "services/cart.go", "services/order.go", "services/renamed.go"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles = %v, want %v", files, want)
+	}
+
+	det := newGoDetector()
+	det.IncludeTests = true
+	if files, err = det.ChangedFiles(root); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(want)+1 {
+		t.Errorf("ChangedFiles with tests = %v, want services/cart_test.go too", files)
+	}
+
+	// A clean tree has nothing to check, and outside a repository it's an error
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "-q", "-m", "changes")
+	if files, err = newGoDetector().ChangedFiles(root); err != nil || len(files) != 0 {
+		t.Errorf("ChangedFiles on a clean tree = %v, %v; want none", files, err)
+	}
+	if _, err := newGoDetector().ChangedFiles(t.TempDir()); err == nil {
+		t.Error("ChangedFiles succeeded outside a git repository")
+	}
+}
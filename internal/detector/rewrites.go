@@ -0,0 +1,127 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Thresholds for inferring anti-patterns from history
+const (
+	// RewriteWindow is how soon after being introduced code must be removed
+	// or rewritten to count as a possible anti-pattern
+	RewriteWindow = 14 * 24 * time.Hour
+	// RewriteChurn is the fraction of a file's original lines that must be
+	// replaced for a change to count as a rewrite
+	RewriteChurn = 0.5
+)
+
+// introduction is where a file first appeared in the scanned history
+type introduction struct {
+	commit string
+	when   time.Time
+	lines  int
+}
+
+// FindRewrittenCode scans the last days of history for supported files that
+// were added and then deleted, or had most of their lines replaced, within
+// RewriteWindow. Each file is reported once, for its first such change.
+func (d *Detector) FindRewrittenCode(gitRepo string, days int) ([]patterns.AntiPatternCandidate, error) {
+	cmd := exec.Command("git", "log", fmt.Sprintf("--since=%d days ago", days), "--reverse",
+		"--no-renames", "--name-status", "--pretty=format:@%h %ct")
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	introduced := make(map[string]introduction)
+	reported := make(map[string]bool)
+	candidates := []patterns.AntiPatternCandidate{}
+
+	var commit string
+	var when time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@") {
+			fields := strings.Fields(line[1:])
+			if len(fields) != 2 {
+				continue
+			}
+			secs, _ := strconv.ParseInt(fields[1], 10, 64)
+			commit, when = fields[0], time.Unix(secs, 0)
+			continue
+		}
+
+		status, path, ok := strings.Cut(line, "\t")
+		if !ok || reported[path] || !d.isSupportedFile(path) {
+			continue
+		}
+		switch status {
+		case "A":
+			introduced[path] = introduction{commit: commit, when: when, lines: fileLines(gitRepo, commit, path)}
+		case "D", "M":
+			intro, ok := introduced[path]
+			if !ok || when.Sub(intro.when) > RewriteWindow {
+				continue
+			}
+			candidate := patterns.AntiPatternCandidate{
+				Path:       path,
+				Commit:     commit + "^", // The version just before this change
+				Introduced: intro.when,
+				Replaced:   when,
+				Removed:    status == "D",
+				Churn:      1,
+			}
+			if status == "M" {
+				if intro.lines == 0 {
+					continue
+				}
+				candidate.Churn = float64(removedLines(gitRepo, intro.commit, commit, path)) / float64(intro.lines)
+				if candidate.Churn < RewriteChurn {
+					continue
+				}
+				if candidate.Churn > 1 {
+					candidate.Churn = 1
+				}
+			}
+			candidates = append(candidates, candidate)
+			reported[path] = true
+		}
+	}
+	return candidates, scanner.Err()
+}
+
+// fileLines counts the lines of a file as of a commit
+func fileLines(gitRepo, commit, path string) int {
+	cmd := exec.Command("git", "show", commit+":"+path)
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	return bytes.Count(output, []byte("\n"))
+}
+
+// removedLines counts the lines of a file deleted between two commits
+func removedLines(gitRepo, from, to, path string) int {
+	cmd := exec.Command("git", "diff", "--numstat", from, to, "--", path)
+	cmd.Dir = gitRepo
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return 0
+	}
+	removed, _ := strconv.Atoi(fields[1])
+	return removed
+}
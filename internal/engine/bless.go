@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// BlessCandidate is a file BlessCandidates selected
+type BlessCandidate struct {
+	Path    string
+	Pattern string // ID
+	Score   float64
+}
+
+// Bless adds example to the pattern its file matches, returning the updated
+// pattern. A zero weight is settings.weights.blessed. Only the config in
+// memory changes: save it to keep the blessing.
+func (e *Engine) Bless(example patterns.BlessedExample) (*patterns.Pattern, error) {
+	if err := e.requireReason(example.Reason); err != nil {
+		return nil, err
+	}
+	id, err := e.matchedPattern(example.Path)
+	if err != nil {
+		return nil, err
+	}
+	p := e.bless(id, example)
+	e.matcher, e.err = NewMatcher(e.cfg)
+	return p, nil
+}
+
+// BlessAnti records the file at path as an anti-pattern of the pattern it
+// matches, which stops using it as a reference, returning the updated
+// pattern. Only the config in memory changes.
+func (e *Engine) BlessAnti(path, reason string) (*patterns.Pattern, error) {
+	if err := e.requireReason(reason); err != nil {
+		return nil, err
+	}
+	id, err := e.matchedPattern(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := e.pattern(id)
+	p.AntiPatterns = append(p.AntiPatterns, patterns.AntiPattern{
+		Path:    path,
+		Pattern: string(p.Type),
+		Reason:  reason,
+	})
+	discovered := p.Discovered[:0]
+	for _, ex := range p.Discovered {
+		if ex.Path != path {
+			discovered = append(discovered, ex)
+		}
+	}
+	p.Discovered = discovered
+	blessed := p.ConfigBlessed[:0]
+	for _, ex := range p.ConfigBlessed {
+		if ex.Path != path {
+			blessed = append(blessed, ex)
+		}
+	}
+	p.ConfigBlessed = blessed
+
+	e.matcher, e.err = NewMatcher(e.cfg)
+	return p, nil
+}
+
+// BlessCandidates lists the files in the repository matching patterns of
+// type (or ID) selector with a score of at least minScore, leaving out the
+// patterns' golden and blessed examples so blessing them all again blesses
+// nothing new
+func (e *Engine) BlessCandidates(selector string, minScore float64) ([]BlessCandidate, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	selects := func(p *patterns.Pattern) bool {
+		return !p.Remote && (string(p.Type) == selector || p.ID == selector)
+	}
+	found := false
+	for i := range e.cfg.Patterns {
+		found = found || selects(&e.cfg.Patterns[i])
+	}
+	if !found {
+		return nil, fmt.Errorf("no pattern of type or ID %q in config", selector)
+	}
+
+	files, err := e.Files([]string{"."})
+	if err != nil {
+		return nil, err
+	}
+	m := e.configure()
+	selected := []BlessCandidate{}
+	for _, file := range files {
+		match, err := m.MatchFile(file)
+		if err != nil || match.Pattern == nil || !selects(match.Pattern) || match.Score < minScore {
+			continue
+		}
+		if isElevated(*match.Pattern, file) {
+			continue
+		}
+		selected = append(selected, BlessCandidate{Path: file, Pattern: match.Pattern.ID, Score: match.Score})
+	}
+	return selected, nil
+}
+
+// BlessAll blesses each candidate in its pattern with example, whose path
+// is each candidate's. Only the config in memory changes.
+func (e *Engine) BlessAll(candidates []BlessCandidate, example patterns.BlessedExample) error {
+	if err := e.requireReason(example.Reason); err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		if e.pattern(c.Pattern) == nil {
+			return fmt.Errorf("no pattern %q in config", c.Pattern)
+		}
+		example.Path = c.Path
+		e.bless(c.Pattern, example)
+	}
+	e.matcher, e.err = NewMatcher(e.cfg)
+	return nil
+}
+
+// requireReason rejects blessing without a reason when
+// settings.require_bless_reason is set
+func (e *Engine) requireReason(reason string) error {
+	if e.cfg.Settings.RequireBlessReason && strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required to bless files (settings.require_bless_reason)")
+	}
+	return nil
+}
+
+// matchedPattern returns the ID of the config pattern the file at path
+// matches
+func (e *Engine) matchedPattern(path string) (string, error) {
+	if e.err != nil {
+		return "", e.err
+	}
+	match, err := e.configure().MatchFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to analyze file: %w", err)
+	}
+	if match.Pattern == nil {
+		return "", fmt.Errorf("no matching pattern found for %s", path)
+	}
+	if e.pattern(match.Pattern.ID) == nil {
+		return "", fmt.Errorf("%s matches pattern %s, which isn't in the config (e.g. a remote pattern)", path, match.Pattern.ID)
+	}
+	return match.Pattern.ID, nil
+}
+
+// bless appends example to the pattern with ID id, defaulting its weight
+func (e *Engine) bless(id string, example patterns.BlessedExample) *patterns.Pattern {
+	if example.Weight == 0 {
+		example.Weight = e.cfg.Settings.Weights.OrDefault().Blessed
+	}
+	p := e.pattern(id)
+	p.ConfigBlessed = append(p.ConfigBlessed, example)
+	return p
+}
+
+// pattern finds the config pattern with ID id, or nil
+func (e *Engine) pattern(id string) *patterns.Pattern {
+	for i := range e.cfg.Patterns {
+		if e.cfg.Patterns[i].ID == id {
+			return &e.cfg.Patterns[i]
+		}
+	}
+	return nil
+}
+
+// isElevated checks if a file already is one of a pattern's golden or
+// blessed examples
+func isElevated(p patterns.Pattern, filePath string) bool {
+	filePath = filepath.Clean(filePath)
+	for _, g := range p.AnnotatedGolden {
+		if filepath.Clean(g.Path) == filePath {
+			return true
+		}
+	}
+	for _, b := range p.ConfigBlessed {
+		if filepath.Clean(b.Path) == filePath {
+			return true
+		}
+	}
+	return false
+}
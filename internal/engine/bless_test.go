@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestBless(t *testing.T) {
+	root := serviceTree(t)
+	user := filepath.Join(root, "services", "user.go")
+	cfg := serviceConfig(root)
+	e := New(cfg)
+
+	p, err := e.Bless(patterns.BlessedExample{Path: user, Reason: "template"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "service" {
+		t.Errorf("Bless returned pattern %s, want service", p.ID)
+	}
+	blessed := cfg.Patterns[0].ConfigBlessed
+	if len(blessed) != 1 || blessed[0].Path != user {
+		t.Fatalf("config_blessed = %+v, want %s", blessed, user)
+	}
+	if want := (patterns.TierWeights{}).OrDefault().Blessed; blessed[0].Weight != want {
+		t.Errorf("weight = %v, want the default %v", blessed[0].Weight, want)
+	}
+
+	// The rebuilt matcher picks the blessing up
+	src, err := os.ReadFile(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, err := e.CheckSource(filepath.Join(root, "services", "order.go"), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.BlessedRef == nil || match.BlessedRef.Path != user {
+		t.Errorf("match reference = %+v, want the blessed %s", match.BlessedRef, user)
+	}
+}
+
+func TestBlessRejects(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go": serviceSource("fmt", "strings"),
+		"README.md":       "# Services\n",
+	})
+	user := filepath.Join(root, "services", "ref.go")
+
+	cfg := serviceConfig(root)
+	cfg.Settings.RequireBlessReason = true
+	if _, err := New(cfg).Bless(patterns.BlessedExample{Path: user}); err == nil {
+		t.Error("Bless without a reason succeeded with require_bless_reason")
+	}
+
+	cfg = serviceConfig(root)
+	if _, err := New(cfg).Bless(patterns.BlessedExample{Path: filepath.Join(root, "README.md")}); err == nil {
+		t.Error("Bless succeeded for a file no pattern matches")
+	}
+	if len(cfg.Patterns[0].ConfigBlessed) != 0 {
+		t.Errorf("failed blessing changed the config: %+v", cfg.Patterns[0].ConfigBlessed)
+	}
+}
+
+func TestBlessAnti(t *testing.T) {
+	root := serviceTree(t)
+	user := filepath.Join(root, "services", "user.go")
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].Discovered = []patterns.Example{{Path: user}}
+	cfg.Patterns[0].ConfigBlessed = []patterns.BlessedExample{{Path: user}}
+
+	if _, err := New(cfg).BlessAnti(user, "imports by hand"); err != nil {
+		t.Fatal(err)
+	}
+	p := cfg.Patterns[0]
+	if len(p.AntiPatterns) != 1 || p.AntiPatterns[0].Path != user || p.AntiPatterns[0].Reason != "imports by hand" {
+		t.Errorf("anti_patterns = %+v, want %s", p.AntiPatterns, user)
+	}
+	if len(p.Discovered) != 0 || len(p.ConfigBlessed) != 0 {
+		t.Errorf("%s is still a reference: discovered %+v, blessed %+v", user, p.Discovered, p.ConfigBlessed)
+	}
+}
+
+// inDir runs the rest of the test from dir, the repository
+// BlessCandidates looks in
+func inDir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestBlessCandidates(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":     serviceSource("fmt", "strings"),
+		"services/user.go":    serviceSource("fmt", "strings"),
+		"services/blessed.go": serviceSource("fmt", "strings"),
+		"services/other.go":   serviceSource("errors"),
+	})
+	inDir(t, root)
+
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].ConfigBlessed = []patterns.BlessedExample{{Path: filepath.Join("services", "blessed.go")}}
+	e := New(cfg)
+	e.Language = "go"
+
+	if _, err := e.BlessCandidates("handler", 90); err == nil {
+		t.Error("BlessCandidates succeeded for a pattern type the config lacks")
+	}
+
+	candidates, err := e.BlessCandidates("service", 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, c := range candidates {
+		got[filepath.ToSlash(c.Path)] = true
+	}
+	// blessed.go is already blessed and other.go scores too low
+	if len(got) != 2 || !got["services/ref.go"] || !got["services/user.go"] {
+		t.Fatalf("BlessCandidates = %+v, want ref.go and user.go", candidates)
+	}
+
+	if err := e.BlessAll(candidates, patterns.BlessedExample{Reason: "best examples"}); err != nil {
+		t.Fatal(err)
+	}
+	if blessed := cfg.Patterns[0].ConfigBlessed; len(blessed) != 3 {
+		t.Errorf("config_blessed = %+v, want 3 examples", blessed)
+	}
+	if again, err := e.BlessCandidates("service", 90); err != nil || len(again) != 0 {
+		t.Errorf("BlessCandidates after BlessAll = %+v, %v; want none", again, err)
+	}
+}
+
+func TestBlessCandidatesSelection(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":    serviceSource("fmt", "strings"),
+		"services/user.go":   serviceSource("fmt", "strings"),
+		"services/golden.go": serviceSource("fmt", "strings"),
+	})
+	inDir(t, root)
+
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].AnnotatedGolden = []patterns.GoldenExample{{Path: "./services/golden.go", Pattern: "service"}}
+	cfg.RemotePatterns = []patterns.Pattern{{ID: "team_service", Type: patterns.PatternService, Remote: true}}
+	e := New(cfg)
+	e.Language = "go"
+
+	// Golden examples are skipped, whichever way their path is written
+	candidates, err := e.BlessCandidates("service", 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range candidates {
+		if filepath.Base(c.Path) == "golden.go" {
+			t.Errorf("golden example selected: %+v", c)
+		}
+		if c.Pattern != "service" || c.Score < 90 {
+			t.Errorf("candidate %+v, want pattern service at 90 or more", c)
+		}
+	}
+	if len(candidates) != 2 {
+		t.Errorf("BlessCandidates = %+v, want ref.go and user.go", candidates)
+	}
+
+	// No file scores above 100
+	if byID, err := e.BlessCandidates("service", 100.1); err != nil || len(byID) != 0 {
+		t.Errorf("BlessCandidates above 100 = %+v, %v; want none", byID, err)
+	}
+
+	// Remote patterns are read-only
+	if _, err := e.BlessCandidates("team_service", 0); err == nil {
+		t.Error("BlessCandidates selected a remote pattern")
+	}
+	if err := e.BlessAll([]BlessCandidate{{Path: "services/user.go", Pattern: "team_service"}}, patterns.BlessedExample{}); err == nil {
+		t.Error("BlessAll blessed into a remote pattern")
+	}
+}
@@ -0,0 +1,373 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/detector"
+	"github.com/loop-hub/code-on-rails/internal/fixer"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+	"github.com/loop-hub/code-on-rails/internal/reporter"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Engine checks files against a config's patterns, the way cr check does,
+// for programs embedding Code on Rails. It never prints or exits: results
+// and failures are returned, or passed to the callbacks below.
+type Engine struct {
+	Language      string                 // Language of files to find in directories; "" for every supported one
+	IncludeTests  bool                   // Also check test files found in directories
+	Branch        string                 // Branch name for the branch detection method; "" reads it from CI or git
+	TypeFilter    []patterns.PatternType // Only match files against these pattern types
+	StrictVersion bool                   // Files matching an older pattern version need review
+	StrictImports bool                   // Note imports the reference lacks, warning about forbidden ones (default settings.strict_imports)
+	ReferencePath string                 // Match against this one reference file instead of the patterns
+	Ignore        []string               // Deviation elements to drop on top of settings.ignore_deviations
+
+	Progress func(done, total int)             // Called before each file CheckFiles matches
+	OnMatch  func(match patterns.PatternMatch) // Called with each match as soon as it's made
+	OnError  func(path string, err error)      // Called for files that fail to match, which are then skipped; when nil CheckFiles stops at the first failure
+
+	cfg     *config.Config
+	matcher *matcher.Matcher
+	err     error
+}
+
+// New creates an engine for cfg. A config the engine can't check with, e.g.
+// one naming an unknown check, is reported by Err and by every check.
+func New(cfg *config.Config) *Engine {
+	e := &Engine{cfg: cfg, StrictImports: cfg.Settings.StrictImports}
+	if len(cfg.Languages) < 2 {
+		e.Language = cfg.Language
+	}
+	e.matcher, e.err = NewMatcher(cfg)
+	return e
+}
+
+// Err returns the error the config was rejected with, if any
+func (e *Engine) Err() error {
+	return e.err
+}
+
+// Matcher returns the engine's matcher, for settings beyond the engine's
+// own, e.g. reading references from a git revision. It is nil when Err isn't.
+func (e *Engine) Matcher() *matcher.Matcher {
+	return e.matcher
+}
+
+// Detector creates a detector for the engine's language and settings
+func (e *Engine) Detector() *detector.Detector {
+	det := NewDetector(e.cfg, e.Language)
+	det.IncludeTests = e.IncludeTests
+	det.Branch = e.Branch
+	return det
+}
+
+// Detect lists the AI-generated files in the git repository at dir, using
+// the config's detection method
+func (e *Engine) Detect(dir string) ([]string, error) {
+	return e.Detector().DetectFiles(dir)
+}
+
+// Files expands directories in paths to the supported files within them;
+// other paths are kept as they are
+func (e *Engine) Files(paths []string) ([]string, error) {
+	return Files(e.Detector(), paths)
+}
+
+// CheckFiles matches files, and the files within directories, against the
+// patterns. Files outside TypeFilter are left out of the results, unless
+// they were skipped as too large or too slow to match.
+func (e *Engine) CheckFiles(paths []string) ([]patterns.PatternMatch, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	files, err := e.Files(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	m := e.configure()
+	matches := []patterns.PatternMatch{}
+	for i, file := range files {
+		if e.Progress != nil {
+			e.Progress(i+1, len(files))
+		}
+		var match *patterns.PatternMatch
+		if e.ReferencePath != "" {
+			match, err = m.MatchAgainstReference(file, e.ReferencePath)
+		} else {
+			match, err = m.MatchFile(file)
+		}
+		if err != nil {
+			if e.OnError == nil {
+				return nil, fmt.Errorf("failed to match %s: %w", file, err)
+			}
+			e.OnError(file, err)
+			continue
+		}
+		// Skipped files still count, whatever their type
+		if len(e.TypeFilter) > 0 && match.Pattern == nil && match.MatchType != "skipped" {
+			continue
+		}
+		matches = append(matches, *match)
+		if e.OnMatch != nil {
+			e.OnMatch(*match)
+		}
+	}
+	return matches, nil
+}
+
+// PatternCandidates groups the files among matches that no pattern matched
+// the way cr learn would, returning the groups with enough files to learn a
+// pattern from: a sign the config is missing a pattern
+func (e *Engine) PatternCandidates(matches []patterns.PatternMatch) []patterns.PatternCandidate {
+	unmatched := []string{}
+	for _, match := range matches {
+		if match.Pattern == nil {
+			unmatched = append(unmatched, match.FilePath)
+		}
+	}
+	if len(unmatched) == 0 {
+		return nil
+	}
+
+	languages := e.cfg.Languages
+	if e.Language != "" || len(languages) == 0 {
+		languages = []string{e.Language}
+	}
+	candidates := []patterns.PatternCandidate{}
+	for _, lang := range languages {
+		a := analyzer.New(lang)
+		a.MinExamples = e.cfg.Settings.MinExamples
+		a.MaxFileBytes = e.cfg.Settings.MaxFileBytes
+		candidates = append(candidates, a.PatternCandidates(unmatched)...)
+	}
+	return candidates
+}
+
+// CheckSource matches src as the contents of path, e.g. an unsaved editor
+// buffer or a file from a pull request that isn't checked out
+func (e *Engine) CheckSource(path string, src []byte) (*patterns.PatternMatch, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.configure().MatchSource(path, src)
+}
+
+// Feedback renders matches as the structured feedback cr feedback writes for
+// AI assistants, with rep's settings and the config's thresholds
+func (e *Engine) Feedback(rep *reporter.Reporter, matches []patterns.PatternMatch) string {
+	rep.SystemicThreshold = e.cfg.Settings.SystemicThreshold
+	rep.ReviewLinesPerMinute = e.cfg.Settings.ReviewLinesPerMinute
+	return rep.FormatAIFeedback(matches, e.Language, e.cfg.Patterns)
+}
+
+// Fix matches files, and the files within directories, and applies the
+// mechanical fixes for their deviations, returning a result per file.
+// Nothing is written: pass the changed results to fixer.Write.
+func (e *Engine) Fix(paths []string) ([]*fixer.Result, error) {
+	matches, err := e.CheckFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	f := fixer.New()
+	results := []*fixer.Result{}
+	for _, match := range matches {
+		result, err := f.Fix(match)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Migrate finds the files, and the files within directories, resembling an
+// anti-pattern, returning the closest anti-pattern of each: enough to plan
+// its migration. Failures are handled like CheckFiles handles them.
+func (e *Engine) Migrate(paths []string) ([]patterns.AntiPatternMatch, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	files, err := e.Files(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	m := e.configure()
+	hits := []patterns.AntiPatternMatch{}
+	for i, file := range files {
+		if e.Progress != nil {
+			e.Progress(i+1, len(files))
+		}
+		fileHits, err := m.MatchAntiPatterns(file)
+		if err != nil {
+			if e.OnError == nil {
+				return nil, fmt.Errorf("failed to match %s: %w", file, err)
+			}
+			e.OnError(file, err)
+			continue
+		}
+		if len(fileHits) > 0 {
+			hits = append(hits, fileHits[0])
+		}
+	}
+	return hits, nil
+}
+
+// configure applies the engine's settings to its matcher
+func (e *Engine) configure() *matcher.Matcher {
+	e.matcher.TypeFilter = e.TypeFilter
+	e.matcher.StrictVersion = e.StrictVersion
+	e.matcher.StrictImports = e.StrictImports
+	e.matcher.IgnoreDeviations = append(append([]string{}, e.cfg.Settings.IgnoreDeviations...), e.Ignore...)
+	return e.matcher
+}
+
+// Files expands directories in paths to the files det finds within them;
+// other paths are kept as they are
+func Files(det *detector.Detector, paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirFiles, err := det.FilesInDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", path, err)
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// NewDetector creates a detector for lang that routes files in overridden
+// directories to their own language
+func NewDetector(cfg *config.Config, lang string) *detector.Detector {
+	det := detector.NewWithLanguage(&cfg.Detection, lang)
+	det.LanguageOverrides = cfg.Settings.LanguageOverrides
+	det.IgnoreDirs = cfg.Settings.IgnoreDirs
+	return det
+}
+
+// NewMatcher creates a matcher configured from settings
+func NewMatcher(cfg *config.Config) (*matcher.Matcher, error) {
+	checks, err := matcher.LookupChecks(cfg.Settings.Checks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checks in config: %w", err)
+	}
+	if cfg.Settings.SecretEntropy < 0 {
+		return nil, fmt.Errorf("invalid secret_entropy in config: must not be negative")
+	}
+	if cfg.Settings.TodoDensity < 0 {
+		return nil, fmt.Errorf("invalid todo_density in config: must not be negative")
+	}
+	if w := cfg.Settings.Weights; w.Golden < 0 || w.Blessed < 0 || w.Discovered < 0 {
+		return nil, fmt.Errorf("invalid weights in config: must not be negative")
+	}
+	for _, rule := range cfg.Settings.IgnoreDirs {
+		if _, err := path.Match(rule, ""); err != nil {
+			return nil, fmt.Errorf("invalid ignore_dirs in config: %q: %w", rule, err)
+		}
+	}
+
+	for i, c := range checks {
+		switch c.Name() {
+		case matcher.LoggingCheckName:
+			checks[i] = matcher.NewLoggingCheck(cfg.Settings.LoggingPackages)
+		case matcher.SecretsCheckName:
+			checks[i] = matcher.NewSecretsCheck(cfg.Settings.SecretAllowlist, cfg.Settings.SecretEntropy)
+		case matcher.TodoCheckName:
+			checks[i] = matcher.NewTodoCheck(cfg.Settings.TodoDensity)
+		case matcher.ValidationCheckName:
+			checks[i] = matcher.NewValidationCheck(cfg.Settings.ValidationCalls)
+		case matcher.TransactionCheckName:
+			checks[i] = matcher.NewTransactionCheck(cfg.Settings.TransactionCalls)
+		case matcher.RouteCheckName:
+			checks[i] = matcher.NewRouteCheck(cfg.Settings.RouteFiles)
+		case matcher.CleanupCheckName:
+			checks[i] = matcher.NewCleanupCheck(cfg.Settings.CleanupCalls)
+		case matcher.InjectionCheckName:
+			checks[i] = matcher.NewInjectionCheck(cfg.Settings.ConstructionCalls)
+		case matcher.EnvCheckName:
+			checks[i] = matcher.NewEnvCheck(cfg.Settings.EnvPackages)
+		case matcher.NamingCheckName:
+			conventions := make(map[patterns.PatternType]string)
+			for patternType, convention := range cfg.Settings.NamingConventions {
+				if !matcher.IsNamingConvention(convention) {
+					return nil, fmt.Errorf("invalid naming_conventions in config: %s: unknown convention %q (available: %s)",
+						patternType, convention, strings.Join(matcher.NamingConventions, ", "))
+				}
+				conventions[patterns.PatternType(patternType)] = convention
+			}
+			checks[i] = matcher.NewNamingCheck(conventions)
+		}
+	}
+
+	m := matcher.New(cfg.AllPatterns(), cfg.Settings.AutoApproveThreshold)
+	m.Checks = checks
+	m.Scoring = Scoring(cfg.Settings.Scoring)
+	if cfg.Settings.SimilarityMethod != "" {
+		m.Scoring.SimilarityMethod = cfg.Settings.SimilarityMethod
+	}
+	if err := m.Scoring.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scoring in config: %w", err)
+	}
+
+	m.Weights = cfg.Settings.Weights.OrDefault()
+	m.StrictImports = cfg.Settings.StrictImports
+	m.IgnoreDeviations = cfg.Settings.IgnoreDeviations
+	if len(cfg.Settings.ForbiddenImports) > 0 {
+		m.ForbiddenImports = make(map[patterns.PatternType][]string)
+		for patternType, rules := range matcher.DefaultForbiddenImports {
+			m.ForbiddenImports[patternType] = rules
+		}
+		for patternType, rules := range cfg.Settings.ForbiddenImports {
+			m.ForbiddenImports[patterns.PatternType(patternType)] = rules
+		}
+	}
+	m.MaxFileBytes = cfg.Settings.MaxFileBytes
+	if cfg.Settings.FileTimeout != 0 {
+		m.FileTimeout = cfg.Settings.FileTimeout
+	}
+
+	switch cfg.Settings.MatchMode {
+	case "", matcher.MatchBest, matcher.MatchConsensus:
+		m.MatchMode = cfg.Settings.MatchMode
+	default:
+		return nil, fmt.Errorf("invalid match_mode in config: %q (valid: %s, %s)",
+			cfg.Settings.MatchMode, matcher.MatchBest, matcher.MatchConsensus)
+	}
+	return m, nil
+}
+
+// Scoring returns the default scoring with the config's overrides applied
+func Scoring(s config.ScoringSettings) matcher.Scoring {
+	scoring := matcher.DefaultScoring()
+	overrides := []struct {
+		value  *float64
+		target *float64
+	}{
+		{s.MissingImportPenalty, &scoring.MissingImportPenalty},
+		{s.MissingErrorHandlingPenalty, &scoring.MissingErrorHandlingPenalty},
+		{s.ErrorPenalty, &scoring.ErrorPenalty},
+		{s.WarningPenalty, &scoring.WarningPenalty},
+		{s.StructureWeight, &scoring.StructureWeight},
+		{s.TieEpsilon, &scoring.TieEpsilon},
+		{s.PathSpecificityBonus, &scoring.PathSpecificityBonus},
+	}
+	for _, o := range overrides {
+		if o.value != nil {
+			*o.target = *o.value
+		}
+	}
+	return scoring
+}
@@ -0,0 +1,468 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+	"github.com/loop-hub/code-on-rails/internal/reporter"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// serviceSource is a service importing imports, each used in its body
+func serviceSource(imports ...string) string {
+	uses := ""
+	for _, imp := range imports {
+		switch imp {
+		case "fmt":
+			uses += "\t_ = fmt.Sprint(name)\n"
+		case "strings":
+			uses += "\t_ = strings.ToLower(name)\n"
+		case "errors":
+			uses += "\t_ = errors.New(name)\n"
+		}
+	}
+	return `package services
+
+import (
+	"` + strings.Join(imports, "\"\n\t\"") + `"
+)
+
+func Normalize(name string) (string, error) {
+` + uses + `	return name, nil
+}
+`
+}
+
+// writeTree writes files under a temp dir, returning its path
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// serviceConfig is a Go config with one service pattern checking files
+// against root's services/ref.go
+func serviceConfig(root string) *config.Config {
+	return &config.Config{
+		Language: "go",
+		Patterns: []patterns.Pattern{{
+			ID:         "service",
+			Name:       "Service",
+			Type:       patterns.PatternService,
+			Detection:  patterns.DetectionRule{FilePattern: "*.go"},
+			Reference:  filepath.Join(root, "services", "ref.go"),
+			Confidence: 0.8,
+		}},
+	}
+}
+
+// serviceTree is a tree with a reference service and a service that uses
+// strings without importing it
+func serviceTree(t *testing.T) string {
+	return writeTree(t, map[string]string{
+		"services/ref.go": serviceSource("fmt", "strings"),
+		"services/user.go": strings.Replace(serviceSource("fmt", "strings"),
+			"\t\"strings\"\n", "", 1),
+	})
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	cfg := serviceConfig(t.TempDir())
+	cfg.Settings.Checks = []string{"no-such-check"}
+
+	e := New(cfg)
+	if e.Err() == nil {
+		t.Fatal("Err() = nil for an unknown check")
+	}
+	if _, err := e.CheckFiles([]string{"user.go"}); err == nil {
+		t.Error("CheckFiles succeeded with a rejected config")
+	}
+	if _, err := e.CheckSource("user.go", []byte("package services\n")); err == nil {
+		t.Error("CheckSource succeeded with a rejected config")
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	root := serviceTree(t)
+	e := New(serviceConfig(root))
+	seen := []string{}
+	e.OnMatch = func(match patterns.PatternMatch) {
+		seen = append(seen, filepath.Base(match.FilePath))
+	}
+
+	matches, err := e.CheckFiles([]string{filepath.Join(root, "services")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("CheckFiles matched %d files, want 2", len(matches))
+	}
+	for _, match := range matches {
+		if match.Pattern == nil || match.Pattern.ID != "service" {
+			t.Errorf("%s matched %v, want pattern service", match.FilePath, match.Pattern)
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("OnMatch saw %v, want both files", seen)
+	}
+}
+
+func TestCheckFilesTypeFilter(t *testing.T) {
+	big := serviceSource("fmt", "strings") + "\nvar table = []string{" + strings.Repeat(`"row", `, 200) + "}\n"
+	root := writeTree(t, map[string]string{
+		"services/ref.go":          serviceSource("fmt", "strings"),
+		"services/user_service.go": serviceSource("fmt", "strings"),
+		"services/big_service.go":  big,
+		"services/big_helpers.go":  big,
+		"services/helpers.go":      serviceSource("fmt"),
+	})
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].Detection.FilePattern = "*_service.go"
+	cfg.Settings.MaxFileBytes = int64(len(big) - 1)
+	e := New(cfg)
+	e.TypeFilter = []patterns.PatternType{patterns.PatternService} // cr check --pattern service
+
+	matches, err := e.CheckFiles([]string{filepath.Join(root, "services")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, match := range matches {
+		got[filepath.Base(match.FilePath)] = match.MatchType
+	}
+	// Unmatched helpers.go is left out, but skipped files of any type still
+	// count in the summary
+	if len(got) != 3 || got["big_service.go"] != "skipped" || got["big_helpers.go"] != "skipped" || got["user_service.go"] == "" {
+		t.Errorf("CheckFiles = %v, want user_service.go and both skipped files", got)
+	}
+	if _, ok := got["helpers.go"]; ok {
+		t.Errorf("helpers.go, matching no service pattern, was kept: %v", got)
+	}
+}
+
+func TestCheckFilesOnError(t *testing.T) {
+	root := serviceTree(t)
+	missing := filepath.Join(root, "services", "missing.go")
+	user := filepath.Join(root, "services", "user.go")
+
+	e := New(serviceConfig(root))
+	if _, err := e.CheckFiles([]string{missing, user}); err == nil {
+		t.Fatal("CheckFiles succeeded with a missing file and no OnError")
+	}
+
+	failed := []string{}
+	e.OnError = func(path string, err error) { failed = append(failed, path) }
+	matches, err := e.CheckFiles([]string{missing, user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0] != missing {
+		t.Errorf("OnError got %v, want %s", failed, missing)
+	}
+	if len(matches) != 1 || matches[0].FilePath != user {
+		t.Errorf("CheckFiles = %v, want only %s", matches, user)
+	}
+}
+
+func TestCheckSource(t *testing.T) {
+	root := serviceTree(t)
+	e := New(serviceConfig(root))
+
+	// The source checked, not the file on disk, decides the deviations
+	match, err := e.CheckSource(filepath.Join(root, "services", "user.go"), []byte(serviceSource("fmt", "strings")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dev := range match.Deviations {
+		if dev.Element == "import" {
+			t.Errorf("complete source deviates: %+v", dev)
+		}
+	}
+}
+
+func TestFeedback(t *testing.T) {
+	root := serviceTree(t)
+	e := New(serviceConfig(root))
+	matches, err := e.CheckFiles([]string{filepath.Join(root, "services", "user.go")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feedback struct {
+		Summary struct {
+			TotalFiles int `json:"total_files"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(e.Feedback(reporter.New(false), matches)), &feedback); err != nil {
+		t.Fatalf("Feedback isn't JSON: %v", err)
+	}
+	if feedback.Summary.TotalFiles != 1 {
+		t.Errorf("feedback covers %d files, want 1", feedback.Summary.TotalFiles)
+	}
+}
+
+func TestFix(t *testing.T) {
+	root := serviceTree(t)
+	user := filepath.Join(root, "services", "user.go")
+	e := New(serviceConfig(root))
+
+	results, err := e.Fix([]string{user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Changed() {
+		t.Fatalf("Fix = %v, want user.go changed", results)
+	}
+	if !strings.Contains(string(results[0].Fixed), `"strings"`) {
+		t.Errorf("fixed source doesn't import strings:\n%s", results[0].Fixed)
+	}
+	if src, _ := os.ReadFile(user); strings.Contains(string(src), `"strings"`) {
+		t.Error("Fix wrote the file")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":    serviceSource("fmt", "strings"),
+		"services/legacy.go": serviceSource("errors"),
+		"services/old.go":    serviceSource("errors"),
+		"services/user.go":   serviceSource("fmt", "strings"),
+	})
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].AntiPatterns = []patterns.AntiPattern{{
+		Path:    filepath.Join(root, "services", "legacy.go"),
+		Pattern: "service",
+		Reason:  "errors belong in the errs package",
+	}}
+	e := New(cfg)
+
+	hits, err := e.Migrate([]string{
+		filepath.Join(root, "services", "old.go"),
+		filepath.Join(root, "services", "user.go"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || filepath.Base(hits[0].FilePath) != "old.go" {
+		t.Fatalf("Migrate = %v, want a hit for old.go only", hits)
+	}
+	if hits[0].AntiPattern.Reason != "errors belong in the errs package" {
+		t.Errorf("hit anti-pattern = %+v", hits[0].AntiPattern)
+	}
+}
+
+func TestPatternCandidates(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":   serviceSource("fmt", "strings"),
+		"services/user.go":  serviceSource("fmt", "strings"),
+		"services/order.go": serviceSource("errors"),
+		"services/cart.go":  serviceSource("errors"),
+	})
+	e := New(serviceConfig(root))
+	e.Language = "go"
+
+	// Only files no pattern matched are grouped
+	matches := []patterns.PatternMatch{{FilePath: filepath.Join(root, "services", "user.go"), Pattern: &e.cfg.Patterns[0]}}
+	for _, name := range []string{"ref.go", "order.go", "cart.go"} {
+		matches = append(matches, patterns.PatternMatch{FilePath: filepath.Join(root, "services", name)})
+	}
+	candidates := e.PatternCandidates(matches)
+	if len(candidates) != 1 || len(candidates[0].Files) != 3 {
+		t.Fatalf("PatternCandidates = %+v, want the three unmatched services", candidates)
+	}
+	for _, file := range candidates[0].Files {
+		if filepath.Base(file) == "user.go" {
+			t.Errorf("matched %s is a candidate", file)
+		}
+	}
+
+	if got := e.PatternCandidates(matches[:1]); len(got) != 0 {
+		t.Errorf("PatternCandidates with every file matched = %+v", got)
+	}
+}
+
+func TestCleanupCalls(t *testing.T) {
+	const borrow = `package services
+
+type Pool interface {
+	Get() (Conn, error)
+}
+
+type Conn interface {
+	Release()
+}
+
+func Borrow(pool Pool) error {
+	conn, err := pool.Get()
+	if err != nil {
+		return err
+	}
+	%s
+	return nil
+}
+`
+	root := writeTree(t, map[string]string{
+		"services/ref.go": strings.Replace(borrow, "%s", "defer conn.Release()", 1),
+	})
+	cfg := serviceConfig(root)
+	cfg.Settings.Checks = []string{"deferred-close"}
+	cfg.Settings.CleanupCalls = map[string]string{"pool.Get": "Release"}
+
+	match, err := New(cfg).CheckSource(filepath.Join(root, "services", "user.go"), []byte(strings.Replace(borrow, "%s", "_ = conn", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, dev := range match.Deviations {
+		found = found || dev.Element == "deferred_close"
+	}
+	if !found {
+		t.Errorf("deviations = %+v, want deferred_close from settings.cleanup_calls", match.Deviations)
+	}
+}
+
+func TestConstructionCalls(t *testing.T) {
+	const service = `package services
+
+type UserService struct {
+	cache *Cache
+}
+
+%s
+
+func (s *UserService) Get(id string) string {
+	%s
+	return cache.Get(id)
+}
+`
+	root := writeTree(t, map[string]string{
+		"services/ref.go": fmt.Sprintf(service, "func NewUserService(cache *Cache) *UserService { return &UserService{cache: cache} }", "cache := s.cache"),
+	})
+	cfg := serviceConfig(root)
+	cfg.Settings.Checks = []string{"dependency-injection"}
+	cfg.Settings.ConstructionCalls = []string{"cachelib.New"}
+
+	src := fmt.Sprintf(service, "func NewUserService(cache *Cache) *UserService { return &UserService{cache: cache} }", "cache := cachelib.New()")
+	match, err := New(cfg).CheckSource(filepath.Join(root, "services", "user.go"), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, dev := range match.Deviations {
+		found = found || dev.Element == "dependency_injection"
+	}
+	if !found {
+		t.Errorf("deviations = %+v, want dependency_injection from settings.construction_calls", match.Deviations)
+	}
+}
+
+func TestForbiddenImports(t *testing.T) {
+	cfg := serviceConfig(t.TempDir())
+	cfg.Settings.StrictImports = true
+	cfg.Settings.ForbiddenImports = map[string][]string{"service": {"os/exec"}}
+
+	e := New(cfg)
+	if !e.StrictImports {
+		t.Error("StrictImports doesn't default to settings.strict_imports")
+	}
+	m, err := NewMatcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.ForbiddenImports[patterns.PatternService]; len(got) != 1 || got[0] != "os/exec" {
+		t.Errorf("service rules = %v, want os/exec", got)
+	}
+	// Types the config leaves out keep their defaults
+	if got := m.ForbiddenImports[patterns.PatternRepository]; len(got) != len(matcher.DefaultForbiddenImports[patterns.PatternRepository]) {
+		t.Errorf("repository rules = %v, want the defaults", got)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	root := serviceTree(t)
+	user := filepath.Join(root, "services", "user.go")
+	cfg := serviceConfig(root)
+	cfg.Settings.IgnoreDeviations = []string{"error_handling"}
+	e := New(cfg)
+
+	hasImport := func() bool {
+		match, err := e.CheckSource(user, []byte(strings.Replace(serviceSource("fmt", "strings"), "\t\"strings\"\n", "", 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dev := range match.Deviations {
+			if dev.Element == "import" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasImport() {
+		t.Fatal("missing import not reported")
+	}
+
+	// --ignore adds to settings.ignore_deviations
+	e.Ignore = []string{"import"}
+	if hasImport() {
+		t.Error("missing import reported with Ignore")
+	}
+	if got := e.Matcher().IgnoreDeviations; len(got) != 2 || got[0] != "error_handling" {
+		t.Errorf("ignored elements = %v, want error_handling and import", got)
+	}
+}
+
+func TestNamingConventions(t *testing.T) {
+	cfg := serviceConfig(t.TempDir())
+	cfg.Settings.Checks = []string{"naming-conventions"}
+	cfg.Settings.NamingConventions = map[string]string{"service": "camel_case"}
+	if _, err := NewMatcher(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Settings.NamingConventions = map[string]string{"service": "kebab_case"}
+	if _, err := NewMatcher(cfg); err == nil || !strings.Contains(err.Error(), "kebab_case") {
+		t.Errorf("NewMatcher = %v, want an unknown convention error", err)
+	}
+}
+
+func TestEnvPackages(t *testing.T) {
+	root := serviceTree(t)
+	cfg := serviceConfig(root)
+	cfg.Settings.Checks = []string{"env-access"}
+	src := []byte("package services\n\nimport \"os\"\n\nfunc Dir() string {\n\treturn os.Getenv(\"DIR\")\n}\n")
+
+	envAccess := func() bool {
+		match, err := New(cfg).CheckSource(filepath.Join(root, "services", "dir.go"), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dev := range match.Deviations {
+			if dev.Element == "env_access" {
+				return true
+			}
+		}
+		return false
+	}
+	if !envAccess() {
+		t.Fatal("env access not reported")
+	}
+	cfg.Settings.EnvPackages = []string{"services"}
+	if envAccess() {
+		t.Error("env access reported in a package settings.env_packages allows")
+	}
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// GoldenSanityScore is the score a golden example must reach against the
+// rest of its pattern's examples not to be reported as mis-annotated
+const GoldenSanityScore = 50.0
+
+// GoldenMisfit is a golden example scoring below GoldenSanityScore against
+// the other examples of the pattern it is annotated for
+type GoldenMisfit struct {
+	Path    string
+	Pattern string // Name of the pattern it is annotated for
+	Score   float64
+}
+
+func (g GoldenMisfit) String() string {
+	return fmt.Sprintf("%s scores %.0f%% against the other %s examples", g.Path, g.Score, g.Pattern)
+}
+
+// GoldenMisfits matches each golden example against the other examples of
+// the pattern it is annotated for, returning the ones that don't follow it:
+// a golden that doesn't would mislead matching. Patterns with no other
+// examples can't be validated, and goldens that fail to match are skipped.
+func GoldenMisfits(cfg *config.Config) ([]GoldenMisfit, error) {
+	m, err := NewMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	misfits := []GoldenMisfit{}
+	for _, p := range cfg.Patterns {
+		for _, golden := range p.AnnotatedGolden {
+			others := withoutExample(p, golden.Path)
+			if len(others.AnnotatedGolden)+len(others.ConfigBlessed)+len(others.Discovered) == 0 {
+				continue
+			}
+
+			m.Patterns = []patterns.Pattern{others}
+			match, err := m.MatchFile(golden.Path)
+			if err != nil || match.Score >= GoldenSanityScore {
+				continue
+			}
+			misfits = append(misfits, GoldenMisfit{Path: golden.Path, Pattern: p.Name, Score: match.Score})
+		}
+	}
+	return misfits, nil
+}
+
+// withoutExample returns a copy of p without the examples at path
+func withoutExample(p patterns.Pattern, path string) patterns.Pattern {
+	others := p
+	others.AnnotatedGolden = nil
+	for _, g := range p.AnnotatedGolden {
+		if g.Path != path {
+			others.AnnotatedGolden = append(others.AnnotatedGolden, g)
+		}
+	}
+	others.ConfigBlessed = nil
+	for _, b := range p.ConfigBlessed {
+		if b.Path != path {
+			others.ConfigBlessed = append(others.ConfigBlessed, b)
+		}
+	}
+	others.Discovered = nil
+	for _, d := range p.Discovered {
+		if d.Path != path {
+			others.Discovered = append(others.Discovered, d)
+		}
+	}
+	return others
+}
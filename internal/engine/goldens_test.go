@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const goldenService = `package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type UserService struct {
+	db *sql.DB
+}
+
+func NewUserService(db *sql.DB) *UserService {
+	return &UserService{db: db}
+}
+
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("empty id")
+	}
+	start := time.Now()
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id); err != nil {
+		return fmt.Errorf("delete user %s: %w", id, err)
+	}
+	slog.Info("deleted user", "id", id, "took", time.Since(start))
+	return nil
+}
+`
+
+func TestGoldenMisfits(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/user.go":    goldenService,
+		"services/order.go":   goldenService,
+		"services/golden.go":  goldenService,
+		"services/version.go": "package services\n\n// Version is the API version services report\nconst Version = \"v2\"\n",
+	})
+	path := func(name string) string { return filepath.Join(root, "services", name) }
+	cfg := serviceConfig(root)
+	cfg.Patterns[0].Reference = ""
+	cfg.Patterns[0].Discovered = []patterns.Example{{Path: path("user.go")}, {Path: path("order.go")}, {Path: path("golden.go")}}
+	cfg.Patterns[0].AnnotatedGolden = []patterns.GoldenExample{
+		{Path: path("golden.go"), Pattern: "service"},
+		// Annotated as a service, but has none of a service's structure
+		{Path: path("version.go"), Pattern: "service"},
+	}
+
+	misfits, err := GoldenMisfits(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(misfits) != 1 || misfits[0].Path != path("version.go") || misfits[0].Pattern != "Service" {
+		t.Fatalf("GoldenMisfits = %+v, want version.go alone", misfits)
+	}
+	if misfits[0].Score >= GoldenSanityScore {
+		t.Errorf("misfit scores %.0f, not below the sanity score", misfits[0].Score)
+	}
+
+	// A golden that is its pattern's only example can't be validated
+	cfg.Patterns[0].Discovered = nil
+	cfg.Patterns[0].AnnotatedGolden = cfg.Patterns[0].AnnotatedGolden[1:]
+	if misfits, err := GoldenMisfits(cfg); err != nil || len(misfits) != 0 {
+		t.Errorf("GoldenMisfits of a lone golden = %+v, %v; want none", misfits, err)
+	}
+}
+
+func TestGoldenMisfitsRejectsInvalidConfig(t *testing.T) {
+	cfg := serviceConfig(t.TempDir())
+	cfg.Settings.Checks = []string{"no-such-check"}
+	if _, err := GoldenMisfits(cfg); err == nil {
+		t.Error("GoldenMisfits succeeded with an unknown check")
+	}
+}
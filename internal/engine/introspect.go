@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// IntrospectionSchema versions cr introspect's JSON: it only changes
+// incompatibly, e.g. a list renamed or removed, with a new number
+const IntrospectionSchema = 1
+
+// Introspection is what cr introspect lists: the values cr understands,
+// whatever the config
+type Introspection struct {
+	Schema           int      `json:"schema_version"`
+	Version          string   `json:"version"`
+	PatternTypes     []string `json:"pattern_types"`
+	Severities       []string `json:"severities"`
+	DeviationTypes   []string `json:"deviation_types"`
+	DetectionMethods []string `json:"detection_methods"`
+	Checks           []string `json:"checks"`
+}
+
+// Introspect lists the values cr understands, for a cr of version
+func Introspect(version string) Introspection {
+	info := Introspection{
+		Schema:           IntrospectionSchema,
+		Version:          version,
+		DetectionMethods: config.DetectionMethods,
+		Checks:           matcher.CheckNames(),
+	}
+	for _, t := range patterns.PatternTypes {
+		info.PatternTypes = append(info.PatternTypes, string(t))
+	}
+	for _, s := range patterns.Severities {
+		info.Severities = append(info.Severities, string(s))
+	}
+	for _, t := range patterns.DeviationTypes {
+		info.DeviationTypes = append(info.DeviationTypes, string(t))
+	}
+	return info
+}
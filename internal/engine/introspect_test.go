@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+)
+
+// declaredConsts reads the values of the constants of typeName declared in
+// pkg/patterns, so values added there without being listed are caught
+func declaredConsts(t *testing.T, typeName string) []string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), "../../pkg/patterns", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := []string{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if id, ok := vs.Type.(*ast.Ident); !ok || id.Name != typeName {
+						continue
+					}
+					for _, v := range vs.Values {
+						if lit, ok := v.(*ast.BasicLit); ok {
+							values = append(values, lit.Value[1:len(lit.Value)-1])
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		t.Fatalf("no %s constants found", typeName)
+	}
+	return values
+}
+
+func TestIntrospect(t *testing.T) {
+	data, err := json.Marshal(Introspect("1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["schema_version"] != float64(IntrospectionSchema) || got["version"] != "1.2.3" {
+		t.Errorf("schema %v, version %v; want %d and 1.2.3", got["schema_version"], got["version"], IntrospectionSchema)
+	}
+
+	listed := func(key string) map[string]bool {
+		values, ok := got[key].([]interface{})
+		if !ok {
+			t.Fatalf("%s = %v, want a list", key, got[key])
+		}
+		set := make(map[string]bool)
+		for _, v := range values {
+			set[v.(string)] = true
+		}
+		return set
+	}
+	for key, typeName := range map[string]string{
+		"pattern_types":   "PatternType",
+		"severities":      "Severity",
+		"deviation_types": "DeviationType",
+	} {
+		set := listed(key)
+		for _, value := range declaredConsts(t, typeName) {
+			if !set[value] {
+				t.Errorf("%s lacks the declared %s %q", key, typeName, value)
+			}
+		}
+	}
+
+	methods := listed("detection_methods")
+	for _, method := range config.DetectionMethods {
+		if !methods[method] {
+			t.Errorf("detection_methods lacks %s", method)
+		}
+	}
+	checks := listed("checks")
+	for _, name := range []string{matcher.EnvCheckName, matcher.NamingCheckName, matcher.CleanupCheckName} {
+		if !checks[name] {
+			t.Errorf("checks lacks %s", name)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package fixer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Fixer applies mechanical fixes for pattern deviations
+type Fixer struct{}
+
+// Result describes the outcome of fixing a single file
+type Result struct {
+	Path      string
+	Original  []byte
+	Fixed     []byte
+	Applied   []patterns.Deviation // Deviations fixed automatically
+	Remaining []patterns.Deviation // Deviations that need a human or AI
+}
+
+// Changed reports whether any fix modified the file
+func (r *Result) Changed() bool {
+	return !bytes.Equal(r.Original, r.Fixed)
+}
+
+// New creates a new fixer
+func New() *Fixer {
+	return &Fixer{}
+}
+
+// Fix computes the fixed contents for a matched file without writing it.
+// A missing import is added the way the reference imports it, and only
+// when the file already refers to what it imports; otherwise the
+// deviation is left for a human, with the reason in its suggestion.
+func (f *Fixer) Fix(match patterns.PatternMatch) (*Result, error) {
+	src, err := os.ReadFile(match.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", match.FilePath, err)
+	}
+
+	result := &Result{
+		Path:     match.FilePath,
+		Original: src,
+		Fixed:    src,
+	}
+
+	var ref []byte
+	if path := referencePath(match); path != "" {
+		ref, _ = os.ReadFile(path) // Without it, imports are added unaliased
+	}
+
+	for _, dev := range match.Deviations {
+		if dev.Type != patterns.DeviationMissing || dev.Element != "import" || dev.Expected == "" {
+			result.Remaining = append(result.Remaining, dev)
+			continue
+		}
+
+		fixed, err := addImport(match.FilePath, result.Fixed, ref, dev.Expected)
+		if err != nil {
+			dev.Suggestion = strings.TrimSpace(fmt.Sprintf("%s (not fixed automatically: %v)", dev.Suggestion, err))
+			result.Remaining = append(result.Remaining, dev)
+			continue
+		}
+		result.Fixed = fixed
+		result.Applied = append(result.Applied, dev)
+	}
+
+	return result, nil
+}
+
+// referencePath is the path of the example a file was matched against
+func referencePath(match patterns.PatternMatch) string {
+	switch {
+	case match.GoldenRef != nil:
+		return match.GoldenRef.Path
+	case match.BlessedRef != nil:
+		return match.BlessedRef.Path
+	case match.DiscoveredRef != nil:
+		return match.DiscoveredRef.Path
+	case len(match.References) > 0:
+		return match.References[0]
+	}
+	return ""
+}
+
+// Write saves the fixed contents back to disk
+func (f *Fixer) Write(result *Result) error {
+	info, err := os.Stat(result.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(result.Path, result.Fixed, info.Mode().Perm())
+}
+
+// addImport inserts an import into a source file based on its language,
+// copying how ref, the reference's source, imports it where it can
+func addImport(path string, src, ref []byte, importPath string) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return addGoImport(path, src, ref, importPath)
+	case ".ts", ".tsx", ".js", ".jsx":
+		return addTypeScriptImport(src, ref, importPath)
+	case ".cs":
+		return addCSharpUsing(src, importPath), nil
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", path)
+	}
+}
+
+// addGoImport inserts an import spec using AST positions so that only the
+// import block is touched. The file is only gofmt'd if it already was. Go
+// rejects unused imports, so the file must already refer to the package,
+// by the reference's alias for it or its default name.
+func addGoImport(path string, src, ref []byte, importPath string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == importPath {
+			return src, nil
+		}
+	}
+
+	name, spec := goImportSpec(ref, importPath)
+	if name == "_" || name == "." {
+		return nil, fmt.Errorf("the reference imports %s as %s, which the file can't be shown to need", importPath, name)
+	}
+	for _, imp := range file.Imports {
+		if goImportName(imp) == name {
+			return nil, fmt.Errorf("%s already names the import of %s", name, strings.Trim(imp.Path.Value, `"`))
+		}
+	}
+	if !refersTo(file, name) {
+		return nil, fmt.Errorf("the file doesn't use %s yet, and Go rejects unused imports", name)
+	}
+
+	var insertAt int
+	var text string
+
+	switch {
+	case len(file.Decls) > 0 && hasGroupedImports(file.Decls[0]):
+		// Add before the closing paren of the first import block
+		insertAt = fset.Position(importRparen(file.Decls[0])).Offset
+		text = "\t" + spec + "\n"
+	case len(file.Imports) > 0:
+		// Add a new single import after the last one
+		last := file.Imports[len(file.Imports)-1]
+		insertAt = fset.Position(last.End()).Offset
+		text = "\nimport " + spec
+	default:
+		// Add the first import after the package clause
+		insertAt = fset.Position(file.Name.End()).Offset
+		text = "\n\nimport " + spec
+	}
+
+	fixed := make([]byte, 0, len(src)+len(text))
+	fixed = append(fixed, src[:insertAt]...)
+	fixed = append(fixed, text...)
+	fixed = append(fixed, src[insertAt:]...)
+
+	if _, err := parser.ParseFile(token.NewFileSet(), path, fixed, parser.ImportsOnly); err != nil {
+		return nil, fmt.Errorf("import insertion produced invalid source: %w", err)
+	}
+
+	// Only gofmt when the original was already formatted, so unrelated code
+	// never gets reformatted
+	if formatted, err := format.Source(src); err == nil && bytes.Equal(formatted, src) {
+		if out, err := format.Source(fixed); err == nil {
+			fixed = out
+		}
+	}
+
+	return fixed, nil
+}
+
+// goImportSpec is the name an import is used under and its spec as written
+// in an import block, taking the reference's alias for it if it has one
+func goImportSpec(ref []byte, importPath string) (string, string) {
+	quoted := strconv.Quote(importPath)
+	if file, err := parser.ParseFile(token.NewFileSet(), "", ref, parser.ImportsOnly); err == nil {
+		for _, imp := range file.Imports {
+			if strings.Trim(imp.Path.Value, `"`) == importPath && imp.Name != nil {
+				return imp.Name.Name, imp.Name.Name + " " + quoted
+			}
+		}
+	}
+	return matcher.DefaultImportName(importPath), quoted
+}
+
+// goImportName is the name an import in the file is used under
+func goImportName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	return matcher.DefaultImportName(strings.Trim(imp.Path.Value, `"`))
+}
+
+// refersTo checks if a file uses name as a package, as in name.Func, with
+// name not declared in the file itself
+func refersTo(file *ast.File, name string) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name && ident.Obj == nil {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// hasGroupedImports checks if a declaration is a parenthesized import block
+func hasGroupedImports(decl ast.Decl) bool {
+	gen, ok := decl.(*ast.GenDecl)
+	return ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid()
+}
+
+// importRparen returns the closing paren of an import block
+func importRparen(decl ast.Decl) token.Pos {
+	return decl.(*ast.GenDecl).Rparen
+}
+
+// tsImportStatement matches an import statement at the start of its input,
+// however many lines its clause spans: import x from 'm', import { a,
+// b } from 'm', import * as m from 'm' or the side-effect import 'm'
+var tsImportStatement = regexp.MustCompile(`^import\b\s*(type\s+)?([^'";()]*?)\s*(?:\bfrom\s*)?(['"])([^'"\n]+)['"][ \t]*;?`)
+
+// tsDirective matches a directive like 'use client' at the start of its input
+var tsDirective = regexp.MustCompile(`^(['"])use [\w ]+['"][ \t]*;?`)
+
+// tsImport is an import statement of a TypeScript/JavaScript file
+type tsImport struct {
+	typeOnly bool
+	clause   string // What it imports, e.g. React, { useState }; empty for a side-effect import
+	quote    string
+	module   string
+	semi     bool
+	end      int // Offset just past the statement
+}
+
+// parseTypeScriptImports reads the import statements heading a file, after
+// any comments and directives. It also returns the offset where the first
+// import is, or would be, so an import can be added to a file without any.
+func parseTypeScriptImports(src []byte) ([]tsImport, int) {
+	imports := []tsImport{}
+	pos, first := 0, -1
+	for {
+		pos = skipSpaceAndComments(src, pos)
+		if len(imports) == 0 {
+			if m := tsDirective.FindIndex(src[pos:]); m != nil {
+				pos += m[1]
+				continue
+			}
+			first = pos
+		}
+		m := tsImportStatement.FindSubmatchIndex(src[pos:])
+		if m == nil {
+			break
+		}
+		stmt := src[pos : pos+m[1]]
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return string(src[pos+m[2*i] : pos+m[2*i+1]])
+		}
+		imports = append(imports, tsImport{
+			typeOnly: group(1) != "",
+			clause:   group(2),
+			quote:    group(3),
+			module:   group(4),
+			semi:     bytes.HasSuffix(stmt, []byte(";")),
+			end:      pos + m[1],
+		})
+		pos += m[1]
+	}
+	return imports, first
+}
+
+// skipSpaceAndComments returns the offset of the first code at or after pos
+func skipSpaceAndComments(src []byte, pos int) int {
+	for pos < len(src) {
+		switch {
+		case bytes.ContainsRune([]byte(" \t\r\n"), rune(src[pos])):
+			pos++
+		case bytes.HasPrefix(src[pos:], []byte("//")):
+			end := bytes.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				return len(src)
+			}
+			pos += end + 1
+		case bytes.HasPrefix(src[pos:], []byte("/*")):
+			end := bytes.Index(src[pos+2:], []byte("*/"))
+			if end < 0 {
+				return len(src)
+			}
+			pos += end + 4
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// importedNames splits an import clause into the specifiers it binds, each
+// with the local name it binds: React, { a, b as c } gives React, a and
+// b as c, binding React, a and c
+func importedNames(clause string) (specs, locals []string) {
+	add := func(spec string) {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			return
+		}
+		fields := strings.Fields(spec)
+		specs = append(specs, spec)
+		locals = append(locals, fields[len(fields)-1])
+	}
+
+	outer := clause
+	if open := strings.Index(clause, "{"); open >= 0 {
+		if close := strings.Index(clause[open:], "}"); close >= 0 {
+			for _, spec := range strings.Split(clause[open+1:open+close], ",") {
+				add("{" + spec)
+			}
+			outer = clause[:open] + clause[open+close+1:]
+		}
+	}
+	for _, spec := range strings.Split(outer, ",") {
+		add(spec)
+	}
+	return specs, locals
+}
+
+// addTypeScriptImport adds the reference's import of a module after the
+// file's last import, importing only the names the file already uses.
+// Side-effect imports are never added: nothing in the file shows it needs
+// one.
+func addTypeScriptImport(src, ref []byte, importPath string) ([]byte, error) {
+	imports, first := parseTypeScriptImports(src)
+	for _, imp := range imports {
+		if imp.module == importPath {
+			return src, nil
+		}
+	}
+
+	var refImport *tsImport
+	refImports, _ := parseTypeScriptImports(ref)
+	for i := range refImports {
+		if refImports[i].module == importPath {
+			refImport = &refImports[i]
+			break
+		}
+	}
+	if refImport == nil {
+		return nil, fmt.Errorf("no reference import of %s to copy", importPath)
+	}
+
+	code := src
+	if len(imports) > 0 {
+		code = src[imports[len(imports)-1].end:]
+	}
+	specs, locals := importedNames(refImport.clause)
+	defaults, named := []string{}, []string{}
+	for i, local := range locals {
+		if !regexp.MustCompile(`(^|[^\w$.])` + regexp.QuoteMeta(local) + `($|[^\w$])`).Match(code) {
+			continue
+		}
+		if strings.HasPrefix(specs[i], "{") {
+			named = append(named, strings.TrimSpace(specs[i][1:]))
+		} else {
+			defaults = append(defaults, specs[i])
+		}
+	}
+	if len(named) > 0 {
+		defaults = append(defaults, "{ "+strings.Join(named, ", ")+" }")
+	}
+	if len(defaults) == 0 {
+		return nil, fmt.Errorf("the file doesn't use anything the reference imports from %s", importPath)
+	}
+
+	stmt := "import "
+	if refImport.typeOnly {
+		stmt += "type "
+	}
+	stmt += strings.Join(defaults, ", ") + " from " + refImport.quote + importPath + refImport.quote
+	if refImport.semi {
+		stmt += ";"
+	}
+
+	fixed := make([]byte, 0, len(src)+len(stmt)+2)
+	if len(imports) > 0 {
+		insertAt := imports[len(imports)-1].end
+		fixed = append(fixed, src[:insertAt]...)
+		fixed = append(fixed, "\n"+stmt...)
+		fixed = append(fixed, src[insertAt:]...)
+		return fixed, nil
+	}
+	fixed = append(fixed, src[:first]...)
+	fixed = append(fixed, stmt+"\n"...)
+	if first < len(src) {
+		fixed = append(fixed, '\n')
+	}
+	fixed = append(fixed, src[first:]...)
+	return fixed, nil
+}
+
+var csUsingLine = regexp.MustCompile(`(?m)^(?:global\s+)?using\s+[\w.=\s]+;.*$`)
+
+// addCSharpUsing inserts a using directive after the last existing one,
+// or at the top of the file
+func addCSharpUsing(src []byte, namespace string) []byte {
+	if regexp.MustCompile(`(?m)^\s*using\s+(?:static\s+)?` + regexp.QuoteMeta(namespace) + `\s*;`).Match(src) {
+		return src
+	}
+
+	line := []byte("using " + namespace + ";\n")
+	matches := csUsingLine.FindAllIndex(src, -1)
+	if len(matches) == 0 {
+		return append(line, src...)
+	}
+
+	insertAt := matches[len(matches)-1][1] + 1
+	if insertAt > len(src) {
+		return append(append(append([]byte{}, src...), '\n'), line...)
+	}
+	fixed := make([]byte, 0, len(src)+len(line))
+	fixed = append(fixed, src[:insertAt]...)
+	fixed = append(fixed, line...)
+	fixed = append(fixed, src[insertAt:]...)
+	return fixed
+}
+
+// Diff renders a minimal line diff between the original and fixed contents
+func Diff(result *Result) string {
+	a := strings.Split(string(result.Original), "\n")
+	b := strings.Split(string(result.Fixed), "\n")
+
+	// Longest common subsequence table over lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	path := filepath.ToSlash(filepath.Clean(result.Path))
+	sb.WriteString(fmt.Sprintf("--- a/%s\n+++ b/%s\n", path, path))
+	i, j := 0, 0
+	inHunk := false
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			inHunk = false
+			i++
+			j++
+			continue
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			if !inHunk {
+				sb.WriteString(fmt.Sprintf("@@ line %d @@\n", j+1))
+				inHunk = true
+			}
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		default:
+			if !inHunk {
+				sb.WriteString(fmt.Sprintf("@@ line %d @@\n", i+1))
+				inHunk = true
+			}
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		}
+	}
+	return sb.String()
+}
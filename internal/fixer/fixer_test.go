@@ -0,0 +1,134 @@
+package fixer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// TestFixFixtures runs each case under testdata: the missing import named
+// in import is added to input the way reference imports it, giving want.
+// Cases without want can't be fixed and must leave input untouched.
+func TestFixFixtures(t *testing.T) {
+	cases, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		dir := filepath.Join("testdata", c.Name())
+		t.Run(c.Name(), func(t *testing.T) {
+			input := fixtureFile(t, dir, "input")
+			importPath, err := os.ReadFile(filepath.Join(dir, "import"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dev := patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "import",
+				Expected:   strings.TrimSpace(string(importPath)),
+				Suggestion: "Add the import",
+			}
+			match := patterns.PatternMatch{
+				FilePath:   input,
+				GoldenRef:  &patterns.GoldenExample{Path: fixtureFile(t, dir, "reference")},
+				Deviations: []patterns.Deviation{dev},
+			}
+
+			result, err := New().Fix(match)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(strings.Replace(input, "input", "want", 1))
+			if os.IsNotExist(err) {
+				if result.Changed() || len(result.Applied) != 0 || len(result.Remaining) != 1 {
+					t.Fatalf("fixed %d, left %d, changed %v; want the deviation left unfixed:\n%s",
+						len(result.Applied), len(result.Remaining), result.Changed(), result.Fixed)
+				}
+				if !strings.Contains(result.Remaining[0].Suggestion, "not fixed automatically") {
+					t.Errorf("suggestion %q doesn't say why it wasn't fixed", result.Remaining[0].Suggestion)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Applied) != 1 || len(result.Remaining) != 0 {
+				t.Errorf("fixed %d, left %d; want 1 fixed: %+v", len(result.Applied), len(result.Remaining), result.Remaining)
+			}
+			if string(result.Fixed) != string(want) {
+				t.Errorf("fixed source:\n%s\nwant:\n%s", result.Fixed, want)
+			}
+		})
+	}
+}
+
+// fixtureFile finds the file named name, with any extension, in dir
+func fixtureFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, name+".*"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("want one %s file in %s, got %v", name, dir, matches)
+	}
+	return matches[0]
+}
+
+func TestFixAlreadyImported(t *testing.T) {
+	src := []byte("package p\n\nimport \"errors\"\n\nvar E = errors.New(\"e\")\n")
+	fixed, err := addGoImport("p.go", src, nil, "errors")
+	if err != nil || string(fixed) != string(src) {
+		t.Errorf("addGoImport = %q, %v; want the source unchanged", fixed, err)
+	}
+}
+
+func TestFixGoNameTaken(t *testing.T) {
+	src := []byte("package p\n\nimport errors \"github.com/pkg/errors\"\n\nvar E = errors.New(\"e\")\n")
+	if _, err := addGoImport("p.go", src, nil, "errors"); err == nil {
+		t.Error("added errors while the name is taken by github.com/pkg/errors")
+	}
+}
+
+func TestParseTypeScriptImports(t *testing.T) {
+	src := []byte(`// Copyright
+/* license */
+"use strict";
+import React, {
+  useState,
+  type FC,
+} from "react"
+import * as api from './api';
+import './styles.css';
+importer.run('x');
+`)
+	imports, first := parseTypeScriptImports(src)
+	if len(imports) != 3 {
+		t.Fatalf("got %d imports, want 3: %+v", len(imports), imports)
+	}
+	if imports[0].module != "react" || imports[0].semi || imports[0].quote != `"` {
+		t.Errorf("first import = %+v", imports[0])
+	}
+	if imports[1].clause != "* as api" || !imports[1].semi {
+		t.Errorf("second import = %+v", imports[1])
+	}
+	if imports[2].clause != "" || imports[2].module != "./styles.css" {
+		t.Errorf("side-effect import = %+v", imports[2])
+	}
+	if !strings.HasPrefix(string(src[first:]), "import React") {
+		t.Errorf("first import at %q", src[first:])
+	}
+	if !strings.HasPrefix(string(src[imports[2].end:]), "\nimporter") {
+		t.Errorf("imports end at %q", src[imports[2].end:])
+	}
+}
+
+func TestImportedNames(t *testing.T) {
+	specs, locals := importedNames("React, { useState, useEffect as useMount, type FC }")
+	wantSpecs := []string{"{ useState", "{ useEffect as useMount", "{ type FC", "React"}
+	wantLocals := []string{"useState", "useMount", "FC", "React"}
+	if strings.Join(specs, "|") != strings.Join(wantSpecs, "|") || strings.Join(locals, "|") != strings.Join(wantLocals, "|") {
+		t.Errorf("importedNames = %q, %q; want %q, %q", specs, locals, wantSpecs, wantLocals)
+	}
+}
@@ -0,0 +1,7 @@
+package services
+
+import "fmt"
+
+func Run() {
+	log.Info(fmt.Sprint("run"))
+}
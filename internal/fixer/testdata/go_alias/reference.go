@@ -0,0 +1,11 @@
+package services
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func Start() {
+	log.Info(fmt.Sprint("start"))
+}
@@ -0,0 +1,8 @@
+package services
+
+import "fmt"
+import log "github.com/sirupsen/logrus"
+
+func Run() {
+	log.Info(fmt.Sprint("run"))
+}
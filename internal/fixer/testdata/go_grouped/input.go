@@ -0,0 +1,13 @@
+package services
+
+import (
+	"fmt"
+)
+
+func Check(id string) error {
+	if id == "" {
+		return errors.New("empty id")
+	}
+	fmt.Println(id)
+	return nil
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+func Check(id string) error {
+	if id == "" {
+		return errors.New("empty id")
+	}
+	fmt.Println(id)
+	return nil
+}
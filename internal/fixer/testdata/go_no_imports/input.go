@@ -0,0 +1,5 @@
+package services
+
+func Now() string {
+	return time.Now().String()
+}
@@ -0,0 +1,7 @@
+package services
+
+import "time"
+
+func Today() string {
+	return time.Now().Format("2006-01-02")
+}
@@ -0,0 +1,7 @@
+package services
+
+import "time"
+
+func Now() string {
+	return time.Now().String()
+}
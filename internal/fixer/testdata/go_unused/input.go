@@ -0,0 +1,8 @@
+package services
+
+import "fmt"
+
+func Run() {
+	errors := []string{}
+	fmt.Println(errors)
+}
@@ -0,0 +1,6 @@
+'use client';
+
+export default function Page() {
+  const router = useRouter();
+  return <Link href="/">{router.pathname}</Link>;
+}
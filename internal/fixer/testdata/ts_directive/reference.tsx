@@ -0,0 +1,6 @@
+'use client';
+import Link, { useRouter as useNextRouter, type LinkProps } from "next/link"
+
+export default function Home(props: LinkProps) {
+  return <Link {...props} />;
+}
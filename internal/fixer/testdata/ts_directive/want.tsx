@@ -0,0 +1,8 @@
+'use client';
+
+import Link from "next/link"
+
+export default function Page() {
+  const router = useRouter();
+  return <Link href="/">{router.pathname}</Link>;
+}
@@ -0,0 +1,9 @@
+import {
+  Button,
+  Card,
+} from './ui';
+
+export function Counter() {
+  const [count, setCount] = useState(0);
+  return <Card><Button onClick={() => setCount(count + 1)}>{count}</Button></Card>;
+}
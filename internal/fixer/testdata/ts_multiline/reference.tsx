@@ -0,0 +1,8 @@
+import { useEffect, useState } from 'react';
+import { Card } from './ui';
+
+export function Clock() {
+  const [now, setNow] = useState(new Date());
+  useEffect(() => setNow(new Date()), []);
+  return <Card>{now.toString()}</Card>;
+}
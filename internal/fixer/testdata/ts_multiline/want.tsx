@@ -0,0 +1,10 @@
+import {
+  Button,
+  Card,
+} from './ui';
+import { useState } from 'react';
+
+export function Counter() {
+  const [count, setCount] = useState(0);
+  return <Card><Button onClick={() => setCount(count + 1)}>{count}</Button></Card>;
+}
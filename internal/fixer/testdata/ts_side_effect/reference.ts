@@ -0,0 +1,4 @@
+import './polyfills';
+import { api } from './api';
+
+export const save = () => api.post('/users');
@@ -0,0 +1,3 @@
+import { api } from './api';
+
+export const load = () => api.get('/users');
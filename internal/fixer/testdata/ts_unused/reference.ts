@@ -0,0 +1,6 @@
+import { api } from './api';
+import { z } from 'zod';
+
+const User = z.object({ name: z.string() });
+
+export const save = (user: unknown) => api.post('/users', User.parse(user));
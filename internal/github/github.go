@@ -0,0 +1,243 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommentMarker is a hidden marker identifying Code on Rails PR comments so
+// later runs update the same comment instead of adding new ones
+const CommentMarker = "<!-- code-on-rails -->"
+
+const defaultAPIURL = "https://api.github.com"
+
+// ActionsBotLogin is the author of comments posted with the GITHUB_TOKEN of
+// a GitHub Actions run, which can't look itself up through GET /user
+const ActionsBotLogin = "github-actions[bot]"
+
+// Client posts review comments to GitHub pull requests
+type Client struct {
+	Token      string
+	Repository string // owner/repo
+	BaseURL    string
+	HTTP       *http.Client
+	// Login is the account posting comments; only its marked comments are
+	// updated. Empty looks it up from the token, falling back to
+	// ActionsBotLogin for tokens that can't read their user.
+	Login string
+}
+
+// NewFromEnv creates a client from GITHUB_TOKEN, GITHUB_REPOSITORY and
+// GITHUB_API_URL
+func NewFromEnv() (*Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	if repo == "" || !strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("GITHUB_REPOSITORY must be set to owner/repo")
+	}
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+
+	return &Client{
+		Token:      token,
+		Repository: repo,
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+var pullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// PRNumberFromRef extracts the PR number from a ref like refs/pull/42/merge
+func PRNumberFromRef(ref string) (int, error) {
+	m := pullRef.FindStringSubmatch(ref)
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a pull request ref; pass --pr", ref)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// comment is the subset of a GitHub issue comment we use
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User user   `json:"user"`
+}
+
+// user is the subset of a GitHub user we use
+type user struct {
+	Login string `json:"login"`
+}
+
+// PostComment creates or updates the Code on Rails comment on a PR
+func (c *Client) PostComment(pr int, body string) error {
+	if !strings.Contains(body, CommentMarker) {
+		body = CommentMarker + "\n" + body
+	}
+
+	existing, err := c.findComment(pr)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]string{"body": body}
+	if existing != nil {
+		url := fmt.Sprintf("%s/repos/%s/issues/comments/%d", c.BaseURL, c.Repository, existing.ID)
+		return c.do(http.MethodPatch, url, payload, nil)
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.BaseURL, c.Repository, pr)
+	return c.do(http.MethodPost, url, payload, nil)
+}
+
+// login returns the account the client posts as
+func (c *Client) login() (string, error) {
+	if c.Login != "" {
+		return c.Login, nil
+	}
+	var me user
+	err := c.do(http.MethodGet, c.BaseURL+"/user", nil, &me)
+	var apiErr *apiStatusError
+	switch {
+	case err == nil && me.Login != "":
+		c.Login = me.Login
+	case errors.As(err, &apiErr) && apiErr.status == http.StatusForbidden && !apiErr.rateLimited:
+		c.Login = ActionsBotLogin // An installation token, as in Actions
+	case err != nil:
+		return "", err
+	default:
+		return "", fmt.Errorf("GitHub didn't say which user GITHUB_TOKEN belongs to")
+	}
+	return c.Login, nil
+}
+
+// findComment returns the existing marked comment on a PR posted by the
+// client's own account, if any. Marked comments by anyone else are left
+// alone: anyone can paste the marker into a comment.
+func (c *Client) findComment(pr int) (*comment, error) {
+	login, err := c.login()
+	if err != nil {
+		return nil, err
+	}
+
+	const perPage = 100
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d&page=%d",
+			c.BaseURL, c.Repository, pr, perPage, page)
+
+		var comments []comment
+		if err := c.do(http.MethodGet, url, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, CommentMarker) && strings.EqualFold(comments[i].User.Login, login) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+// do sends an API request and decodes the JSON response into out
+func (c *Client) do(method, url string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return apiError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiStatusError is a failed API response, described for the user
+type apiStatusError struct {
+	status      int
+	rateLimited bool
+	err         error
+}
+
+func (e *apiStatusError) Error() string { return e.err.Error() }
+
+// apiError turns a failed response into an actionable error
+func apiError(resp *http.Response) error {
+	return &apiStatusError{status: resp.StatusCode, rateLimited: rateLimited(resp), err: describeAPIError(resp)}
+}
+
+// rateLimited checks if a request failed for hitting a rate limit
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// describeAPIError explains a failed response
+func describeAPIError(resp *http.Response) error {
+	var msg struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&msg)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("GitHub authentication failed: check that GITHUB_TOKEN is valid")
+	case rateLimited(resp):
+		return fmt.Errorf("GitHub rate limit exceeded%s", rateLimitReset(resp))
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("GitHub denied access: GITHUB_TOKEN needs pull-requests: write permission (%s)", msg.Message)
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("GitHub repository or pull request not found (check GITHUB_REPOSITORY and --pr)")
+	default:
+		return fmt.Errorf("GitHub API returned %s: %s", resp.Status, msg.Message)
+	}
+}
+
+// rateLimitReset describes when a rate limit resets, if GitHub told us
+func rateLimitReset(resp *http.Response) string {
+	if retry := resp.Header.Get("Retry-After"); retry != "" {
+		return fmt.Sprintf("; retry after %ss", retry)
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return fmt.Sprintf("; resets at %s", time.Unix(reset, 0).Format(time.RFC3339))
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeGitHub serves a PR's comments and records the writes made to them
+type fakeGitHub struct {
+	login    string // GET /user's login; empty answers 403 like an Actions token
+	comments []comment
+	writes   []string // "POST" or "PATCH <id>"
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/user":
+		if f.login == "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(user{Login: f.login})
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/issues/7/comments":
+		json.NewEncoder(w).Encode(f.comments)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/issues/7/comments":
+		f.writes = append(f.writes, "POST")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{}`)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/o/r/issues/comments/"):
+		f.writes = append(f.writes, "PATCH "+strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"))
+		fmt.Fprint(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeGitHub) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return &Client{Token: "t", Repository: "o/r", BaseURL: srv.URL, HTTP: srv.Client()}
+}
+
+func TestPostCommentUpdatesOwnComment(t *testing.T) {
+	f := &fakeGitHub{login: "cr-bot", comments: []comment{
+		{ID: 1, Body: "looks good", User: user{Login: "cr-bot"}},
+		{ID: 2, Body: CommentMarker + "\nold report", User: user{Login: "cr-bot"}},
+	}}
+	if err := newTestClient(t, f).PostComment(7, "new report"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.writes) != 1 || f.writes[0] != "PATCH 2" {
+		t.Errorf("writes = %v, want PATCH 2", f.writes)
+	}
+}
+
+func TestPostCommentLeavesOthersMarkedComments(t *testing.T) {
+	f := &fakeGitHub{login: "cr-bot", comments: []comment{
+		{ID: 3, Body: "quoting it: " + CommentMarker, User: user{Login: "mallory"}},
+	}}
+	if err := newTestClient(t, f).PostComment(7, "report"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.writes) != 1 || f.writes[0] != "POST" {
+		t.Errorf("writes = %v, want a new comment", f.writes)
+	}
+}
+
+func TestPostCommentActionsToken(t *testing.T) {
+	f := &fakeGitHub{comments: []comment{
+		{ID: 4, Body: CommentMarker, User: user{Login: "someone"}},
+		{ID: 5, Body: CommentMarker, User: user{Login: ActionsBotLogin}},
+	}}
+	if err := newTestClient(t, f).PostComment(7, "report"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.writes) != 1 || f.writes[0] != "PATCH 5" {
+		t.Errorf("writes = %v, want PATCH 5", f.writes)
+	}
+}
+
+func TestPostCommentConfiguredLogin(t *testing.T) {
+	f := &fakeGitHub{comments: []comment{
+		{ID: 6, Body: CommentMarker, User: user{Login: "Team-Bot"}},
+	}}
+	c := newTestClient(t, f)
+	c.Login = "team-bot"
+	if err := c.PostComment(7, "report"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.writes) != 1 || f.writes[0] != "PATCH 6" {
+		t.Errorf("writes = %v, want PATCH 6", f.writes)
+	}
+}
+
+func TestPRNumberFromRef(t *testing.T) {
+	if pr, err := PRNumberFromRef("refs/pull/42/merge"); err != nil || pr != 42 {
+		t.Errorf("PRNumberFromRef = %d, %v; want 42", pr, err)
+	}
+	if _, err := PRNumberFromRef("refs/heads/main"); err == nil {
+		t.Error("PRNumberFromRef accepted a branch ref")
+	}
+}
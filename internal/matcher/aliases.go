@@ -0,0 +1,97 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// ImportAliasCheckName is the config name of the import alias check
+const ImportAliasCheckName = "import-aliases"
+
+var (
+	// majorVersion matches a module major version path element, e.g. v2
+	majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+	// gopkgVersion matches a gopkg.in version suffix, e.g. .v3 in yaml.v3
+	gopkgVersion = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// importAliasCheck flags packages both files import under different names,
+// e.g. the reference imports github.com/gorilla/mux as router and the
+// candidate doesn't rename it, so the same calls read differently. Dot
+// imports count as a name of their own; blank imports are ignored.
+type importAliasCheck struct{}
+
+func (importAliasCheck) Name() string { return ImportAliasCheckName }
+
+func (importAliasCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refNames := make(map[string]*ast.ImportSpec)
+	for _, imp := range ref.Imports {
+		if imp.Name == nil || imp.Name.Name != "_" {
+			refNames[importPath(imp)] = imp
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, imp := range file.Imports {
+		path := importPath(imp)
+		refImp, ok := refNames[path]
+		if !ok || (imp.Name != nil && imp.Name.Name == "_") {
+			continue
+		}
+		name, refName := importName(imp), importName(refImp)
+		if name == refName {
+			continue
+		}
+		suggestion := fmt.Sprintf("Import %s as %s, like the reference, so call sites read the same", path, refName)
+		if refImp.Name == nil {
+			suggestion = fmt.Sprintf("Drop the alias on %s, like the reference, so call sites use %s", path, refName)
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "import_alias",
+			Expected:   importSpec(refImp),
+			Actual:     importSpec(imp),
+			Severity:   patterns.SeverityInfo,
+			Suggestion: suggestion,
+			LineNumber: LineOf(file, imp.Pos()),
+		})
+	}
+	return deviations
+}
+
+// importName is the name an import is used under: its alias, "." for a dot
+// import, or otherwise the package name guessed from its path
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	return DefaultImportName(importPath(imp))
+}
+
+// DefaultImportName guesses the name a package is used under when imported
+// without an alias, from the conventions package paths follow: the last
+// element, skipping a major version like /v2 and dropping gopkg.in's .v3
+// and a go- prefix or -go suffix
+func DefaultImportName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if majorVersion.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	name = gopkgVersion.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "-go"), ".go")
+	return name
+}
+
+// importSpec formats an import as written in an import block
+func importSpec(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name + " " + imp.Path.Value
+	}
+	return imp.Path.Value
+}
@@ -0,0 +1,80 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestImportAliases(t *testing.T) {
+	ref := parseFixture(t, "aliases/reference.go")
+	check := importAliasCheck{}
+
+	// Leaving out the blank import isn't an aliasing difference
+	got := check.Evaluate(parseFixture(t, "aliases/consistent.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "aliases/candidate.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{
+		{"import_alias", 4}, // net/http renamed
+		{"import_alias", 6}, // respond not dot imported
+		{"import_alias", 7}, // mux not renamed to router
+	})
+	if got[0].Severity != patterns.SeverityInfo || got[0].Expected != `"net/http"` || got[0].Actual != `stdhttp "net/http"` {
+		t.Errorf("net/http deviation = %+v", got[0])
+	}
+	if got[1].Expected != `. "github.com/acme/shop/internal/respond"` {
+		t.Errorf("dot import deviation = %+v", got[1])
+	}
+	if got[2].Expected != `router "github.com/gorilla/mux"` || got[2].Suggestion != "Import github.com/gorilla/mux as router, like the reference, so call sites read the same" {
+		t.Errorf("mux deviation = %+v", got[2])
+	}
+}
+
+func TestDefaultImportName(t *testing.T) {
+	tests := map[string]string{
+		"net/http":                      "http",
+		"github.com/gorilla/mux":        "mux",
+		"github.com/jackc/pgx/v5":       "pgx",
+		"gopkg.in/yaml.v3":              "yaml",
+		"github.com/mattn/go-sqlite3":   "sqlite3",
+		"github.com/opentracing/ot-go":  "ot",
+		"github.com/nats-io/nats.go":    "nats",
+		"github.com/acme/shop/internal": "internal",
+	}
+	for path, want := range tests {
+		if got := DefaultImportName(path); got != want {
+			t.Errorf("DefaultImportName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBlankImportsNotRequired(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"handlers/ref.go":  string(readFixture(t, "aliases/reference.go")),
+		"handlers/user.go": string(readFixture(t, "aliases/consistent.go")),
+	})
+	m := New([]patterns.Pattern{declared("handler", patterns.PatternHTTPHandler, filepath.Join(root, "handlers", "ref.go"))}, 0)
+
+	match, err := m.MatchFile(filepath.Join(root, "handlers", "user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingImportsOf(match); len(missing) != 0 {
+		t.Errorf("missing imports %v, want none: the blank driver import isn't needed in every file", missing)
+	}
+
+	// The dot import still counts as using the package
+	file := parseFixture(t, "aliases/reference.go")
+	imports := extractImports(file)
+	want := []string{"net/http", "github.com/acme/shop/internal/respond", "github.com/gorilla/mux"}
+	if len(imports) != len(want) {
+		t.Fatalf("extractImports = %v, want %v", imports, want)
+	}
+	for i := range want {
+		if imports[i] != want[i] {
+			t.Errorf("extractImports = %v, want %v", imports, want)
+		}
+	}
+}
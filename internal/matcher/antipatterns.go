@@ -0,0 +1,125 @@
+package matcher
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// AntiPatternThreshold is the similarity at which a file is considered to
+// follow an anti-pattern
+const AntiPatternThreshold = 0.8
+
+// MatchAntiPatterns finds the anti-patterns a file resembles, most similar first
+func (m *Matcher) MatchAntiPatterns(filePath string) ([]patterns.AntiPatternMatch, error) {
+	c, err := loadCandidate(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	return m.antiPatternHits(c), nil
+}
+
+// antiPatternHits finds the anti-patterns a loaded candidate resembles
+func (m *Matcher) antiPatternHits(c *candidate) []patterns.AntiPatternMatch {
+	hits := []patterns.AntiPatternMatch{}
+	for i := range m.Patterns {
+		pattern := &m.Patterns[i]
+		if len(pattern.AntiPatterns) == 0 || !m.shouldTryPattern(c.path, *pattern) {
+			continue
+		}
+
+		for _, anti := range pattern.AntiPatterns {
+			similarity := 1.0
+			if filepath.Clean(anti.Path) != filepath.Clean(c.path) {
+				var err error
+				similarity, err = m.similarity(c, anti.Path)
+				if err != nil || similarity < AntiPatternThreshold {
+					continue
+				}
+			}
+
+			hits = append(hits, patterns.AntiPatternMatch{
+				FilePath:    c.path,
+				Pattern:     pattern,
+				AntiPattern: anti,
+				Similarity:  similarity,
+				Replacement: replacementFor(*pattern),
+			})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Similarity > hits[j].Similarity
+	})
+	return hits
+}
+
+// similarity combines structural similarity with import overlap so files
+// only resemble a reference when they also depend on the same packages
+func (m *Matcher) similarity(c *candidate, referencePath string) (float64, error) {
+	var structure float64
+	var refImports []string
+	if c.file == nil {
+		ref, err := m.parseTextReference(referencePath)
+		if err != nil {
+			return 0, err
+		}
+		structure = m.Scoring.nodeSimilarity(declarationCounts(c.info), declarationCounts(ref))
+		refImports = ref.Imports
+	} else {
+		src, err := m.readReference(referencePath)
+		if err != nil {
+			return 0, err
+		}
+		ref, err := parser.ParseFile(token.NewFileSet(), referencePath, src, parser.ImportsOnly)
+		if err != nil {
+			return 0, err
+		}
+		structure = m.compareStructure(c.path, c.src, referencePath)
+		refImports = extractImports(ref)
+	}
+	return structure * jaccard(c.imports, refImports), nil
+}
+
+// replacementFor picks the example a pattern's anti-patterns should migrate
+// towards: the newest golden, otherwise the first blessed example
+func replacementFor(pattern patterns.Pattern) string {
+	superseded := supersededVersions(pattern.AnnotatedGolden)
+	for _, golden := range pattern.AnnotatedGolden {
+		if golden.Version == "" || !superseded[golden.Version] {
+			return golden.Path
+		}
+	}
+	if len(pattern.ConfigBlessed) > 0 {
+		return pattern.ConfigBlessed[0].Path
+	}
+	return ""
+}
+
+// jaccard computes the overlap between two sets of strings
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	inA := make(map[string]bool)
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool)
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	shared := 0
+	for s := range inB {
+		if inA[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(inA)+len(inB)-shared)
+}
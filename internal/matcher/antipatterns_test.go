@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestAntiPatternBlocksAutoApproval(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":    scoringReference,
+		"services/legacy.go": scoringReference,
+		"services/user.go":   scoringReference,
+	})
+	file := filepath.Join(root, "services/user.go")
+	pattern := declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))
+
+	// A perfect match is approved until it resembles an anti-pattern
+	match, err := New([]patterns.Pattern{pattern}, 80).MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match.AutoApprove || len(match.AntiPatternHits) != 0 {
+		t.Fatalf("match = %+v, want approved with no anti-pattern hits", match)
+	}
+
+	legacy := filepath.Join(root, "services/legacy.go")
+	pattern.AntiPatterns = []patterns.AntiPattern{{Path: legacy, Pattern: "service", Reason: "globals"}}
+	match, err = New([]patterns.Pattern{pattern}, 80).MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.AutoApprove {
+		t.Errorf("file resembling %s was auto-approved at %g", legacy, match.Score)
+	}
+	if match.Score < 80 {
+		t.Errorf("score = %g, want the hit to block approval without lowering the score", match.Score)
+	}
+	if len(match.AntiPatternHits) != 1 || match.AntiPatternHits[0].AntiPattern.Path != legacy || match.AntiPatternHits[0].Similarity < AntiPatternThreshold {
+		t.Errorf("anti-pattern hits = %+v, want %s", match.AntiPatternHits, legacy)
+	}
+	if match.Breakdown == nil || match.Breakdown.AntiPatternHits != 1 {
+		t.Errorf("breakdown = %+v, want one anti-pattern hit", match.Breakdown)
+	}
+}
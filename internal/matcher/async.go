@@ -0,0 +1,114 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// AsyncCheckName is the config name of the async/await consistency check
+const AsyncCheckName = "async-await"
+
+var (
+	// awaitedCall matches an awaited call, capturing the callee, e.g.
+	// api.get in await api.get(url)
+	awaitedCall = regexp.MustCompile(`\bawait\s+([\w$.]+)\s*\(`)
+	// thenCall matches a promise chained with .then
+	thenCall = regexp.MustCompile(`\.then\s*\(`)
+	// calleeCall matches a call, capturing the callee and what precedes it
+	calleeCall = regexp.MustCompile(`(^|[^\w$.])([\w$.]+)\s*\(`)
+)
+
+// asyncCheck flags TypeScript services and API clients that drop async/await
+// where their reference uses it throughout: .then chains, and calls the
+// reference awaits left without await
+type asyncCheck struct{}
+
+func (asyncCheck) Name() string { return AsyncCheckName }
+
+func (asyncCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	return nil // Go has no promises; see EvaluateSource
+}
+
+// EvaluateSource runs the check on TypeScript/JavaScript files
+func (asyncCheck) EvaluateSource(src, refSrc []byte, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternService && pattern.Type != patterns.PatternAPI {
+		return nil
+	}
+	refText := string(refSrc)
+	awaited := awaitedCallees(refText)
+	if len(awaited) == 0 || thenCall.MatchString(refText) {
+		return nil // The reference doesn't consistently await
+	}
+
+	text := string(src)
+	deviations := []patterns.Deviation{}
+	if lines := typedLines(text, thenCall); len(lines) > 0 {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "async_style",
+			Expected:   "async/await",
+			Actual:     ".then chain on " + joinLines(lines),
+			Severity:   patterns.SeverityWarning,
+			Suggestion: "Use async/await instead of .then chains, like the reference",
+			LineNumber: lines[0],
+		})
+	}
+	for _, call := range unawaitedCalls(text, awaited) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "await",
+			Expected:   "await " + call.callee + "(...)",
+			Actual:     call.callee + " called without await",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Await %s, which the reference awaits; otherwise its result is a pending promise and its errors go unhandled", call.callee),
+			LineNumber: call.line,
+		})
+	}
+	return deviations
+}
+
+// awaitedCallees lists the callees a source awaits
+func awaitedCallees(src string) map[string]bool {
+	callees := make(map[string]bool)
+	for _, m := range awaitedCall.FindAllStringSubmatch(src, -1) {
+		callees[m[1]] = true
+	}
+	return callees
+}
+
+// unawaitedCall is a call to an awaited callee made without await
+type unawaitedCall struct {
+	callee string
+	line   int
+}
+
+// unawaitedCalls finds calls to the awaited callees that are neither
+// awaited nor returned, skipping declarations, comments and lines that
+// gather promises with Promise.all and the like or chain them with .then
+func unawaitedCalls(src string, awaited map[string]bool) []unawaitedCall {
+	calls := []unawaitedCall{}
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "/*") ||
+			strings.Contains(line, "Promise.") || thenCall.MatchString(line) {
+			continue
+		}
+		for _, m := range calleeCall.FindAllStringSubmatchIndex(line, -1) {
+			callee := line[m[4]:m[5]]
+			if !awaited[callee] {
+				continue
+			}
+			before := strings.TrimRight(line[:m[4]], " \t")
+			if strings.HasSuffix(before, "await") || strings.HasSuffix(before, "return") ||
+				strings.HasSuffix(before, "=>") || strings.HasSuffix(before, "function") || strings.HasSuffix(before, "async") {
+				continue
+			}
+			calls = append(calls, unawaitedCall{callee: callee, line: i + 1})
+		}
+	}
+	return calls
+}
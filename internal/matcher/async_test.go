@@ -0,0 +1,56 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestAsyncAwait(t *testing.T) {
+	ref := readFixture(t, "async/reference.ts")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := asyncCheck{}
+
+	got := check.EvaluateSource(readFixture(t, "async/awaited.ts"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// The .then line isn't also reported as a missing await
+	got = check.EvaluateSource(readFixture(t, "async/unawaited.ts"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"async_style", 5},
+		{"await", 10}, // api.post
+		{"await", 11}, // api.get
+	})
+	if got[0].Actual != ".then chain on line 5" || got[0].Severity != patterns.SeverityWarning {
+		t.Errorf("then deviation = %+v, want a warning for line 5", got[0])
+	}
+	if got[1].Expected != "await api.post(...)" || got[1].Type != patterns.DeviationMissing {
+		t.Errorf("await deviation = %+v, want a missing await on api.post", got[1])
+	}
+}
+
+func TestAsyncAwaitFollowsReference(t *testing.T) {
+	file := readFixture(t, "async/unawaited.ts")
+	check := asyncCheck{}
+
+	// A reference mixing .then with await sets no expectation
+	got := check.EvaluateSource(file, file, patterns.Pattern{Type: patterns.PatternAPI})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Only services and API clients are checked
+	got = check.EvaluateSource(file, readFixture(t, "async/reference.ts"), patterns.Pattern{Type: patterns.PatternHook})
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestAsyncAwaitOptIn(t *testing.T) {
+	if m := New(nil, 0); len(m.Checks) != 0 {
+		t.Errorf("matcher runs checks %v without any configured", m.Checks)
+	}
+	checks, err := LookupChecks([]string{AsyncCheckName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := checks[0].(SourceCheck); !ok {
+		t.Errorf("%s doesn't run on TypeScript", AsyncCheckName)
+	}
+}
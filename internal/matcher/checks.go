@@ -0,0 +1,486 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Check is a custom structural rule evaluated against a candidate file and
+// the reference it is being compared with
+type Check interface {
+	Name() string
+	Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation
+}
+
+// SourceCheck is a check that also runs on TypeScript/JavaScript and C#
+// files, which are compared as text rather than Go syntax trees
+type SourceCheck interface {
+	Check
+	EvaluateSource(src, refSrc []byte, pattern patterns.Pattern) []patterns.Deviation
+}
+
+var (
+	checkRegistry = make(map[string]Check)
+	fileSets      sync.Map // *ast.File -> *token.FileSet
+)
+
+func init() {
+	RegisterCheck(contextFirstArgCheck{})
+	RegisterCheck(exportedDocCommentCheck{})
+	RegisterCheck(contextPropagationCheck{})
+	RegisterCheck(errorWrappingCheck{})
+	RegisterCheck(middlewareOrderCheck{})
+	RegisterCheck(NewLoggingCheck(nil))
+	RegisterCheck(NewSecretsCheck(nil, 0))
+	RegisterCheck(statusCodeCheck{})
+	RegisterCheck(NewTodoCheck(0))
+	RegisterCheck(NewValidationCheck(nil))
+	RegisterCheck(NewTransactionCheck(nil))
+	RegisterCheck(NewRouteCheck(nil))
+	RegisterCheck(asyncCheck{})
+	RegisterCheck(importAliasCheck{})
+	RegisterCheck(NewCleanupCheck(nil))
+	RegisterCheck(NewInjectionCheck(nil))
+	RegisterCheck(NewNamingCheck(nil))
+	RegisterCheck(NewEnvCheck(nil))
+}
+
+// RegisterCheck makes a check available to be enabled by name in config
+func RegisterCheck(c Check) {
+	checkRegistry[c.Name()] = c
+}
+
+// LookupChecks resolves check names into registered checks
+func LookupChecks(names []string) ([]Check, error) {
+	checks := make([]Check, 0, len(names))
+	for _, name := range names {
+		c, ok := checkRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q (available: %s)", name, strings.Join(CheckNames(), ", "))
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// CheckNames lists all registered check names
+func CheckNames() []string {
+	names := make([]string, 0, len(checkRegistry))
+	for name := range checkRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LineOf returns the line number of pos within a file parsed by the matcher
+func LineOf(file *ast.File, pos token.Pos) int {
+	if fset, ok := fileSets.Load(file); ok {
+		return fset.(*token.FileSet).Position(pos).Line
+	}
+	return 0
+}
+
+// trackFileSet remembers the file set a file was parsed with for LineOf
+func trackFileSet(file *ast.File, fset *token.FileSet) {
+	fileSets.Store(file, fset)
+}
+
+// contextFirstArgCheck flags exported methods that don't take a
+// context.Context first when the reference's methods consistently do
+type contextFirstArgCheck struct{}
+
+func (contextFirstArgCheck) Name() string { return "context-first-arg" }
+
+func (contextFirstArgCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refMethods := exportedMethods(ref)
+	if len(refMethods) == 0 {
+		return nil
+	}
+	for _, fn := range refMethods {
+		if !hasContextFirstArg(fn) {
+			return nil
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range exportedMethods(file) {
+		if hasContextFirstArg(fn) {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "context_arg",
+			Expected:   "ctx context.Context",
+			Actual:     fn.Name.Name,
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Accept ctx context.Context as the first parameter of %s", fn.Name.Name),
+			LineNumber: LineOf(file, fn.Pos()),
+		})
+	}
+	return deviations
+}
+
+// contextPropagationCheck flags service and repository methods missing a
+// context.Context parameter where the reference's corresponding method has
+// one, taking it in another position than the reference, or passing a fresh
+// context.Background() or context.TODO() to callees instead of their own
+type contextPropagationCheck struct{}
+
+func (contextPropagationCheck) Name() string { return "context-propagation" }
+
+func (contextPropagationCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternService && pattern.Type != patterns.PatternRepository {
+		return nil
+	}
+
+	refMethods := exportedMethods(ref)
+	if len(refMethods) == 0 {
+		return nil
+	}
+	refContext := make(map[string]int)
+	allHaveContext := true
+	allFirst := true
+	propagates := true
+	for _, fn := range refMethods {
+		name, index := contextParam(fn)
+		refContext[fn.Name.Name] = index
+		allHaveContext = allHaveContext && index >= 0
+		allFirst = allFirst && index == 0
+		if index >= 0 && len(freshContexts(fn, name)) > 0 {
+			propagates = false
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range exportedMethods(file) {
+		// Compare against the same-named reference method, falling back to
+		// the reference's overall convention for methods it doesn't have.
+		// Only the sign matters: none, first or elsewhere.
+		expected, ok := refContext[fn.Name.Name]
+		if !ok {
+			expected = -1
+			if allFirst {
+				expected = 0
+			} else if allHaveContext {
+				expected = 1
+			}
+		}
+
+		name, index := contextParam(fn)
+		signature := fmt.Sprintf("%s(%s)", fn.Name.Name, strings.Join(analyzer.FieldTypes(fn.Type.Params), ", "))
+		switch {
+		case index < 0 && expected >= 0:
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "context_arg",
+				Expected:   "ctx context.Context",
+				Actual:     signature,
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Accept ctx context.Context first in %s and pass it to downstream calls", fn.Name.Name),
+				LineNumber: LineOf(file, fn.Pos()),
+			})
+			continue
+		case index > 0 && expected == 0:
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationDifferent,
+				Element:    "context_arg",
+				Expected:   "ctx context.Context as first parameter",
+				Actual:     signature,
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Move the context.Context parameter of %s first", fn.Name.Name),
+				LineNumber: LineOf(file, fn.Pos()),
+			})
+		}
+
+		if !propagates || index < 0 {
+			continue
+		}
+		for _, call := range freshContexts(fn, name) {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationDifferent,
+				Element:    "context_propagation",
+				Expected:   name,
+				Actual:     types.ExprString(call),
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Pass %s's %s on instead of %s so cancellation and deadlines reach the callee", fn.Name.Name, name, types.ExprString(call)),
+				LineNumber: LineOf(file, call.Pos()),
+			})
+		}
+	}
+	return deviations
+}
+
+// exportedDocCommentCheck flags undocumented exported functions and types
+// when the reference documents all of its exported symbols
+type exportedDocCommentCheck struct{}
+
+func (exportedDocCommentCheck) Name() string { return "exported-doc-comment" }
+
+func (exportedDocCommentCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refSymbols := exportedSymbols(ref)
+	if len(refSymbols) == 0 {
+		return nil
+	}
+	for _, sym := range refSymbols {
+		if !sym.documented {
+			return nil
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, sym := range exportedSymbols(file) {
+		if sym.documented {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "doc_comment",
+			Expected:   "// " + sym.name[strings.LastIndex(sym.name, ".")+1:] + " ...",
+			Actual:     sym.name,
+			Severity:   patterns.SeverityInfo,
+			Suggestion: fmt.Sprintf("Add a doc comment to exported %s %s", sym.kind, sym.name),
+			LineNumber: LineOf(file, sym.pos),
+		})
+	}
+	return deviations
+}
+
+// errorWrappingCheck flags bare error returns and %v-formatted errors when
+// the reference consistently wraps errors with fmt.Errorf("...: %w", err)
+type errorWrappingCheck struct{}
+
+func (errorWrappingCheck) Name() string { return "error-wrapping" }
+
+func (errorWrappingCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refStyle := inspectErrorWrapping(ref)
+	if refStyle.wrapped == 0 || len(refStyle.bareReturns) > 0 || len(refStyle.unwrapped) > 0 {
+		return nil
+	}
+
+	style := inspectErrorWrapping(file)
+	deviations := []patterns.Deviation{}
+	for _, pos := range style.bareReturns {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "error_wrapping",
+			Expected:   `fmt.Errorf("...: %w", err)`,
+			Actual:     "return err",
+			Severity:   patterns.SeverityInfo,
+			Suggestion: "Wrap the error with context using fmt.Errorf and %w",
+			LineNumber: LineOf(file, pos),
+		})
+	}
+	for _, pos := range style.unwrapped {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "error_wrapping",
+			Expected:   "%w",
+			Actual:     "%v",
+			Severity:   patterns.SeverityInfo,
+			Suggestion: "Use %w instead of %v so callers can unwrap the error",
+			LineNumber: LineOf(file, pos),
+		})
+	}
+	return deviations
+}
+
+// errorWrappingStyle summarizes how a file returns errors
+type errorWrappingStyle struct {
+	wrapped     int         // fmt.Errorf calls using %w
+	unwrapped   []token.Pos // fmt.Errorf calls formatting err without %w
+	bareReturns []token.Pos // return statements passing err through unchanged
+}
+
+// inspectErrorWrapping walks a file collecting error return styles
+func inspectErrorWrapping(file *ast.File) errorWrappingStyle {
+	style := errorWrappingStyle{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.ReturnStmt:
+			if len(node.Results) == 0 {
+				return true
+			}
+			if ident, ok := node.Results[len(node.Results)-1].(*ast.Ident); ok && ident.Name == "err" {
+				style.bareReturns = append(style.bareReturns, node.Pos())
+			}
+		case *ast.CallExpr:
+			if !isSelectorCall(node, "fmt", "Errorf") || len(node.Args) < 2 {
+				return true
+			}
+			lit, ok := node.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			if strings.Contains(lit.Value, "%w") {
+				style.wrapped++
+				return true
+			}
+			for _, arg := range node.Args[1:] {
+				if ident, ok := arg.(*ast.Ident); ok && ident.Name == "err" {
+					style.unwrapped = append(style.unwrapped, node.Pos())
+					break
+				}
+			}
+		}
+		return true
+	})
+	return style
+}
+
+// isSelectorCall checks if a call is pkg.name(...)
+func isSelectorCall(call *ast.CallExpr, pkg, name string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == pkg
+}
+
+// exportedSymbol is an exported top-level declaration
+type exportedSymbol struct {
+	name       string // Methods are qualified by receiver, e.g. UserService.Create
+	kind       string // function, method, type, const or var
+	pos        token.Pos
+	documented bool
+}
+
+// exportedSymbols lists a file's exported API with its doc status. Methods
+// on unexported types aren't part of the API and are skipped; a doc comment
+// on a parenthesized const or var block documents every name in it.
+func exportedSymbols(file *ast.File) []exportedSymbol {
+	symbols := []exportedSymbol{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			sym := exportedSymbol{name: d.Name.Name, kind: "function", pos: d.Pos(), documented: d.Doc != nil}
+			if recv := receiverType(d); recv != "" {
+				if !ast.IsExported(recv) {
+					continue
+				}
+				sym.name, sym.kind = recv+"."+d.Name.Name, "method"
+			}
+			symbols = append(symbols, sym)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if !sp.Name.IsExported() {
+						continue
+					}
+					documented := sp.Doc != nil || (d.Doc != nil && len(d.Specs) == 1)
+					symbols = append(symbols, exportedSymbol{name: sp.Name.Name, kind: "type", pos: sp.Pos(), documented: documented})
+				case *ast.ValueSpec:
+					documented := sp.Doc != nil || d.Doc != nil
+					for _, name := range sp.Names {
+						if name.IsExported() {
+							symbols = append(symbols, exportedSymbol{name: name.Name, kind: d.Tok.String(), pos: name.Pos(), documented: documented})
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverType returns the base type name of a method's receiver, or "" for
+// a plain function
+func receiverType(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// exportedMethods lists exported methods declared in a file
+func exportedMethods(file *ast.File) []*ast.FuncDecl {
+	methods := []*ast.FuncDecl{}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Name.IsExported() {
+			methods = append(methods, fn)
+		}
+	}
+	return methods
+}
+
+// hasContextFirstArg checks if a function's first parameter is a context.Context
+func hasContextFirstArg(fn *ast.FuncDecl) bool {
+	params := fn.Type.Params
+	if params == nil || len(params.List) == 0 {
+		return false
+	}
+	return isContextType(params.List[0].Type)
+}
+
+// isContextType checks if a type expression is context.Context
+func isContextType(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "context" && sel.Sel.Name == "Context"
+}
+
+// contextParam finds a function's context.Context parameter, returning its
+// name and index in the parameter list, or -1 when it takes none
+func contextParam(fn *ast.FuncDecl) (string, int) {
+	if fn.Type.Params == nil {
+		return "", -1
+	}
+	index := 0
+	for _, field := range fn.Type.Params.List {
+		if isContextType(field.Type) {
+			name := "_"
+			if len(field.Names) > 0 {
+				name = field.Names[0].Name
+			}
+			return name, index
+		}
+		index += max(len(field.Names), 1)
+	}
+	return "", -1
+}
+
+// freshContexts finds the context.Background() and context.TODO() calls in
+// a function taking its own context, named name, that it could pass on
+// instead. A function ignoring its context, named _, has nothing to pass.
+func freshContexts(fn *ast.FuncDecl, name string) []*ast.CallExpr {
+	calls := []*ast.CallExpr{}
+	if fn.Body == nil || name == "_" || name == "" {
+		return calls
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && (isSelectorCall(call, "context", "Background") || isSelectorCall(call, "context", "TODO")) {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls
+}
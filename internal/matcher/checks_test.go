@@ -0,0 +1,199 @@
+package matcher
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// parseFixture parses a Go file under testdata the way the matcher does,
+// so LineOf works on it
+func parseFixture(t *testing.T, name string) *ast.File {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join("testdata", name), nil, goParseMode)
+	if err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+	trackFileSet(file, fset)
+	return file
+}
+
+// parseSource parses Go source the way the matcher does
+func parseSource(t *testing.T, src string) *ast.File {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "src.go", src, goParseMode)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	trackFileSet(file, fset)
+	return file
+}
+
+// deviationAt is the element and line of a deviation, for comparing results
+type deviationAt struct {
+	element string
+	line    int
+}
+
+func deviationsAt(deviations []patterns.Deviation) []deviationAt {
+	at := []deviationAt{}
+	for _, dev := range deviations {
+		at = append(at, deviationAt{dev.Element, dev.LineNumber})
+	}
+	return at
+}
+
+func sameDeviations(t *testing.T, got []patterns.Deviation, want []deviationAt) {
+	t.Helper()
+	at := deviationsAt(got)
+	if len(at) != len(want) {
+		t.Fatalf("got deviations %v, want %v", at, want)
+	}
+	for i := range want {
+		if at[i] != want[i] {
+			t.Fatalf("got deviations %v, want %v", at, want)
+		}
+	}
+}
+
+func TestContextPropagation(t *testing.T) {
+	ref := parseFixture(t, "context/reference.go")
+	file := parseFixture(t, "context/missing_ctx.go")
+
+	got := contextPropagationCheck{}.Evaluate(file, ref, patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{
+		{"context_arg", 12},         // Get(id string): no context at all
+		{"context_arg", 20},         // Delete(id string, ctx context.Context): not first
+		{"context_propagation", 25}, // Cancel passes context.TODO() on
+	})
+	if got[0].Type != patterns.DeviationMissing || got[0].Actual != "Get(string)" {
+		t.Errorf("missing ctx = %+v", got[0])
+	}
+	if got[1].Type != patterns.DeviationDifferent || got[1].Actual != "Delete(string, context.Context)" {
+		t.Errorf("ctx not first = %+v", got[1])
+	}
+	if got[2].Expected != "ctx" || got[2].Actual != "context.TODO()" {
+		t.Errorf("fresh context = %+v", got[2])
+	}
+}
+
+func TestContextPropagationOnlyServices(t *testing.T) {
+	ref := parseFixture(t, "context/reference.go")
+	file := parseFixture(t, "context/missing_ctx.go")
+
+	if got := (contextPropagationCheck{}).Evaluate(file, ref, patterns.Pattern{Type: patterns.PatternHTTPHandler}); len(got) != 0 {
+		t.Errorf("handler pattern got %v, want none", deviationsAt(got))
+	}
+}
+
+func TestContextPropagationReferenceWithoutContext(t *testing.T) {
+	ref := parseSource(t, `package services
+
+import "context"
+
+type Cache struct{}
+
+func (c *Cache) Get(key string) string { return "" }
+
+func (c *Cache) Warm(ctx context.Context) error {
+	return c.load(context.Background())
+}
+`)
+	file := parseFixture(t, "context/missing_ctx.go")
+
+	// Get takes no context in the reference, not every reference method
+	// takes one for the others to follow, and fresh contexts are the
+	// reference's own style
+	got := contextPropagationCheck{}.Evaluate(file, ref, patterns.Pattern{Type: patterns.PatternRepository})
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestContextParam(t *testing.T) {
+	file := parseSource(t, `package p
+
+import "context"
+
+func A(ctx context.Context) {}
+func B(a, b int, c context.Context) {}
+func C(int, context.Context) {}
+func D(_ context.Context) {}
+func E(a string) {}
+`)
+	want := map[string]struct {
+		name  string
+		index int
+	}{
+		"A": {"ctx", 0},
+		"B": {"c", 2},
+		"C": {"_", 1},
+		"D": {"_", 0},
+		"E": {"", -1},
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name, index := contextParam(fn)
+		if w := want[fn.Name.Name]; name != w.name || index != w.index {
+			t.Errorf("contextParam(%s) = %q, %d, want %q, %d", fn.Name.Name, name, index, w.name, w.index)
+		}
+	}
+}
+
+func TestOverlappingChecksReportOnce(t *testing.T) {
+	ref, err := filepath.Abs("testdata/context/reference.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New([]patterns.Pattern{{
+		ID:        "service",
+		Type:      patterns.PatternService,
+		Detection: patterns.DetectionRule{FilePattern: "*.go"},
+		Reference: ref,
+	}}, 90)
+	m.Checks = []Check{contextFirstArgCheck{}, contextPropagationCheck{}}
+
+	match, err := m.MatchFile("testdata/context/missing_ctx.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[deviationAt]int)
+	for _, at := range deviationsAt(match.Deviations) {
+		counts[at]++
+	}
+	for _, at := range []deviationAt{{"context_arg", 12}, {"context_arg", 20}, {"context_propagation", 25}} {
+		if counts[at] != 1 {
+			t.Errorf("%v reported %d times, want once (deviations %v)", at, counts[at], deviationsAt(match.Deviations))
+		}
+	}
+}
+
+func TestExportedDocComment(t *testing.T) {
+	ref := parseFixture(t, "doccomment/reference.go")
+	check := exportedDocCommentCheck{}
+
+	got := check.Evaluate(parseFixture(t, "doccomment/documented.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "doccomment/undocumented.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{
+		{"doc_comment", 3}, // var DefaultTimeout
+		{"doc_comment", 5}, // type OrderService
+		{"doc_comment", 7}, // method OrderService.Create
+		{"doc_comment", 9}, // func NewOrderService
+	})
+	if got[2].Actual != "OrderService.Create" || got[2].Expected != "// Create ..." || got[2].Severity != patterns.SeverityInfo {
+		t.Errorf("method deviation = %+v", got[2])
+	}
+
+	// A reference that doesn't document everything sets no convention
+	got = check.Evaluate(parseFixture(t, "doccomment/undocumented.go"), parseFixture(t, "doccomment/undocumented.go"), patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+}
@@ -0,0 +1,190 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// CleanupCheckName is the config name of the deferred cleanup check
+const CleanupCheckName = "deferred-close"
+
+// DefaultCleanupCalls are the calls recognized as acquiring a resource out
+// of the box, each with the method that releases its first result: files,
+// database rows and HTTP response bodies
+var DefaultCleanupCalls = map[string]string{
+	"os.Open":       "Close",
+	"os.OpenFile":   "Close",
+	"os.Create":     "Close",
+	"Query":         "Close",
+	"QueryContext":  "Close",
+	"Queryx":        "Close",
+	"QueryxContext": "Close",
+	"http.Get":      "Body.Close",
+	"http.Post":     "Body.Close",
+	"http.PostForm": "Body.Close",
+	"http.Head":     "Body.Close",
+	"Do":            "Body.Close",
+}
+
+// cleanupCheck flags functions that acquire a closable resource without
+// deferring its release, where every resource the reference acquires is
+// released with defer
+type cleanupCheck struct {
+	names     map[string]string // Function or method names -> release method
+	qualified map[string]string // pkg.Func names -> release method
+}
+
+// NewCleanupCheck creates the deferred cleanup check recognizing extra
+// acquiring calls on top of DefaultCleanupCalls, each mapped to the method
+// releasing its result, e.g. "pool.Get": "Release". Like validation calls,
+// a name without a dot matches a function or method of that name on
+// anything.
+func NewCleanupCheck(extra map[string]string) Check {
+	c := cleanupCheck{names: make(map[string]string), qualified: make(map[string]string)}
+	for _, calls := range []map[string]string{DefaultCleanupCalls, extra} {
+		for name, release := range calls {
+			if strings.Contains(name, ".") {
+				c.qualified[name] = release
+			} else {
+				c.names[name] = release
+			}
+		}
+	}
+	return c
+}
+
+func (cleanupCheck) Name() string { return CleanupCheckName }
+
+func (c cleanupCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refAcquires := false
+	for _, fn := range funcDecls(ref) {
+		acquired := c.acquisitions(fn)
+		if len(acquired) == 0 {
+			continue
+		}
+		if len(leaks(fn, acquired)) > 0 {
+			return nil // The reference doesn't consistently defer cleanup
+		}
+		refAcquires = true
+	}
+	if !refAcquires {
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range funcDecls(file) {
+		for _, a := range leaks(fn, c.acquisitions(fn)) {
+			release := a.name + "." + a.release + "()"
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "deferred_close",
+				Expected:   "defer " + release,
+				Actual:     fmt.Sprintf("%s from %s is never released with defer", a.name, a.call),
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Add defer %s once the error from %s is checked, like the reference, so it is released on every return path", release, a.call),
+				LineNumber: LineOf(file, a.pos),
+			})
+		}
+	}
+	return deviations
+}
+
+// acquisition is a resource a function assigns from an acquiring call
+type acquisition struct {
+	name    string // Variable holding the resource
+	call    string // Acquiring call, e.g. os.Open
+	release string // Method releasing it, e.g. Close or Body.Close
+	pos     token.Pos
+}
+
+// acquisitions finds the resources a function assigns from acquiring calls
+func (c cleanupCheck) acquisitions(fn *ast.FuncDecl) []acquisition {
+	acquired := []acquisition{}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 || len(assign.Lhs) == 0 {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || name.Name == "_" {
+			return true
+		}
+		if callee, release, ok := c.acquiring(call); ok {
+			acquired = append(acquired, acquisition{name: name.Name, call: callee, release: release, pos: assign.Pos()})
+		}
+		return true
+	})
+	return acquired
+}
+
+// acquiring checks if a call is a recognized acquiring call, returning how
+// it is written and the method releasing its result
+func (c cleanupCheck) acquiring(call *ast.CallExpr) (string, string, bool) {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		release, ok := c.names[fun.Name]
+		return fun.Name, release, ok
+	case *ast.SelectorExpr:
+		x, isIdent := fun.X.(*ast.Ident)
+		if isIdent {
+			if release, ok := c.qualified[x.Name+"."+fun.Sel.Name]; ok {
+				return x.Name + "." + fun.Sel.Name, release, true
+			}
+		}
+		if release, ok := c.names[fun.Sel.Name]; ok {
+			if isIdent {
+				return x.Name + "." + fun.Sel.Name, release, true
+			}
+			return fun.Sel.Name, release, true
+		}
+	}
+	return "", "", false
+}
+
+// leaks lists the acquisitions a function neither defers anything with nor
+// returns as they are, handing them to its caller. Any deferred call
+// mentioning the resource counts, e.g. defer closeQuietly(f) or a deferred
+// func literal.
+func leaks(fn *ast.FuncDecl, acquired []acquisition) []acquisition {
+	if len(acquired) == 0 {
+		return nil
+	}
+	released := make(map[string]bool)
+	mark := func(node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				released[id.Name] = true
+			}
+			return true
+		})
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.DeferStmt:
+			mark(n.Call)
+		case *ast.ReturnStmt:
+			for _, result := range n.Results {
+				if id, ok := result.(*ast.Ident); ok {
+					released[id.Name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	leaked := []acquisition{}
+	for _, a := range acquired {
+		if !released[a.name] {
+			leaked = append(leaked, a)
+		}
+	}
+	return leaked
+}
@@ -0,0 +1,56 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestDeferredClose(t *testing.T) {
+	ref := parseFixture(t, "cleanup/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := NewCleanupCheck(nil)
+
+	// Deferring any call with the resource, or returning it, releases it
+	got := check.Evaluate(parseFixture(t, "cleanup/closes.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// Closing rows without defer misses early returns; pool.Get isn't
+	// recognized yet
+	got = check.Evaluate(parseFixture(t, "cleanup/leaks.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"deferred_close", 15}, // os.Open
+		{"deferred_close", 23}, // db.Query
+		{"deferred_close", 36}, // http.Get
+	})
+	if got[0].Severity != patterns.SeverityWarning || got[0].Expected != "defer f.Close()" {
+		t.Errorf("deviation = %+v, want a warning expecting defer f.Close()", got[0])
+	}
+	if got[2].Expected != "defer resp.Body.Close()" {
+		t.Errorf("deviation = %+v, want defer resp.Body.Close()", got[2])
+	}
+
+	got = NewCleanupCheck(map[string]string{"pool.Get": "Release"}).Evaluate(parseFixture(t, "cleanup/leaks.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"deferred_close", 15},
+		{"deferred_close", 23},
+		{"deferred_close", 36},
+		{"deferred_close", 44}, // pool.Get
+	})
+	if got[3].Expected != "defer conn.Release()" {
+		t.Errorf("deviation = %+v, want defer conn.Release()", got[3])
+	}
+}
+
+func TestDeferredCloseFollowsReference(t *testing.T) {
+	file := parseFixture(t, "cleanup/leaks.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := NewCleanupCheck(nil)
+
+	// References that release without defer, or acquire nothing, set no
+	// expectation
+	for _, ref := range []string{"cleanup/leaky_reference.go", "cleanup/plain_reference.go"} {
+		got := check.Evaluate(file, parseFixture(t, ref), service)
+		sameDeviations(t, got, []deviationAt{})
+	}
+}
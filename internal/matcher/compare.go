@@ -0,0 +1,112 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"math"
+	"sort"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Compare reports the structural similarity of two files along with the
+// imports and exported declarations they share, using the same comparison
+// the matcher scores with
+func (m *Matcher) Compare(fileA, fileB string) (*patterns.FileComparison, error) {
+	a, err := loadCandidate(fileA)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileA, err)
+	}
+	defer a.release()
+	b, err := loadCandidate(fileB)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileB, err)
+	}
+	defer b.release()
+
+	if (a.file == nil) != (b.file == nil) {
+		return nil, fmt.Errorf("cannot compare Go with TypeScript/JavaScript: %s, %s", fileA, fileB)
+	}
+
+	cmp := &patterns.FileComparison{FileA: fileA, FileB: fileB}
+	if a.file != nil {
+		cmp.Structure = m.compareStructure(fileA, nil, fileB)
+	} else {
+		cmp.Structure = m.Scoring.nodeSimilarity(declarationCounts(a.info), declarationCounts(b.info))
+	}
+	cmp.Structure = math.Min(cmp.Structure, 1) // Absorb float rounding
+	cmp.SharedImports, cmp.OnlyAImports, cmp.OnlyBImports = splitSets(a.imports, b.imports)
+	cmp.SharedExports, cmp.OnlyAExports, cmp.OnlyBExports = splitSets(a.exports(), b.exports())
+	return cmp, nil
+}
+
+// exports lists a candidate's exported functions and types. Go methods are
+// qualified by their receiver type.
+func (c *candidate) exports() []string {
+	names := []string{}
+	if c.file == nil {
+		for _, fn := range c.info.Functions {
+			if fn.Exported {
+				names = append(names, fn.Name)
+			}
+		}
+		for _, t := range c.info.Types {
+			if t.Exported {
+				names = append(names, t.Name)
+			}
+		}
+		return names
+	}
+
+	for _, decl := range c.file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			if recv := receiverType(d); recv != "" {
+				name = recv + "." + name
+			}
+			names = append(names, name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.IsExported() {
+					names = append(names, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// splitSets divides two string sets into shared, only-in-a and only-in-b,
+// each sorted
+func splitSets(a, b []string) (shared, onlyA, onlyB []string) {
+	inA := make(map[string]bool)
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool)
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	shared, onlyA, onlyB = []string{}, []string{}, []string{}
+	for s := range inA {
+		if inB[s] {
+			shared = append(shared, s)
+		} else {
+			onlyA = append(onlyA, s)
+		}
+	}
+	for s := range inB {
+		if !inA[s] {
+			onlyB = append(onlyB, s)
+		}
+	}
+	sort.Strings(shared)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	return shared, onlyA, onlyB
+}
@@ -0,0 +1,120 @@
+package matcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Match modes
+const (
+	// MatchBest scores a file against its single best-scoring reference
+	MatchBest = "best"
+	// MatchConsensus scores a file against what most of a pattern's
+	// references agree on, so deviations don't hinge on one example
+	MatchConsensus = "consensus"
+)
+
+// reference is a pattern example with its tier weight
+type reference struct {
+	path   string
+	weight float64
+}
+
+// references lists a pattern's current goldens, blessed and discovered
+// examples with their weights
+func (m *Matcher) references(pattern patterns.Pattern) []reference {
+	refs := []reference{}
+	superseded := supersededVersions(pattern.AnnotatedGolden)
+	for _, golden := range pattern.AnnotatedGolden {
+		if golden.Version == "" || !superseded[golden.Version] {
+			refs = append(refs, reference{golden.Path, m.Weights.Golden})
+		}
+	}
+	for _, blessed := range pattern.Blessed() {
+		refs = append(refs, reference{blessed.Path, m.blessedWeight(blessed)})
+	}
+	for _, discovered := range pattern.Discovered {
+		refs = append(refs, reference{discovered.Path, m.Weights.Discovered})
+	}
+	return refs
+}
+
+// consensusMatch scores a candidate against a pattern's consensus: a
+// deviation only counts when a majority of references produce it, and
+// structural similarity is averaged across them. The weighted score uses the
+// highest tier among the references. Returns nil if no reference could be read.
+func (m *Matcher) consensusMatch(c *candidate, pattern *patterns.Pattern) *patterns.PatternMatch {
+	defer m.Profile.Pattern(pattern.ID, time.Now())
+	votes := make(map[string]int)
+	first := make(map[string]patterns.Deviation)
+	order := []string{}
+	compared := []string{}
+	similarity := 0.0
+	weight := 0.0
+
+	for _, ref := range m.references(*pattern) {
+		var deviations []patterns.Deviation
+		var sim float64
+		var err error
+		if c.file == nil {
+			deviations, sim, err = m.compareText(c, ref.path, *pattern)
+		} else {
+			deviations, sim, err = m.compareGo(c, ref.path, *pattern)
+		}
+		if err != nil {
+			continue
+		}
+
+		compared = append(compared, ref.path)
+		similarity += sim
+		if ref.weight > weight {
+			weight = ref.weight
+		}
+
+		// A reference votes once per distinct deviation
+		seen := make(map[string]bool)
+		for _, dev := range deviations {
+			key := deviationKey(dev)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if votes[key] == 0 {
+				first[key] = dev
+				order = append(order, key)
+			}
+			votes[key]++
+		}
+	}
+	if len(compared) == 0 {
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, key := range order {
+		if votes[key]*2 > len(compared) {
+			deviations = append(deviations, first[key])
+		}
+	}
+
+	result := m.finishScore(c, deviations, similarity/float64(len(compared)), *pattern)
+	return &patterns.PatternMatch{
+		Pattern:       pattern,
+		FilePath:      c.path,
+		Score:         result.score,
+		WeightedScore: result.score * weight,
+		MatchType:     "consensus",
+		Deviations:    result.deviations,
+		Suppressed:    result.suppressed,
+		Breakdown:     result.breakdown,
+		AutoApprove:   result.score >= m.Threshold,
+		References:    compared,
+	}
+}
+
+// deviationKey identifies equivalent deviations reported against different references
+func deviationKey(dev patterns.Deviation) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d", dev.Type, dev.Element, dev.Expected, dev.Actual, dev.LineNumber)
+}
@@ -0,0 +1,83 @@
+package matcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// consensusSource is a service importing the given packages
+func consensusSource(imports ...string) string {
+	return `package services
+
+import (
+	"` + strings.Join(imports, "\"\n\t\"") + `"
+)
+
+func Normalize(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	return name, nil
+}
+`
+}
+
+// missingImportsOf lists the imports a match reports missing
+func missingImportsOf(match *patterns.PatternMatch) map[string]bool {
+	missing := make(map[string]bool)
+	for _, dev := range match.Deviations {
+		if dev.Element == "import" && dev.Type == patterns.DeviationMissing {
+			missing[dev.Expected] = true
+		}
+	}
+	return missing
+}
+
+func TestMatchModes(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/errs.go":    consensusSource("errors", "fmt"),
+		"services/strings.go": consensusSource("fmt", "strings"),
+		"services/both.go":    consensusSource("errors", "fmt", "sort"),
+		// Borrows fmt from every reference and strings from just one
+		"services/user.go": consensusSource("fmt", "strings"),
+	})
+	pattern := patterns.Pattern{
+		ID:        "service",
+		Type:      patterns.PatternService,
+		Detection: patterns.DetectionRule{FilePattern: "*.go"},
+	}
+	for _, name := range []string{"errs.go", "strings.go", "both.go"} {
+		pattern.Discovered = append(pattern.Discovered, patterns.Example{Path: filepath.Join(root, "services", name)})
+	}
+	file := filepath.Join(root, "services/user.go")
+
+	m := New([]patterns.Pattern{pattern}, 80)
+	best, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingImportsOf(best); len(missing) != 0 {
+		t.Errorf("best mode: missing imports %v, want none against the matching reference", missing)
+	}
+
+	m = New([]patterns.Pattern{pattern}, 80)
+	m.MatchMode = MatchConsensus
+	consensus, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consensus.MatchType != "consensus" || len(consensus.References) != 3 {
+		t.Errorf("consensus match type %q against %v, want all 3 references", consensus.MatchType, consensus.References)
+	}
+	// Two of three references import errors; only one imports sort
+	missing := missingImportsOf(consensus)
+	if !missing["errors"] || missing["sort"] || len(missing) != 1 {
+		t.Errorf("consensus mode: missing imports %v, want just errors", missing)
+	}
+	if consensus.Score >= best.Score {
+		t.Errorf("consensus score %g not below best score %g despite the majority's missing import", consensus.Score, best.Score)
+	}
+}
@@ -0,0 +1,75 @@
+package matcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestDegradedUnparseableReference(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":   "package services\n\nfunc Broken( {\n",
+		"services/user.go":  scoringCandidate,
+		"services/order.go": scoringCandidate,
+	})
+	ref := filepath.Join(root, "services", "ref.go")
+	m := New([]patterns.Pattern{declared("service", patterns.PatternService, ref)}, 90)
+	warnings := []string{}
+	m.OnDegraded = func(err error) { warnings = append(warnings, err.Error()) }
+
+	for _, name := range []string{"user.go", "order.go"} {
+		match, err := m.MatchFile(filepath.Join(root, "services", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match.Score != defaultReferenceScore {
+			t.Errorf("%s scores %g against the broken reference, want the default %g", name, match.Score, defaultReferenceScore)
+		}
+	}
+	// Once however many files run into it
+	if len(warnings) != 1 {
+		t.Fatalf("OnDegraded got %q, want one warning", warnings)
+	}
+	if !strings.Contains(warnings[0], "ref.go") || !strings.Contains(warnings[0], "using the default score (50%)") {
+		t.Errorf("warning = %q, want the reference and the default score", warnings[0])
+	}
+}
+
+func TestDegradedStructure(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  "package services\n\nfunc Broken( {\n",
+		"services/user.go": scoringCandidate,
+	})
+	m := New(nil, 90)
+	warnings := []string{}
+	m.OnDegraded = func(err error) { warnings = append(warnings, err.Error()) }
+
+	got := m.compareStructure(filepath.Join(root, "services", "user.go"), nil, filepath.Join(root, "services", "ref.go"))
+	if got != defaultStructureSimilarity {
+		t.Errorf("similarity = %g, want the default %g", got, defaultStructureSimilarity)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "using the default structural similarity (50%)") {
+		t.Errorf("OnDegraded got %q, want the structural similarity fallback", warnings)
+	}
+
+	// Without OnDegraded the fallback is silent
+	m.OnDegraded = nil
+	if got := m.compareStructure(filepath.Join(root, "services", "user.go"), nil, filepath.Join(root, "services", "missing.go")); got != defaultStructureSimilarity {
+		t.Errorf("similarity to a missing file = %g, want the default", got)
+	}
+}
+
+func TestStructureIgnoresComments(t *testing.T) {
+	documented := "// Package services does things\npackage services\n\n" +
+		strings.Replace(scoringCandidate[len("package services\n\n"):], "func Normalize", "// Normalize trims a name\nfunc Normalize", 1)
+	root := writeTree(t, map[string]string{
+		"services/user.go":       scoringCandidate,
+		"services/documented.go": documented,
+	})
+	m := New(nil, 90)
+	if got := m.compareStructure(filepath.Join(root, "services", "user.go"), nil, filepath.Join(root, "services", "documented.go")); got < 0.999 {
+		t.Errorf("similarity to the same code with comments = %g, want 1", got)
+	}
+}
@@ -0,0 +1,92 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// EnvCheckName is the config name of the environment access check
+const EnvCheckName = "env-access"
+
+// DefaultEnvPackages are the packages allowed to read environment variables
+// out of the box: the config package and main, which wires it up
+var DefaultEnvPackages = []string{"config", "main"}
+
+// envFuncs are the os functions reading environment variables
+var envFuncs = []string{"Getenv", "LookupEnv"}
+
+// envCheck flags files reading environment variables directly where the
+// reference never does, getting its settings from injected config instead.
+// Files in the allowed packages are never flagged.
+type envCheck struct {
+	packages []string
+}
+
+// NewEnvCheck creates the environment access check allowing extra packages,
+// by name, on top of DefaultEnvPackages
+func NewEnvCheck(extra []string) Check {
+	return envCheck{packages: append(append([]string{}, DefaultEnvPackages...), extra...)}
+}
+
+func (envCheck) Name() string { return EnvCheckName }
+
+func (c envCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if contains(c.packages, file.Name.Name) || len(envReads(ref)) > 0 {
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, call := range envReads(file) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "env_access",
+			Expected:   "settings from injected config",
+			Actual:     call.callee + " called directly",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Read the variable in the config package and pass the value in, like the reference, instead of calling %s here", call.callee),
+			LineNumber: LineOf(file, call.pos.Pos()),
+		})
+	}
+	return deviations
+}
+
+// envRead is a call reading an environment variable
+type envRead struct {
+	callee string // As written, e.g. os.Getenv
+	pos    ast.Node
+}
+
+// envReads finds a file's os.Getenv and os.LookupEnv calls, however os is
+// imported
+func envReads(file *ast.File) []envRead {
+	osName := ""
+	for _, imp := range file.Imports {
+		if importPath(imp) == "os" {
+			osName = importName(imp)
+		}
+	}
+	if osName == "" || osName == "_" {
+		return nil
+	}
+
+	reads := []envRead{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		for _, name := range envFuncs {
+			if osName == "." {
+				if id, ok := call.Fun.(*ast.Ident); ok && id.Name == name {
+					reads = append(reads, envRead{callee: name, pos: call})
+				}
+			} else if isSelectorCall(call, osName, name) {
+				reads = append(reads, envRead{callee: osName + "." + name, pos: call})
+			}
+		}
+		return true
+	})
+	return reads
+}
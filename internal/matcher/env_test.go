@@ -0,0 +1,49 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestEnvAccess(t *testing.T) {
+	ref := parseFixture(t, "env/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := NewEnvCheck(nil)
+
+	// Using os for something else isn't reading the environment
+	got := check.Evaluate(parseFixture(t, "env/injected.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "env/direct.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"env_access", 11}, // os.Getenv
+		{"env_access", 15}, // os.LookupEnv
+	})
+	if got[0].Severity != patterns.SeverityWarning || got[0].Actual != "os.Getenv called directly" {
+		t.Errorf("deviation = %+v, want a warning for os.Getenv", got[0])
+	}
+
+	got = check.Evaluate(parseFixture(t, "env/aliased.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{{"env_access", 6}})
+	if got[0].Actual != "sys.Getenv called directly" {
+		t.Errorf("aliased deviation = %+v, want sys.Getenv", got[0])
+	}
+}
+
+func TestEnvAccessAllowed(t *testing.T) {
+	ref := parseFixture(t, "env/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+
+	// The config package is allowed out of the box
+	got := NewEnvCheck(nil).Evaluate(parseFixture(t, "env/config.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// Further packages can be allowed
+	got = NewEnvCheck([]string{"services"}).Evaluate(parseFixture(t, "env/direct.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// A reference reading the environment sets no expectation
+	got = NewEnvCheck(nil).Evaluate(parseFixture(t, "env/direct.go"), parseFixture(t, "env/env_reference.go"), service)
+	sameDeviations(t, got, []deviationAt{})
+}
@@ -0,0 +1,58 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestIgnoreDeviations(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringCandidate,
+	})
+	file := filepath.Join(root, "services/user.go")
+	pats := []patterns.Pattern{declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))}
+
+	m := New(pats, 95)
+	m.Scoring.StructureWeight = 0 // Only penalties count
+	m.IgnoreDeviations = []string{"import"}
+	match, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingImportsOf(match); len(missing) != 0 {
+		t.Errorf("ignored imports reported missing: %v", missing)
+	}
+	// 90 with the two missing imports counted
+	if match.Score != 100 || match.Breakdown.Imports != 0 || !match.AutoApprove {
+		t.Errorf("score %g, import penalty %g, auto-approve %v; want 100, 0 and approved", match.Score, match.Breakdown.Imports, match.AutoApprove)
+	}
+}
+
+func TestIgnoreDeviationsChecks(t *testing.T) {
+	ref, err := filepath.Abs("testdata/context/reference.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pats := []patterns.Pattern{declared("service", patterns.PatternService, ref)}
+
+	m := New(pats, 90)
+	m.Checks = []Check{contextFirstArgCheck{}, contextPropagationCheck{}}
+	all, err := m.MatchFile("testdata/context/missing_ctx.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.IgnoreDeviations = []string{"context_arg"}
+	match, err := m.MatchFile("testdata/context/missing_ctx.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Other elements are still reported
+	sameDeviations(t, match.Deviations, []deviationAt{{"context_propagation", 25}})
+	if match.Score <= all.Score {
+		t.Errorf("score with context_arg ignored = %g, want above %g", match.Score, all.Score)
+	}
+}
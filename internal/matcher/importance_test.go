@@ -0,0 +1,91 @@
+package matcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// importanceCandidates are services lacking one of scoringReference's
+// imports each
+var importanceCandidates = map[string]string{
+	"services/no_errors.go": `package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Normalize(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty name")
+	}
+	return fmt.Sprint(strings.TrimSpace(name)), nil
+}
+`,
+	"services/no_strings.go": strings.Replace(strings.Replace(scoringReference, "\t\"strings\"\n", "", 1),
+		"strings.TrimSpace(name)", "name", 1),
+}
+
+// importScores scores each importance candidate against a service pattern
+// whose imports have the given importance, returning the import penalty of
+// each file by base name
+func importScores(t *testing.T, importance map[string]float64) map[string]float64 {
+	t.Helper()
+	files := map[string]string{"services/ref.go": scoringReference}
+	for path, src := range importanceCandidates {
+		files[path] = src
+	}
+	root := writeTree(t, files)
+
+	p := declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))
+	for _, imp := range []string{"errors", "fmt", "strings"} {
+		if w, ok := importance[imp]; ok {
+			p.Structure.Elements = append(p.Structure.Elements, patterns.StructureElement{Name: imp, Type: patterns.ElementImport, Importance: w})
+		}
+	}
+	m := New([]patterns.Pattern{p}, 95)
+
+	penalties := map[string]float64{}
+	for path := range importanceCandidates {
+		match, err := m.MatchFile(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missingImportsOf(match)) != 1 {
+			t.Fatalf("%s: missing imports %v, want one", path, missingImportsOf(match))
+		}
+		penalties[filepath.Base(path)] = match.Breakdown.Imports
+	}
+	return penalties
+}
+
+func TestImportanceWeighsMissingImports(t *testing.T) {
+	penalty := DefaultScoring().MissingImportPenalty
+
+	// errors is in every example, strings in 80% of them
+	got := importScores(t, map[string]float64{"errors": 1, "fmt": 1, "strings": 0.8})
+	if got["no_errors.go"] != penalty {
+		t.Errorf("missing core import costs %g, want the full %g", got["no_errors.go"], penalty)
+	}
+	if want := 0.8 * penalty; got["no_strings.go"] != want {
+		t.Errorf("missing 80%% import costs %g, want %g", got["no_strings.go"], want)
+	}
+	if got["no_strings.go"] >= got["no_errors.go"] {
+		t.Errorf("missing rare import costs %g, no less than the core import's %g", got["no_strings.go"], got["no_errors.go"])
+	}
+
+	// An import the pattern doesn't require is incidental
+	got = importScores(t, map[string]float64{"errors": 1, "fmt": 1})
+	if want := incidentalImportance * penalty; got["no_strings.go"] != want {
+		t.Errorf("missing incidental import costs %g, want %g", got["no_strings.go"], want)
+	}
+
+	// Patterns recording no importance weigh every import fully
+	got = importScores(t, nil)
+	if got["no_errors.go"] != penalty || got["no_strings.go"] != penalty {
+		t.Errorf("without importance missing imports cost %v, want %g each", got, penalty)
+	}
+}
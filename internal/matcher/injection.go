@@ -0,0 +1,185 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// InjectionCheckName is the config name of the dependency injection check
+const InjectionCheckName = "dependency-injection"
+
+// DefaultConstructionCalls are the calls recognized as constructing a
+// dependency out of the box: database, cache, message queue and RPC clients
+var DefaultConstructionCalls = []string{
+	"sql.Open",
+	"sqlx.Open",
+	"sqlx.Connect",
+	"gorm.Open",
+	"pgx.Connect",
+	"pgxpool.New",
+	"pgxpool.Connect",
+	"redis.NewClient",
+	"mongo.Connect",
+	"grpc.Dial",
+	"grpc.NewClient",
+	"amqp.Dial",
+	"kafka.NewReader",
+	"kafka.NewWriter",
+}
+
+// injectionCheck flags services that construct their dependencies inline
+// or have no constructor, where the reference service receives them
+// through a New constructor and never constructs one in its methods
+type injectionCheck struct {
+	names     map[string]bool // Function or method names, whatever they are called on
+	qualified map[string]bool // pkg.Func names
+}
+
+// NewInjectionCheck creates the dependency injection check recognizing
+// extra construction calls on top of DefaultConstructionCalls. As with
+// validation calls, "Connect" matches any function or method of that name
+// while "db.Connect" only matches calls through db.
+func NewInjectionCheck(extra []string) Check {
+	c := injectionCheck{names: make(map[string]bool), qualified: make(map[string]bool)}
+	for _, name := range append(append([]string{}, DefaultConstructionCalls...), extra...) {
+		if strings.Contains(name, ".") {
+			c.qualified[name] = true
+		} else {
+			c.names[name] = true
+		}
+	}
+	return c
+}
+
+func (injectionCheck) Name() string { return InjectionCheckName }
+
+func (c injectionCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternService {
+		return nil
+	}
+	refConstructor := constructor(ref)
+	if refConstructor == nil || len(c.constructions(ref)) > 0 {
+		return nil // The reference doesn't inject its dependencies
+	}
+
+	deviations := []patterns.Deviation{}
+	if constructor(file) == nil {
+		name, line := "NewXxxService", 0
+		if spec := serviceType(file); spec != nil {
+			name, line = "New"+spec.Name.Name, LineOf(file, spec.Pos())
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "constructor",
+			Expected:   name + " taking the service's dependencies",
+			Actual:     "no New constructor",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Add %s and pass the dependencies in, like the reference's %s", name, refConstructor.Name.Name),
+			LineNumber: line,
+		})
+	}
+	for _, call := range c.constructions(file) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "dependency_injection",
+			Expected:   "dependencies injected through " + refConstructor.Name.Name,
+			Actual:     fmt.Sprintf("%s calls %s", call.method, call.callee),
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Take what %s returns as a field set by the constructor instead of calling it in %s, like the reference", call.callee, call.method),
+			LineNumber: call.line,
+		})
+	}
+	return deviations
+}
+
+// construction is a dependency a method constructs inline
+type construction struct {
+	method string // Receiver.Method
+	callee string
+	line   int
+}
+
+// constructions finds the construction calls made in a file's methods
+func (c injectionCheck) constructions(file *ast.File) []construction {
+	found := []construction{}
+	for _, fn := range funcDecls(file) {
+		recv := receiverType(fn)
+		if recv == "" {
+			continue
+		}
+		method := recv + "." + fn.Name.Name
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if callee, ok := c.constructing(call); ok {
+				found = append(found, construction{method: method, callee: callee, line: LineOf(file, call.Pos())})
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// constructing checks if a call is a recognized construction call,
+// returning how it is written
+func (c injectionCheck) constructing(call *ast.CallExpr) (string, bool) {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name, c.names[fun.Name]
+	case *ast.SelectorExpr:
+		x, isIdent := fun.X.(*ast.Ident)
+		if isIdent && c.qualified[x.Name+"."+fun.Sel.Name] {
+			return x.Name + "." + fun.Sel.Name, true
+		}
+		if c.names[fun.Sel.Name] {
+			if isIdent {
+				return x.Name + "." + fun.Sel.Name, true
+			}
+			return fun.Sel.Name, true
+		}
+	}
+	return "", false
+}
+
+// constructor finds a file's first New function taking parameters
+func constructor(file *ast.File) *ast.FuncDecl {
+	for _, fn := range funcDecls(file) {
+		if fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "New") && fn.Type.Params.NumFields() > 0 {
+			return fn
+		}
+	}
+	return nil
+}
+
+// serviceType finds the struct a service file declares, preferring one
+// named like a service
+func serviceType(file *ast.File) *ast.TypeSpec {
+	var first *ast.TypeSpec
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if _, ok := ts.Type.(*ast.StructType); !ok {
+				continue
+			}
+			if strings.HasSuffix(ts.Name.Name, "Service") {
+				return ts
+			}
+			if first == nil {
+				first = ts
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,54 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestDependencyInjection(t *testing.T) {
+	ref := parseFixture(t, "injection/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := NewInjectionCheck(nil)
+
+	got := check.Evaluate(parseFixture(t, "injection/injected.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// audit.Connect isn't recognized yet
+	got = check.Evaluate(parseFixture(t, "injection/inline.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"constructor", 11},
+		{"dependency_injection", 14}, // sql.Open
+		{"dependency_injection", 22}, // redis.NewClient
+	})
+	if got[0].Expected != "NewUserService taking the service's dependencies" {
+		t.Errorf("constructor deviation = %+v, want NewUserService", got[0])
+	}
+	if got[1].Severity != patterns.SeverityWarning || got[1].Actual != "UserService.Delete calls sql.Open" {
+		t.Errorf("deviation = %+v, want a warning for sql.Open in Delete", got[1])
+	}
+
+	got = NewInjectionCheck([]string{"audit.Connect"}).Evaluate(parseFixture(t, "injection/inline.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"constructor", 11},
+		{"dependency_injection", 14},
+		{"dependency_injection", 22},
+		{"dependency_injection", 27}, // audit.Connect
+	})
+}
+
+func TestDependencyInjectionFollowsReference(t *testing.T) {
+	file := parseFixture(t, "injection/inline.go")
+	check := NewInjectionCheck(nil)
+
+	// References constructing dependencies, or without a constructor, set
+	// no expectation
+	for _, ref := range []string{"injection/inline_reference.go", "injection/inline.go"} {
+		got := check.Evaluate(file, parseFixture(t, ref), patterns.Pattern{Type: patterns.PatternService})
+		sameDeviations(t, got, []deviationAt{})
+	}
+
+	// Only services are checked
+	got := check.Evaluate(file, parseFixture(t, "injection/reference.go"), patterns.Pattern{Type: patterns.PatternRepository})
+	sameDeviations(t, got, []deviationAt{})
+}
@@ -0,0 +1,91 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+var (
+	// httpImports are HTTP server packages, which belong to handlers
+	httpImports = []string{
+		"net/http",
+		"github.com/gin-gonic/gin",
+		"github.com/labstack/echo",
+		"github.com/gofiber/fiber",
+		"github.com/go-chi/chi",
+		"github.com/gorilla/mux",
+	}
+	// databaseImports are database packages, which belong to repositories
+	databaseImports = []string{
+		"database/sql",
+		"github.com/jmoiron/sqlx",
+		"gorm.io/gorm",
+		"github.com/jackc/pgx",
+		"go.mongodb.org/mongo-driver",
+	}
+)
+
+// DefaultForbiddenImports are the imports, with their subpackages, that
+// each pattern type shouldn't need out of the box: HTTP packages below the
+// handler layer and database packages in handlers
+var DefaultForbiddenImports = map[patterns.PatternType][]string{
+	patterns.PatternRepository:  httpImports,
+	patterns.PatternModel:       httpImports,
+	patterns.PatternHTTPHandler: databaseImports,
+}
+
+// extraImports flags, under StrictImports, imports the candidate has and
+// the reference doesn't: as notes, or as warnings when the pattern type
+// forbids them, a sign of code in the wrong layer. lines maps each import
+// to the line it is on.
+func (m *Matcher) extraImports(fileImports, refImports []string, lines map[string]int, patternType patterns.PatternType) []patterns.Deviation {
+	if !m.StrictImports {
+		return nil
+	}
+	forbidden := m.ForbiddenImports
+	if forbidden == nil {
+		forbidden = DefaultForbiddenImports
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, imp := range fileImports {
+		if contains(refImports, imp) {
+			continue
+		}
+		if rule := forbiddenBy(imp, forbidden[patternType]); rule != "" {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationNovel,
+				Element:    "layering_violation",
+				Expected:   "no " + rule + " import in a " + string(patternType),
+				Actual:     imp,
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Move the code using %s to the layer it belongs to; %s files shouldn't depend on it", imp, patternType),
+				LineNumber: lines[imp],
+			})
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationNovel,
+			Element:    "extra_import",
+			Expected:   "the reference's imports",
+			Actual:     imp,
+			Severity:   patterns.SeverityInfo,
+			Suggestion: fmt.Sprintf("The reference doesn't import %s; check it belongs in this file", imp),
+			LineNumber: lines[imp],
+		})
+	}
+	return deviations
+}
+
+// forbiddenBy returns the rule an import breaks: the forbidden path it is
+// or is a subpackage of, or "" if none
+func forbiddenBy(imp string, rules []string) string {
+	for _, rule := range rules {
+		if imp == rule || strings.HasPrefix(imp, rule+"/") {
+			return rule
+		}
+	}
+	return ""
+}
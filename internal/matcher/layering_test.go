@@ -0,0 +1,82 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// importDeviations keeps the extra import and layering deviations of a match
+func importDeviations(match *patterns.PatternMatch) []patterns.Deviation {
+	found := []patterns.Deviation{}
+	for _, dev := range match.Deviations {
+		if dev.Element == "extra_import" || dev.Element == "layering_violation" {
+			found = append(found, dev)
+		}
+	}
+	return found
+}
+
+// layeringMatch matches a layering fixture as a repository
+func layeringMatch(t *testing.T, m *Matcher, fixture string) *patterns.PatternMatch {
+	t.Helper()
+	match, err := m.MatchSource(filepath.Join(filepath.Dir(m.Patterns[0].Reference), "order.go"), readFixture(t, fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match
+}
+
+func TestStrictImports(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"repository/ref.go": string(readFixture(t, "layering/reference.go")),
+	})
+	m := New([]patterns.Pattern{declared("repository", patterns.PatternRepository, filepath.Join(root, "repository", "ref.go"))}, 0)
+
+	// Extra imports pass unless asked for
+	if got := importDeviations(layeringMatch(t, m, "layering/violating.go")); len(got) != 0 {
+		t.Errorf("deviations without StrictImports: %+v", got)
+	}
+
+	m.StrictImports = true
+	got := importDeviations(layeringMatch(t, m, "layering/extra.go"))
+	sameDeviations(t, got, []deviationAt{
+		{"extra_import", 6}, // fmt
+		{"extra_import", 7}, // strings
+	})
+	if got[0].Type != patterns.DeviationNovel || got[0].Severity != patterns.SeverityInfo || got[0].Actual != "fmt" {
+		t.Errorf("extra import deviation = %+v, want a note about fmt", got[0])
+	}
+
+	// HTTP packages, and their subpackages, don't belong in repositories
+	got = importDeviations(layeringMatch(t, m, "layering/violating.go"))
+	sameDeviations(t, got, []deviationAt{
+		{"layering_violation", 6}, // net/http
+		{"layering_violation", 8}, // chi
+	})
+	if got[1].Severity != patterns.SeverityWarning || got[1].Expected != "no github.com/go-chi/chi import in a repository" {
+		t.Errorf("layering deviation = %+v, want a warning about chi", got[1])
+	}
+}
+
+func TestForbiddenImports(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"repository/ref.go": string(readFixture(t, "layering/reference.go")),
+	})
+	m := New([]patterns.Pattern{declared("repository", patterns.PatternRepository, filepath.Join(root, "repository", "ref.go"))}, 0)
+	m.StrictImports = true
+	m.ForbiddenImports = map[patterns.PatternType][]string{patterns.PatternRepository: {"fmt"}}
+
+	// The configured rules replace the type's defaults
+	got := importDeviations(layeringMatch(t, m, "layering/extra.go"))
+	sameDeviations(t, got, []deviationAt{
+		{"layering_violation", 6}, // fmt
+		{"extra_import", 7},       // strings
+	})
+	got = importDeviations(layeringMatch(t, m, "layering/violating.go"))
+	sameDeviations(t, got, []deviationAt{
+		{"extra_import", 6},
+		{"extra_import", 8},
+	})
+}
@@ -0,0 +1,167 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// LoggingCheckName is the config name of the logging consistency check
+const LoggingCheckName = "logging-consistency"
+
+// DefaultLoggingPackages are the logging imports recognized out of the box
+var DefaultLoggingPackages = []string{
+	"log",
+	"log/slog",
+	"go.uber.org/zap",
+	"github.com/sirupsen/logrus",
+	"github.com/rs/zerolog",
+	"github.com/rs/zerolog/log",
+	"github.com/go-kit/log",
+	"github.com/golang/glog",
+	"k8s.io/klog/v2",
+}
+
+// loggingCheck flags files logging through a different package than the
+// one the reference consistently uses, e.g. the standard log package or
+// fmt.Println where the reference uses slog
+type loggingCheck struct {
+	packages []string // Exact import paths
+	suffixes []string // Project packages matched by trailing path
+}
+
+// NewLoggingCheck creates the logging consistency check recognizing extra
+// packages, such as a project logger, on top of DefaultLoggingPackages. An
+// entry without a dot matches any import path ending in it, so "logger"
+// covers github.com/acme/app/internal/logger.
+func NewLoggingCheck(extra []string) Check {
+	c := loggingCheck{packages: append([]string{}, DefaultLoggingPackages...)}
+	for _, pkg := range extra {
+		if strings.Contains(pkg, ".") {
+			c.packages = append(c.packages, pkg)
+		} else {
+			c.suffixes = append(c.suffixes, pkg)
+		}
+	}
+	return c
+}
+
+func (loggingCheck) Name() string { return LoggingCheckName }
+
+func (c loggingCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refLoggers := c.loggers(ref)
+	if len(refLoggers) != 1 || len(printCalls(ref)) > 0 {
+		return nil
+	}
+	var standard string
+	for imp := range refLoggers {
+		standard = imp
+	}
+
+	deviations := []patterns.Deviation{}
+	loggers := c.loggers(file)
+	imports := make([]string, 0, len(loggers))
+	for imp := range loggers {
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool { return loggers[imports[i]] < loggers[imports[j]] })
+	for _, imp := range imports {
+		if imp == standard {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "logging",
+			Expected:   standard,
+			Actual:     imp,
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Log with %s like the rest of the codebase instead of %s", standard, imp),
+			LineNumber: LineOf(file, loggers[imp]),
+		})
+	}
+	for _, call := range printCalls(file) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "logging",
+			Expected:   standard,
+			Actual:     call.name,
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Log with %s instead of printing with %s", standard, call.name),
+			LineNumber: LineOf(file, call.pos),
+		})
+	}
+	return deviations
+}
+
+// loggers maps the recognized logging packages a file imports to where
+func (c loggingCheck) loggers(file *ast.File) map[string]token.Pos {
+	found := make(map[string]token.Pos)
+	for _, spec := range file.Imports {
+		imp, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if c.recognizes(imp) {
+			found[imp] = spec.Pos()
+		}
+	}
+	return found
+}
+
+// recognizes checks if an import path is a logging package
+func (c loggingCheck) recognizes(imp string) bool {
+	for _, pkg := range c.packages {
+		if imp == pkg {
+			return true
+		}
+	}
+	for _, suffix := range c.suffixes {
+		if imp == suffix || strings.HasSuffix(imp, "/"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// printCall is an fmt.Print* call used as ad-hoc logging
+type printCall struct {
+	name string
+	pos  token.Pos
+}
+
+// printCalls finds fmt.Print, fmt.Printf and fmt.Println calls
+func printCalls(file *ast.File) []printCall {
+	fmtName := ""
+	for _, spec := range file.Imports {
+		if spec.Path.Value != `"fmt"` {
+			continue
+		}
+		fmtName = "fmt"
+		if spec.Name != nil {
+			fmtName = spec.Name.Name
+		}
+	}
+	if fmtName == "" || fmtName == "_" {
+		return nil
+	}
+
+	calls := []printCall{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		for _, name := range []string{"Print", "Printf", "Println"} {
+			if isSelectorCall(call, fmtName, name) {
+				calls = append(calls, printCall{name: "fmt." + name, pos: call.Pos()})
+			}
+		}
+		return true
+	})
+	return calls
+}
@@ -0,0 +1,54 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestLoggingConsistency(t *testing.T) {
+	ref := parseFixture(t, "logging/reference.go")
+	check := NewLoggingCheck([]string{"logger"})
+
+	got := check.Evaluate(parseFixture(t, "logging/consistent.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "logging/nonstandard.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{
+		{"logging", 5},  // import "log"
+		{"logging", 7},  // import "go.uber.org/zap"
+		{"logging", 15}, // fmt.Println
+	})
+	want := []string{"log", "go.uber.org/zap", "fmt.Println"}
+	for i, dev := range got {
+		if dev.Actual != want[i] || dev.Expected != "github.com/acme/app/internal/logger" || dev.Severity != patterns.SeverityWarning {
+			t.Errorf("deviation %d = %+v, want %s substituted for the project logger", i, dev, want[i])
+		}
+	}
+}
+
+func TestLoggingConsistencyUnrecognizedLogger(t *testing.T) {
+	ref := parseFixture(t, "logging/reference.go")
+
+	// Without "logger" configured the reference has no recognized logger,
+	// so there's no convention to hold the candidate to
+	got := NewLoggingCheck(nil).Evaluate(parseFixture(t, "logging/nonstandard.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestLoggingRecognizes(t *testing.T) {
+	c := NewLoggingCheck([]string{"logger", "example.com/obs/logging"}).(loggingCheck)
+	for imp, want := range map[string]bool{
+		"log/slog":                              true,
+		"github.com/acme/app/internal/logger":   true,
+		"logger":                                true,
+		"example.com/obs/logging":               true,
+		"github.com/acme/app/internal/mylogger": false,
+		"example.com/obs/logging/v2":            false,
+		"fmt":                                   false,
+	} {
+		if got := c.recognizes(imp); got != want {
+			t.Errorf("recognizes(%q) = %v, want %v", imp, got, want)
+		}
+	}
+}
@@ -1,13 +1,24 @@
 package matcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/internal/profile"
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
 )
 
@@ -15,95 +26,280 @@ import (
 type Matcher struct {
 	Patterns  []patterns.Pattern
 	Threshold float64
+	// TypeFilter restricts matching to these pattern types (all when empty)
+	TypeFilter []patterns.PatternType
+	// StrictVersion treats matches against old pattern versions as needs-review
+	StrictVersion bool
+	// StrictImports notes imports the reference doesn't have, and warns
+	// about those ForbiddenImports rules out for the pattern type
+	StrictImports bool
+	// ForbiddenImports are the import paths, with their subpackages, each
+	// pattern type must not use; nil uses DefaultForbiddenImports
+	ForbiddenImports map[patterns.PatternType][]string
+	// IgnoreDeviations are deviation elements, e.g. import_alias, dropped
+	// before scoring: they neither appear in matches nor cost points
+	IgnoreDeviations []string
+	// Checks are custom structural rules run against each reference
+	Checks []Check
+	// Scoring holds the penalties and weights used to compute scores
+	Scoring Scoring
+	// Weights multiply the score against each kind of reference. Golden and
+	// discovered examples always take their tier's weight; a blessed example
+	// keeps the weight it was blessed with, if any.
+	Weights patterns.TierWeights
+	// MatchMode is MatchBest (default) or MatchConsensus
+	MatchMode string
+	// MaxFileBytes skips larger files; 0 uses analyzer.DefaultMaxFileBytes
+	MaxFileBytes int64
+	// FileTimeout bounds how long one file may take to match; <= 0 disables it
+	FileTimeout time.Duration
+	// Profile, when set, records parse and match times per file and pattern
+	Profile *profile.Profile
+	// OnDegraded, when set, is called when a comparison falls back to a
+	// default, e.g. a reference that doesn't parse getting the default
+	// structural similarity, so the scores involving it are less meaningful
+	OnDegraded func(err error)
+	// ReadReference reads golden, blessed, discovered and anti-pattern
+	// example files, e.g. from a git revision so a change can't edit the
+	// examples it is checked against. Nil reads them from disk.
+	ReadReference func(path string) ([]byte, error)
+
+	structures        *structureCache
+	structureCompares atomic.Int64 // Node counts compared, cached comparisons aside
+	degradedSeen      sync.Map     // Problems already reported to OnDegraded
 }
 
+// defaultReferenceScore is the score against a reference the candidate
+// can't be compared with, e.g. one that doesn't parse
+const defaultReferenceScore = 50.0
+
+// goParseMode is how the matcher parses Go files, candidates and references
+// alike, so the same file always yields the same syntax tree
+const goParseMode = parser.ParseComments
+
+// DefaultFileTimeout is how long a single file may take to match
+const DefaultFileTimeout = 30 * time.Second
+
 // New creates a new matcher
 func New(pats []patterns.Pattern, threshold float64) *Matcher {
 	return &Matcher{
-		Patterns:  pats,
-		Threshold: threshold,
+		Patterns:    pats,
+		Threshold:   threshold,
+		Scoring:     DefaultScoring(),
+		Weights:     patterns.DefaultTierWeights,
+		FileTimeout: DefaultFileTimeout,
+		structures:  newStructureCache(),
 	}
 }
 
-// MatchFile matches a file against all patterns using weighted hybrid approach
+// MatchFile matches a file against all patterns using weighted hybrid
+// approach. Oversized files and files exceeding FileTimeout are reported as
+// skipped rather than failing the run.
 func (m *Matcher) MatchFile(filePath string) (*patterns.PatternMatch, error) {
-	// Parse the file
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse file: %w", err)
+	ctx, cancel := m.fileContext()
+	defer cancel()
+	return m.MatchFileContext(ctx, filePath)
+}
+
+// MatchFileContext matches a file, giving up once ctx is done. Parsing can't
+// be interrupted, so an abandoned match finishes in the background.
+func (m *Matcher) MatchFileContext(ctx context.Context, filePath string) (*patterns.PatternMatch, error) {
+	if analyzer.TooLarge(filePath, m.MaxFileBytes) {
+		return skippedMatch(filePath, "file_size", "File too large to analyze; review it manually"), nil
 	}
+	return m.matchWithin(ctx, filePath, func() (*candidate, error) {
+		return loadCandidate(filePath)
+	}, m.matchPatterns)
+}
+
+// MatchSource matches src as the contents of filePath, e.g. an unsaved
+// editor buffer, with the same size and time limits as MatchFile
+func (m *Matcher) MatchSource(filePath string, src []byte) (*patterns.PatternMatch, error) {
+	if analyzer.OverLimit(int64(len(src)), m.MaxFileBytes) {
+		return skippedMatch(filePath, "file_size", "File too large to analyze; review it manually"), nil
+	}
+
+	ctx, cancel := m.fileContext()
+	defer cancel()
+	return m.matchWithin(ctx, filePath, func() (*candidate, error) {
+		return parseCandidate(filePath, src)
+	}, m.matchPatterns)
+}
+
+// fileContext returns the context a single file is matched within, ending
+// after FileTimeout
+func (m *Matcher) fileContext() (context.Context, context.CancelFunc) {
+	if m.FileTimeout > 0 {
+		return context.WithTimeout(context.Background(), m.FileTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// matchWithin loads a candidate and matches it, giving up once ctx is done
+func (m *Matcher) matchWithin(ctx context.Context, filePath string, load func() (*candidate, error),
+	match func(c *candidate) (*patterns.PatternMatch, error)) (*patterns.PatternMatch, error) {
+	type result struct {
+		match *patterns.PatternMatch
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		start := time.Now()
+		c, err := load()
+		m.Profile.File("parse", filePath, start)
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+		defer c.release()
+		start = time.Now()
+		matched, err := match(c)
+		m.Profile.File("match", filePath, start)
+		done <- result{matched, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.match, r.err
+	case <-ctx.Done():
+		return skippedMatch(filePath, "timeout", "Analysis timed out; review it manually"), nil
+	}
+}
+
+// skippedMatch reports a file that wasn't analyzed. It is never auto-approved.
+func skippedMatch(filePath, element, suggestion string) *patterns.PatternMatch {
+	return &patterns.PatternMatch{
+		FilePath:  filePath,
+		MatchType: "skipped",
+		Deviations: []patterns.Deviation{{
+			Type:       patterns.DeviationNovel,
+			Element:    element,
+			Severity:   patterns.SeverityInfo,
+			Suggestion: suggestion,
+		}},
+	}
+}
+
+// matchPatterns matches a parsed file against every pattern, as matchWithin
+// expects
+func (m *Matcher) matchPatterns(c *candidate) (*patterns.PatternMatch, error) {
+	return m.matchCandidate(c), nil
+}
+
+// matchCandidate matches a parsed file against every pattern
+func (m *Matcher) matchCandidate(c *candidate) *patterns.PatternMatch {
+	filePath := c.path
 
 	// Try to match against each pattern
 	var bestMatch *patterns.PatternMatch
-	bestScore := 0.0
+	typeScores := make(map[patterns.PatternType]float64) // Best score per pattern type
+	record := func(patternType patterns.PatternType, score float64) {
+		if score > typeScores[patternType] {
+			typeScores[patternType] = score
+		}
+	}
 
 	for _, pattern := range m.Patterns {
-		if !m.shouldTryPattern(filePath, pattern) {
+		pattern := pattern // matches keep a pointer to this pattern
+		if !m.canCompare(filePath, pattern) {
+			continue
+		}
+		// Patterns whose path conventions the file doesn't follow are
+		// scored only to spot files doing another pattern's job
+		conventional := followsConventions(filePath, pattern.Detection)
+
+		// Path conventions nudge near-ties toward the intended pattern
+		bonus := m.Scoring.PathSpecificityBonus * pathSpecificity(filePath, pattern.Detection)
+
+		if m.MatchMode == MatchConsensus {
+			match := m.consensusMatch(c, &pattern)
+			if match != nil {
+				record(pattern.Type, match.Score)
+			}
+			if match != nil && match.WeightedScore > 0 {
+				match.WeightedScore += bonus
+			}
+			if match != nil && conventional && m.prefer(c, match.WeightedScore, pattern, bestMatch) {
+				bestMatch = match
+			}
 			continue
 		}
 
-		// Try annotated golden first (highest weight: 2.0x)
+		// Try annotated golden first (highest weight by default)
 		if len(pattern.AnnotatedGolden) > 0 {
+			superseded := supersededVersions(pattern.AnnotatedGolden)
 			for _, golden := range pattern.AnnotatedGolden {
-				score, deviations := m.scoreAgainstGolden(file, golden, filePath)
-				weightedScore := score * golden.Weight // 2.0x
+				// Prefer goldens that supersede this one
+				if golden.Version != "" && superseded[golden.Version] {
+					continue
+				}
+				golden := golden
+				result := m.scoreAgainstGolden(c, golden, pattern)
+				record(pattern.Type, result.score)
+				weightedScore := weigh(result.score, m.Weights.Golden, bonus)
 
-				if weightedScore > bestScore {
-					bestScore = weightedScore
+				if conventional && m.prefer(c, weightedScore, pattern, bestMatch) {
 					bestMatch = &patterns.PatternMatch{
 						Pattern:       &pattern,
 						FilePath:      filePath,
-						Score:         score,
+						Score:         result.score,
 						WeightedScore: weightedScore,
 						MatchType:     "annotated_golden",
 						GoldenRef:     &golden,
-						Deviations:    deviations,
-						AutoApprove:   score >= m.Threshold,
+						Deviations:    result.deviations,
+						Suppressed:    result.suppressed,
+						Breakdown:     result.breakdown,
+						AutoApprove:   result.score >= m.Threshold,
 					}
 				}
 			}
 		}
 
-		// Try config blessed (weight: 1.5x)
-		if len(pattern.ConfigBlessed) > 0 {
-			for _, blessed := range pattern.ConfigBlessed {
-				score, deviations := m.scoreAgainstBlessed(file, blessed, filePath)
-				weightedScore := score * blessed.Weight // 1.5x
+		// Try config blessed
+		if blessedExamples := pattern.Blessed(); len(blessedExamples) > 0 {
+			for _, blessed := range blessedExamples {
+				blessed := blessed
+				result := m.scoreAgainstBlessed(c, blessed, pattern)
+				record(pattern.Type, result.score)
+				weightedScore := weigh(result.score, m.blessedWeight(blessed), bonus)
 
-				if weightedScore > bestScore {
-					bestScore = weightedScore
+				if conventional && m.prefer(c, weightedScore, pattern, bestMatch) {
 					bestMatch = &patterns.PatternMatch{
 						Pattern:       &pattern,
 						FilePath:      filePath,
-						Score:         score,
+						Score:         result.score,
 						WeightedScore: weightedScore,
 						MatchType:     "config_blessed",
 						BlessedRef:    &blessed,
-						Deviations:    deviations,
-						AutoApprove:   score >= m.Threshold,
+						Deviations:    result.deviations,
+						Suppressed:    result.suppressed,
+						Breakdown:     result.breakdown,
+						AutoApprove:   result.score >= m.Threshold,
 					}
 				}
 			}
 		}
 
-		// Try discovered examples (weight: 1.0x)
+		// Try discovered examples (lowest weight by default)
 		if len(pattern.Discovered) > 0 {
 			for _, discovered := range pattern.Discovered {
-				score, deviations := m.scoreAgainstDiscovered(file, discovered, filePath)
-				weightedScore := score * discovered.Weight // 1.0x
+				discovered := discovered
+				result := m.scoreAgainstDiscovered(c, discovered, pattern)
+				record(pattern.Type, result.score)
+				weightedScore := weigh(result.score, m.Weights.Discovered, bonus)
 
-				if weightedScore > bestScore {
-					bestScore = weightedScore
+				if conventional && m.prefer(c, weightedScore, pattern, bestMatch) {
 					bestMatch = &patterns.PatternMatch{
 						Pattern:       &pattern,
 						FilePath:      filePath,
-						Score:         score,
+						Score:         result.score,
 						WeightedScore: weightedScore,
 						MatchType:     "discovered",
 						DiscoveredRef: &discovered,
-						Deviations:    deviations,
-						AutoApprove:   score >= m.Threshold,
+						Deviations:    result.deviations,
+						Suppressed:    result.suppressed,
+						Breakdown:     result.breakdown,
+						AutoApprove:   result.score >= m.Threshold,
 					}
 				}
 			}
@@ -124,112 +320,636 @@ func (m *Matcher) MatchFile(filePath string) (*patterns.PatternMatch, error) {
 					Suggestion: "No matching pattern found. This may be a new pattern.",
 				},
 			},
-		}, nil
+			AntiPatternHits: m.antiPatternHits(c),
+		}
 	}
 
-	return bestMatch, nil
+	m.checkMixedResponsibilities(bestMatch, typeScores)
+	m.resolveVersion(bestMatch, c.imports)
+
+	// Resembling an anti-pattern always needs a human
+	bestMatch.AntiPatternHits = m.antiPatternHits(c)
+	if bestMatch.Breakdown != nil {
+		bestMatch.Breakdown.AntiPatternHits = len(bestMatch.AntiPatternHits)
+	}
+	if len(bestMatch.AntiPatternHits) > 0 {
+		bestMatch.AutoApprove = false
+	}
+
+	return bestMatch
+}
+
+// Mixed responsibilities are flagged when the best scores of two pattern
+// types are within mixedMargin points of each other and both at least
+// mixedMinScore
+const (
+	mixedMargin   = 5.0
+	mixedMinScore = 50.0
+)
+
+// checkMixedResponsibilities records the runner-up pattern types and flags
+// a file scoring about as well against another pattern type as its own,
+// such as a handler with a repository mixed in. Reference files are
+// usually single-purpose, so this suggests the file does too much.
+// typeScores holds the best score per pattern type from choosing the match;
+// the match is compared by its own score, before any other adjustments.
+func (m *Matcher) checkMixedResponsibilities(match *patterns.PatternMatch, typeScores map[patterns.PatternType]float64) {
+	for patternType, score := range typeScores {
+		if patternType != match.Pattern.Type {
+			match.RunnerUps = append(match.RunnerUps, patterns.PatternScore{Type: patternType, Score: score})
+		}
+	}
+	if len(match.RunnerUps) == 0 {
+		return
+	}
+	sort.Slice(match.RunnerUps, func(i, j int) bool {
+		if match.RunnerUps[i].Score != match.RunnerUps[j].Score {
+			return match.RunnerUps[i].Score > match.RunnerUps[j].Score
+		}
+		return match.RunnerUps[i].Type < match.RunnerUps[j].Type
+	})
+
+	best, second := match.Score, match.RunnerUps[0]
+	if best-second.Score > mixedMargin || second.Score < mixedMinScore || m.ignores("mixed_responsibilities") {
+		return
+	}
+	dev := patterns.Deviation{
+		Type:     patterns.DeviationDifferent,
+		Element:  "mixed_responsibilities",
+		Expected: "one responsibility per file",
+		Actual:   fmt.Sprintf("%s (%.0f%%) and %s (%.0f%%)", match.Pattern.Type, best, second.Type, second.Score),
+		Severity: patterns.SeverityWarning,
+		Suggestion: fmt.Sprintf("The file matches %s and %s almost equally; consider splitting it",
+			match.Pattern.Type, second.Type),
+	}
+	match.Deviations = append(match.Deviations, dev)
+	match.Score = math.Max(0, match.Score-m.penalty(dev))
+	if match.Breakdown != nil {
+		match.Breakdown.Checks += m.penalty(dev)
+	}
+	match.AutoApprove = match.AutoApprove && match.Score >= m.Threshold
+}
+
+// resolveVersion records which version of the pattern the file conforms to
+func (m *Matcher) resolveVersion(match *patterns.PatternMatch, fileImports []string) {
+	pattern := match.Pattern
+	match.MatchedVersion = pattern.Version
+	if containsAll(fileImports, pattern.Structure.Required) {
+		return
+	}
+
+	// Walk history from newest to oldest looking for a version the file satisfies
+	for i := len(pattern.History) - 1; i >= 0; i-- {
+		old := pattern.History[i]
+		if !containsAll(fileImports, old.Required) {
+			continue
+		}
+
+		match.MatchedVersion = old.Version
+		if m.ignores("pattern_version") {
+			return
+		}
+		severity := patterns.SeverityInfo
+		if m.StrictVersion {
+			severity = patterns.SeverityWarning
+			match.AutoApprove = false
+		}
+		match.Deviations = append(match.Deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "pattern_version",
+			Expected:   pattern.Version,
+			Actual:     old.Version,
+			Severity:   severity,
+			Suggestion: fmt.Sprintf("Matches %s v%s, current is v%s", pattern.Name, old.Version, pattern.Version),
+		})
+		return
+	}
+}
+
+// ignores checks if deviations of an element are ignored
+func (m *Matcher) ignores(element string) bool {
+	return contains(m.IgnoreDeviations, element)
+}
+
+// withoutIgnored drops the deviations of ignored elements
+func (m *Matcher) withoutIgnored(deviations []patterns.Deviation) []patterns.Deviation {
+	if len(m.IgnoreDeviations) == 0 {
+		return deviations
+	}
+	kept := []patterns.Deviation{}
+	for _, dev := range deviations {
+		if !m.ignores(dev.Element) {
+			kept = append(kept, dev)
+		}
+	}
+	return kept
+}
+
+// supersededVersions collects golden example versions replaced by newer goldens
+func supersededVersions(goldens []patterns.GoldenExample) map[string]bool {
+	superseded := make(map[string]bool)
+	for _, golden := range goldens {
+		if golden.Supersedes != "" {
+			superseded[golden.Supersedes] = true
+		}
+	}
+	return superseded
+}
+
+// prefer decides whether a new weighted score for pattern should replace the
+// current best match. Scores from different patterns within the tie epsilon
+// are broken by pattern confidence, then by how specifically the pattern's
+// detection rules match the file, so assignment doesn't flip on score noise.
+func (m *Matcher) prefer(c *candidate, weightedScore float64, pattern patterns.Pattern, best *patterns.PatternMatch) bool {
+	if best == nil {
+		return weightedScore > 0
+	}
+
+	diff := weightedScore - best.WeightedScore
+	if best.Pattern.ID == pattern.ID || math.Abs(diff) > m.Scoring.TieEpsilon {
+		return diff > 0
+	}
+
+	if pattern.Confidence != best.Pattern.Confidence {
+		return pattern.Confidence > best.Pattern.Confidence
+	}
+	specificity := detectionSpecificity(c, pattern.Detection)
+	bestSpecificity := detectionSpecificity(c, best.Pattern.Detection)
+	if specificity != bestSpecificity {
+		return specificity > bestSpecificity
+	}
+	return diff > 0
+}
+
+// detectionSpecificity counts the detection rules that positively match a
+// file. File and package rules are already known to match if set.
+func detectionSpecificity(c *candidate, rule patterns.DetectionRule) int {
+	specificity := 0
+	if rule.FilePattern != "" {
+		specificity++
+	}
+	if rule.PackagePath != "" {
+		specificity++
+	}
+	for _, expr := range []string{rule.FuncPattern, rule.StructPattern} {
+		if expr == "" {
+			continue
+		}
+		if re, err := regexp.Compile(expr); err == nil && re.Match(c.src) {
+			specificity++
+		}
+	}
+	return specificity
+}
+
+// blessedWeight is a blessed example's own weight, or the blessed tier
+// weight for examples blessed without one
+func (m *Matcher) blessedWeight(blessed patterns.BlessedExample) float64 {
+	if blessed.Weight > 0 {
+		return blessed.Weight
+	}
+	return m.Weights.Blessed
+}
+
+// weigh applies an example's weight and the path bonus to a score. Scores
+// of zero stay zero so the bonus alone never makes a match.
+func weigh(score, weight, bonus float64) float64 {
+	if score <= 0 {
+		return 0
+	}
+	return score*weight + bonus
+}
+
+// pathSpecificity is the fraction of a file's path spelled out by a
+// pattern's file and package rules: longer rules, and both rules matching,
+// are more specific. Rules are already known to match when set.
+func pathSpecificity(filePath string, rule patterns.DetectionRule) float64 {
+	path := "/" + filepath.ToSlash(filePath)
+	matched := len(strings.ReplaceAll(rule.FilePattern, "*", "")) +
+		len(strings.ReplaceAll(rule.PackagePath, "*", ""))
+	return math.Min(1, float64(matched)/float64(len(path)))
 }
 
 // shouldTryPattern checks if a file might match a pattern
 func (m *Matcher) shouldTryPattern(filePath string, pattern patterns.Pattern) bool {
+	return m.canCompare(filePath, pattern) && followsConventions(filePath, pattern.Detection)
+}
+
+// canCompare checks if a file can be scored against a pattern at all,
+// regardless of where the file lives
+func (m *Matcher) canCompare(filePath string, pattern patterns.Pattern) bool {
+	// Disabled patterns are kept in the config but never matched
+	if !pattern.IsEnabled() {
+		return false
+	}
+
+	// Check type filter
+	if len(m.TypeFilter) > 0 && !m.allowsType(pattern.Type) {
+		return false
+	}
+
+	// Patterns learned for one language never match another's files
+	if pattern.Language != "" && !patterns.SupportsFile(pattern.Language, filePath) {
+		return false
+	}
+
+	// Test files only match test patterns, and vice versa
+	return patterns.IsTestFile(filePath) == (pattern.Type == patterns.PatternTest)
+}
+
+// followsConventions checks if a file's path fits a pattern's detection rule
+func followsConventions(filePath string, rule patterns.DetectionRule) bool {
 	// Check file pattern
-	if pattern.Detection.FilePattern != "" {
+	if rule.FilePattern != "" {
 		// Simple glob matching
-		patternGlob := strings.ReplaceAll(pattern.Detection.FilePattern, "*", "")
+		patternGlob := strings.ReplaceAll(rule.FilePattern, "*", "")
 		if !strings.Contains(filePath, patternGlob) {
 			return false
 		}
 	}
 
 	// Check package path
-	if pattern.Detection.PackagePath != "" {
-		pkgPath := strings.ReplaceAll(pattern.Detection.PackagePath, "*", "")
-		if !strings.Contains(filePath, pkgPath) {
-			return false
-		}
+	if rule.PackagePath != "" && !inPackage(filePath, rule.PackagePath) {
+		return false
 	}
 
 	return true
 }
 
+// inPackage checks if a file lives under a package path rule such as
+// "*/services". The file's path is anchored with a leading slash, so the
+// rule matches the relative paths directory arguments are walked into,
+// like services/user.go, as well as internal/services/user.go.
+func inPackage(filePath, packagePath string) bool {
+	pkgPath := strings.ReplaceAll(packagePath, "*", "")
+	return strings.Contains("/"+filepath.ToSlash(filePath), pkgPath)
+}
+
+// allowsType checks if a pattern type passes the type filter
+func (m *Matcher) allowsType(patternType patterns.PatternType) bool {
+	for _, t := range m.TypeFilter {
+		if t == patternType {
+			return true
+		}
+	}
+	return false
+}
+
+// candidate holds the parsed file being matched. Go files carry their AST;
+// TypeScript/JavaScript and C# files carry the analyzer's text-based FileInfo.
+type candidate struct {
+	path          string
+	src           []byte
+	file          *ast.File          // nil for TypeScript/JavaScript and C#
+	info          *patterns.FileInfo // nil for Go
+	imports       []string
+	suppressions  []suppression
+	invalidAllows []patterns.Deviation // allow annotations missing a reason
+}
+
+// loadCandidate reads and parses a file for matching
+func loadCandidate(filePath string) (*candidate, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return parseCandidate(filePath, src)
+}
+
+// parseCandidate parses src as the contents of filePath for matching
+func parseCandidate(filePath string, src []byte) (*candidate, error) {
+	c := &candidate{path: filePath, src: src}
+
+	if isTypeScriptFile(filePath) || isCSharpFile(filePath) {
+		info := parseText(filePath, src)
+		c.info = info
+		c.imports = info.Imports
+	} else {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filePath, src, goParseMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse file: %w", err)
+		}
+		trackFileSet(file, fset)
+		c.file = file
+		c.imports = extractImports(file)
+	}
+
+	c.suppressions, c.invalidAllows = loadSuppressions(c)
+	return c, nil
+}
+
+// release drops bookkeeping held for the candidate's AST
+func (c *candidate) release() {
+	if c.file != nil {
+		fileSets.Delete(c.file)
+	}
+}
+
+// referenceScore is the outcome of comparing a candidate to one reference
+type referenceScore struct {
+	score      float64
+	deviations []patterns.Deviation
+	suppressed []patterns.SuppressedDeviation
+	breakdown  *patterns.ScoreBreakdown
+}
+
 // scoreAgainstGolden calculates similarity against a golden example
-func (m *Matcher) scoreAgainstGolden(file *ast.File, golden patterns.GoldenExample, filePath string) (float64, []patterns.Deviation) {
-	return m.scoreAgainstReference(file, golden.Path, filePath)
+func (m *Matcher) scoreAgainstGolden(c *candidate, golden patterns.GoldenExample, pattern patterns.Pattern) referenceScore {
+	return m.scoreAgainstReference(c, golden.Path, pattern)
 }
 
 // scoreAgainstBlessed calculates similarity against a blessed example
-func (m *Matcher) scoreAgainstBlessed(file *ast.File, blessed patterns.BlessedExample, filePath string) (float64, []patterns.Deviation) {
-	return m.scoreAgainstReference(file, blessed.Path, filePath)
+func (m *Matcher) scoreAgainstBlessed(c *candidate, blessed patterns.BlessedExample, pattern patterns.Pattern) referenceScore {
+	return m.scoreAgainstReference(c, blessed.Path, pattern)
 }
 
 // scoreAgainstDiscovered calculates similarity against a discovered example
-func (m *Matcher) scoreAgainstDiscovered(file *ast.File, discovered patterns.Example, filePath string) (float64, []patterns.Deviation) {
-	return m.scoreAgainstReference(file, discovered.Path, filePath)
+func (m *Matcher) scoreAgainstDiscovered(c *candidate, discovered patterns.Example, pattern patterns.Pattern) referenceScore {
+	return m.scoreAgainstReference(c, discovered.Path, pattern)
 }
 
 // scoreAgainstReference calculates similarity against a reference file
-func (m *Matcher) scoreAgainstReference(file *ast.File, referencePath string, filePath string) (float64, []patterns.Deviation) {
-	score := 100.0
-	deviations := []patterns.Deviation{}
+func (m *Matcher) scoreAgainstReference(c *candidate, referencePath string, pattern patterns.Pattern) referenceScore {
+	defer m.Profile.Pattern(pattern.ID, time.Now())
+	var deviations []patterns.Deviation
+	var structureSimilarity float64
+	var err error
+	if c.file == nil {
+		deviations, structureSimilarity, err = m.compareText(c, referencePath, pattern)
+	} else {
+		deviations, structureSimilarity, err = m.compareGo(c, referencePath, pattern)
+	}
+	if err != nil {
+		m.degraded(err, fmt.Sprintf("the default score (%.0f%%)", defaultReferenceScore))
+		return referenceScore{score: defaultReferenceScore, deviations: []patterns.Deviation{}}
+	}
+	return m.finishScore(c, deviations, structureSimilarity, pattern)
+}
+
+// finishScore applies test conventions and suppressions to a reference
+// comparison and turns it into a score
+func (m *Matcher) finishScore(c *candidate, deviations []patterns.Deviation, structureSimilarity float64, pattern patterns.Pattern) referenceScore {
+	// Check established test conventions
+	if pattern.Type == patterns.PatternTest {
+		deviations = append(deviations, testConventionDeviations(c, pattern)...)
+	}
+	if pattern.Type == patterns.PatternModel && c.file != nil {
+		deviations = append(deviations, modelFieldDeviations(c, pattern)...)
+	}
 
-	// Check required imports from file
-	fileImports := m.extractImports(file)
+	// Drop ignored deviations, and those the file explicitly allows,
+	// before scoring
+	deviations = m.withoutIgnored(deviations)
+	deviations, suppressed := applySuppressions(c, deviations)
+	deviations = append(deviations, c.invalidAllows...)
+
+	breakdown := &patterns.ScoreBreakdown{StructureSimilarity: structureSimilarity}
+	for _, dev := range deviations {
+		switch {
+		case dev.Type == patterns.DeviationMissing && dev.Element == "import":
+			breakdown.Imports += m.penalty(dev) * importance(pattern, dev.Expected)
+		case dev.Type == patterns.DeviationMissing && dev.Element == "error_handling":
+			breakdown.ErrorHandling += m.penalty(dev)
+		default:
+			breakdown.Checks += m.penalty(dev)
+		}
+	}
+	score := 100 - breakdown.Imports - breakdown.ErrorHandling - breakdown.Checks
+
+	// Apply structure similarity
+	structured := score * m.Scoring.structureFactor(structureSimilarity)
+	breakdown.Structure = score - structured
+
+	return referenceScore{
+		score:      math.Max(0, structured),
+		deviations: deviations,
+		breakdown:  breakdown,
+		suppressed: suppressed,
+	}
+}
+
+// compareGo compares a Go candidate to a Go reference file, returning the
+// deviations found and their structural similarity
+func (m *Matcher) compareGo(c *candidate, referencePath string, pattern patterns.Pattern) ([]patterns.Deviation, float64, error) {
+	file := c.file
+	deviations := []patterns.Deviation{}
 
 	// Parse reference file
+	refSrc, err := m.readReference(referencePath)
+	if err != nil {
+		return nil, 0, err
+	}
 	fset := token.NewFileSet()
-	refFile, err := parser.ParseFile(fset, referencePath, nil, parser.ParseComments)
+	refFile, err := parser.ParseFile(fset, referencePath, refSrc, goParseMode)
 	if err != nil {
-		return 50.0, deviations
+		return nil, 0, err
 	}
-
-	refImports := m.extractImports(refFile)
+	trackFileSet(refFile, fset)
+	defer fileSets.Delete(refFile)
 
 	// Check import similarity
+	importLine := 0
+	if len(file.Imports) > 0 {
+		importLine = LineOf(file, file.Imports[len(file.Imports)-1].Pos())
+	}
+	refImports := extractImports(refFile)
+	deviations = append(deviations, missingImports(c.imports, refImports, importLine)...)
+	importLines := make(map[string]int)
+	for _, imp := range file.Imports {
+		importLines[importPath(imp)] = LineOf(file, imp.Pos())
+	}
+	deviations = append(deviations, m.extraImports(c.imports, refImports, importLines, pattern.Type)...)
+
+	// Check for error handling patterns
+	hasErrorHandling := m.checkErrorHandling(file)
+	refHasErrorHandling := m.checkErrorHandling(refFile)
+
+	if refHasErrorHandling && !hasErrorHandling {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "error_handling",
+			Expected:   "proper error handling",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: "Add error handling like reference implementation",
+		})
+	}
+
+	// Run custom checks. Overlapping checks, like context-first-arg and
+	// context-propagation, may flag the same element on the same line;
+	// it's reported once.
+	reported := make(map[string]bool)
+	for _, check := range m.Checks {
+		found := check.Evaluate(file, refFile, pattern)
+		if pc, ok := check.(PathCheck); ok {
+			found = append(found, pc.EvaluatePath(c.path, file, pattern)...)
+		}
+		keys := []string{}
+		for _, dev := range found {
+			key := fmt.Sprintf("%s:%d", dev.Element, dev.LineNumber)
+			if dev.LineNumber > 0 && reported[key] {
+				continue
+			}
+			keys = append(keys, key)
+			deviations = append(deviations, dev)
+		}
+		for _, key := range keys {
+			reported[key] = true
+		}
+	}
+
+	return deviations, m.compareStructure(c.path, c.src, referencePath), nil
+}
+
+// readReference reads a reference file through ReadReference, or from disk
+func (m *Matcher) readReference(path string) ([]byte, error) {
+	if m.ReadReference != nil {
+		return m.ReadReference(path)
+	}
+	return os.ReadFile(path)
+}
+
+// missingImports flags reference imports the candidate lacks, pointing at
+// the candidate's import block so links land where the import belongs
+func missingImports(fileImports, refImports []string, importLine int) []patterns.Deviation {
+	deviations := []patterns.Deviation{}
 	for _, refImport := range refImports {
 		if !contains(fileImports, refImport) {
-			score -= 5.0
 			deviations = append(deviations, patterns.Deviation{
 				Type:       patterns.DeviationMissing,
 				Element:    "import",
 				Expected:   refImport,
 				Severity:   patterns.SeverityWarning,
 				Suggestion: fmt.Sprintf("Consider adding import: %s", refImport),
+				LineNumber: importLine,
 			})
 		}
 	}
+	return deviations
+}
 
-	// Check for error handling patterns
-	hasErrorHandling := m.checkErrorHandling(file)
-	refHasErrorHandling := m.checkErrorHandling(refFile)
+// modelFieldDeviations flags fields shared by the pattern's models that the
+// candidate's structs lack. Fields promoted from embedded structs declared
+// in the candidate's package count as present; if an embedded type can't be
+// resolved nothing is flagged, since it may provide the fields.
+func modelFieldDeviations(c *candidate, pattern patterns.Pattern) []patterns.Deviation {
+	required := []string{}
+	for _, elem := range pattern.Structure.Elements {
+		if elem.Type == patterns.ElementField {
+			required = append(required, elem.Name)
+		}
+	}
+	if len(required) == 0 {
+		return nil
+	}
 
-	if refHasErrorHandling && !hasErrorHandling {
-		score -= 10.0
+	info, err := analyzer.ParseGoSource(c.path, c.src)
+	if err != nil {
+		return nil
+	}
+	types := analyzer.TypesByName(append(packageSiblings(c.path), *info))
+
+	have := make(map[string]bool)
+	line := 0
+	for _, t := range info.Types {
+		if t.Kind != "struct" || !t.Exported {
+			continue
+		}
+		fields, ok := analyzer.StructFields(t, types)
+		if !ok {
+			return nil
+		}
+		for _, field := range fields {
+			have[field] = true
+		}
+		if line == 0 {
+			line = t.Line
+		}
+	}
+	if line == 0 {
+		return nil // No exported structs to compare
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, field := range required {
+		if have[field] {
+			continue
+		}
 		deviations = append(deviations, patterns.Deviation{
 			Type:       patterns.DeviationMissing,
-			Element:    "error_handling",
-			Expected:   "proper error handling",
+			Element:    "field",
+			Expected:   field,
 			Severity:   patterns.SeverityWarning,
-			Suggestion: "Add error handling like reference implementation",
+			Suggestion: fmt.Sprintf("Add the %s field, or embed the base struct that provides it, like other models", field),
+			LineNumber: line,
 		})
 	}
+	return deviations
+}
 
-	// Check structure similarity
-	structureSimilarity := m.compareStructure(filePath, referencePath)
-	score *= structureSimilarity
+// packageSiblings parses the other non-test Go files in a file's directory,
+// where embedded base structs are usually declared
+func packageSiblings(path string) []patterns.FileInfo {
+	siblings := []patterns.FileInfo{}
+	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.go"))
+	for _, sibling := range paths {
+		if filepath.Clean(sibling) == filepath.Clean(path) || patterns.IsTestFile(sibling) {
+			continue
+		}
+		src, err := os.ReadFile(sibling)
+		if err != nil {
+			continue
+		}
+		if info, err := analyzer.ParseGoSource(sibling, src); err == nil {
+			siblings = append(siblings, *info)
+		}
+	}
+	return siblings
+}
 
-	return math.Max(0, score), deviations
+// testConventionDeviations flags established test conventions a test file
+// doesn't follow
+func testConventionDeviations(c *candidate, pattern patterns.Pattern) []patterns.Deviation {
+	deviations := []patterns.Deviation{}
+	for _, elem := range pattern.Structure.Elements {
+		if elem.Type != patterns.ElementTestConvention {
+			continue
+		}
+		re, err := regexp.Compile(elem.Pattern)
+		if err != nil || re.Match(c.src) {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "test_convention",
+			Expected:   elem.Name,
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Follow the established test style: %s", strings.ReplaceAll(elem.Name, "_", " ")),
+		})
+	}
+	return deviations
 }
 
-// extractImports gets all imports from a file
-func (m *Matcher) extractImports(file *ast.File) []string {
+// extractImports gets the imports a file uses, dot imports included.
+// Blank imports are left out: they only run a package's init, e.g. to
+// register a database driver, which one file per program is enough for,
+// and a blank import doesn't make the package's names usable.
+func extractImports(file *ast.File) []string {
 	imports := []string{}
 	for _, imp := range file.Imports {
-		path := strings.Trim(imp.Path.Value, `"`)
-		imports = append(imports, path)
+		if imp.Name != nil && imp.Name.Name == "_" {
+			continue
+		}
+		imports = append(imports, importPath(imp))
 	}
 	return imports
 }
 
+// importPath returns an import spec's unquoted path
+func importPath(imp *ast.ImportSpec) string {
+	return strings.Trim(imp.Path.Value, `"`)
+}
+
 // checkErrorHandling checks if file has proper error handling
 func (m *Matcher) checkErrorHandling(file *ast.File) bool {
 	hasErrorCheck := false
@@ -252,30 +972,63 @@ func (m *Matcher) checkErrorHandling(file *ast.File) bool {
 	return hasErrorCheck
 }
 
-// compareStructure compares structural similarity between files
-func (m *Matcher) compareStructure(file1, file2 string) float64 {
-	// Parse both files
-	fset1 := token.NewFileSet()
-	ast1, err1 := parser.ParseFile(fset1, file1, nil, 0)
-	fset2 := token.NewFileSet()
-	ast2, err2 := parser.ParseFile(fset2, file2, nil, 0)
-
+// compareStructure compares structural similarity between files. The
+// second is a reference, read through readReference. Results are cached by
+// content, as the same pairs come up once per tier and for anti-patterns.
+func (m *Matcher) compareStructure(file1 string, src1 []byte, file2 string) float64 {
+	// Read both files, the first from src1 when given
+	var err1 error
+	if src1 == nil {
+		src1, err1 = os.ReadFile(file1)
+	}
+	src2, err2 := m.readReference(file2)
 	if err1 != nil || err2 != nil {
-		return 0.5 // Default similarity if we can't parse
+		m.degraded(errors.Join(err1, err2), structureFallback)
+		return defaultStructureSimilarity
+	}
+	compare := func(a, b map[string]int) float64 {
+		m.structureCompares.Add(1)
+		return m.Scoring.nodeSimilarity(a, b)
+	}
+	score, err := m.structures.similarity(file1, src1, file2, src2, m.countStructure, compare)
+	if err != nil {
+		m.degraded(err, structureFallback)
 	}
+	return score
+}
 
-	// Count node types in both files
-	counts1 := m.countNodeTypes(ast1)
-	counts2 := m.countNodeTypes(ast2)
+// structureFallback describes the default structural similarity to OnDegraded
+var structureFallback = fmt.Sprintf("the default structural similarity (%.0f%%)", defaultStructureSimilarity*100)
+
+// degraded reports a comparison that fell back to a default to OnDegraded,
+// each problem once however many files run into it
+func (m *Matcher) degraded(err error, fallback string) {
+	if m.OnDegraded == nil {
+		return
+	}
+	if _, seen := m.degradedSeen.LoadOrStore(err.Error(), true); seen {
+		return
+	}
+	m.OnDegraded(fmt.Errorf("%w; using %s, so scores involving it are less meaningful", err, fallback))
+}
 
-	// Calculate similarity using cosine similarity
-	return cosineSimilarity(counts1, counts2)
+// countStructure parses Go source and counts its node types
+func (m *Matcher) countStructure(path string, src []byte) (map[string]int, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, src, goParseMode)
+	if err != nil {
+		return nil, err
+	}
+	return m.countNodeTypes(file), nil
 }
 
-// countNodeTypes counts different AST node types
+// countNodeTypes counts different AST node types. Comments are left out,
+// so documenting a file doesn't change its structure.
 func (m *Matcher) countNodeTypes(file *ast.File) map[string]int {
 	counts := make(map[string]int)
 	ast.Inspect(file, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CommentGroup); ok {
+			return false
+		}
 		if n != nil {
 			nodeType := fmt.Sprintf("%T", n)
 			counts[nodeType]++
@@ -296,7 +1049,28 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+func containsAll(slice []string, items []string) bool {
+	for _, item := range items {
+		if !contains(slice, item) {
+			return false
+		}
+	}
+	return true
+}
+
 func cosineSimilarity(a, b map[string]int) float64 {
+	weightsA := make(map[string]float64, len(a))
+	for k, v := range a {
+		weightsA[k] = float64(v)
+	}
+	weightsB := make(map[string]float64, len(b))
+	for k, v := range b {
+		weightsB[k] = float64(v)
+	}
+	return cosineWeights(weightsA, weightsB)
+}
+
+func cosineWeights(a, b map[string]float64) float64 {
 	// Calculate dot product and magnitudes
 	dotProduct := 0.0
 	magA := 0.0
@@ -312,8 +1086,8 @@ func cosineSimilarity(a, b map[string]int) float64 {
 	}
 
 	for k := range keys {
-		valA := float64(a[k])
-		valB := float64(b[k])
+		valA := a[k]
+		valB := b[k]
 		dotProduct += valA * valB
 		magA += valA * valA
 		magB += valB * valB
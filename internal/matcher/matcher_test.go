@@ -0,0 +1,290 @@
+package matcher
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// writeTree creates files, keyed by slash-separated path, under a temporary
+// directory and returns it
+func writeTree(t testing.TB, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// declared is a hand-written pattern checking files against reference
+func declared(id string, patternType patterns.PatternType, reference string) patterns.Pattern {
+	return patterns.Pattern{
+		ID:         id,
+		Type:       patternType,
+		Detection:  patterns.DetectionRule{FilePattern: "*.go"},
+		Reference:  reference,
+		Confidence: 0.8,
+	}
+}
+
+func TestInPackage(t *testing.T) {
+	tests := []struct {
+		path, rule string
+		want       bool
+	}{
+		{"services/user.go", "*/services", true},
+		{"internal/services/user.go", "*/services", true},
+		{"/repo/services/user.go", "*/services", true},
+		{"myservices/user.go", "*/services", false},
+		{"handlers/user.go", "*/services", false},
+	}
+	for _, tt := range tests {
+		if got := inPackage(tt.path, tt.rule); got != tt.want {
+			t.Errorf("inPackage(%q, %q) = %v, want %v", tt.path, tt.rule, got, tt.want)
+		}
+	}
+}
+
+const testFileTableDriven = `package services
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	tests := []struct{ id string }{{"a"}}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {})
+	}
+}
+`
+
+const testFilePlain = `package services
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	if got := 1; got != 1 {
+		t.Fatal(got)
+	}
+}
+`
+
+func TestTestConventions(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref_test.go":   testFileTableDriven,
+		"services/plain_test.go": testFilePlain,
+		"services/table_test.go": testFileTableDriven,
+		"services/user.go":       "package services\n",
+	})
+	pattern := declared("tests", patterns.PatternTest, filepath.Join(root, "services/ref_test.go"))
+	pattern.Detection.FilePattern = "*_test.go"
+	pattern.Structure.Elements = []patterns.StructureElement{
+		{Name: "subtests", Type: patterns.ElementTestConvention, Pattern: `\bt\.Run\(`},
+		{Name: "table_driven", Type: patterns.ElementTestConvention, Pattern: `\[\]struct\s*\{`},
+	}
+	m := New([]patterns.Pattern{pattern}, 90)
+
+	match, err := m.MatchFile(filepath.Join(root, "services/plain_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, dev := range match.Deviations {
+		if dev.Element == "test_convention" {
+			got = append(got, dev.Expected)
+		}
+	}
+	if len(got) != 2 || got[0] != "subtests" || got[1] != "table_driven" {
+		t.Errorf("test convention deviations %v, want subtests and table_driven", got)
+	}
+
+	match, err = m.MatchFile(filepath.Join(root, "services/table_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dev := range match.Deviations {
+		if dev.Element == "test_convention" {
+			t.Errorf("table-driven test flagged: %+v", dev)
+		}
+	}
+
+	// Test patterns never match other files, nor other patterns test files
+	if match, err := m.MatchFile(filepath.Join(root, "services/user.go")); err != nil || match.Pattern != nil {
+		t.Errorf("user.go matched %v, %v; want no pattern", match.Pattern, err)
+	}
+}
+
+func TestMatchFileTooLarge(t *testing.T) {
+	big := scoringReference + "\nvar table = []string{" + strings.Repeat(`"row", `, 200) + "}\n"
+	root := writeTree(t, map[string]string{
+		"services/ref.go": scoringReference,
+		"services/big.go": big,
+	})
+	m := New([]patterns.Pattern{declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))}, 80)
+	m.MaxFileBytes = int64(len(scoringReference) + 100)
+
+	match, err := m.MatchFile(filepath.Join(root, "services/big.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType != "skipped" || match.AutoApprove || len(match.Deviations) != 1 ||
+		match.Deviations[0].Element != "file_size" || match.Deviations[0].Severity != patterns.SeverityInfo {
+		t.Errorf("oversized file match = %+v", match)
+	}
+
+	m.MaxFileBytes = -1
+	match, err = m.MatchFile(filepath.Join(root, "services/big.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType == "skipped" {
+		t.Error("file skipped with the size limit disabled")
+	}
+}
+
+// blockingCheck never finishes until release is closed
+type blockingCheck struct{ release chan struct{} }
+
+func (blockingCheck) Name() string { return "blocking" }
+
+func (c blockingCheck) Evaluate(file, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	<-c.release
+	return nil
+}
+
+func TestMatchFileTimeout(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringReference,
+	})
+	release := make(chan struct{})
+	defer close(release)
+
+	m := New([]patterns.Pattern{declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))}, 80)
+	m.Checks = []Check{blockingCheck{release}}
+	m.FileTimeout = 10 * time.Millisecond
+
+	match, err := m.MatchFile(filepath.Join(root, "services/user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType != "skipped" || match.AutoApprove || len(match.Deviations) != 1 || match.Deviations[0].Element != "timeout" {
+		t.Errorf("timed out match = %+v", match)
+	}
+}
+
+func TestMatchAgainstReferenceLimits(t *testing.T) {
+	big := scoringReference + "\nvar table = []string{" + strings.Repeat(`"row", `, 200) + "}\n"
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringReference,
+		"services/big.go":  big,
+	})
+	ref := filepath.Join(root, "services/ref.go")
+	m := New(nil, 80)
+	m.MaxFileBytes = int64(len(scoringReference) + 100)
+
+	match, err := m.MatchAgainstReference(filepath.Join(root, "services/big.go"), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType != "skipped" || match.AutoApprove || len(match.Deviations) != 1 || match.Deviations[0].Element != "file_size" {
+		t.Errorf("oversized file match = %+v", match)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	m.Checks = []Check{blockingCheck{release}}
+	m.FileTimeout = 10 * time.Millisecond
+	match, err = m.MatchAgainstReference(filepath.Join(root, "services/user.go"), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType != "skipped" || match.AutoApprove || len(match.Deviations) != 1 || match.Deviations[0].Element != "timeout" {
+		t.Errorf("timed out match = %+v", match)
+	}
+
+	// Within the limits the reference is matched as before
+	m = New(nil, 80)
+	match, err = m.MatchAgainstReference(filepath.Join(root, "services/user.go"), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.MatchType != "reference" || !match.AutoApprove || len(match.Deviations) != 0 || match.BlessedRef == nil || match.BlessedRef.Path != ref {
+		t.Errorf("reference match = %+v, want an approved reference match", match)
+	}
+	if _, err := m.MatchAgainstReference(filepath.Join(root, "services/missing.go"), ref); err == nil {
+		t.Error("MatchAgainstReference matched a missing file")
+	}
+}
+
+func TestModelFieldDeviations(t *testing.T) {
+	pattern := patterns.Pattern{Type: patterns.PatternModel}
+	for _, field := range []string{"CreatedAt", "ID", "UpdatedAt"} {
+		pattern.Structure.Elements = append(pattern.Structure.Elements,
+			patterns.StructureElement{Name: field, Type: patterns.ElementField})
+	}
+
+	tests := []struct {
+		file string
+		want []deviationAt
+	}{
+		{"embedded.go", []deviationAt{}},   // Promoted through two levels of embedding
+		{"gorm.go", []deviationAt{}},       // A well-known base struct
+		{"unresolved.go", []deviationAt{}}, // The embed may provide them
+		{"missing.go", []deviationAt{{"field", 4}, {"field", 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			c, err := loadCandidate(filepath.Join("testdata/models", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := modelFieldDeviations(c, pattern)
+			sameDeviations(t, got, tt.want)
+			for i, field := range []string{"CreatedAt", "UpdatedAt"}[:len(got)] {
+				if got[i].Expected != field {
+					t.Errorf("deviation %d expects %s, want %s", i, got[i].Expected, field)
+				}
+			}
+		})
+	}
+}
+
+func TestDisabledPatternSkipped(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  consensusSource("errors", "fmt"),
+		"services/user.go": consensusSource("errors", "fmt"),
+	})
+	pattern := declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))
+	file := filepath.Join(root, "services/user.go")
+
+	match, err := New([]patterns.Pattern{pattern}, 80).MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Pattern == nil || match.Pattern.ID != "service" {
+		t.Fatalf("enabled pattern not matched: %+v", match)
+	}
+
+	disabled := false
+	pattern.Enabled = &disabled
+	match, err = New([]patterns.Pattern{pattern}, 80).MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Pattern != nil {
+		t.Errorf("matched disabled pattern %s", match.Pattern.ID)
+	}
+}
@@ -0,0 +1,185 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// middlewareOrderCheck flags middleware chains that wrap handlers in a
+// different order than the reference, e.g. auth before logging
+type middlewareOrderCheck struct{}
+
+func (middlewareOrderCheck) Name() string { return "middleware-order" }
+
+func (middlewareOrderCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternMiddleware && pattern.Type != patterns.PatternHTTPHandler {
+		return nil
+	}
+
+	known := declaredMiddleware(file)
+	for name := range declaredMiddleware(ref) {
+		known[name] = true
+	}
+
+	expected := middlewareChain(ref, known)
+	actual, pos := middlewareChainPos(file, known)
+	if len(expected) < 2 || len(actual) < 2 {
+		return nil
+	}
+
+	// Only compare middleware both chains use
+	rank := make(map[string]int)
+	for i, name := range expected {
+		rank[name] = i
+	}
+	last := -1
+	for _, name := range actual {
+		r, ok := rank[name]
+		if !ok {
+			continue
+		}
+		if r < last {
+			return []patterns.Deviation{{
+				Type:       patterns.DeviationDifferent,
+				Element:    "middleware_order",
+				Expected:   strings.Join(expected, " → "),
+				Actual:     strings.Join(actual, " → "),
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Wrap handlers in the established order: %s → handler", strings.Join(expected, " → ")),
+				LineNumber: LineOf(file, pos),
+			}}
+		}
+		last = r
+	}
+	return nil
+}
+
+// middlewareChain returns the outermost-first middleware order used in a file
+func middlewareChain(file *ast.File, known map[string]bool) []string {
+	chain, _ := middlewareChainPos(file, known)
+	return chain
+}
+
+// middlewareChainPos finds a file's middleware chain and where it starts.
+// Router .Use(...) registrations of middleware take precedence over nested
+// wrapping calls like Recovery(Logging(Auth(h))). Either way only calls that
+// look like middleware count, so db.Use(plugin) or http.HandlerFunc(f) don't.
+func middlewareChainPos(file *ast.File, known map[string]bool) ([]string, token.Pos) {
+	var used []string
+	var usePos token.Pos
+	var nested []string
+	var nestedPos token.Pos
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Use" {
+				registered := false
+				for _, arg := range call.Args {
+					if name, pkg := calleeName(arg); name != "" && isMiddleware(name, pkg, known) {
+						used = append(used, name)
+						registered = true
+					}
+				}
+				if registered && !usePos.IsValid() {
+					usePos = call.Pos()
+				}
+				return true
+			}
+
+			if chain := wrappingChain(call, known); len(chain) > len(nested) {
+				nested = chain
+				nestedPos = call.Pos()
+			}
+			return true
+		})
+	}
+
+	if len(used) > 0 {
+		return used, usePos
+	}
+	return nested, nestedPos
+}
+
+// wrappingChain follows single-argument calls inward while they look like
+// middleware, e.g. Recovery(Logging(h)) yields [Recovery Logging]
+func wrappingChain(call *ast.CallExpr, known map[string]bool) []string {
+	chain := []string{}
+	for {
+		name, pkg := calleeName(call.Fun)
+		if name == "" || len(call.Args) != 1 || !isMiddleware(name, pkg, known) {
+			break
+		}
+		chain = append(chain, name)
+
+		inner, ok := call.Args[0].(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		call = inner
+	}
+	return chain
+}
+
+// calleeName extracts the function name and package qualifier of an
+// expression like Logging, middleware.Logging or middleware.Logging(cfg)
+func calleeName(expr ast.Expr) (string, string) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, ""
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			return e.Sel.Name, pkg.Name
+		}
+		return e.Sel.Name, ""
+	case *ast.CallExpr:
+		return calleeName(e.Fun)
+	}
+	return "", ""
+}
+
+// isMiddleware checks if a call looks like a middleware wrapper
+func isMiddleware(name, pkg string, known map[string]bool) bool {
+	return known[name] ||
+		strings.HasSuffix(name, "Middleware") ||
+		strings.Contains(strings.ToLower(pkg), "middleware")
+}
+
+// isHandlerType checks if a type expression is http.Handler or http.HandlerFunc
+func isHandlerType(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "http" && (sel.Sel.Name == "Handler" || sel.Sel.Name == "HandlerFunc")
+}
+
+// declaredMiddleware lists functions whose first parameter is the next
+// http.Handler or http.HandlerFunc being wrapped
+func declaredMiddleware(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Type.Params == nil || len(fn.Type.Params.List) == 0 {
+			continue
+		}
+		if isHandlerType(fn.Type.Params.List[0].Type) {
+			names[fn.Name.Name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,48 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	ref := parseFixture(t, "middleware/reference.go")
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+	check := middlewareOrderCheck{}
+
+	// The reference's order, split across Use calls, beside unrelated ones
+	got := check.Evaluate(parseFixture(t, "middleware/ordered.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "middleware/misordered.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{{"middleware_order", 11}})
+	if got[0].Expected != "Recovery → Logging → Auth" || got[0].Actual != "Recovery → Auth → Logging" {
+		t.Errorf("deviation = %+v, want Recovery → Logging → Auth", got[0])
+	}
+
+	// Unrelated Use calls don't hide a nested chain, and plain wrapping
+	// calls don't make a longer one
+	got = check.Evaluate(parseFixture(t, "middleware/wrapped.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{{"middleware_order", 14}})
+	if got[0].Actual != "Recovery → Auth → Logging" {
+		t.Errorf("deviation = %+v, want the nested Recovery → Auth → Logging", got[0])
+	}
+
+	// Only handler and middleware patterns are checked
+	got = check.Evaluate(parseFixture(t, "middleware/misordered.go"), ref, patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	file := parseFixture(t, "middleware/ordered.go")
+	known := declaredMiddleware(parseFixture(t, "middleware/reference.go"))
+	chain, _ := middlewareChainPos(file, known)
+	if len(chain) != 3 || chain[0] != "Recovery" || chain[1] != "Logging" || chain[2] != "Auth" {
+		t.Errorf("chain = %v, want Recovery, Logging and Auth without plugin or cfg", chain)
+	}
+	// Nothing declared or named like middleware is registered
+	if chain, _ := middlewareChainPos(file, map[string]bool{}); len(chain) != 0 {
+		t.Errorf("chain with no known middleware = %v, want none", chain)
+	}
+}
@@ -0,0 +1,81 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// mixedPatterns are a handler and a repository pattern, each declared
+// with a single-purpose reference from testdata/mixed
+func mixedPatterns(t *testing.T) []patterns.Pattern {
+	t.Helper()
+	dir, err := filepath.Abs("testdata/mixed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []patterns.Pattern{
+		declared("handler", patterns.PatternHTTPHandler, filepath.Join(dir, "handler_ref.go")),
+		declared("repository", patterns.PatternRepository, filepath.Join(dir, "repository_ref.go")),
+	}
+}
+
+// mixedDeviation returns a match's mixed responsibilities deviation, if any
+func mixedDeviation(match *patterns.PatternMatch) *patterns.Deviation {
+	for i, dev := range match.Deviations {
+		if dev.Element == "mixed_responsibilities" {
+			return &match.Deviations[i]
+		}
+	}
+	return nil
+}
+
+func TestMixedResponsibilities(t *testing.T) {
+	m := New(mixedPatterns(t), 80)
+
+	match, err := m.MatchFile("testdata/mixed/mixed.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := mixedDeviation(match)
+	if dev == nil {
+		t.Fatalf("no mixed responsibilities deviation; matched %s at %g, runner-ups %+v", match.Pattern.ID, match.Score, match.RunnerUps)
+	}
+	if dev.Severity != patterns.SeverityWarning {
+		t.Errorf("deviation severity %s, want warning", dev.Severity)
+	}
+	if len(match.RunnerUps) != 1 || match.RunnerUps[0].Type == match.Pattern.Type {
+		t.Errorf("runner-ups %+v, want the other pattern type", match.RunnerUps)
+	}
+
+	match, err = m.MatchFile("testdata/mixed/handler.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Pattern.ID != "handler" || mixedDeviation(match) != nil {
+		t.Errorf("single-purpose handler: matched %s with deviations %v", match.Pattern.ID, deviationsAt(match.Deviations))
+	}
+	if len(match.RunnerUps) != 1 || match.RunnerUps[0].Type != patterns.PatternRepository || match.RunnerUps[0].Score >= match.Score {
+		t.Errorf("runner-ups %+v, want the repository scoring below %g", match.RunnerUps, match.Score)
+	}
+}
+
+func TestMixedResponsibilitiesOutsideConventions(t *testing.T) {
+	// The repository pattern's path conventions exclude the file, so it
+	// can't be the match, but the file still scores about as well against it
+	pats := mixedPatterns(t)
+	pats[1].Detection.PackagePath = "*/repository"
+	m := New(pats, 80)
+
+	match, err := m.MatchFile("testdata/mixed/mixed.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Pattern.ID != "handler" {
+		t.Errorf("matched %s, want handler", match.Pattern.ID)
+	}
+	if mixedDeviation(match) == nil {
+		t.Errorf("no mixed responsibilities deviation; score %g, runner-ups %+v", match.Score, match.RunnerUps)
+	}
+}
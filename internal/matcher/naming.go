@@ -0,0 +1,250 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// NamingCheckName is the config name of the naming convention check
+const NamingCheckName = "naming-conventions"
+
+// Naming conventions for exported names
+const (
+	NamingMixedCaps  = "mixed_caps"  // No underscores, Go's convention
+	NamingPascalCase = "pascal_case" // MixedCaps starting upper case
+	NamingCamelCase  = "camel_case"  // MixedCaps starting lower case
+	NamingHook       = "hook"        // useXxx
+	NamingNone       = "none"        // Not checked
+)
+
+// NamingConventions lists the conventions a pattern type can be given
+var NamingConventions = []string{NamingMixedCaps, NamingPascalCase, NamingCamelCase, NamingHook, NamingNone}
+
+// IsNamingConvention checks if a name is one of NamingConventions
+func IsNamingConvention(name string) bool {
+	return contains(NamingConventions, name)
+}
+
+// DefaultNamingConventions are the conventions for exported names out of
+// the box: TypeScript components are PascalCase and hooks useXxx. Go
+// pattern types not listed here use mixed_caps.
+var DefaultNamingConventions = map[patterns.PatternType]string{
+	patterns.PatternComponent: NamingPascalCase,
+	patterns.PatternHook:      NamingHook,
+}
+
+// goTestFuncPrefixes start Go test functions, whose names may use
+// underscores, e.g. TestParse_empty or ExampleClient_Do
+var goTestFuncPrefixes = []string{"Test", "Benchmark", "Example", "Fuzz"}
+
+// namingCheck flags exported names breaking their pattern type's naming
+// convention, such as snake_case Go functions or a hook not named useXxx,
+// where the reference's exported names follow it
+type namingCheck struct {
+	conventions map[patterns.PatternType]string
+}
+
+// NewNamingCheck creates the naming convention check with conventions
+// per pattern type overriding DefaultNamingConventions
+func NewNamingCheck(conventions map[patterns.PatternType]string) Check {
+	c := namingCheck{conventions: make(map[patterns.PatternType]string)}
+	for _, byType := range []map[patterns.PatternType]string{DefaultNamingConventions, conventions} {
+		for patternType, convention := range byType {
+			c.conventions[patternType] = convention
+		}
+	}
+	return c
+}
+
+func (namingCheck) Name() string { return NamingCheckName }
+
+func (c namingCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	convention, ok := c.conventions[pattern.Type]
+	if !ok {
+		convention = NamingMixedCaps
+	}
+	if convention == NamingNone {
+		return nil
+	}
+	for _, name := range exportedGoNames(ref) {
+		if !follows(name.name, convention) {
+			return nil // The reference doesn't follow the convention
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, name := range exportedGoNames(file) {
+		if !follows(name.name, convention) {
+			deviations = append(deviations, namingDeviation(name.kind, name.name, convention, LineOf(file, name.pos.Pos())))
+		}
+	}
+	return deviations
+}
+
+// EvaluateSource runs the check on the exported functions of
+// TypeScript/JavaScript files
+func (c namingCheck) EvaluateSource(src, refSrc []byte, pattern patterns.Pattern) []patterns.Deviation {
+	convention, ok := c.conventions[pattern.Type]
+	if !ok || convention == NamingNone {
+		return nil
+	}
+	for _, fn := range analyzer.ParseTypeScriptSource("", refSrc).Functions {
+		if fn.Exported && !follows(fn.Name, convention) {
+			return nil // The reference doesn't follow the convention
+		}
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range analyzer.ParseTypeScriptSource("", src).Functions {
+		if fn.Exported && !follows(fn.Name, convention) {
+			deviations = append(deviations, namingDeviation("function", fn.Name, convention, fn.Line))
+		}
+	}
+	return deviations
+}
+
+// namingDeviation reports a name breaking a convention, suggesting a rename
+func namingDeviation(kind, name, convention string, line int) patterns.Deviation {
+	rename := conventional(name, convention)
+	return patterns.Deviation{
+		Type:       patterns.DeviationDifferent,
+		Element:    "naming_convention",
+		Expected:   fmt.Sprintf("%s (%s)", rename, convention),
+		Actual:     fmt.Sprintf("%s %s", kind, name),
+		Severity:   patterns.SeverityInfo,
+		Suggestion: fmt.Sprintf("Rename %s to %s, following the %s convention like the reference", name, rename, convention),
+		LineNumber: line,
+	}
+}
+
+// goName is an exported Go identifier
+type goName struct {
+	kind string // function, method, type or field
+	name string
+	pos  ast.Node
+}
+
+// exportedGoNames lists a file's exported functions, methods, types and
+// struct fields. Test functions are left out: underscores are conventional
+// in their names.
+func exportedGoNames(file *ast.File) []goName {
+	names := []goName{}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() || isGoTestFunc(decl) {
+				continue
+			}
+			kind := "function"
+			if decl.Recv != nil {
+				kind = "method"
+			}
+			names = append(names, goName{kind: kind, name: decl.Name.Name, pos: decl.Name})
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if ts.Name.IsExported() {
+					names = append(names, goName{kind: "type", name: ts.Name.Name, pos: ts.Name})
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					for _, id := range field.Names {
+						if id.IsExported() {
+							names = append(names, goName{kind: "field", name: id.Name, pos: id})
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// isGoTestFunc checks if a function is a test, benchmark, example or fuzz
+// function
+func isGoTestFunc(fn *ast.FuncDecl) bool {
+	if fn.Recv != nil {
+		return false
+	}
+	for _, prefix := range goTestFuncPrefixes {
+		if strings.HasPrefix(fn.Name.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// follows checks if a name follows a naming convention
+func follows(name, convention string) bool {
+	if name == "" || strings.Contains(strings.Trim(name, "_"), "_") {
+		return false
+	}
+	first := []rune(name)[0]
+	switch convention {
+	case NamingPascalCase:
+		return unicode.IsUpper(first)
+	case NamingCamelCase:
+		return unicode.IsLower(first)
+	case NamingHook:
+		rest := []rune(strings.TrimPrefix(name, "use"))
+		return strings.HasPrefix(name, "use") && (len(rest) == 0 || unicode.IsUpper(rest[0]))
+	}
+	return true
+}
+
+// conventional renames a name to follow a naming convention, e.g.
+// Get_user_id to GetUserId under mixed_caps or fetchData to useFetchData
+// under hook
+func conventional(name, convention string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' })
+	if len(words) == 0 {
+		return name
+	}
+	for i := 1; i < len(words); i++ {
+		words[i] = upperFirst(words[i])
+	}
+	joined := strings.Join(words, "")
+
+	switch convention {
+	case NamingPascalCase:
+		return upperFirst(joined)
+	case NamingCamelCase:
+		return lowerFirst(joined)
+	case NamingHook:
+		// Only a use word is dropped: userOrders becomes useUserOrders
+		if rest := []rune(joined); len(rest) > 3 && strings.EqualFold(string(rest[:3]), "use") && unicode.IsUpper(rest[3]) {
+			joined = string(rest[3:])
+		}
+		return "use" + upperFirst(joined)
+	}
+	return joined
+}
+
+// upperFirst upper-cases a word's first letter
+func upperFirst(word string) string {
+	runes := []rune(word)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// lowerFirst lower-cases a word's first letter
+func lowerFirst(word string) string {
+	runes := []rune(word)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+	return string(runes)
+}
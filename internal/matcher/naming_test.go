@@ -0,0 +1,90 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestNamingConventions(t *testing.T) {
+	ref := parseFixture(t, "naming/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := NewNamingCheck(nil)
+
+	// Unexported names, blank fields and test functions aren't checked
+	got := check.Evaluate(parseFixture(t, "naming/consistent.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "naming/violating.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"naming_convention", 5},  // Order_Service
+		{"naming_convention", 6},  // Max_items
+		{"naming_convention", 9},  // New_order_service
+		{"naming_convention", 13}, // Cancel_order
+	})
+	if got[0].Severity != patterns.SeverityInfo || got[0].Actual != "type Order_Service" || got[0].Expected != "OrderService (mixed_caps)" {
+		t.Errorf("type deviation = %+v", got[0])
+	}
+	if got[3].Suggestion != "Rename Cancel_order to CancelOrder, following the mixed_caps convention like the reference" {
+		t.Errorf("method deviation = %+v", got[3])
+	}
+
+	// A reference breaking the convention sets no expectation
+	got = check.Evaluate(parseFixture(t, "naming/violating.go"), parseFixture(t, "naming/violating.go"), service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// Nor does a pattern type configured not to be checked
+	got = NewNamingCheck(map[patterns.PatternType]string{patterns.PatternService: NamingNone}).Evaluate(parseFixture(t, "naming/violating.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestNamingConventionsTypeScript(t *testing.T) {
+	check := NewNamingCheck(nil).(namingCheck)
+
+	got := check.EvaluateSource(readFixture(t, "naming/component_violating.tsx"), readFixture(t, "naming/component.tsx"), patterns.Pattern{Type: patterns.PatternComponent})
+	sameDeviations(t, got, []deviationAt{
+		{"naming_convention", 3}, // userCard
+		{"naming_convention", 7}, // User_badge
+	})
+	if got[0].Expected != "UserCard (pascal_case)" {
+		t.Errorf("component deviation = %+v, want UserCard", got[0])
+	}
+
+	got = check.EvaluateSource(readFixture(t, "naming/hook_violating.ts"), readFixture(t, "naming/hook.ts"), patterns.Pattern{Type: patterns.PatternHook})
+	sameDeviations(t, got, []deviationAt{
+		{"naming_convention", 3},  // fetchOrders
+		{"naming_convention", 11}, // user_orders
+		{"naming_convention", 15}, // useful isn't useXxx
+	})
+	if got[0].Expected != "useFetchOrders (hook)" || got[1].Expected != "useUserOrders (hook)" {
+		t.Errorf("hook deviations = %+v", got)
+	}
+
+	// TypeScript types without a convention of their own aren't checked
+	got = check.EvaluateSource(readFixture(t, "naming/hook_violating.ts"), readFixture(t, "naming/hook.ts"), patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Conventions are configurable per pattern type
+	camel := NewNamingCheck(map[patterns.PatternType]string{patterns.PatternService: NamingCamelCase}).(namingCheck)
+	got = camel.EvaluateSource(readFixture(t, "naming/hook_violating.ts"), readFixture(t, "naming/hook.ts"), patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{
+		{"naming_convention", 11}, // user_orders
+	})
+}
+
+func TestConventional(t *testing.T) {
+	tests := []struct{ name, convention, want string }{
+		{"Get_user_id", NamingMixedCaps, "GetUserId"},
+		{"get_user", NamingPascalCase, "GetUser"},
+		{"User_card", NamingCamelCase, "userCard"},
+		{"fetchData", NamingHook, "useFetchData"},
+		{"use_data", NamingHook, "useData"},
+		{"user_orders", NamingHook, "useUserOrders"},
+		{"useful", NamingHook, "useUseful"},
+	}
+	for _, tt := range tests {
+		if got := conventional(tt.name, tt.convention); got != tt.want {
+			t.Errorf("conventional(%q, %s) = %q, want %q", tt.name, tt.convention, got, tt.want)
+		}
+	}
+}
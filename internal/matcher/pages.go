@@ -0,0 +1,153 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// dataFetchingExports are the Next.js exports a page uses to load data and
+// metadata
+var dataFetchingExports = []string{
+	"getServerSideProps",
+	"getStaticProps",
+	"getStaticPaths",
+	"generateMetadata",
+	"generateStaticParams",
+	"metadata",
+}
+
+var (
+	// defaultExport matches export default ... and export { X as default }
+	defaultExport = regexp.MustCompile(`(?m)^\s*export\s+(default\b|\{[^}]*\bas\s+default\b)`)
+	// namedExport matches declarations exported by name
+	namedExport = regexp.MustCompile(`(?m)^\s*export\s+(?:async\s+)?(?:const|let|var|function\*?|class)\s+(\w+)`)
+	// exportList matches export { A, B as C } lists
+	exportList = regexp.MustCompile(`(?m)^\s*export\s*\{([^}]*)\}`)
+	// storyMetaKey matches the title or component key of a story file's
+	// default export
+	storyMetaKey = regexp.MustCompile(`(?m)^\s*(title|component)\s*:`)
+)
+
+// tsExports lists the names a TypeScript/JavaScript source exports, with
+// "default" for a default export
+func tsExports(src string) []string {
+	names := []string{}
+	if defaultExport.MatchString(src) {
+		names = append(names, "default")
+	}
+	for _, m := range namedExport.FindAllStringSubmatch(src, -1) {
+		names = append(names, m[1])
+	}
+	for _, m := range exportList.FindAllStringSubmatch(src, -1) {
+		for _, item := range strings.Split(m[1], ",") {
+			fields := strings.Fields(item)
+			if len(fields) == 0 {
+				continue
+			}
+			// Exported under the alias, if any: X as Y exports Y
+			if name := fields[len(fields)-1]; name != "default" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// frameworkDeviations applies what the framework expects of pages and
+// stories, consistent with the reference: a Next.js page keeps its default
+// export and the data fetching exports the reference has, and a Storybook
+// file has a default export with a title or component and at least one
+// named story, and a component types its props as strictly as the reference
+func frameworkDeviations(src, refSrc []byte, pattern patterns.Pattern) []patterns.Deviation {
+	switch pattern.Type {
+	case patterns.PatternComponent:
+		return componentDeviations(string(src), string(refSrc))
+	case patterns.PatternPage:
+		return pageDeviations(string(src), string(refSrc))
+	case patterns.PatternStorybook:
+		return storyDeviations(string(src), string(refSrc))
+	}
+	return nil
+}
+
+// pageDeviations flags a page missing the default export or data fetching
+// exports its reference has
+func pageDeviations(src, refSrc string) []patterns.Deviation {
+	have, want := tsExports(src), tsExports(refSrc)
+	deviations := []patterns.Deviation{}
+	if contains(want, "default") && !contains(have, "default") {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "default_export",
+			Expected:   "export default",
+			Severity:   patterns.SeverityError,
+			Suggestion: "Export the page component as the default export, like the reference",
+		})
+	}
+	for _, name := range dataFetchingExports {
+		if contains(want, name) && !contains(have, name) {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "data_fetching",
+				Expected:   name,
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Export %s like the reference page", name),
+			})
+		}
+	}
+	return deviations
+}
+
+// storyDeviations flags a story file missing its default export, the
+// title/component keys its reference sets, or named stories
+func storyDeviations(src, refSrc string) []patterns.Deviation {
+	have := tsExports(src)
+	deviations := []patterns.Deviation{}
+	if !contains(have, "default") {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "default_export",
+			Expected:   "export default { title, component }",
+			Severity:   patterns.SeverityError,
+			Suggestion: "Add a default export describing the stories, e.g. export default { title: 'Button', component: Button }",
+		})
+	} else {
+		keys := storyMetaKeys(src)
+		for _, key := range storyMetaKeys(refSrc) {
+			if !contains(keys, key) {
+				deviations = append(deviations, patterns.Deviation{
+					Type:       patterns.DeviationMissing,
+					Element:    "story_meta",
+					Expected:   key,
+					Severity:   patterns.SeverityWarning,
+					Suggestion: fmt.Sprintf("Set %s in the default export, like the reference", key),
+				})
+			}
+		}
+	}
+
+	if len(have) == 0 || (len(have) == 1 && have[0] == "default") {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "story_export",
+			Expected:   "at least one named story export",
+			Severity:   patterns.SeverityError,
+			Suggestion: "Export at least one story, e.g. export const Primary = {}",
+		})
+	}
+	return deviations
+}
+
+// storyMetaKeys lists which of title and component a story file sets
+func storyMetaKeys(src string) []string {
+	keys := []string{}
+	for _, m := range storyMetaKey.FindAllStringSubmatch(src, -1) {
+		if !contains(keys, m[1]) {
+			keys = append(keys, m[1])
+		}
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+package matcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// readFixture reads a testdata file
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+// missingElements lists the element and expected value of each deviation
+func missingElements(deviations []patterns.Deviation) []string {
+	missing := []string{}
+	for _, dev := range deviations {
+		if dev.Type != patterns.DeviationMissing {
+			continue
+		}
+		missing = append(missing, dev.Element+":"+dev.Expected)
+	}
+	return missing
+}
+
+func TestTSExports(t *testing.T) {
+	got := tsExports(string(readFixture(t, "pages/complete.tsx")))
+	want := []string{"default", "generateMetadata"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tsExports = %v, want %v", got, want)
+	}
+}
+
+func TestPageDeviations(t *testing.T) {
+	page := patterns.Pattern{Type: patterns.PatternPage}
+	ref := readFixture(t, "pages/reference.tsx")
+
+	got := missingElements(frameworkDeviations(readFixture(t, "pages/missing_default.tsx"), ref, page))
+	want := []string{"default_export:export default", "data_fetching:generateMetadata"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("page missing its default export: deviations %v, want %v", got, want)
+	}
+
+	if got := frameworkDeviations(readFixture(t, "pages/complete.tsx"), ref, page); len(got) != 0 {
+		t.Errorf("complete page: deviations %v, want none", missingElements(got))
+	}
+
+	// The same file as a plain module isn't held to page conventions
+	module := patterns.Pattern{Type: patterns.PatternUtil}
+	if got := frameworkDeviations(readFixture(t, "pages/missing_default.tsx"), ref, module); len(got) != 0 {
+		t.Errorf("utility: deviations %v, want none", missingElements(got))
+	}
+}
+
+func TestStoryDeviations(t *testing.T) {
+	story := patterns.Pattern{Type: patterns.PatternStorybook}
+	ref := readFixture(t, "pages/reference.stories.tsx")
+
+	got := missingElements(frameworkDeviations(readFixture(t, "pages/no_named.stories.tsx"), ref, story))
+	want := []string{"story_meta:component", "story_export:at least one named story export"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("story missing named exports: deviations %v, want %v", got, want)
+	}
+
+	got = missingElements(frameworkDeviations(readFixture(t, "pages/no_default.stories.tsx"), ref, story))
+	want = []string{"default_export:export default { title, component }"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("story missing its default export: deviations %v, want %v", got, want)
+	}
+
+	if got := frameworkDeviations(ref, ref, story); len(got) != 0 {
+		t.Errorf("reference story: deviations %v, want none", missingElements(got))
+	}
+}
@@ -0,0 +1,120 @@
+package matcher
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+var (
+	// propsDecl matches a props interface or type alias, e.g. interface
+	// ButtonProps or type Props =
+	propsDecl = regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:interface\s+\w*Props\b|type\s+\w*Props\s*=)`)
+	// propsUse matches a props type used in an annotation or type argument,
+	// e.g. ({ label }: ButtonProps) or FC<ButtonProps>
+	propsUse = regexp.MustCompile(`[:<]\s*\w*Props\b`)
+	// takesProps matches a component function taking props, destructured
+	// or not
+	takesProps = regexp.MustCompile(`(?:function\s+[A-Z]\w*|=)\s*\(\s*(?:\{|props\b)`)
+	// anyType matches an explicit any, e.g. props: any or x as any
+	anyType = regexp.MustCompile(`:\s*any\b|<any>|\bas\s+any\b`)
+	// fcType matches a component typed as React.FC or FunctionComponent,
+	// capturing its type argument's opening bracket if it has one
+	fcType = regexp.MustCompile(`:\s*(?:React\.)?(?:FC|FunctionComponent)\b(\s*<)?`)
+)
+
+// componentDeviations flags a React component whose props are typed less
+// strictly than its reference's: no props type where the reference
+// declares one, explicit any where the reference has none, or React.FC
+// without a props type argument where the reference always gives one
+func componentDeviations(src, refSrc string) []patterns.Deviation {
+	deviations := []patterns.Deviation{}
+
+	if propsDecl.MatchString(refSrc) && takesProps.MatchString(src) &&
+		!propsDecl.MatchString(src) && !propsUse.MatchString(src) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "props_type",
+			Expected:   "a Props interface",
+			Actual:     "untyped props",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: "Declare the component's props in an interface, e.g. interface ButtonProps, and annotate the props with it like the reference",
+			LineNumber: firstLine(src, takesProps),
+		})
+	}
+
+	if len(typedLines(refSrc, anyType)) == 0 {
+		if lines := typedLines(src, anyType); len(lines) > 0 {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationDifferent,
+				Element:    "any_type",
+				Expected:   "specific types",
+				Actual:     "explicit any on " + joinLines(lines),
+				Severity:   patterns.SeverityWarning,
+				Suggestion: "Replace any with the actual types; the reference component types everything",
+				LineNumber: lines[0],
+			})
+		}
+	}
+
+	if fcType.MatchString(refSrc) && !bareFC(refSrc) && bareFC(src) {
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "props_type",
+			Expected:   "React.FC<Props>",
+			Actual:     "React.FC without a props type",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: "Give React.FC the props type, e.g. React.FC<ButtonProps>, like the reference",
+			LineNumber: firstLine(src, fcType),
+		})
+	}
+	return deviations
+}
+
+// bareFC checks if src types a component as React.FC without a type argument
+func bareFC(src string) bool {
+	for _, m := range fcType.FindAllStringSubmatch(src, -1) {
+		if m[1] == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// typedLines lists the lines outside comments that re matches
+func typedLines(src string, re *regexp.Regexp) []int {
+	lines := []int{}
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "/*") {
+			continue
+		}
+		if re.MatchString(line) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// firstLine is the line of re's first match in src, or 0
+func firstLine(src string, re *regexp.Regexp) int {
+	loc := re.FindStringIndex(src)
+	if loc == nil {
+		return 0
+	}
+	return strings.Count(src[:loc[0]], "\n") + 1
+}
+
+// joinLines lists line numbers for a message, e.g. "line 3" or "lines 3, 7"
+func joinLines(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = strconv.Itoa(line)
+	}
+	if len(parts) == 1 {
+		return "line " + parts[0]
+	}
+	return "lines " + strings.Join(parts, ", ")
+}
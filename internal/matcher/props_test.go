@@ -0,0 +1,49 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestComponentDeviations(t *testing.T) {
+	component := patterns.Pattern{Type: patterns.PatternComponent}
+	ref := readFixture(t, "props/reference.tsx")
+
+	got := frameworkDeviations(readFixture(t, "props/typed.tsx"), ref, component)
+	sameDeviations(t, got, []deviationAt{})
+
+	// The comment mentioning any isn't flagged
+	got = frameworkDeviations(readFixture(t, "props/any_props.tsx"), ref, component)
+	sameDeviations(t, got, []deviationAt{
+		{"props_type", 4}, // any is no props interface
+		{"any_type", 4},
+	})
+	if got[1].Actual != "explicit any on lines 4, 5" || got[1].Severity != patterns.SeverityWarning {
+		t.Errorf("any deviation = %+v, want a warning for lines 4 and 5", got[1])
+	}
+
+	got = frameworkDeviations(readFixture(t, "props/untyped.tsx"), ref, component)
+	sameDeviations(t, got, []deviationAt{
+		{"props_type", 3}, // No props interface
+		{"props_type", 3}, // React.FC without one
+	})
+	if got[0].Type != patterns.DeviationMissing || got[1].Expected != "React.FC<Props>" {
+		t.Errorf("deviations = %+v, want a missing interface and a bare React.FC", got)
+	}
+}
+
+func TestComponentDeviationsFollowReference(t *testing.T) {
+	component := patterns.Pattern{Type: patterns.PatternComponent}
+
+	// A reference as loosely typed sets no expectation
+	loose := readFixture(t, "props/any_props.tsx")
+	got := frameworkDeviations(readFixture(t, "props/any_props.tsx"), loose, component)
+	sameDeviations(t, got, []deviationAt{})
+	got = frameworkDeviations(readFixture(t, "props/untyped.tsx"), readFixture(t, "props/untyped.tsx"), component)
+	sameDeviations(t, got, []deviationAt{})
+
+	// Only components are checked
+	got = frameworkDeviations(readFixture(t, "props/any_props.tsx"), readFixture(t, "props/reference.tsx"), patterns.Pattern{Type: patterns.PatternHook})
+	sameDeviations(t, got, []deviationAt{})
+}
@@ -0,0 +1,98 @@
+package matcher
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// MatchAgainstReference scores a file against a single reference file, as
+// if it were the only blessed example (weight 1.0), bypassing pattern
+// discovery. Conventions of the configured pattern the reference belongs
+// to, if any, still apply. Like MatchFile, oversized files and files
+// exceeding FileTimeout are reported as skipped.
+func (m *Matcher) MatchAgainstReference(filePath, referencePath string) (*patterns.PatternMatch, error) {
+	if analyzer.TooLarge(filePath, m.MaxFileBytes) {
+		return skippedMatch(filePath, "file_size", "File too large to analyze; review it manually"), nil
+	}
+	src, err := m.readReference(referencePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", referencePath, err)
+	}
+	ref, err := parseCandidate(referencePath, src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", referencePath, err)
+	}
+	ref.release()
+
+	ctx, cancel := m.fileContext()
+	defer cancel()
+	load := func() (*candidate, error) {
+		c, err := loadCandidate(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		return c, nil
+	}
+	return m.matchWithin(ctx, filePath, load, func(c *candidate) (*patterns.PatternMatch, error) {
+		if (c.file == nil) != (ref.file == nil) {
+			return nil, fmt.Errorf("cannot match Go against TypeScript/JavaScript: %s, %s", filePath, referencePath)
+		}
+		return m.matchReference(c, referencePath), nil
+	})
+}
+
+// matchReference scores a loaded candidate against a reference file
+func (m *Matcher) matchReference(c *candidate, referencePath string) *patterns.PatternMatch {
+	pattern := m.referencePattern(referencePath)
+	blessed := patterns.BlessedExample{Path: referencePath, Reason: "--reference", Weight: 1.0}
+	result := m.scoreAgainstBlessed(c, blessed, pattern)
+	return &patterns.PatternMatch{
+		Pattern:       &pattern,
+		FilePath:      c.path,
+		Score:         result.score,
+		WeightedScore: weigh(result.score, blessed.Weight, 0),
+		MatchType:     "reference",
+		BlessedRef:    &blessed,
+		Deviations:    result.deviations,
+		Suppressed:    result.suppressed,
+		Breakdown:     result.breakdown,
+		AutoApprove:   result.score >= m.Threshold,
+	}
+}
+
+// referencePattern finds the configured pattern a reference file belongs
+// to: one listing it as an example, else one whose detection rule fits its
+// path. A reference outside every pattern gets a bare pattern of its own.
+func (m *Matcher) referencePattern(referencePath string) patterns.Pattern {
+	for _, pattern := range m.Patterns {
+		for _, path := range examplePaths(pattern) {
+			if filepath.Clean(path) == filepath.Clean(referencePath) {
+				return pattern
+			}
+		}
+	}
+	for _, pattern := range m.Patterns {
+		if m.shouldTryPattern(referencePath, pattern) {
+			return pattern
+		}
+	}
+	return patterns.Pattern{ID: "reference", Name: filepath.Base(referencePath)}
+}
+
+// examplePaths lists the paths of all of a pattern's examples
+func examplePaths(pattern patterns.Pattern) []string {
+	paths := []string{}
+	for _, golden := range pattern.AnnotatedGolden {
+		paths = append(paths, golden.Path)
+	}
+	for _, blessed := range pattern.Blessed() {
+		paths = append(paths, blessed.Path)
+	}
+	for _, discovered := range pattern.Discovered {
+		paths = append(paths, discovered.Path)
+	}
+	return paths
+}
@@ -0,0 +1,47 @@
+package matcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestReadReferenceModifiedInBranch(t *testing.T) {
+	// The branch rewrote the golden example to look like its own change
+	root := writeTree(t, map[string]string{
+		"services/golden.go": consensusSource("fmt", "strings"),
+		"services/user.go":   consensusSource("fmt", "strings"),
+	})
+	golden := filepath.Join(root, "services/golden.go")
+	pattern := declared("service", patterns.PatternService, "")
+	pattern.AnnotatedGolden = []patterns.GoldenExample{{Path: golden, Pattern: "service"}}
+	file := filepath.Join(root, "services/user.go")
+
+	m := New([]patterns.Pattern{pattern}, 80)
+	working, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingImportsOf(working); len(missing) != 0 {
+		t.Errorf("against the working tree: missing imports %v, want none", missing)
+	}
+
+	// On main the golden example also imports errors
+	onMain := map[string]string{golden: consensusSource("errors", "fmt", "strings")}
+	m = New([]patterns.Pattern{pattern}, 80)
+	m.ReadReference = func(path string) ([]byte, error) {
+		if src, ok := onMain[path]; ok {
+			return []byte(src), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	atMain, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := missingImportsOf(atMain); !missing["errors"] || len(missing) != 1 {
+		t.Errorf("against main: missing imports %v, want just errors", missing)
+	}
+}
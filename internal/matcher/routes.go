@@ -0,0 +1,148 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/loop-hub/code-on-rails/internal/walk"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// RouteCheckName is the config name of the route registration check
+const RouteCheckName = "route-registration"
+
+// DefaultRouteFiles are the globs matching the files handlers are
+// registered with a router in, by default
+var DefaultRouteFiles = []string{"**/routes*.go", "**/router*.go"}
+
+// PathCheck is a check that also needs to know where the candidate file is,
+// e.g. to look for it in other files of the repository
+type PathCheck interface {
+	Check
+	EvaluatePath(path string, file *ast.File, pattern patterns.Pattern) []patterns.Deviation
+}
+
+// routeCheck flags HTTP handlers that no route file refers to, so a new
+// handler that is never wired into the router doesn't pass unnoticed.
+// Route files are read once, from the working directory down.
+type routeCheck struct {
+	globs []string
+
+	once  sync.Once
+	names map[string]bool // Identifiers used in route files
+}
+
+// NewRouteCheck creates the route registration check, looking for route
+// files matching globs, or DefaultRouteFiles when empty. A glob starting
+// with **/ matches in any directory.
+func NewRouteCheck(globs []string) Check {
+	if len(globs) == 0 {
+		globs = DefaultRouteFiles
+	}
+	return &routeCheck{globs: globs}
+}
+
+func (*routeCheck) Name() string { return RouteCheckName }
+
+func (*routeCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	return nil // Needs the candidate's path; see EvaluatePath
+}
+
+func (c *routeCheck) EvaluatePath(filePath string, file *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternHTTPHandler || c.isRouteFile(filePath) {
+		return nil
+	}
+	c.once.Do(c.load)
+	if len(c.names) == 0 {
+		return nil // No route files to look in
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range handlerFuncs(file) {
+		if c.names[fn.Name.Name] {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "route_registration",
+			Expected:   "handler registered in a router",
+			Actual:     fn.Name.Name + " is not registered in any router",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Register %s in the routes (%s), or remove it if it is dead code", fn.Name.Name, strings.Join(c.globs, ", ")),
+			LineNumber: LineOf(file, fn.Pos()),
+		})
+	}
+	return deviations
+}
+
+// load collects the identifiers used in the repository's route files.
+// Function names are left out unless they are used, so a handler declared
+// in a route file doesn't count as registered.
+func (c *routeCheck) load() {
+	c.names = make(map[string]bool)
+	skipDir := func(name string) bool {
+		return name == "vendor" || name == "node_modules" || name == "testdata" || strings.HasPrefix(name, ".")
+	}
+	collect := func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			c.names[n.Name] = true
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				ast.Inspect(n.Body, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						c.names[id.Name] = true
+					}
+					return true
+				})
+			}
+			return false
+		}
+		return true
+	}
+	walk.Files(".", skipDir, func(filePath string) error {
+		if !strings.HasSuffix(filePath, ".go") || !c.isRouteFile(filePath) {
+			return nil
+		}
+		src, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filePath, src, goParseMode)
+		if err != nil {
+			return nil
+		}
+		for _, decl := range file.Decls {
+			ast.Inspect(decl, collect)
+		}
+		return nil
+	})
+}
+
+// isRouteFile checks if a path matches one of the route file globs
+func (c *routeCheck) isRouteFile(filePath string) bool {
+	filePath = filepath.ToSlash(filepath.Clean(filePath))
+	for _, glob := range c.globs {
+		rest, anyDir := strings.CutPrefix(glob, "**/")
+		if !anyDir {
+			if ok, _ := path.Match(glob, filePath); ok {
+				return true
+			}
+			continue
+		}
+		parts := strings.Split(filePath, "/")
+		for i := range parts {
+			if ok, _ := path.Match(rest, strings.Join(parts[i:], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package matcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// inRoutesFixture runs the rest of the test from testdata/routes, the
+// repository route files are looked for in
+func inRoutesFixture(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("testdata", "routes")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestRouteRegistration(t *testing.T) {
+	handlers := parseFixture(t, "routes/handlers/user.go")
+	routes := parseFixture(t, "routes/api/routes.go")
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+	inRoutesFixture(t)
+
+	// Healthz is only declared in the route file; decodeUser isn't a handler
+	check := NewRouteCheck(nil).(PathCheck)
+	got := check.EvaluatePath(filepath.Join("handlers", "user.go"), handlers, handler)
+	sameDeviations(t, got, []deviationAt{
+		{"route_registration", 16}, // DeleteUser
+		{"route_registration", 20}, // Healthz
+	})
+	if got[0].Severity != patterns.SeverityWarning || got[0].Actual != "DeleteUser is not registered in any router" {
+		t.Errorf("deviation = %+v, want a warning for DeleteUser", got[0])
+	}
+
+	// Route files themselves and other pattern types aren't checked
+	got = check.EvaluatePath(filepath.Join("api", "routes.go"), routes, handler)
+	sameDeviations(t, got, []deviationAt{})
+	got = check.EvaluatePath(filepath.Join("handlers", "user.go"), handlers, patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Without route files there is nothing to be registered in
+	got = NewRouteCheck([]string{"server/*.go"}).(PathCheck).EvaluatePath(filepath.Join("handlers", "user.go"), handlers, handler)
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestIsRouteFile(t *testing.T) {
+	tests := []struct {
+		globs []string
+		path  string
+		want  bool
+	}{
+		{nil, "routes.go", true},
+		{nil, "internal/api/routes_v2.go", true},
+		{nil, "cmd/server/router.go", true},
+		{nil, "handlers/user.go", false},
+		{nil, "internal/myroutes.go", false},
+		{[]string{"server/*.go"}, "server/http.go", true},
+		{[]string{"server/*.go"}, "internal/server/http.go", false},
+		{[]string{"**/server/*.go"}, "internal/server/http.go", true},
+	}
+	for _, tt := range tests {
+		c := NewRouteCheck(tt.globs).(*routeCheck)
+		if got := c.isRouteFile(filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("isRouteFile(%q) with %v = %v, want %v", tt.path, tt.globs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Similarity methods for comparing node-type counts
+const (
+	// SimilarityCosine compares raw counts
+	SimilarityCosine = "cosine"
+	// SimilarityCosineNormalized damps counts logarithmically first, so
+	// constructs repeated throughout a long file don't outweigh the overall
+	// shape it shares with a short one
+	SimilarityCosineNormalized = "cosine_normalized"
+)
+
+// Scoring controls how deviations and structural similarity affect a score
+type Scoring struct {
+	MissingImportPenalty        float64 // Per reference import the file lacks
+	MissingErrorHandlingPenalty float64 // When the reference handles errors and the file doesn't
+	ErrorPenalty                float64 // Per error-severity check deviation
+	WarningPenalty              float64 // Per warning-severity check deviation
+	StructureWeight             float64 // 0 ignores structural similarity, 1 multiplies by it fully
+	TieEpsilon                  float64 // Weighted scores this close across patterns count as a tie
+	PathSpecificityBonus        float64 // Weighted-score bonus for a pattern whose detection rules spell out the whole path
+	SimilarityMethod            string  // SimilarityCosine or SimilarityCosineNormalized
+}
+
+// DefaultScoring returns the built-in scoring weights
+func DefaultScoring() Scoring {
+	return Scoring{
+		MissingImportPenalty:        5.0,
+		MissingErrorHandlingPenalty: 10.0,
+		ErrorPenalty:                10.0,
+		WarningPenalty:              5.0,
+		StructureWeight:             1.0,
+		TieEpsilon:                  1.0,
+		PathSpecificityBonus:        2.0,
+		SimilarityMethod:            SimilarityCosine,
+	}
+}
+
+// Validate checks that penalties are non-negative and the weight is in range
+func (s Scoring) Validate() error {
+	penalties := []struct {
+		name  string
+		value float64
+	}{
+		{"missing_import_penalty", s.MissingImportPenalty},
+		{"missing_error_handling_penalty", s.MissingErrorHandlingPenalty},
+		{"error_penalty", s.ErrorPenalty},
+		{"warning_penalty", s.WarningPenalty},
+		{"tie_epsilon", s.TieEpsilon},
+		{"path_specificity_bonus", s.PathSpecificityBonus},
+	}
+	for _, p := range penalties {
+		if p.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %g", p.name, p.value)
+		}
+	}
+	if s.StructureWeight < 0 || s.StructureWeight > 1 {
+		return fmt.Errorf("structure_weight must be between 0 and 1, got %g", s.StructureWeight)
+	}
+	switch s.SimilarityMethod {
+	case SimilarityCosine, SimilarityCosineNormalized:
+	default:
+		return fmt.Errorf("similarity_method must be %q or %q, got %q",
+			SimilarityCosine, SimilarityCosineNormalized, s.SimilarityMethod)
+	}
+	return nil
+}
+
+// penalty returns the score penalty for a deviation
+func (m *Matcher) penalty(dev patterns.Deviation) float64 {
+	switch {
+	case dev.Type == patterns.DeviationMissing && dev.Element == "import":
+		return m.Scoring.MissingImportPenalty
+	case dev.Type == patterns.DeviationMissing && dev.Element == "error_handling":
+		return m.Scoring.MissingErrorHandlingPenalty
+	}
+
+	switch dev.Severity {
+	case patterns.SeverityError:
+		return m.Scoring.ErrorPenalty
+	case patterns.SeverityWarning:
+		return m.Scoring.WarningPenalty
+	default:
+		return 0
+	}
+}
+
+// incidentalImportance is the importance of an import a pattern records
+// importance for but doesn't require: fewer than 80% of its examples had it
+const incidentalImportance = 0.5
+
+// importance scales the penalty for a missing import by how consistently
+// the pattern's examples have it: a required import's share of examples, so
+// one every example has costs the full penalty, or incidentalImportance
+// otherwise. Patterns recording no importance, e.g. learned before it was
+// recorded or declared by hand, weigh every import fully.
+func importance(pattern patterns.Pattern, imp string) float64 {
+	recorded := false
+	for _, elem := range pattern.Structure.Elements {
+		if elem.Type != patterns.ElementImport || elem.Importance <= 0 {
+			continue
+		}
+		if elem.Name == imp {
+			return elem.Importance
+		}
+		recorded = true
+	}
+	if !recorded {
+		return 1
+	}
+	return incidentalImportance
+}
+
+// structureFactor blends structural similarity into a score multiplier
+func (s Scoring) structureFactor(similarity float64) float64 {
+	return 1 - s.StructureWeight + s.StructureWeight*similarity
+}
+
+// nodeSimilarity compares two sets of node-type counts with the configured method
+func (s Scoring) nodeSimilarity(a, b map[string]int) float64 {
+	if s.SimilarityMethod == SimilarityCosineNormalized {
+		return cosineWeights(dampedFrequencies(a), dampedFrequencies(b))
+	}
+	return cosineSimilarity(a, b)
+}
+
+// dampedFrequencies turns counts into a frequency distribution over
+// 1+ln(count), so how often a node type occurs matters less than whether it
+// occurs at all
+func dampedFrequencies(counts map[string]int) map[string]float64 {
+	weights := make(map[string]float64, len(counts))
+	total := 0.0
+	for k, n := range counts {
+		if n <= 0 {
+			continue
+		}
+		weights[k] = 1 + math.Log(float64(n))
+		total += weights[k]
+	}
+	for k := range weights {
+		weights[k] /= total
+	}
+	return weights
+}
@@ -0,0 +1,280 @@
+package matcher
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const scoringReference = `package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func Normalize(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("empty name")
+	}
+	return fmt.Sprint(strings.TrimSpace(name)), nil
+}
+`
+
+// scoringCandidate lacks two of the reference's imports
+const scoringCandidate = `package services
+
+import "fmt"
+
+func Normalize(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty name")
+	}
+	return fmt.Sprint(name), nil
+}
+`
+
+func TestScoringTunedPenalties(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringCandidate,
+	})
+	file := filepath.Join(root, "services/user.go")
+	pats := []patterns.Pattern{declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))}
+
+	m := New(pats, 95)
+	m.Scoring.StructureWeight = 0 // Only penalties count
+	match, err := m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Score != 90 || match.AutoApprove {
+		t.Fatalf("default scoring: score %g, auto-approve %v; want 90 and needs review", match.Score, match.AutoApprove)
+	}
+
+	m = New(pats, 95)
+	m.Scoring.StructureWeight = 0
+	m.Scoring.MissingImportPenalty = 1
+	match, err = m.MatchFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Score != 98 || !match.AutoApprove {
+		t.Errorf("tuned scoring: score %g, auto-approve %v; want 98 and approved", match.Score, match.AutoApprove)
+	}
+}
+
+func TestScoringStructureWeight(t *testing.T) {
+	s := DefaultScoring()
+	if got := s.structureFactor(0.5); got != 0.5 {
+		t.Errorf("full weight factor = %g, want 0.5", got)
+	}
+	s.StructureWeight = 0
+	if got := s.structureFactor(0.5); got != 1 {
+		t.Errorf("zero weight factor = %g, want 1", got)
+	}
+	s.StructureWeight = 1.5
+	if err := s.Validate(); err == nil {
+		t.Error("structure_weight 1.5 accepted")
+	}
+	s = DefaultScoring()
+	s.MissingImportPenalty = -1
+	if err := s.Validate(); err == nil {
+		t.Error("negative missing_import_penalty accepted")
+	}
+}
+
+func TestTieBreaking(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringReference,
+	})
+	file := filepath.Join(root, "services/user.go")
+	ref := filepath.Join(root, "services/ref.go")
+
+	service := declared("service", patterns.PatternService, ref)
+	handler := declared("handler", patterns.PatternHTTPHandler, ref)
+
+	// Near-tied scores against the same reference: the more confident
+	// pattern wins, whichever comes first
+	handler.Confidence = 0.9
+	for _, order := range [][]patterns.Pattern{{service, handler}, {handler, service}} {
+		match, err := New(order, 90).MatchFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match.Pattern.ID != "handler" {
+			t.Errorf("order %s first: matched %s, want the more confident handler", order[0].ID, match.Pattern.ID)
+		}
+	}
+
+	// Equally confident: the pattern whose detection rules say more about
+	// the file wins
+	handler.Confidence = service.Confidence
+	service.Detection.PackagePath = "*/services"
+	for _, order := range [][]patterns.Pattern{{service, handler}, {handler, service}} {
+		m := New(order, 90)
+		m.Scoring.PathSpecificityBonus = 0
+		match, err := m.MatchFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match.Pattern.ID != "service" {
+			t.Errorf("order %s first: matched %s, want the more specific service", order[0].ID, match.Pattern.ID)
+		}
+	}
+}
+
+// smallService is a faithful, minimal example of the service pattern
+const smallService = `package services
+
+import "context"
+
+var statuses = []string{"active"}
+
+type UserService struct{ repo Repo }
+
+func (s *UserService) Get(ctx context.Context, id string) (*User, error) {
+	return s.repo.Find(ctx, id)
+}
+`
+
+// largeService follows the same pattern, with most of its length in one
+// long table, so a single node type dominates its raw counts
+const largeService = `package services
+
+import "context"
+
+var statuses = []string{
+	"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9", "s10", "s11", "s12", "s13", "s14", "s15", "s16", "s17", "s18", "s19", "s20", "s21", "s22", "s23", "s24", "s25", "s26", "s27", "s28", "s29", "s30", "s31", "s32", "s33", "s34", "s35", "s36", "s37", "s38", "s39", "s40", "s41", "s42", "s43", "s44", "s45", "s46", "s47", "s48", "s49", "s50", "s51", "s52", "s53", "s54", "s55", "s56", "s57", "s58", "s59",
+}
+
+type OrderService struct{ repo Repo }
+
+func (s *OrderService) Get(ctx context.Context, id string) (*Order, error) {
+	return s.repo.Find(ctx, id)
+}
+`
+
+func TestSimilarityMethodSizeBias(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"small.go": smallService,
+		"large.go": largeService,
+	})
+	small, large := filepath.Join(root, "small.go"), filepath.Join(root, "large.go")
+
+	m := New(nil, 80)
+	raw := m.compareStructure(small, nil, large)
+
+	m = New(nil, 80)
+	m.Scoring.SimilarityMethod = SimilarityCosineNormalized
+	normalized := m.compareStructure(small, nil, large)
+
+	if normalized <= raw {
+		t.Errorf("cosine_normalized similarity %g not above cosine %g for a small faithful example", normalized, raw)
+	}
+	t.Logf("cosine %.3f, cosine_normalized %.3f", raw, normalized)
+
+	// Normalizing never changes how a file compares to itself
+	if got := m.compareStructure(large, nil, large); got < 0.999 {
+		t.Errorf("normalized self-similarity = %g, want 1", got)
+	}
+}
+
+func TestSimilarityMethodValidate(t *testing.T) {
+	s := DefaultScoring()
+	s.SimilarityMethod = SimilarityCosineNormalized
+	if err := s.Validate(); err != nil {
+		t.Errorf("cosine_normalized rejected: %v", err)
+	}
+	s.SimilarityMethod = "euclidean"
+	if err := s.Validate(); err == nil {
+		t.Error("unknown similarity_method accepted")
+	}
+}
+
+func TestPathSpecificity(t *testing.T) {
+	file := "internal/services/handlers/user.go"
+	service := patterns.DetectionRule{FilePattern: "*.go", PackagePath: "*/services"}
+	handler := patterns.DetectionRule{FilePattern: "*.go", PackagePath: "*/services/handlers"}
+	if s, h := pathSpecificity(file, service), pathSpecificity(file, handler); h <= s || h > 1 {
+		t.Errorf("specificity: service %g, handler %g; want handler higher, at most 1", s, h)
+	}
+	if got := pathSpecificity(file, patterns.DetectionRule{}); got != 0 {
+		t.Errorf("specificity of empty rules = %g, want 0", got)
+	}
+}
+
+func TestPathSpecificityBonus(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"ref.go":                             scoringReference,
+		"internal/services/handlers/user.go": scoringReference,
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Both patterns' globs match the file and score it the same; the
+	// service pattern is more confident, the handler's rules more specific
+	service := declared("service", patterns.PatternService, "ref.go")
+	service.Detection.PackagePath = "*/services"
+	service.Confidence = 0.9
+	handler := declared("handler", patterns.PatternHTTPHandler, "ref.go")
+	handler.Detection.PackagePath = "*/services/handlers"
+
+	for _, tt := range []struct {
+		bonus float64
+		want  string
+	}{
+		{0, "service"},  // A tie, broken by confidence
+		{10, "handler"}, // The path conventions decide
+	} {
+		m := New([]patterns.Pattern{service, handler}, 80)
+		m.Scoring.PathSpecificityBonus = tt.bonus
+		match, err := m.MatchFile("internal/services/handlers/user.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match.Pattern.ID != tt.want {
+			t.Errorf("bonus %g: matched %s, want %s", tt.bonus, match.Pattern.ID, tt.want)
+		}
+	}
+}
+
+func TestScoreBreakdownSumsToScore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"services/ref.go":  scoringReference,
+		"services/user.go": scoringCandidate,
+	})
+	m := New([]patterns.Pattern{declared("service", patterns.PatternService, filepath.Join(root, "services/ref.go"))}, 95)
+
+	match, err := m.MatchFile(filepath.Join(root, "services/user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := match.Breakdown
+	if b == nil {
+		t.Fatal("no score breakdown")
+	}
+	if want := 2 * m.Scoring.MissingImportPenalty; b.Imports != want {
+		t.Errorf("imports component = %g, want %g for the two missing imports", b.Imports, want)
+	}
+	if b.StructureSimilarity <= 0 || b.StructureSimilarity > 1 {
+		t.Errorf("structure similarity = %g, want within (0, 1]", b.StructureSimilarity)
+	}
+	if b.Structure < 0 {
+		t.Errorf("structure component = %g, want no bonus", b.Structure)
+	}
+	if got := 100 - b.Imports - b.ErrorHandling - b.Checks - b.Structure; math.Abs(got-match.Score) > 1e-9 {
+		t.Errorf("components %+v sum to %g, score is %g", b, got, match.Score)
+	}
+}
@@ -0,0 +1,170 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// SecretsCheckName is the config name of the hardcoded secrets check
+const SecretsCheckName = "hardcoded-secrets"
+
+// DefaultSecretEntropy is the Shannon entropy, in bits per character, above
+// which a long token is treated as a likely secret
+const DefaultSecretEntropy = 4.0
+
+// minSecretLength is the shortest token checked for entropy
+const minSecretLength = 20
+
+// secretPrefixes mark well-known credential formats
+var secretPrefixes = []struct{ prefix, kind string }{
+	{"sk-", "an API key"},
+	{"sk_live_", "an API key"},
+	{"AKIA", "an AWS access key"},
+	{"ASIA", "an AWS access key"},
+	{"ghp_", "a GitHub token"},
+	{"github_pat_", "a GitHub token"},
+	{"xoxb-", "a Slack token"},
+	{"xoxp-", "a Slack token"},
+	{"AIza", "a Google API key"},
+	{"-----BEGIN", "a private key"},
+}
+
+var (
+	// credentialURL matches URLs carrying a user and password, such as
+	// connection strings
+	credentialURL = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)
+	// secretToken matches the characters keys and tokens are made of
+	secretToken = regexp.MustCompile(`^[A-Za-z0-9+/=_\-]+$`)
+)
+
+// secretsCheck flags string literals in handlers and services that look
+// like credentials, which belong in configuration or the environment
+type secretsCheck struct {
+	allowlist  []string // Literals containing any of these are ignored
+	minEntropy float64
+}
+
+// NewSecretsCheck creates the hardcoded secrets check. Literals containing
+// an allowlist entry are never flagged; minEntropy 0 means
+// DefaultSecretEntropy.
+func NewSecretsCheck(allowlist []string, minEntropy float64) Check {
+	if minEntropy == 0 {
+		minEntropy = DefaultSecretEntropy
+	}
+	return secretsCheck{allowlist: allowlist, minEntropy: minEntropy}
+}
+
+func (secretsCheck) Name() string { return SecretsCheckName }
+
+func (c secretsCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	switch pattern.Type {
+	case patterns.PatternHTTPHandler, patterns.PatternAPI, patterns.PatternService:
+	default:
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, lit := range stringLiterals(file) {
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil || c.allowed(value) {
+			continue
+		}
+		kind := c.secretKind(value)
+		if kind == "" {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationDifferent,
+			Element:    "hardcoded_secret",
+			Expected:   "value from configuration or the environment",
+			Actual:     redact(value),
+			Severity:   patterns.SeverityError,
+			Suggestion: fmt.Sprintf("This literal looks like %s; load it from configuration or the environment instead", kind),
+			LineNumber: LineOf(file, lit.Pos()),
+		})
+	}
+	return deviations
+}
+
+// allowed checks if a literal contains an allowlist entry
+func (c secretsCheck) allowed(value string) bool {
+	for _, entry := range c.allowlist {
+		if entry != "" && strings.Contains(value, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretKind describes what a literal looks like, or "" if it doesn't look
+// like a secret
+func (c secretsCheck) secretKind(value string) string {
+	for _, p := range secretPrefixes {
+		if strings.HasPrefix(value, p.prefix) && len(value) > len(p.prefix)+8 {
+			return p.kind
+		}
+	}
+	if credentialURL.MatchString(value) {
+		return "a URL with credentials"
+	}
+	for _, token := range strings.Fields(value) {
+		if len(token) >= minSecretLength && secretToken.MatchString(token) &&
+			strings.ContainsAny(token, "0123456789") && entropy(token) >= c.minEntropy {
+			return "a high-entropy token"
+		}
+	}
+	return ""
+}
+
+// stringLiterals collects a file's string literals, skipping import paths
+// and struct tags
+func stringLiterals(file *ast.File) []*ast.BasicLit {
+	tags := make(map[*ast.BasicLit]bool)
+	literals := []*ast.BasicLit{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			if node.Tag != nil {
+				tags[node.Tag] = true
+			}
+		case *ast.BasicLit:
+			if node.Kind == token.STRING && !tags[node] {
+				literals = append(literals, node)
+			}
+		}
+		return true
+	})
+	return literals
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	bits := 0.0
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// redact shortens a suspected secret so reports don't repeat it
+func redact(value string) string {
+	if len(value) <= 4 {
+		return "****"
+	}
+	return value[:4] + "****"
+}
@@ -0,0 +1,55 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestSecretsCheck(t *testing.T) {
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+	check := NewSecretsCheck(nil, 0)
+
+	got := check.Evaluate(parseFixture(t, "secrets/planted.go"), nil, handler)
+	sameDeviations(t, got, []deviationAt{
+		{"hardcoded_secret", 5},  // sk- API key
+		{"hardcoded_secret", 7},  // AWS access key
+		{"hardcoded_secret", 14}, // Connection string with a password
+		{"hardcoded_secret", 15}, // High-entropy bearer token
+	})
+	for _, dev := range got {
+		if dev.Severity != patterns.SeverityError || len(dev.Actual) != 8 {
+			t.Errorf("deviation %+v: want an error with the literal redacted", dev)
+		}
+	}
+
+	got = check.Evaluate(parseFixture(t, "secrets/clean.go"), nil, handler)
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestSecretsCheckAllowlistAndEntropy(t *testing.T) {
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+	file := parseFixture(t, "secrets/planted.go")
+
+	// Allowlisted literals are never flagged
+	got := NewSecretsCheck([]string{"EXAMPLE", "db.internal"}, 0).Evaluate(file, nil, handler)
+	sameDeviations(t, got, []deviationAt{{"hardcoded_secret", 5}, {"hardcoded_secret", 15}})
+
+	// A higher entropy threshold lets the bearer token through, but not
+	// the known key formats
+	got = NewSecretsCheck(nil, 6).Evaluate(file, nil, handler)
+	sameDeviations(t, got, []deviationAt{{"hardcoded_secret", 5}, {"hardcoded_secret", 7}, {"hardcoded_secret", 14}})
+}
+
+func TestSecretsCheckPatternTypes(t *testing.T) {
+	file := parseFixture(t, "secrets/planted.go")
+	check := NewSecretsCheck(nil, 0)
+	for _, patternType := range []patterns.PatternType{patterns.PatternService, patterns.PatternAPI} {
+		if got := check.Evaluate(file, nil, patterns.Pattern{Type: patternType}); len(got) != 4 {
+			t.Errorf("%s: got %d deviations, want 4", patternType, len(got))
+		}
+	}
+	if got := check.Evaluate(file, nil, patterns.Pattern{Type: patterns.PatternModel}); len(got) != 0 {
+		t.Errorf("model: got %v, want none", deviationsAt(got))
+	}
+}
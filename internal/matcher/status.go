@@ -0,0 +1,226 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// StatusCodeCheckName is the config name of the HTTP status code check
+const StatusCodeCheckName = "http-status-codes"
+
+// statusConstants names the net/http constants for common status codes
+var statusConstants = map[int]string{
+	200: "StatusOK",
+	201: "StatusCreated",
+	202: "StatusAccepted",
+	204: "StatusNoContent",
+	301: "StatusMovedPermanently",
+	302: "StatusFound",
+	303: "StatusSeeOther",
+	304: "StatusNotModified",
+	307: "StatusTemporaryRedirect",
+	308: "StatusPermanentRedirect",
+	400: "StatusBadRequest",
+	401: "StatusUnauthorized",
+	403: "StatusForbidden",
+	404: "StatusNotFound",
+	405: "StatusMethodNotAllowed",
+	409: "StatusConflict",
+	410: "StatusGone",
+	412: "StatusPreconditionFailed",
+	413: "StatusRequestEntityTooLarge",
+	415: "StatusUnsupportedMediaType",
+	422: "StatusUnprocessableEntity",
+	429: "StatusTooManyRequests",
+	500: "StatusInternalServerError",
+	501: "StatusNotImplemented",
+	502: "StatusBadGateway",
+	503: "StatusServiceUnavailable",
+	504: "StatusGatewayTimeout",
+}
+
+// statusMethods are response methods taking the status code as their first
+// argument: net/http's WriteHeader and the gin/echo response helpers
+var statusMethods = map[string]bool{
+	"WriteHeader":         true,
+	"JSON":                true,
+	"XML":                 true,
+	"String":              true,
+	"Status":              true,
+	"NoContent":           true,
+	"Redirect":            true,
+	"AbortWithStatus":     true,
+	"AbortWithStatusJSON": true,
+}
+
+// statusCodeCheck flags handlers that set status codes with numeric
+// literals where the reference uses the http.Status* constants, and
+// handlers that never set a status where every reference handler does
+type statusCodeCheck struct{}
+
+func (statusCodeCheck) Name() string { return StatusCodeCheckName }
+
+func (statusCodeCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternHTTPHandler && pattern.Type != patterns.PatternAPI {
+		return nil
+	}
+
+	refHandlers := handlerFuncs(ref)
+	refAlwaysSets := len(refHandlers) > 0
+	refConstants, refLiterals := 0, 0
+	for _, fn := range refHandlers {
+		codes := statusCodes(fn)
+		refAlwaysSets = refAlwaysSets && len(codes) > 0
+		for _, code := range codes {
+			if code.literal == 0 {
+				refConstants++
+			} else {
+				refLiterals++
+			}
+		}
+	}
+	refUsesConstants := refConstants > 0 && refLiterals == 0
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range handlerFuncs(file) {
+		codes := statusCodes(fn)
+		if len(codes) == 0 && refAlwaysSets {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "status_code",
+				Expected:   "explicit status code",
+				Actual:     fn.Name.Name + " sets none",
+				Severity:   patterns.SeverityInfo,
+				Suggestion: fmt.Sprintf("Set the response status explicitly in %s, e.g. w.WriteHeader(http.StatusOK), like the reference handlers", fn.Name.Name),
+				LineNumber: LineOf(file, fn.Pos()),
+			})
+		}
+		if !refUsesConstants {
+			continue
+		}
+		for _, code := range codes {
+			if code.literal == 0 {
+				continue
+			}
+			expected := "http.Status* constant"
+			if name, ok := statusConstants[code.literal]; ok {
+				expected = "http." + name
+			}
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationDifferent,
+				Element:    "status_code",
+				Expected:   expected,
+				Actual:     strconv.Itoa(code.literal),
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Use %s instead of the literal %d, like the reference handlers", expected, code.literal),
+				LineNumber: LineOf(file, code.pos),
+			})
+		}
+	}
+	return deviations
+}
+
+// statusCode is a status code a handler sets. literal is the numeric value
+// when it is written as a number, 0 when it is a named constant.
+type statusCode struct {
+	literal int
+	pos     token.Pos
+}
+
+// handlerFuncs lists the functions taking an http.ResponseWriter or a
+// gin/echo context
+func handlerFuncs(file *ast.File) []*ast.FuncDecl {
+	handlers := []*ast.FuncDecl{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || fn.Type.Params == nil {
+			continue
+		}
+		for _, param := range fn.Type.Params.List {
+			if isResponseParam(param.Type) {
+				handlers = append(handlers, fn)
+				break
+			}
+		}
+	}
+	return handlers
+}
+
+// isResponseParam checks if a parameter type is http.ResponseWriter,
+// *gin.Context or echo.Context
+func isResponseParam(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch pkg.Name + "." + sel.Sel.Name {
+	case "http.ResponseWriter", "gin.Context", "echo.Context":
+		return true
+	}
+	return false
+}
+
+// statusCodes finds the status codes a function sets through WriteHeader,
+// http.Error or a framework response helper. Calls whose argument is
+// neither a status-range literal nor an http.Status* constant are ignored.
+func statusCodes(fn *ast.FuncDecl) []statusCode {
+	codes := []statusCode{}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		var arg ast.Expr
+		switch {
+		case isSelectorCall(call, "http", "Error") && len(call.Args) == 3:
+			arg = call.Args[2]
+		case statusMethods[sel.Sel.Name] && len(call.Args) > 0:
+			arg = call.Args[0]
+		default:
+			return true
+		}
+		if code, ok := statusArg(arg); ok {
+			codes = append(codes, code)
+		}
+		return true
+	})
+	return codes
+}
+
+// statusArg recognizes a status code argument
+func statusArg(arg ast.Expr) (statusCode, bool) {
+	switch a := arg.(type) {
+	case *ast.BasicLit:
+		if a.Kind != token.INT {
+			return statusCode{}, false
+		}
+		value, err := strconv.Atoi(a.Value)
+		if err != nil || value < 100 || value > 599 {
+			return statusCode{}, false
+		}
+		return statusCode{literal: value, pos: a.Pos()}, true
+	case *ast.SelectorExpr:
+		pkg, ok := a.X.(*ast.Ident)
+		if ok && pkg.Name == "http" && len(a.Sel.Name) > len("Status") && strings.HasPrefix(a.Sel.Name, "Status") {
+			return statusCode{pos: a.Pos()}, true
+		}
+	}
+	return statusCode{}, false
+}
@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestStatusCodeCheck(t *testing.T) {
+	ref := parseFixture(t, "status/reference.go")
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+
+	got := statusCodeCheck{}.Evaluate(parseFixture(t, "status/literals.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{
+		{"status_code", 11}, // http.Error(..., 404)
+		{"status_code", 14}, // WriteHeader(200)
+		{"status_code", 19}, // WriteHeader(299), no named constant
+		{"status_code", 22}, // ListOrders never sets a status
+	})
+	wantExpected := []string{"http.StatusNotFound", "http.StatusOK", "http.Status* constant", "explicit status code"}
+	wantSeverity := []patterns.Severity{patterns.SeverityWarning, patterns.SeverityWarning, patterns.SeverityWarning, patterns.SeverityInfo}
+	for i, dev := range got {
+		if dev.Expected != wantExpected[i] || dev.Severity != wantSeverity[i] {
+			t.Errorf("deviation %d = %+v, want %s as %s", i, dev, wantExpected[i], wantSeverity[i])
+		}
+	}
+
+	got = statusCodeCheck{}.Evaluate(parseFixture(t, "status/constants.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{})
+
+	got = statusCodeCheck{}.Evaluate(parseFixture(t, "status/gin.go"), ref, patterns.Pattern{Type: patterns.PatternAPI})
+	sameDeviations(t, got, []deviationAt{{"status_code", 10}})
+}
+
+func TestStatusCodeCheckFollowsReference(t *testing.T) {
+	// A reference using literals sets no convention for constants, and one
+	// that doesn't always set a status none for setting it
+	literals := parseFixture(t, "status/literals.go")
+	got := statusCodeCheck{}.Evaluate(literals, literals, patterns.Pattern{Type: patterns.PatternHTTPHandler})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Only handler patterns are checked
+	ref := parseFixture(t, "status/reference.go")
+	got = statusCodeCheck{}.Evaluate(literals, ref, patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+}
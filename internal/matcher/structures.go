@@ -0,0 +1,100 @@
+package matcher
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// structureCache memoizes structural similarity for one matcher, keyed by
+// content hashes: each unique file is parsed and counted once, and each
+// ordered pair of files compared once, however many tiers and anti-pattern
+// checks ask. A nil cache computes everything afresh. It holds at most
+// maxCachedStructures sources and as many pairs, starting over once full, so
+// a long-lived matcher, e.g. cr serve's, doesn't grow with every buffer.
+type structureCache struct {
+	mu     sync.Mutex
+	counts map[[sha256.Size]byte]map[string]int // nil counts: doesn't parse
+	pairs  map[[2][sha256.Size]byte]float64
+}
+
+// maxCachedStructures bounds each of a structureCache's maps
+const maxCachedStructures = 1024
+
+// defaultStructureSimilarity is the similarity given when either file
+// doesn't parse
+const defaultStructureSimilarity = 0.5
+
+// countFunc tallies a source's nodes, failing if it doesn't parse
+type countFunc func(path string, src []byte) (map[string]int, error)
+
+func newStructureCache() *structureCache {
+	return &structureCache{
+		counts: make(map[[sha256.Size]byte]map[string]int),
+		pairs:  make(map[[2][sha256.Size]byte]float64),
+	}
+}
+
+// similarity compares the structure of src1 with src2's using count to
+// tally each source's nodes and compare to score the tallies, or returns
+// defaultStructureSimilarity if either doesn't parse. The parse error is
+// returned the first time a source fails, not again for cached results.
+func (sc *structureCache) similarity(path1 string, src1 []byte, path2 string, src2 []byte, count countFunc,
+	compare func(a, b map[string]int) float64) (float64, error) {
+	if sc == nil {
+		counts1, err := count(path1, src1)
+		if err != nil {
+			return defaultStructureSimilarity, err
+		}
+		counts2, err := count(path2, src2)
+		if err != nil {
+			return defaultStructureSimilarity, err
+		}
+		return compare(counts1, counts2), nil
+	}
+
+	key := [2][sha256.Size]byte{sha256.Sum256(src1), sha256.Sum256(src2)}
+	sc.mu.Lock()
+	score, ok := sc.pairs[key]
+	sc.mu.Unlock()
+	if ok {
+		return score, nil
+	}
+
+	counts1, err1 := sc.countsOf(key[0], path1, src1, count)
+	counts2, err2 := sc.countsOf(key[1], path2, src2, count)
+	err := err1
+	if err == nil {
+		err = err2
+	}
+	score = defaultStructureSimilarity
+	if counts1 != nil && counts2 != nil {
+		score = compare(counts1, counts2)
+	}
+
+	sc.mu.Lock()
+	if len(sc.pairs) >= maxCachedStructures {
+		clear(sc.pairs)
+	}
+	sc.pairs[key] = score
+	sc.mu.Unlock()
+	return score, err
+}
+
+// countsOf returns the cached node counts of a source, counting them the
+// first time it is seen. Only that first count reports a parse error.
+func (sc *structureCache) countsOf(hash [sha256.Size]byte, path string, src []byte, count countFunc) (map[string]int, error) {
+	sc.mu.Lock()
+	counts, ok := sc.counts[hash]
+	sc.mu.Unlock()
+	if ok {
+		return counts, nil
+	}
+	counts, err := count(path, src)
+	sc.mu.Lock()
+	if len(sc.counts) >= maxCachedStructures {
+		clear(sc.counts)
+	}
+	sc.counts[hash] = counts
+	sc.mu.Unlock()
+	return counts, err
+}
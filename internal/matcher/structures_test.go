@@ -0,0 +1,114 @@
+package matcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// multiTierTree writes a service pattern with the same source as its
+// golden, blessed and discovered examples and as an anti-pattern, the way
+// an example blessed and then discovered again is, plus candidates to match
+func multiTierTree(tb testing.TB) (patterns.Pattern, []string) {
+	tb.Helper()
+	files := map[string]string{
+		"services/golden.go":     consensusSource("errors", "fmt", "strings"),
+		"services/blessed.go":    consensusSource("errors", "fmt", "strings"),
+		"services/discovered.go": consensusSource("fmt", "strings"),
+		"services/legacy.go":     consensusSource("fmt"),
+	}
+	candidates := []string{}
+	for i := 0; i < 4; i++ {
+		path := fmt.Sprintf("services/user%d.go", i)
+		files[path] = consensusSource("fmt", "strings")
+		candidates = append(candidates, path)
+	}
+	root := writeTree(tb, files)
+	for i, path := range candidates {
+		candidates[i] = filepath.Join(root, path)
+	}
+
+	pattern := declared("service", patterns.PatternService, "")
+	pattern.AnnotatedGolden = []patterns.GoldenExample{{Path: filepath.Join(root, "services/golden.go"), Pattern: "service"}}
+	pattern.ConfigBlessed = []patterns.BlessedExample{{Path: filepath.Join(root, "services/blessed.go")}}
+	pattern.Discovered = []patterns.Example{
+		{Path: filepath.Join(root, "services/discovered.go")},
+		{Path: filepath.Join(root, "services/golden.go")},
+	}
+	pattern.AntiPatterns = []patterns.AntiPattern{{Path: filepath.Join(root, "services/legacy.go"), Pattern: "service"}}
+	return pattern, candidates
+}
+
+// matchAll matches every candidate, uncached when cached is false
+func matchAll(tb testing.TB, pattern patterns.Pattern, candidates []string, cached bool) (*Matcher, []*patterns.PatternMatch) {
+	tb.Helper()
+	m := New([]patterns.Pattern{pattern}, 80)
+	if !cached {
+		m.structures = nil
+	}
+	matches := []*patterns.PatternMatch{}
+	for _, path := range candidates {
+		match, err := m.MatchFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		matches = append(matches, match)
+	}
+	return m, matches
+}
+
+func TestStructureCacheKeepsScores(t *testing.T) {
+	pattern, candidates := multiTierTree(t)
+	cachedMatcher, cached := matchAll(t, pattern, candidates, true)
+	uncachedMatcher, uncached := matchAll(t, pattern, candidates, false)
+
+	for i := range candidates {
+		c, u := cached[i], uncached[i]
+		if c.Score != u.Score || c.MatchType != u.MatchType || c.Breakdown.StructureSimilarity != u.Breakdown.StructureSimilarity {
+			t.Errorf("%s: cached %g against %s (similarity %g), uncached %g against %s (similarity %g)", filepath.Base(candidates[i]),
+				c.Score, c.MatchType, c.Breakdown.StructureSimilarity, u.Score, u.MatchType, u.Breakdown.StructureSimilarity)
+		}
+	}
+	cachedCompares, uncachedCompares := cachedMatcher.structureCompares.Load(), uncachedMatcher.structureCompares.Load()
+	if cachedCompares >= uncachedCompares {
+		t.Errorf("cached matching compared %d times, uncached %d; want fewer", cachedCompares, uncachedCompares)
+	}
+}
+
+func TestStructureCacheBounded(t *testing.T) {
+	sc := newStructureCache()
+	count := func(path string, src []byte) (map[string]int, error) {
+		return map[string]int{"*ast.File": len(src)}, nil
+	}
+	compare := func(a, b map[string]int) float64 { return 1 }
+	ref := []byte("package services\n")
+	for i := 0; i < 2*maxCachedStructures; i++ {
+		src := []byte(fmt.Sprintf("package services\n\nvar n = %d\n", i))
+		if _, err := sc.similarity("user.go", src, "ref.go", ref, count, compare); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sc.counts) > maxCachedStructures || len(sc.pairs) > maxCachedStructures {
+		t.Errorf("cache holds %d sources and %d pairs, want at most %d each", len(sc.counts), len(sc.pairs), maxCachedStructures)
+	}
+}
+
+func BenchmarkMultiTierStructures(b *testing.B) {
+	pattern, candidates := multiTierTree(b)
+	for _, cached := range []bool{true, false} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			compares := int64(0)
+			for i := 0; i < b.N; i++ {
+				m, _ := matchAll(b, pattern, candidates, cached)
+				compares += m.structureCompares.Load()
+			}
+			b.ReportMetric(float64(compares)/float64(b.N), "compares/op")
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"math"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// suppression is an allow annotation silencing one deviation element for
+// a whole file, the lines of a single function or, trailing code, its line
+type suppression struct {
+	Element   string
+	Reason    string
+	StartLine int // 0 for file scope
+	EndLine   int
+	Line      int        // Line of the annotation
+	Expires   *time.Time // Last day the annotation applies, nil for never
+	Lapsed    bool       // Past its expiry, so the deviation counts again
+}
+
+// covers checks if a deviation falls within the suppression's scope
+func (s suppression) covers(dev patterns.Deviation) bool {
+	if dev.Element != s.Element {
+		return false
+	}
+	if s.StartLine == 0 || fileLevel(dev) {
+		return true
+	}
+	return dev.LineNumber >= s.StartLine && dev.LineNumber <= s.EndLine
+}
+
+// fileLevel checks if a deviation is about the file as a whole, like
+// missing error handling or a missing import, rather than one of its
+// lines. Any allow naming its element covers it, wherever it is placed.
+func fileLevel(dev patterns.Deviation) bool {
+	return dev.LineNumber == 0 || dev.Element == "import"
+}
+
+// loadSuppressions collects allow annotations from a file. Annotations
+// without a reason are not honored and are reported as deviations instead.
+func loadSuppressions(c *candidate) ([]suppression, []patterns.Deviation) {
+	annotations, err := analyzer.NewAnnotationParser().Parse(bytes.NewReader(c.src))
+	if err != nil {
+		return nil, nil
+	}
+
+	suppressions := []suppression{}
+	invalid := []patterns.Deviation{}
+	for _, ann := range annotations {
+		if ann.Type != "allow" {
+			continue
+		}
+		if ann.Reason == "" {
+			invalid = append(invalid, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "allow_reason",
+				Expected:   fmt.Sprintf(`allow %s reason="..."`, ann.Element),
+				Actual:     "allow " + ann.Element,
+				Severity:   patterns.SeverityInfo,
+				Suggestion: "Give a reason for the allow annotation; it is ignored without one",
+				LineNumber: ann.LineNumber,
+			})
+			continue
+		}
+
+		s := suppression{
+			Element: ann.Element,
+			Reason:  ann.Reason,
+			Line:    ann.LineNumber,
+			Expires: ann.Expires,
+			Lapsed:  ann.Lapsed(time.Now()),
+		}
+		if ann.Trailing {
+			s.StartLine, s.EndLine = ann.LineNumber, ann.LineNumber
+		} else if ann.FunctionName != "" {
+			s.StartLine, s.EndLine = c.funcLines(ann.FunctionName)
+		}
+		suppressions = append(suppressions, s)
+	}
+	return suppressions, invalid
+}
+
+// applySuppressions splits deviations into those that still count and those
+// silenced by the candidate's allow annotations. Deviations covered only by
+// lapsed annotations count again, with a note that the suppression expired.
+func applySuppressions(c *candidate, deviations []patterns.Deviation) ([]patterns.Deviation, []patterns.SuppressedDeviation) {
+	if len(c.suppressions) == 0 {
+		return deviations, nil
+	}
+
+	kept := []patterns.Deviation{}
+	suppressed := []patterns.SuppressedDeviation{}
+	for _, dev := range deviations {
+		if s, ok := c.suppressionFor(dev, false); ok {
+			suppressed = append(suppressed, patterns.SuppressedDeviation{Deviation: dev, Reason: s.Reason})
+			continue
+		}
+		if s, ok := c.suppressionFor(dev, true); ok {
+			dev.Suggestion = fmt.Sprintf("%s (the allow annotation on line %d expired on %s)",
+				dev.Suggestion, s.Line, s.Expires.Format("2006-01-02"))
+		}
+		kept = append(kept, dev)
+	}
+	return kept, suppressed
+}
+
+// suppressionFor returns the first active, or with lapsed the first
+// expired, suppression covering a deviation
+func (c *candidate) suppressionFor(dev patterns.Deviation, lapsed bool) (suppression, bool) {
+	for _, s := range c.suppressions {
+		if s.Lapsed == lapsed && s.covers(dev) {
+			return s, true
+		}
+	}
+	return suppression{}, false
+}
+
+// funcLines returns the line range of a named function, or zeros if it
+// can't be found. TypeScript functions are assumed to run until the next one.
+func (c *candidate) funcLines(name string) (int, int) {
+	if c.file != nil {
+		if fn := findFunc(c.file, name); fn != nil {
+			return LineOf(c.file, fn.Pos()), LineOf(c.file, fn.End())
+		}
+		return 0, 0
+	}
+
+	for i, fn := range c.info.Functions {
+		if fn.Name != name {
+			continue
+		}
+		end := math.MaxInt
+		if i+1 < len(c.info.Functions) {
+			end = c.info.Functions[i+1].Line - 1
+		}
+		return fn.Line, end
+	}
+	return 0, 0
+}
+
+// findFunc finds a top-level function or method by name
+func findFunc(file *ast.File, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name {
+			return fn
+		}
+	}
+	return nil
+}
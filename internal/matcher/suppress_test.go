@@ -0,0 +1,110 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const suppressSource = `package services
+
+import "fmt"
+
+// @code-on-rails: allow error_handling reason="errors are logged by the caller"
+func (s *UserService) Get(id string) *User {
+	user := s.repo.Find(context.Background(), id) // @code-on-rails: allow context_propagation reason="detached lookup"
+	fmt.Println(user)
+	return user
+}
+
+func (s *UserService) List() []User {
+	return s.repo.All(context.Background())
+}
+
+// @code-on-rails: allow import reason="no logger in this package"
+func (s *UserService) Count() int {
+	return len(s.List()) // @code-on-rails: allow doc_comment
+}
+`
+
+func TestApplySuppressions(t *testing.T) {
+	c, err := parseCandidate("services/user.go", []byte(suppressSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deviations := []patterns.Deviation{
+		{Element: "error_handling"},                      // No line: covered by Get's allow
+		{Element: "import", LineNumber: 3},               // The import block, outside Count
+		{Element: "context_propagation", LineNumber: 7},  // The trailing allow's line
+		{Element: "context_propagation", LineNumber: 13}, // Another line, still counted
+		{Element: "doc_comment", LineNumber: 18},         // Trailing allow without a reason
+	}
+	kept, suppressed := applySuppressions(c, deviations)
+
+	if len(suppressed) != 3 {
+		t.Fatalf("suppressed %v, want error_handling, import and context_propagation on line 7", suppressed)
+	}
+	for i, want := range []string{"errors are logged by the caller", "no logger in this package", "detached lookup"} {
+		if suppressed[i].Reason != want {
+			t.Errorf("suppressed[%d] reason %q, want %q", i, suppressed[i].Reason, want)
+		}
+	}
+	sameDeviations(t, kept, []deviationAt{{"context_propagation", 13}, {"doc_comment", 18}})
+
+	if len(c.invalidAllows) != 1 || c.invalidAllows[0].LineNumber != 18 {
+		t.Errorf("invalid allows %v, want the reasonless one on line 18", deviationsAt(c.invalidAllows))
+	}
+}
+
+func TestSuppressionCovers(t *testing.T) {
+	fn := suppression{Element: "context_arg", StartLine: 10, EndLine: 20}
+	tests := []struct {
+		dev  patterns.Deviation
+		want bool
+	}{
+		{patterns.Deviation{Element: "context_arg", LineNumber: 15}, true},
+		{patterns.Deviation{Element: "context_arg", LineNumber: 25}, false},
+		{patterns.Deviation{Element: "context_arg"}, true}, // About the whole file
+		{patterns.Deviation{Element: "doc_comment", LineNumber: 15}, false},
+	}
+	for _, tt := range tests {
+		if got := fn.covers(tt.dev); got != tt.want {
+			t.Errorf("covers(%+v) = %v, want %v", tt.dev, got, tt.want)
+		}
+	}
+}
+
+const expiringSource = `package services
+
+// @code-on-rails: allow error_handling reason="legacy" expires=2020-01-31
+func Past() {}
+
+// @code-on-rails: allow error_handling reason="migrating" expires=2099-12-31
+func Future() {}
+
+// @code-on-rails: allow error_handling reason="by design"
+func Never() {}
+`
+
+func TestApplySuppressionsExpiry(t *testing.T) {
+	c, err := parseCandidate("services/user.go", []byte(expiringSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deviations := []patterns.Deviation{
+		{Element: "error_handling", LineNumber: 4, Suggestion: "Handle the error"},
+		{Element: "error_handling", LineNumber: 7, Suggestion: "Handle the error"},
+		{Element: "error_handling", LineNumber: 10, Suggestion: "Handle the error"},
+	}
+	kept, suppressed := applySuppressions(c, deviations)
+
+	if len(suppressed) != 2 || suppressed[0].Reason != "migrating" || suppressed[1].Reason != "by design" {
+		t.Errorf("suppressed %+v, want the future and never-expiring allows", suppressed)
+	}
+	sameDeviations(t, kept, []deviationAt{{"error_handling", 4}})
+	if want := "Handle the error (the allow annotation on line 3 expired on 2020-01-31)"; kept[0].Suggestion != want {
+		t.Errorf("lapsed suggestion = %q, want %q", kept[0].Suggestion, want)
+	}
+}
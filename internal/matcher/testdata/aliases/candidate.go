@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	stdhttp "net/http"
+
+	"github.com/acme/shop/internal/respond"
+	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+)
+
+func Routes(r *mux.Router) {
+	r.HandleFunc("/orders", ListOrders)
+}
+
+func ListOrders(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+	respond.JSON(w, stdhttp.StatusOK, []string{})
+}
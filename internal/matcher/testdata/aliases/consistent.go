@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	. "github.com/acme/shop/internal/respond"
+	router "github.com/gorilla/mux"
+)
+
+func Routes(r *router.Router) {
+	r.HandleFunc("/orders", ListOrders)
+}
+
+func ListOrders(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, []string{})
+}
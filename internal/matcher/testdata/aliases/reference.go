@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	. "github.com/acme/shop/internal/respond"
+	router "github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+)
+
+func Routes(r *router.Router) {
+	r.HandleFunc("/users", ListUsers)
+}
+
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, []string{})
+}
@@ -0,0 +1,17 @@
+import { api } from '../lib/api';
+import { Order } from '../types';
+
+export async function getOrder(id: string): Promise<Order> {
+  const res = await api.get(`/orders/${id}`);
+  return res.data;
+}
+
+export async function getOrders(ids: string[]): Promise<Order[]> {
+  // Fetched together; Promise.all awaits them
+  const results = await Promise.all(ids.map((id) => api.get(`/orders/${id}`)));
+  return results.map((res) => res.data);
+}
+
+export function deleteOrder(id: string): Promise<void> {
+  return api.post(`/orders/${id}/delete`);
+}
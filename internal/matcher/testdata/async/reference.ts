@@ -0,0 +1,11 @@
+import { api } from '../lib/api';
+import { User } from '../types';
+
+export async function getUser(id: string): Promise<User> {
+  const res = await api.get(`/users/${id}`);
+  return res.data;
+}
+
+export async function saveUser(user: User): Promise<void> {
+  await api.post('/users', user);
+}
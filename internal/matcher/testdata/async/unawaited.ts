@@ -0,0 +1,13 @@
+import { api } from '../lib/api';
+import { Order } from '../types';
+
+export async function getOrder(id: string): Promise<Order> {
+  return api.get(`/orders/${id}`).then((res) => res.data);
+}
+
+export async function saveOrder(order: Order): Promise<void> {
+  // api.post(...) is fire and forget here
+  api.post('/orders', order);
+  const audit = api.get('/audit');
+  console.log(audit);
+}
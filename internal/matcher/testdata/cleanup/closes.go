@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"io"
+	"os"
+)
+
+func ReadUsers(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeQuietly(f)
+	return io.ReadAll(f)
+}
+
+func CountOrders(db *sql.DB) (int, error) {
+	rows, err := db.Query("SELECT id FROM orders")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		rows.Close()
+	}()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+// OpenLog hands the file to its caller to close
+func OpenLog(path string) (*os.File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func closeQuietly(c io.Closer) {
+	_ = c.Close()
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"os"
+)
+
+type Pool interface {
+	Get() (io.Closer, error)
+}
+
+func ReadUsers(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+func CountOrders(db *sql.DB) (int, error) {
+	rows, err := db.Query("SELECT id FROM orders")
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	rows.Close()
+	return n, rows.Err()
+}
+
+func Ping(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
+
+func Borrow(pool Pool) error {
+	conn, err := pool.Get()
+	if err != nil {
+		return err
+	}
+	_ = conn
+	return nil
+}
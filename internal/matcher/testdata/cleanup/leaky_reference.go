@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+func ReadConfig(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	return data, err
+}
@@ -0,0 +1,7 @@
+package storage
+
+import "strings"
+
+func Normalize(name string) string {
+	return strings.ToLower(name)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+type OrderService struct {
+	repo OrderRepository
+}
+
+func (s *OrderService) Get(id string) (*Order, error) {
+	order, err := s.repo.Find(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+	return order, nil
+}
+
+func (s *OrderService) Delete(id string, ctx context.Context) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *OrderService) Cancel(ctx context.Context, id string) error {
+	return s.repo.Cancel(context.TODO(), id)
+}
+
+func (s *OrderService) Close(_ context.Context) error {
+	return s.repo.Close(context.Background())
+}
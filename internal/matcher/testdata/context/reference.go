@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+type UserService struct {
+	repo UserRepository
+}
+
+func (s *UserService) Get(ctx context.Context, id string) (*User, error) {
+	user, err := s.repo.Find(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
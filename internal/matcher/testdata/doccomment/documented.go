@@ -0,0 +1,20 @@
+package services
+
+// Limits on orders
+const (
+	MaxOrders = 10
+	MinOrders = 1
+)
+
+// OrderService manages orders
+type OrderService struct{}
+
+// Create adds an order
+func (s *OrderService) Create(id string) error { return nil }
+
+// NewOrderService creates an order service
+func NewOrderService() *OrderService { return &OrderService{} }
+
+type cache struct{}
+
+func (c *cache) Get(key string) string { return "" }
@@ -0,0 +1,13 @@
+package services
+
+// MaxUsers caps how many users a service holds
+const MaxUsers = 100
+
+// UserService manages users
+type UserService struct{}
+
+// Create adds a user
+func (s *UserService) Create(name string) error { return nil }
+
+// NewUserService creates a user service
+func NewUserService() *UserService { return &UserService{} }
@@ -0,0 +1,16 @@
+package services
+
+var DefaultTimeout = 30
+
+type OrderService struct{}
+
+func (s *OrderService) Create(id string) error { return nil }
+
+func NewOrderService() *OrderService { return &OrderService{} }
+
+type cache struct{}
+
+func (c *cache) Get(key string) string { return "" }
+
+// helper is unexported and needs no doc comment
+func helper() {}
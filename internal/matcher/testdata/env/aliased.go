@@ -0,0 +1,7 @@
+package services
+
+import sys "os"
+
+func reportDir() string {
+	return sys.Getenv("REPORT_DIR")
+}
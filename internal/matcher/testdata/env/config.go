@@ -0,0 +1,7 @@
+package config
+
+import "os"
+
+func ReportDir() string {
+	return os.Getenv("REPORT_DIR")
+}
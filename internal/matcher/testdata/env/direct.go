@@ -0,0 +1,20 @@
+package services
+
+import (
+	"os"
+	"time"
+)
+
+type ReportService struct{}
+
+func (s *ReportService) Dir() string {
+	return os.Getenv("REPORT_DIR")
+}
+
+func (s *ReportService) Timeout() time.Duration {
+	if v, ok := os.LookupEnv("REPORT_TIMEOUT"); ok {
+		d, _ := time.ParseDuration(v)
+		return d
+	}
+	return time.Minute
+}
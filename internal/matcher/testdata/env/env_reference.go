@@ -0,0 +1,7 @@
+package services
+
+import "os"
+
+func apiKey() string {
+	return os.Getenv("API_KEY")
+}
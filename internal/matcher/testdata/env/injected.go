@@ -0,0 +1,15 @@
+package services
+
+import "os"
+
+type ReportService struct {
+	dir string
+}
+
+func NewReportService(dir string) *ReportService {
+	return &ReportService{dir: dir}
+}
+
+func (s *ReportService) Open(name string) (*os.File, error) {
+	return os.Open(s.dir + "/" + name)
+}
@@ -0,0 +1,20 @@
+package services
+
+import "time"
+
+type Settings struct {
+	APIKey  string
+	Timeout time.Duration
+}
+
+type BillingService struct {
+	settings Settings
+}
+
+func NewBillingService(settings Settings) *BillingService {
+	return &BillingService{settings: settings}
+}
+
+func (s *BillingService) Key() string {
+	return s.settings.APIKey
+}
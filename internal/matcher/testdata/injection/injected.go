@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type UserService struct {
+	db    *sql.DB
+	cache *redis.Client
+}
+
+func NewUserService(db *sql.DB, cache *redis.Client) *UserService {
+	return &UserService{db: db, cache: cache}
+}
+
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id); err != nil {
+		return err
+	}
+	return s.cache.Del(ctx, "user:"+id).Err()
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type UserService struct{}
+
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id); err != nil {
+		return err
+	}
+	cache := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	return cache.Del(ctx, "user:"+id).Err()
+}
+
+func (s *UserService) Audit(ctx context.Context, id string) error {
+	log, err := audit.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	return log.Record(ctx, "delete", id)
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+)
+
+type OrderService struct {
+	dsn string
+}
+
+func NewOrderService(dsn string) *OrderService {
+	return &OrderService{dsn: dsn}
+}
+
+func (s *OrderService) Cancel(ctx context.Context, id string) error {
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.ExecContext(ctx, "UPDATE orders SET cancelled = true WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+)
+
+type OrderService struct {
+	db *sql.DB
+}
+
+func NewOrderService(db *sql.DB) *OrderService {
+	return &OrderService{db: db}
+}
+
+func (s *OrderService) Cancel(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE orders SET cancelled = true WHERE id = $1", id)
+	return err
+}
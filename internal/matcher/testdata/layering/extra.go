@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func (r *OrderRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", strings.TrimSpace(id)); err != nil {
+		return fmt.Errorf("delete order %s: %w", id, err)
+	}
+	return nil
+}
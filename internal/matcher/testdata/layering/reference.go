@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	return err
+}
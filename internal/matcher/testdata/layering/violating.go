@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func (r *OrderRepository) Delete(ctx context.Context, req *http.Request) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", chi.URLParam(req, "id"))
+	return err
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/acme/app/internal/logger"
+)
+
+type OrderService struct{}
+
+func (s *OrderService) Create(id string) error {
+	logger.Info("creating order", "id", id)
+	return fmt.Errorf("not implemented: %s", id)
+}
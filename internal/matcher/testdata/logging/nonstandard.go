@@ -0,0 +1,17 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"go.uber.org/zap"
+)
+
+type OrderService struct{ log *zap.Logger }
+
+func (s *OrderService) Create(id string) error {
+	log.Printf("creating order %s", id)
+	s.log.Info("creating order")
+	fmt.Println("order", id)
+	return nil
+}
@@ -0,0 +1,10 @@
+package services
+
+import "github.com/acme/app/internal/logger"
+
+type UserService struct{}
+
+func (s *UserService) Create(name string) error {
+	logger.Info("creating user", "name", name)
+	return nil
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(Recovery)
+	r.Use(Auth)
+	r.Use(Logging)
+	return r
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func Routes(db *gorm.DB, app *App, cfg Config) http.Handler {
+	// Unrelated Use methods aren't middleware registrations
+	db.Use(plugin)
+	app.Use(cfg)
+
+	r := chi.NewRouter()
+	r.Use(Recovery, Logging)
+	r.Use(Auth)
+	return r
+}
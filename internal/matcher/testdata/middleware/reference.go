@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Recovery(next http.Handler) http.Handler { return next }
+
+func Logging(next http.Handler) http.Handler { return next }
+
+func Auth(next http.Handler) http.Handler { return next }
+
+func Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(Recovery)
+	r.Use(Logging)
+	r.Use(Auth)
+	return r
+}
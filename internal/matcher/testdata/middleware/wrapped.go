@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+func Handler(db *gorm.DB, x http.HandlerFunc) http.Handler {
+	db.Use(plugin)
+
+	// Plain calls wrapping the handler aren't middleware
+	inner := http.HandlerFunc(wrap(trace(decode(x))))
+	return Recovery(Auth(Logging(inner)))
+}
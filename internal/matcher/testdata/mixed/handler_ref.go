@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type UserHandler struct {
+	service UserService
+}
+
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.Get(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(user)
+}
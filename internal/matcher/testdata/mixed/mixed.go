@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+type OrderHandler struct {
+	db *sql.DB
+}
+
+func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	order, err := h.find(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *OrderHandler) find(ctx context.Context, id string) (*Order, error) {
+	row := h.db.QueryRowContext(ctx, "SELECT id, total FROM orders WHERE id = $1", id)
+	var o Order
+	if err := row.Scan(&o.ID, &o.Total); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
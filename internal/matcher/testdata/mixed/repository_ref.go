@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func (r *UserRepository) Find(ctx context.Context, id string) (*User, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT id, name FROM users WHERE id = $1", id)
+	var u User
+	if err := row.Scan(&u.ID, &u.Name); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Timestamps records when a row changed
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// BaseEntity is embedded by every model
+type BaseEntity struct {
+	ID string
+	Timestamps
+}
@@ -0,0 +1,7 @@
+package models
+
+// Order gets ID, CreatedAt and UpdatedAt from BaseEntity
+type Order struct {
+	*BaseEntity
+	Total int
+}
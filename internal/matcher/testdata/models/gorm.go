@@ -0,0 +1,9 @@
+package models
+
+import "gorm.io/gorm"
+
+// Invoice gets its fields from gorm.Model
+type Invoice struct {
+	gorm.Model
+	Amount int
+}
@@ -0,0 +1,7 @@
+package models
+
+// Coupon declares its ID but not its timestamps
+type Coupon struct {
+	ID   string
+	Code string
+}
@@ -0,0 +1,9 @@
+package models
+
+import "example.com/shared"
+
+// Refund embeds a base struct from outside the package
+type Refund struct {
+	shared.Entity
+	Amount int
+}
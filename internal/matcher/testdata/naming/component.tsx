@@ -0,0 +1,5 @@
+type Props = { name: string };
+
+export function UserCard({ name }: Props) {
+  return <div>{name}</div>;
+}
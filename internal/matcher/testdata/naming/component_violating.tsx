@@ -0,0 +1,13 @@
+type Props = { name: string };
+
+export function userCard({ name }: Props) {
+  return <div>{name}</div>;
+}
+
+export function User_badge({ name }: Props) {
+  return <span>{name}</span>;
+}
+
+function formatName(name: string) {
+  return name.trim();
+}
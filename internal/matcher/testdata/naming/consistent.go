@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+type OrderService struct {
+	MaxItems      int
+	_             struct{}
+	internal_note string
+}
+
+func NewOrderService(max int) *OrderService {
+	return &OrderService{MaxItems: max}
+}
+
+func (s *OrderService) CancelOrder(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *OrderService) fetch_items(ctx context.Context) {}
+
+func TestCancelOrder_missing(t *testing.T) {}
@@ -0,0 +1,9 @@
+import { useEffect, useState } from "react";
+
+export function useUser(id: string) {
+  const [user, setUser] = useState(null);
+  useEffect(() => {
+    fetch(`/users/${id}`).then((r) => r.json()).then(setUser);
+  }, [id]);
+  return user;
+}
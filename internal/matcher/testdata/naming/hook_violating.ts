@@ -0,0 +1,17 @@
+import { useEffect, useState } from "react";
+
+export function fetchOrders() {
+  const [orders, setOrders] = useState([]);
+  useEffect(() => {
+    fetch("/orders").then((r) => r.json()).then(setOrders);
+  }, []);
+  return orders;
+}
+
+export function user_orders() {
+  return fetchOrders();
+}
+
+export function useful() {
+  return true;
+}
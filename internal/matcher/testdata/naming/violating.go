@@ -0,0 +1,15 @@
+package services
+
+import "context"
+
+type Order_Service struct {
+	Max_items int
+}
+
+func New_order_service(max int) *Order_Service {
+	return &Order_Service{Max_items: max}
+}
+
+func (s *Order_Service) Cancel_order(ctx context.Context, id string) error {
+	return nil
+}
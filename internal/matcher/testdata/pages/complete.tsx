@@ -0,0 +1,13 @@
+import type { Metadata } from 'next';
+import { getOrders } from '@/lib/orders';
+
+async function OrdersPage() {
+  const orders = await getOrders();
+  return <OrderList orders={orders} />;
+}
+
+export async function generateMetadata(): Promise<Metadata> {
+  return { title: 'Orders' };
+}
+
+export { OrdersPage as default };
@@ -0,0 +1,6 @@
+import { getOrders } from '@/lib/orders';
+
+export async function OrdersPage() {
+  const orders = await getOrders();
+  return <OrderList orders={orders} />;
+}
@@ -0,0 +1,5 @@
+import { Card } from './Card';
+
+const Default = { args: {} };
+
+export { Default };
@@ -0,0 +1,8 @@
+import { Button } from './Button';
+
+export default {
+  title: 'Button',
+  component: Button,
+};
+
+export const Primary = { args: { primary: true } };
@@ -0,0 +1,11 @@
+import type { Metadata } from 'next';
+import { getUsers } from '@/lib/users';
+
+export async function generateMetadata(): Promise<Metadata> {
+  return { title: 'Users' };
+}
+
+export default async function UsersPage() {
+  const users = await getUsers();
+  return <UserList users={users} />;
+}
@@ -0,0 +1,7 @@
+import React from 'react';
+
+// Props are typed as any until the API settles
+export function Link(props: any) {
+  const target = props.external as any;
+  return <a href={props.href} target={target}>{props.label}</a>;
+}
@@ -0,0 +1,10 @@
+import React from 'react';
+
+export interface LinkProps {
+  href: string;
+  label: string;
+}
+
+export const Link: React.FC<LinkProps> = ({ href, label }) => {
+  return <a href={href}>{label}</a>;
+};
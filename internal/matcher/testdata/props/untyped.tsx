@@ -0,0 +1,5 @@
+import React from 'react';
+
+export const Link: React.FC = ({ href, label }) => {
+  return <a href={href}>{label}</a>;
+};
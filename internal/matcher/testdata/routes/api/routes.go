@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"example.com/shop/handlers"
+)
+
+func Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/users", handlers.ListUsers)
+	mux.HandleFunc("/users/new", handlers.CreateUser)
+}
+
+// Healthz is declared in the route file but never registered
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode([]string{})
+}
+
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+}
+
+func DeleteUser(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func decodeUser(r *http.Request) (string, error) {
+	var name string
+	err := json.NewDecoder(r.Body).Decode(&name)
+	return name, err
+}
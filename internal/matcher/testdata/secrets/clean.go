@@ -0,0 +1,15 @@
+package handlers
+
+import "net/http"
+
+type UserHandler struct {
+	DSN string `json:"dsn" env:"USER_DB_DSN_WITH_A_LONG_TAG_1234567890"`
+}
+
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	url := "https://api.example.com/v1/users"
+	id := "00000000-0000-0000-0000-000000000000"
+	sha := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	_, _, _ = url, id, sha
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetOrder(c *gin.Context) {
+	c.JSON(200, gin.H{"ok": true})
+}
+
+func Ping(c *gin.Context) {
+	c.String(http.StatusOK, "pong")
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := load(r)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(order)
+}
+
+func CreateOrder(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(299)
+}
+
+func ListOrders(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(nil)
+}
+
+func helper(n int) int {
+	return n * 200
+}
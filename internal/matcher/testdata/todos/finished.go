@@ -0,0 +1,22 @@
+package services
+
+import "errors"
+
+type OrderService struct{}
+
+func (s *OrderService) Get(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("empty id")
+	}
+	return id, nil
+}
+
+// TODO: cache orders
+func (s *OrderService) List() ([]string, error) {
+	return nil, nil
+}
+
+// Cancel says "todo" in a string, which isn't a marker
+func (s *OrderService) Cancel(id string) error {
+	return errors.New("todo list is empty")
+}
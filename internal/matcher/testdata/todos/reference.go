@@ -0,0 +1,20 @@
+package services
+
+import "errors"
+
+type UserService struct{}
+
+func (s *UserService) Get(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("empty id")
+	}
+	return id, nil
+}
+
+// TODO(ops): move to the audit service once it ships
+func (s *UserService) Audit(id string) error {
+	if id == "" {
+		return errors.New("empty id")
+	}
+	return nil
+}
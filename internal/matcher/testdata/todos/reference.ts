@@ -0,0 +1,9 @@
+import { api } from './api';
+
+export async function getUser(id: string) {
+  return api.get(`/users/${id}`);
+}
+
+export async function listUsers() {
+  return api.get('/users');
+}
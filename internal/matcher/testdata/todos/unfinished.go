@@ -0,0 +1,23 @@
+package services
+
+import "errors"
+
+type OrderService struct{}
+
+// TODO: validate the id
+func (s *OrderService) Get(id string) (string, error) {
+	return id, nil
+}
+
+func (s *OrderService) List() ([]string, error) {
+	panic("not implemented")
+}
+
+func (s *OrderService) Cancel(id string) error {
+	// FIXME: handle refunds
+	return errors.New("cancel failed")
+}
+
+func (s *OrderService) Refund(id string) error {
+	panic("TODO")
+}
@@ -0,0 +1,15 @@
+import { api } from './api';
+
+// TODO: validate the id
+export async function getOrder(id: string) {
+  return api.get(`/orders/${id}`);
+}
+
+export async function listOrders() {
+  throw new Error("Not implemented");
+}
+
+export async function cancelOrder(id: string) {
+  /* FIXME: cancel on the server */
+  throw new NotImplementedException();
+}
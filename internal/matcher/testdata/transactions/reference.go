@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func (r *OrderRepository) Create(ctx context.Context, id, item string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO orders (id) VALUES ($1)", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO order_items (order_id, item) VALUES ($1, $2)", id, item); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *OrderRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func (r *UserRepository) Create(ctx context.Context, id, role string) error {
+	if _, err := r.db.ExecContext(ctx, "INSERT INTO users (id) VALUES ($1)", id); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) VALUES ($1, $2)", id, role)
+	return err
+}
+
+func (r *UserRepository) Rename(ctx context.Context, id, name string) error {
+	return r.store.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET name = $1 WHERE id = $2", name, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO user_events (user_id, kind) VALUES ($1, 'rename')", id)
+		return err
+	})
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func (r *OrderRepository) Create(ctx context.Context, id, item string) error {
+	if _, err := r.db.ExecContext(ctx, "INSERT INTO orders (id) VALUES ($1)", id); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO order_items (order_id, item) VALUES ($1, $2)", id, item)
+	return err
+}
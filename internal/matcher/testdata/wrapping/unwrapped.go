@@ -0,0 +1,21 @@
+package services
+
+import (
+	"fmt"
+	"os"
+)
+
+func Save(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Stat(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %v", path, err)
+	}
+	return info, nil
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"fmt"
+	"os"
+)
+
+func Save(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("save %s: %w", path, err)
+	}
+	return nil
+}
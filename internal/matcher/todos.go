@@ -0,0 +1,141 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// TodoCheckName is the config name of the unfinished work check
+const TodoCheckName = "todo-density"
+
+// DefaultTodoDensity is how many unfinished work markers per 100 lines a
+// file may have beyond its reference's density
+const DefaultTodoDensity = 1.0
+
+var (
+	// todoMarker matches TODO and FIXME notes in comment text
+	todoMarker = regexp.MustCompile(`\b(TODO|FIXME)\b`)
+	// textTodoComment matches a TODO or FIXME in a // or /* */ comment line
+	textTodoComment = regexp.MustCompile(`(//|/\*|^\s*\*).*\b(TODO|FIXME)\b`)
+	// textStub matches throw statements for unimplemented code, e.g.
+	// throw new Error("not implemented") or throw new NotImplementedException()
+	textStub = regexp.MustCompile(`throw\s+new\s+(NotImplementedException\b|\w*Error\(\s*["'\x60][^"'\x60]*(?i:not\s+implemented|unimplemented|todo))`)
+)
+
+// todoCheck flags files with noticeably more TODO/FIXME notes and
+// not-implemented stubs than their reference, a sign of unfinished work
+// that otherwise resembles the pattern
+type todoCheck struct {
+	density float64 // Markers per 100 lines allowed beyond the reference's
+}
+
+// NewTodoCheck creates the unfinished work check. density is the number of
+// markers per 100 lines a file may have beyond its reference's; 0 means
+// DefaultTodoDensity.
+func NewTodoCheck(density float64) Check {
+	if density == 0 {
+		density = DefaultTodoDensity
+	}
+	return todoCheck{density: density}
+}
+
+func (todoCheck) Name() string { return TodoCheckName }
+
+func (c todoCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	refMarkers := goTodoMarkers(ref)
+	return c.deviations(goTodoMarkers(file), LineOf(file, file.End()), len(refMarkers), LineOf(ref, ref.End()))
+}
+
+// EvaluateSource runs the check on TypeScript/JavaScript and C# files
+func (c todoCheck) EvaluateSource(src, refSrc []byte, pattern patterns.Pattern) []patterns.Deviation {
+	refMarkers := textTodoMarkers(refSrc)
+	return c.deviations(textTodoMarkers(src), bytes.Count(src, []byte("\n"))+1, len(refMarkers), bytes.Count(refSrc, []byte("\n"))+1)
+}
+
+// deviations compares the marker density of a file with its reference's
+func (c todoCheck) deviations(markers []int, lines, refMarkers, refLines int) []patterns.Deviation {
+	if len(markers) == 0 || lines == 0 || refLines == 0 {
+		return nil
+	}
+	allowed := per100(refMarkers, refLines) + c.density
+	if per100(len(markers), lines) <= allowed {
+		return nil
+	}
+
+	at := make([]string, len(markers))
+	for i, line := range markers {
+		at[i] = strconv.Itoa(line)
+	}
+	return []patterns.Deviation{{
+		Type:       patterns.DeviationNovel,
+		Element:    "unfinished_work",
+		Expected:   fmt.Sprintf("at most %.1f TODO/FIXME/not-implemented markers per 100 lines", allowed),
+		Actual:     fmt.Sprintf("%d in %d lines", len(markers), lines),
+		Severity:   patterns.SeverityWarning,
+		Suggestion: fmt.Sprintf("Finish or remove the unfinished work at line(s) %s", strings.Join(at, ", ")),
+		LineNumber: markers[0],
+	}}
+}
+
+// per100 is a count per 100 lines
+func per100(count, lines int) float64 {
+	return float64(count) * 100 / float64(lines)
+}
+
+// goTodoMarkers finds the lines of TODO/FIXME comments and of panics
+// reporting unimplemented code in a Go file
+func goTodoMarkers(file *ast.File) []int {
+	markers := []int{}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if todoMarker.MatchString(comment.Text) {
+				markers = append(markers, LineOf(file, comment.Pos()))
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return true
+		}
+		if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "panic" {
+			return true
+		}
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING && isStubMessage(lit.Value) {
+			markers = append(markers, LineOf(file, call.Pos()))
+		}
+		return true
+	})
+	sort.Ints(markers)
+	return markers
+}
+
+// isStubMessage checks if a quoted panic message reports unimplemented code
+func isStubMessage(quoted string) bool {
+	msg, err := strconv.Unquote(quoted)
+	if err != nil {
+		return false
+	}
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "not implemented") || strings.Contains(msg, "unimplemented") || strings.HasPrefix(msg, "todo")
+}
+
+// textTodoMarkers finds the lines of TODO/FIXME comments and of
+// not-implemented throws in TypeScript/JavaScript or C# source
+func textTodoMarkers(src []byte) []int {
+	markers := []int{}
+	for i, line := range strings.Split(string(src), "\n") {
+		if textTodoComment.MatchString(line) || textStub.MatchString(line) {
+			markers = append(markers, i+1)
+		}
+	}
+	return markers
+}
@@ -0,0 +1,52 @@
+package matcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestTodoDensity(t *testing.T) {
+	ref := parseFixture(t, "todos/reference.go")
+	check := NewTodoCheck(0)
+
+	// One TODO, like the reference's, is within the allowance
+	got := check.Evaluate(parseFixture(t, "todos/finished.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+
+	got = check.Evaluate(parseFixture(t, "todos/unfinished.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{{"unfinished_work", 7}})
+	dev := got[0]
+	if dev.Severity != patterns.SeverityWarning || dev.Actual != "4 in 23 lines" {
+		t.Errorf("deviation = %+v, want a warning for 4 markers in 23 lines", dev)
+	}
+	if want := "Finish or remove the unfinished work at line(s) 7, 13, 17, 22"; dev.Suggestion != want {
+		t.Errorf("suggestion = %q, want %q", dev.Suggestion, want)
+	}
+
+	// A looser threshold lets the same file through
+	got = NewTodoCheck(20).Evaluate(parseFixture(t, "todos/unfinished.go"), ref, patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+}
+
+func TestTodoDensitySource(t *testing.T) {
+	read := func(name string) []byte {
+		src, err := os.ReadFile(filepath.Join("testdata", "todos", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+	check := NewTodoCheck(0).(SourceCheck)
+
+	got := check.EvaluateSource(read("unfinished.ts"), read("reference.ts"), patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{{"unfinished_work", 3}})
+	if want := "Finish or remove the unfinished work at line(s) 3, 9, 13, 14"; got[0].Suggestion != want {
+		t.Errorf("suggestion = %q, want %q", got[0].Suggestion, want)
+	}
+
+	got = check.EvaluateSource(read("reference.ts"), read("reference.ts"), patterns.Pattern{})
+	sameDeviations(t, got, []deviationAt{})
+}
@@ -0,0 +1,134 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// TransactionCheckName is the config name of the transaction boundary check
+const TransactionCheckName = "transaction-boundaries"
+
+// DefaultTransactionCalls are the method names recognized as opening a
+// transaction out of the box: database/sql, sqlx, pgx and GORM
+var DefaultTransactionCalls = []string{
+	"Begin",
+	"BeginTx",
+	"Beginx",
+	"BeginTxx",
+	"Transaction",
+}
+
+// writeCalls are the method names counted as writes to a database
+var writeCalls = map[string]bool{
+	"Exec":             true,
+	"ExecContext":      true,
+	"NamedExec":        true,
+	"NamedExecContext": true,
+	"Create":           true,
+	"Save":             true,
+	"Update":           true,
+	"Updates":          true,
+	"UpdateColumn":     true,
+	"UpdateColumns":    true,
+	"Delete":           true,
+	"Insert":           true,
+	"InsertOne":        true,
+	"InsertMany":       true,
+}
+
+// transactionCheck flags repository and service functions that make several
+// writes outside a transaction, where every reference function that makes
+// several writes wraps them in one
+type transactionCheck struct {
+	begins map[string]bool
+}
+
+// NewTransactionCheck creates the transaction boundary check recognizing
+// extra methods that open a transaction, e.g. a project's own WithTx, on top
+// of DefaultTransactionCalls
+func NewTransactionCheck(extra []string) Check {
+	c := transactionCheck{begins: make(map[string]bool)}
+	for _, name := range append(append([]string{}, DefaultTransactionCalls...), extra...) {
+		c.begins[name] = true
+	}
+	return c
+}
+
+func (transactionCheck) Name() string { return TransactionCheckName }
+
+func (c transactionCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternRepository && pattern.Type != patterns.PatternService {
+		return nil
+	}
+
+	refWrites := false
+	for _, fn := range funcDecls(ref) {
+		writes, transaction := c.writes(fn)
+		if len(writes) < 2 {
+			continue
+		}
+		if !transaction {
+			return nil
+		}
+		refWrites = true
+	}
+	if !refWrites {
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range funcDecls(file) {
+		writes, transaction := c.writes(fn)
+		if len(writes) < 2 || transaction {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    string(patterns.ElementTransaction),
+			Expected:   "writes wrapped in a transaction",
+			Actual:     fmt.Sprintf("%s makes %d writes without a transaction", fn.Name.Name, len(writes)),
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Wrap the writes in %s in a transaction, like the reference, so a failure can't leave them half applied", fn.Name.Name),
+			LineNumber: LineOf(file, writes[1]),
+		})
+	}
+	return deviations
+}
+
+// writes finds the write calls a function makes, and whether it opens a
+// transaction
+func (c transactionCheck) writes(fn *ast.FuncDecl) ([]token.Pos, bool) {
+	writes := []token.Pos{}
+	transaction := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if c.begins[sel.Sel.Name] {
+			transaction = true
+		} else if writeCalls[sel.Sel.Name] {
+			writes = append(writes, call.Pos())
+		}
+		return true
+	})
+	return writes, transaction
+}
+
+// funcDecls lists the functions and methods with a body declared in a file
+func funcDecls(file *ast.File) []*ast.FuncDecl {
+	funcs := []*ast.FuncDecl{}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			funcs = append(funcs, fn)
+		}
+	}
+	return funcs
+}
@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestTransactionBoundaries(t *testing.T) {
+	ref := parseFixture(t, "transactions/reference.go")
+	repository := patterns.Pattern{Type: patterns.PatternRepository}
+	check := NewTransactionCheck(nil)
+
+	got := check.Evaluate(parseFixture(t, "transactions/transactional.go"), ref, repository)
+	sameDeviations(t, got, []deviationAt{})
+
+	// Rename opens its transaction through WithTx, which isn't recognized yet
+	got = check.Evaluate(parseFixture(t, "transactions/untransactional.go"), ref, repository)
+	sameDeviations(t, got, []deviationAt{
+		{"transaction", 16}, // Create, at its second write
+		{"transaction", 25}, // Rename
+	})
+	if got[0].Severity != patterns.SeverityWarning || got[0].Actual != "Create makes 2 writes without a transaction" {
+		t.Errorf("deviation = %+v, want a warning for Create", got[0])
+	}
+
+	got = NewTransactionCheck([]string{"WithTx"}).Evaluate(parseFixture(t, "transactions/untransactional.go"), ref, repository)
+	sameDeviations(t, got, []deviationAt{
+		{"transaction", 16},
+	})
+}
+
+func TestTransactionBoundariesFollowReference(t *testing.T) {
+	file := parseFixture(t, "transactions/untransactional.go")
+	check := NewTransactionCheck(nil)
+
+	// References writing several times outside a transaction set no expectation
+	got := check.Evaluate(file, parseFixture(t, "transactions/untransactional_reference.go"), patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Only repositories and services are checked
+	got = check.Evaluate(file, parseFixture(t, "transactions/reference.go"), patterns.Pattern{Type: patterns.PatternHTTPHandler})
+	sameDeviations(t, got, []deviationAt{})
+}
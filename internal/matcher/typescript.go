@@ -0,0 +1,106 @@
+package matcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/internal/analyzer"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// isTypeScriptFile checks if a file is matched with the text-based
+// TypeScript/JavaScript parser instead of go/parser
+func isTypeScriptFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ts", ".tsx", ".js", ".jsx":
+		return true
+	}
+	return false
+}
+
+// isCSharpFile checks if a file is matched with the text-based C# parser
+func isCSharpFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".cs"
+}
+
+// parseText parses a TypeScript/JavaScript or C# file's contents
+func parseText(path string, src []byte) *patterns.FileInfo {
+	if isCSharpFile(path) {
+		return analyzer.ParseCSharpSource(path, src)
+	}
+	return analyzer.ParseTypeScriptSource(path, src)
+}
+
+// parseTextReference reads and parses a TypeScript/JavaScript or C#
+// reference file
+func (m *Matcher) parseTextReference(path string) (*patterns.FileInfo, error) {
+	src, err := m.readReference(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseText(path, src), nil
+}
+
+// compareText compares a TypeScript/JavaScript or C# candidate to a
+// reference file, returning the deviations found and their structural similarity
+func (m *Matcher) compareText(c *candidate, referencePath string, pattern patterns.Pattern) ([]patterns.Deviation, float64, error) {
+	refSrc, err := m.readReference(referencePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	ref := parseText(referencePath, refSrc)
+
+	importLine := 0
+	for _, line := range c.info.ImportLines {
+		if line > importLine {
+			importLine = line
+		}
+	}
+	deviations := missingImports(c.imports, ref.Imports, importLine)
+	deviations = append(deviations, m.extraImports(c.imports, ref.Imports, c.info.ImportLines, pattern.Type)...)
+	deviations = append(deviations, missingAttributes(c.info.Attributes, ref.Attributes)...)
+	deviations = append(deviations, frameworkDeviations(c.src, refSrc, pattern)...)
+
+	// Run the custom checks that work on source text
+	for _, check := range m.Checks {
+		if sc, ok := check.(SourceCheck); ok {
+			deviations = append(deviations, sc.EvaluateSource(c.src, refSrc, pattern)...)
+		}
+	}
+
+	return deviations, m.Scoring.nodeSimilarity(declarationCounts(c.info), declarationCounts(ref)), nil
+}
+
+// declarationCounts summarizes a file's top-level shape for similarity
+func declarationCounts(info *patterns.FileInfo) map[string]int {
+	counts := map[string]int{
+		"import":   len(info.Imports),
+		"function": len(info.Functions),
+	}
+	for _, typ := range info.Types {
+		counts[typ.Kind]++
+	}
+	if len(info.Attributes) > 0 {
+		counts["attribute"] = len(info.Attributes)
+	}
+	return counts
+}
+
+// missingAttributes flags C# attributes the reference uses and the
+// candidate lacks, such as [ApiController] or [Authorize]
+func missingAttributes(attributes, refAttributes []string) []patterns.Deviation {
+	deviations := []patterns.Deviation{}
+	for _, attr := range refAttributes {
+		if !contains(attributes, attr) {
+			deviations = append(deviations, patterns.Deviation{
+				Type:       patterns.DeviationMissing,
+				Element:    "attribute",
+				Expected:   "[" + attr + "]",
+				Severity:   patterns.SeverityWarning,
+				Suggestion: fmt.Sprintf("Add the [%s] attribute like the reference", attr),
+			})
+		}
+	}
+	return deviations
+}
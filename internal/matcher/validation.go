@@ -0,0 +1,171 @@
+package matcher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// ValidationCheckName is the config name of the input validation check
+const ValidationCheckName = "input-validation"
+
+// DefaultValidationCalls are the calls recognized as validating a decoded
+// request out of the box: a Validate method and the go-playground/validator,
+// ozzo-validation and govalidator entry points
+var DefaultValidationCalls = []string{
+	"Validate",
+	"ValidateWithContext",
+	"Struct",
+	"StructCtx",
+	"ValidateStruct",
+	"ValidateStructWithContext",
+}
+
+// validationCheck flags API handlers that decode a request body without
+// validating it, where every reference handler that decodes one validates
+// it afterwards
+type validationCheck struct {
+	names     map[string]bool // Function or method names, whatever they are called on
+	qualified map[string]bool // pkg.Func names, called through a package or variable of that name
+}
+
+// NewValidationCheck creates the input validation check recognizing extra
+// validation calls on top of DefaultValidationCalls. An entry such as
+// "Check" matches any call to a function or method of that name, while
+// "rules.Check" only matches calls through rules.
+func NewValidationCheck(extra []string) Check {
+	c := validationCheck{names: make(map[string]bool), qualified: make(map[string]bool)}
+	for _, name := range append(append([]string{}, DefaultValidationCalls...), extra...) {
+		if strings.Contains(name, ".") {
+			c.qualified[name] = true
+		} else {
+			c.names[name] = true
+		}
+	}
+	return c
+}
+
+func (validationCheck) Name() string { return ValidationCheckName }
+
+func (c validationCheck) Evaluate(file *ast.File, ref *ast.File, pattern patterns.Pattern) []patterns.Deviation {
+	if pattern.Type != patterns.PatternHTTPHandler && pattern.Type != patterns.PatternAPI {
+		return nil
+	}
+
+	refDecodes := false
+	for _, fn := range handlerFuncs(ref) {
+		decode := bodyDecode(fn)
+		if decode == token.NoPos {
+			continue
+		}
+		if !c.validatesAfter(fn, decode) {
+			return nil
+		}
+		refDecodes = true
+	}
+	if !refDecodes {
+		return nil
+	}
+
+	deviations := []patterns.Deviation{}
+	for _, fn := range handlerFuncs(file) {
+		decode := bodyDecode(fn)
+		if decode == token.NoPos || c.validatesAfter(fn, decode) {
+			continue
+		}
+		deviations = append(deviations, patterns.Deviation{
+			Type:       patterns.DeviationMissing,
+			Element:    "input_validation",
+			Expected:   "validation of the decoded request",
+			Actual:     fn.Name.Name + " decodes without validating",
+			Severity:   patterns.SeverityWarning,
+			Suggestion: fmt.Sprintf("Validate the request %s decodes before using it, like the reference handlers", fn.Name.Name),
+			LineNumber: LineOf(file, decode),
+		})
+	}
+	return deviations
+}
+
+// bodyDecode finds where a function first decodes JSON with
+// json.NewDecoder(...).Decode, directly or through a decoder variable
+func bodyDecode(fn *ast.FuncDecl) token.Pos {
+	decoders := make(map[string]bool)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, rhs := range assign.Rhs {
+			call, ok := rhs.(*ast.CallExpr)
+			if !ok || !isSelectorCall(call, "json", "NewDecoder") {
+				continue
+			}
+			if id, ok := assign.Lhs[i].(*ast.Ident); ok {
+				decoders[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	pos := token.NoPos
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if pos != token.NoPos {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Decode" {
+			return true
+		}
+		switch x := sel.X.(type) {
+		case *ast.CallExpr:
+			if isSelectorCall(x, "json", "NewDecoder") {
+				pos = call.Pos()
+			}
+		case *ast.Ident:
+			if decoders[x.Name] {
+				pos = call.Pos()
+			}
+		}
+		return true
+	})
+	return pos
+}
+
+// validatesAfter checks if a function makes a validation call after pos
+func (c validationCheck) validatesAfter(fn *ast.FuncDecl, pos token.Pos) bool {
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if ok && call.Pos() > pos && c.isValidation(call) {
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// isValidation checks if a call is a recognized validation call
+func (c validationCheck) isValidation(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return c.names[fun.Name]
+	case *ast.SelectorExpr:
+		if c.names[fun.Sel.Name] {
+			return true
+		}
+		if x, ok := fun.X.(*ast.Ident); ok {
+			return c.qualified[x.Name+"."+fun.Sel.Name]
+		}
+	}
+	return false
+}
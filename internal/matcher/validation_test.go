@@ -0,0 +1,46 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestInputValidation(t *testing.T) {
+	ref := parseFixture(t, "validation/reference.go")
+	handler := patterns.Pattern{Type: patterns.PatternHTTPHandler}
+	check := NewValidationCheck(nil)
+
+	got := check.Evaluate(parseFixture(t, "validation/validated.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{})
+
+	// CancelOrder validates through rules.Check, which isn't recognized yet
+	got = check.Evaluate(parseFixture(t, "validation/unvalidated.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{
+		{"input_validation", 10}, // CreateOrder
+		{"input_validation", 24}, // UpdateOrder validates before decoding
+		{"input_validation", 33}, // CancelOrder
+	})
+	if got[0].Severity != patterns.SeverityWarning || got[0].Actual != "CreateOrder decodes without validating" {
+		t.Errorf("deviation = %+v, want a warning for CreateOrder", got[0])
+	}
+
+	got = NewValidationCheck([]string{"rules.Check"}).Evaluate(parseFixture(t, "validation/unvalidated.go"), ref, handler)
+	sameDeviations(t, got, []deviationAt{
+		{"input_validation", 10},
+		{"input_validation", 24},
+	})
+}
+
+func TestInputValidationFollowsReference(t *testing.T) {
+	file := parseFixture(t, "validation/unvalidated.go")
+	check := NewValidationCheck(nil)
+
+	// References that don't validate set no expectation
+	got := check.Evaluate(file, parseFixture(t, "validation/unvalidated_reference.go"), patterns.Pattern{Type: patterns.PatternAPI})
+	sameDeviations(t, got, []deviationAt{})
+
+	// Only handlers are checked
+	got = check.Evaluate(file, parseFixture(t, "validation/reference.go"), patterns.Pattern{Type: patterns.PatternService})
+	sameDeviations(t, got, []deviationAt{})
+}
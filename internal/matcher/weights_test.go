@@ -0,0 +1,53 @@
+package matcher
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestTierWeightsPickReference(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		// The golden example imports errors, which the file lacks, so the
+		// discovered example is the closer match before weighting
+		"services/golden.go":     consensusSource("errors", "fmt", "strings"),
+		"services/discovered.go": consensusSource("fmt", "strings"),
+		"services/user.go":       consensusSource("fmt", "strings"),
+	})
+	pattern := declared("service", patterns.PatternService, "")
+	pattern.AnnotatedGolden = []patterns.GoldenExample{{Path: filepath.Join(root, "services/golden.go"), Pattern: "service"}}
+	pattern.Discovered = []patterns.Example{{Path: filepath.Join(root, "services/discovered.go")}}
+	file := filepath.Join(root, "services/user.go")
+
+	tests := []struct {
+		weights patterns.TierWeights
+		want    string
+	}{
+		{patterns.DefaultTierWeights, "annotated_golden"},
+		{patterns.TierWeights{Golden: 1.0, Blessed: 1.5, Discovered: 2.0}, "discovered"},
+		{patterns.TierWeights{Golden: 1.0, Blessed: 1.0, Discovered: 1.0}, "discovered"},
+	}
+	for _, tt := range tests {
+		m := New([]patterns.Pattern{pattern}, 80)
+		m.Weights = tt.weights
+		match, err := m.MatchFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match.MatchType != tt.want {
+			t.Errorf("weights %+v: matched %s reference, want %s", tt.weights, match.MatchType, tt.want)
+		}
+	}
+}
+
+func TestBlessedWeight(t *testing.T) {
+	m := New(nil, 80)
+	m.Weights = patterns.TierWeights{Golden: 1, Blessed: 3, Discovered: 1}
+	if got := m.blessedWeight(patterns.BlessedExample{}); got != 3 {
+		t.Errorf("unweighted blessed example = %v, want the tier weight 3", got)
+	}
+	if got := m.blessedWeight(patterns.BlessedExample{Weight: 1.2}); got != 1.2 {
+		t.Errorf("blessed example with its own weight = %v, want 1.2", got)
+	}
+}
@@ -0,0 +1,40 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestErrorWrapping(t *testing.T) {
+	ref := parseFixture(t, "wrapping/reference.go")
+	service := patterns.Pattern{Type: patterns.PatternService}
+	check := errorWrappingCheck{}
+
+	got := check.Evaluate(parseFixture(t, "wrapping/wraps.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{})
+
+	// A bare return err and a %v-formatted error lose the chain
+	got = check.Evaluate(parseFixture(t, "wrapping/unwrapped.go"), ref, service)
+	sameDeviations(t, got, []deviationAt{
+		{"error_wrapping", 10}, // return err
+		{"error_wrapping", 18}, // %v
+	})
+	if got[0].Actual != "return err" || got[1].Actual != "%v" || got[1].Expected != "%w" {
+		t.Errorf("deviations = %+v, want return err and %%v", got)
+	}
+	if got[0].Severity != patterns.SeverityInfo {
+		t.Errorf("severity = %s, want info", got[0].Severity)
+	}
+}
+
+func TestErrorWrappingMixedReference(t *testing.T) {
+	service := patterns.Pattern{Type: patterns.PatternService}
+	file := parseFixture(t, "wrapping/unwrapped.go")
+
+	// A reference that wraps only sometimes, or never, sets no expectation
+	for _, ref := range []string{"wrapping/mixed_reference.go", "wrapping/unwrapped.go"} {
+		got := errorWrappingCheck{}.Evaluate(file, parseFixture(t, ref), service)
+		sameDeviations(t, got, []deviationAt{})
+	}
+}
@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SlowestFiles is how many of the slowest files Print lists
+const SlowestFiles = 10
+
+// Profile accumulates where a run spends its time: per stage (walk, parse,
+// match...), per pattern and per file. A nil *Profile records nothing, so
+// instrumented code needn't check whether profiling is on. It is safe for
+// concurrent use, as matches that time out finish in the background.
+type Profile struct {
+	mu       sync.Mutex
+	start    time.Time
+	stages   map[string]time.Duration
+	order    []string // Stages in the order first seen
+	patterns map[string]time.Duration
+	files    map[string]time.Duration
+}
+
+// New starts a profile
+func New() *Profile {
+	return &Profile{
+		start:    time.Now(),
+		stages:   make(map[string]time.Duration),
+		patterns: make(map[string]time.Duration),
+		files:    make(map[string]time.Duration),
+	}
+}
+
+// Stage adds the time since start to a stage
+func (p *Profile) Stage(stage string, start time.Time) {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addStage(stage, elapsed)
+}
+
+// File adds the time since start to a stage and to a file's total
+func (p *Profile) File(stage, path string, start time.Time) {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addStage(stage, elapsed)
+	p.files[path] += elapsed
+}
+
+// Pattern adds the time since start to a pattern's match time
+func (p *Profile) Pattern(id string, start time.Time) {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.patterns[id] += elapsed
+}
+
+func (p *Profile) addStage(stage string, elapsed time.Duration) {
+	if _, seen := p.stages[stage]; !seen {
+		p.order = append(p.order, stage)
+	}
+	p.stages[stage] += elapsed
+}
+
+// Print writes the stage totals, the per-pattern match times, slowest
+// first, and the SlowestFiles slowest files
+func (p *Profile) Print(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintf(w, "\nProfile (total %s)\n", round(time.Since(p.start)))
+	for _, stage := range p.order {
+		fmt.Fprintf(w, "  %-12s %s\n", stage, round(p.stages[stage]))
+	}
+
+	if len(p.patterns) > 0 {
+		fmt.Fprintln(w, "Match time per pattern")
+		for _, e := range slowest(p.patterns, len(p.patterns)) {
+			fmt.Fprintf(w, "  %-30s %s\n", e.name, round(e.elapsed))
+		}
+	}
+
+	if len(p.files) > 0 {
+		fmt.Fprintln(w, "Slowest files")
+		for _, e := range slowest(p.files, SlowestFiles) {
+			fmt.Fprintf(w, "  %s  %s\n", round(e.elapsed), e.name)
+		}
+	}
+}
+
+type entry struct {
+	name    string
+	elapsed time.Duration
+}
+
+// slowest returns up to n entries of times, slowest first
+func slowest(times map[string]time.Duration, n int) []entry {
+	entries := make([]entry, 0, len(times))
+	for name, elapsed := range times {
+		entries = append(entries, entry{name, elapsed})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].elapsed != entries[j].elapsed {
+			return entries[i].elapsed > entries[j].elapsed
+		}
+		return entries[i].name < entries[j].name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// round shortens a duration for display
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,249 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/reporter"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// TTL is how long a fetched pattern library is used before it is fetched
+// again
+const TTL = time.Hour
+
+// DefaultSkillFile is the skill file read from a git repository whose URL
+// doesn't name one after #
+const DefaultSkillFile = ".code-on-rails-skills.json"
+
+// HTTP is the client pattern libraries and their examples are fetched with
+var HTTP = &http.Client{Timeout: 30 * time.Second}
+
+// Patterns loads the patterns of the skill file at rawURL, as written by cr
+// learn --update-skills. A git URL (ending in .git, or git@ or ssh://) is
+// cloned, reading the skill file named after # or DefaultSkillFile, and
+// its examples from the clone; otherwise the skill file is downloaded and
+// each example fetched relative to it. Libraries are cached for TTL, or
+// fetched anew when refresh is set; when fetching fails the cached copy is
+// used, and only without one is it an error. Example paths are rewritten
+// to the cached files, and the patterns are marked Remote.
+func Patterns(rawURL string, refresh bool) ([]patterns.Pattern, error) {
+	if strings.HasPrefix(rawURL, "-") {
+		return nil, fmt.Errorf("invalid pattern library URL %q: it would be read as a git option", rawURL)
+	}
+	dir, err := cacheDir(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filepath.Join(dir, "fetched"))
+	cached := err == nil
+	if !cached || refresh || time.Since(info.ModTime()) > TTL {
+		if err := fetchInto(rawURL, dir); err != nil && !cached {
+			return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+		}
+		// A failed fetch falls back to the cached copy
+	}
+	return load(rawURL, dir)
+}
+
+// isGit checks if a URL names a git repository rather than a file
+func isGit(rawURL string) bool {
+	repo, _, _ := strings.Cut(rawURL, "#")
+	return strings.HasSuffix(repo, ".git") || strings.HasPrefix(repo, "git@") || strings.HasPrefix(repo, "ssh://")
+}
+
+// cacheDir returns the directory a library is cached in, creating the
+// directory it is in
+func cacheDir(rawURL string) (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	parent := filepath.Join(base, "code-on-rails", "remote")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("failed to create pattern cache: %w", err)
+	}
+	return filepath.Join(parent, hex.EncodeToString(sum[:])[:16]), nil
+}
+
+// fetchInto fetches a library into a temp dir beside its cache directory
+// and swaps it into place, so examples the library dropped don't linger
+// and a failed fetch leaves the cached copy as it was
+func fetchInto(rawURL, dir string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".fetch-")
+	if err != nil {
+		return fmt.Errorf("failed to create pattern cache: %w", err)
+	}
+	defer os.RemoveAll(tmp) // Gone already once swapped in
+	if err := fetch(rawURL, tmp); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "fetched"), nil, 0644); err != nil {
+		return err
+	}
+
+	old := dir + ".old"
+	os.RemoveAll(old) // Left over from an interrupted swap
+	if err := os.Rename(dir, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.Rename(old, dir) // Keep the cached copy
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// fetch downloads a library into an empty directory
+func fetch(rawURL, dir string) error {
+	if isGit(rawURL) {
+		repo, _, _ := strings.Cut(rawURL, "#")
+		return run(exec.Command("git", "clone", "--quiet", "--depth", "1", "--", repo, filepath.Join(dir, "repo")))
+	}
+
+	data, err := download(rawURL)
+	if err != nil {
+		return err
+	}
+	var skills reporter.SkillFile
+	if err := json.Unmarshal(data, &skills); err != nil {
+		return fmt.Errorf("not a skill file: %w", err)
+	}
+	for _, skill := range skills.Skills {
+		for _, example := range skill.Examples {
+			rel, ok := localPath(example)
+			if !ok {
+				continue
+			}
+			src, err := download(resolve(rawURL, example))
+			if err != nil {
+				continue // The pattern keeps its other examples
+			}
+			target := filepath.Join(dir, "examples", rel)
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, src, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return os.WriteFile(filepath.Join(dir, "skills.json"), data, 0644)
+}
+
+// load reads a cached library's patterns
+func load(rawURL, dir string) ([]patterns.Pattern, error) {
+	skillPath := filepath.Join(dir, "skills.json")
+	exampleDir := filepath.Join(dir, "examples")
+	if isGit(rawURL) {
+		_, file, _ := strings.Cut(rawURL, "#")
+		if file == "" {
+			file = DefaultSkillFile
+		}
+		exampleDir = filepath.Join(dir, "repo")
+		skillPath = filepath.Join(exampleDir, filepath.FromSlash(file))
+	}
+
+	data, err := os.ReadFile(skillPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skill file: %w", err)
+	}
+	var skills reporter.SkillFile
+	if err := json.Unmarshal(data, &skills); err != nil {
+		return nil, fmt.Errorf("failed to parse skill file: %w", err)
+	}
+
+	result := []patterns.Pattern{}
+	for _, skill := range skills.Skills {
+		p := patterns.Pattern{
+			ID:       skill.ID,
+			Name:     skill.Name,
+			Type:     patterns.PatternType(skill.Type),
+			Version:  "1.0",
+			Language: skills.Language,
+			Remote:   true,
+			Detection: patterns.DetectionRule{
+				FilePattern:   skill.Detection.FilePattern,
+				FuncPattern:   skill.Detection.FuncPattern,
+				StructPattern: skill.Detection.StructPattern,
+			},
+			Structure: patterns.CodeStructure{
+				Required: skill.Structure.Required,
+				Optional: skill.Structure.Optional,
+			},
+		}
+		for _, example := range skill.Examples {
+			rel, ok := localPath(example)
+			if !ok {
+				continue
+			}
+			cached := filepath.Join(exampleDir, rel)
+			if _, err := os.Stat(cached); err == nil {
+				p.Discovered = append(p.Discovered, patterns.Example{Path: cached})
+			}
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// localPath turns an example path from a skill file into a relative path
+// that stays within the cache, rejecting absolute paths and ones escaping
+// it with ..
+func localPath(example string) (string, bool) {
+	clean := path.Clean(filepath.ToSlash(example))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return filepath.FromSlash(clean), true
+}
+
+// resolve returns the URL of an example relative to the skill file's URL
+func resolve(skillURL, example string) string {
+	base, err := url.Parse(skillURL)
+	if err != nil {
+		return example
+	}
+	ref, err := url.Parse(filepath.ToSlash(example))
+	if err != nil {
+		return example
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// download fetches a URL's body
+func download(rawURL string) ([]byte, error) {
+	resp, err := HTTP.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// run runs a git command, reporting its output when it fails
+func run(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git: %s", msg)
+		}
+		return fmt.Errorf("git: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package remote
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/reporter"
+)
+
+// library serves a skill file at /skills.json and its examples beside it,
+// counting the requests it gets
+type library struct {
+	mu       sync.Mutex
+	files    map[string]string
+	requests int
+}
+
+func (l *library) set(files map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.files = files
+}
+
+func (l *library) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.requests
+}
+
+func (l *library) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests++
+	src, ok := l.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(src))
+}
+
+// skillFile is a skill file with one service pattern learned from examples
+func skillFile(t *testing.T, examples ...string) string {
+	t.Helper()
+	data, err := json.Marshal(reporter.SkillFile{
+		Version:  "1.0",
+		Language: "go",
+		Skills: []reporter.Skill{{
+			ID:        "team_service",
+			Name:      "Team service",
+			Type:      "service",
+			Detection: reporter.SkillDetection{FilePattern: "*_service.go"},
+			Examples:  examples,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// serve starts a library serving files, with the cache in a temp dir
+func serve(t *testing.T, files map[string]string) (*library, *httptest.Server) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	lib := &library{files: files}
+	srv := httptest.NewServer(lib)
+	t.Cleanup(srv.Close)
+	return lib, srv
+}
+
+// examplePaths lists the base names of a pattern library's examples
+func examplePaths(t *testing.T, rawURL string, refresh bool) []string {
+	t.Helper()
+	got, err := Patterns(rawURL, refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "team_service" || !got[0].Remote {
+		t.Fatalf("Patterns = %+v, want the remote team_service pattern", got)
+	}
+	paths := []string{}
+	for _, ex := range got[0].Discovered {
+		paths = append(paths, filepath.Base(ex.Path))
+	}
+	return paths
+}
+
+func TestPatternsCached(t *testing.T) {
+	lib, srv := serve(t, map[string]string{
+		"/skills.json":              skillFile(t, "services/user_service.go", "../../etc/passwd"),
+		"/services/user_service.go": "package services\n",
+	})
+	rawURL := srv.URL + "/skills.json"
+
+	if got := examplePaths(t, rawURL, false); len(got) != 1 || got[0] != "user_service.go" {
+		t.Fatalf("examples = %v, want user_service.go alone", got)
+	}
+	fetched := lib.count()
+	if fetched != 2 {
+		t.Errorf("first load made %d requests, want the skill file and its example", fetched)
+	}
+
+	// Within the TTL the cached copy is used
+	examplePaths(t, rawURL, false)
+	if lib.count() != fetched {
+		t.Errorf("cached load made %d requests", lib.count()-fetched)
+	}
+
+	// After it, the library is fetched again
+	dir, err := cacheDir(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * TTL)
+	if err := os.Chtimes(filepath.Join(dir, "fetched"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	examplePaths(t, rawURL, false)
+	if lib.count() != 2*fetched {
+		t.Errorf("stale load made %d requests, want %d", lib.count()-fetched, fetched)
+	}
+}
+
+func TestPatternsOffline(t *testing.T) {
+	_, srv := serve(t, map[string]string{
+		"/skills.json":              skillFile(t, "services/user_service.go"),
+		"/services/user_service.go": "package services\n",
+	})
+	rawURL := srv.URL + "/skills.json"
+	examplePaths(t, rawURL, false)
+
+	// Even a forced refresh falls back to the cached copy
+	srv.Close()
+	if got := examplePaths(t, rawURL, true); len(got) != 1 {
+		t.Errorf("offline examples = %v, want the cached user_service.go", got)
+	}
+
+	// Without one it is an error
+	if _, err := Patterns(srv.URL+"/other.json", false); err == nil {
+		t.Error("Patterns succeeded offline with nothing cached")
+	}
+}
+
+func TestPatternsRefresh(t *testing.T) {
+	lib, srv := serve(t, map[string]string{
+		"/skills.json":               skillFile(t, "services/user_service.go"),
+		"/services/user_service.go":  "package services\n",
+		"/services/order_service.go": "package services\n",
+	})
+	rawURL := srv.URL + "/skills.json"
+	examplePaths(t, rawURL, false)
+
+	// The library replaced its example; the old one leaves the cache
+	lib.set(map[string]string{
+		"/skills.json":               skillFile(t, "services/order_service.go"),
+		"/services/order_service.go": "package services\n",
+	})
+	if got := examplePaths(t, rawURL, false); len(got) != 1 || got[0] != "user_service.go" {
+		t.Errorf("examples before refresh = %v, want the cached user_service.go", got)
+	}
+	if got := examplePaths(t, rawURL, true); len(got) != 1 || got[0] != "order_service.go" {
+		t.Errorf("examples after refresh = %v, want order_service.go", got)
+	}
+	dir, err := cacheDir(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "examples", "services", "user_service.go")); !os.IsNotExist(err) {
+		t.Errorf("dropped example still cached: %v", err)
+	}
+	leftovers, _ := filepath.Glob(dir + ".*")
+	if len(leftovers) != 0 {
+		t.Errorf("fetching left %v beside the cache", leftovers)
+	}
+}
+
+func TestPatternsGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := filepath.Join(t.TempDir(), "patterns.git")
+	files := map[string]string{
+		"team.json":                 skillFile(t, "services/user_service.go"),
+		"services/user_service.go":  "package services\n",
+		"services/order_service.go": "package services\n",
+	}
+	for path, src := range files {
+		full := filepath.Join(repo, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "patterns")
+
+	rawURL := repo + "#team.json"
+	if got := examplePaths(t, rawURL, false); len(got) != 1 || got[0] != "user_service.go" {
+		t.Fatalf("examples = %v, want user_service.go", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "team.json"), []byte(skillFile(t, "services/order_service.go")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("rm", "--quiet", "services/user_service.go")
+	git("commit", "--quiet", "-am", "replace the example")
+	if got := examplePaths(t, rawURL, true); len(got) != 1 || got[0] != "order_service.go" {
+		t.Errorf("examples after refresh = %v, want order_service.go", got)
+	}
+}
+
+func TestPatternsRejectsOptions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	for _, rawURL := range []string{"--upload-pack=touch pwned.git", "-oProxyCommand=x.git#team.json"} {
+		if _, err := Patterns(rawURL, false); err == nil {
+			t.Errorf("Patterns(%q) succeeded", rawURL)
+		}
+	}
+	if _, err := os.Stat("pwned.git"); !os.IsNotExist(err) {
+		t.Error("an option URL ran")
+	}
+}
@@ -0,0 +1,93 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// antiPatternMatches are a file resembling an anti-pattern, which needs
+// review, and an approved one
+func antiPatternMatches(t *testing.T) []patterns.PatternMatch {
+	t.Helper()
+	pattern := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	legacy := linesFile(t, "legacy.go", 10)
+	user := linesFile(t, "user.go", 10)
+	return []patterns.PatternMatch{
+		{FilePath: user, Pattern: pattern, Score: 95, AntiPatternHits: []patterns.AntiPatternMatch{{
+			FilePath: user,
+			Pattern:  pattern,
+			AntiPattern: patterns.AntiPattern{
+				Path: legacy, Pattern: "service", Reason: "package-level state", MigrationGuide: "docs/di.md",
+			},
+			Similarity:  0.92,
+			Replacement: "services/golden.go",
+		}}},
+		{FilePath: linesFile(t, "order.go", 10), Pattern: pattern, Score: 97, AutoApprove: true},
+	}
+}
+
+func TestAntiPatternHitsJSON(t *testing.T) {
+	matches := antiPatternMatches(t)
+	var report JSONReport
+	if err := json.Unmarshal([]byte(New(false).ReportJSON(matches, "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.AntiPatternHits) != 1 {
+		t.Fatalf("anti_pattern_hits = %+v, want one", report.AntiPatternHits)
+	}
+	hit := report.AntiPatternHits[0]
+	if hit.FilePath != matches[0].FilePath || hit.Pattern != "service" || hit.Similarity != 0.92 ||
+		hit.Reason != "package-level state" || hit.MigrationGuide != "docs/di.md" || hit.Replacement != "services/golden.go" {
+		t.Errorf("anti-pattern hit = %+v", hit)
+	}
+
+	// Without hits the array is empty rather than missing
+	raw := New(false).ReportJSON(matches[1:], "go")
+	if !strings.Contains(raw, `"anti_pattern_hits": []`) {
+		t.Errorf("JSON without hits lacks an empty anti_pattern_hits array:\n%s", raw)
+	}
+}
+
+func TestAntiPatternHitsText(t *testing.T) {
+	var out bytes.Buffer
+	r := New(false)
+	r.Out = &out
+	r.Report(antiPatternMatches(t))
+
+	text := out.String()
+	section := strings.Index(text, "⛔ Anti-patterns detected (1)")
+	if section < 0 {
+		t.Fatalf("text report has no anti-pattern section:\n%s", text)
+	}
+	for _, want := range []string{"resembles", "(92%)", "Why: package-level state", "Migration guide: docs/di.md"} {
+		if !strings.Contains(text[section:], want) {
+			t.Errorf("anti-pattern section lacks %q:\n%s", want, text[section:])
+		}
+	}
+}
+
+func TestAntiPatternHitsGitHub(t *testing.T) {
+	comment := New(false).FormatForGitHub(antiPatternMatches(t), "", "")
+	anti := strings.Index(comment, "### ⛔ Anti-patterns Detected")
+	review := strings.Index(comment, "### 🔍 Needs Human Review")
+	if anti < 0 || review < 0 {
+		t.Fatalf("comment lacks the anti-pattern or review section:\n%s", comment)
+	}
+	if anti > review {
+		t.Errorf("anti-patterns come after needs-review:\n%s", comment)
+	}
+	for _, want := range []string{"Why: package-level state", "Migration guide: docs/di.md", "Migrate towards `services/golden.go`"} {
+		if !strings.Contains(comment[anti:review], want) {
+			t.Errorf("anti-pattern section lacks %q:\n%s", want, comment[anti:review])
+		}
+	}
+
+	// No hits, no section
+	if comment := New(false).FormatForGitHub(antiPatternMatches(t)[1:], "", ""); strings.Contains(comment, "Anti-patterns") {
+		t.Errorf("comment without hits has an anti-pattern section:\n%s", comment)
+	}
+}
@@ -0,0 +1,42 @@
+package reporter
+
+import "fmt"
+
+// NewPatternReport is a group of files no pattern matched that look like
+// a pattern the config lacks
+type NewPatternReport struct {
+	PatternType string   `json:"pattern_type"`
+	Summary     string   `json:"summary"`
+	Files       []string `json:"files"`
+}
+
+// newPatterns describes the reporter's pattern candidates
+func (r *Reporter) newPatterns() []NewPatternReport {
+	reports := []NewPatternReport{}
+	for _, candidate := range r.NewPatterns {
+		report := NewPatternReport{
+			PatternType: string(candidate.Type),
+			Summary: fmt.Sprintf("%d files look like a new %s pattern: run 'cr learn' to add it, or annotate the best one as a golden example",
+				len(candidate.Files), candidate.Type),
+		}
+		for _, file := range candidate.Files {
+			report.Files = append(report.Files, r.path(file))
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// printNewPatterns lists pattern candidates after the per-file results
+func (r *Reporter) printNewPatterns(reports []NewPatternReport) {
+	if len(reports) == 0 {
+		return
+	}
+	fmt.Fprintf(r.Out, "\n→ Possible new patterns (%d)\n", len(reports))
+	for _, report := range reports {
+		fmt.Fprintf(r.Out, "  %s\n", report.Summary)
+		for _, file := range report.Files {
+			fmt.Fprintf(r.Out, "    - %s\n", file)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestNewPatterns(t *testing.T) {
+	root := t.TempDir()
+	matches := []patterns.PatternMatch{
+		{FilePath: filepath.Join(root, "jobs", "email.go")},
+		{FilePath: filepath.Join(root, "jobs", "report.go")},
+		{FilePath: filepath.Join(root, "jobs", "sync.go")},
+	}
+
+	var text bytes.Buffer
+	r := New(false)
+	r.Root = root
+	r.Out = &text
+	r.NewPatterns = []patterns.PatternCandidate{{
+		Type:  patterns.PatternService,
+		Files: []string{matches[0].FilePath, matches[1].FilePath, matches[2].FilePath},
+	}}
+	wantFiles := []string{"jobs/email.go", "jobs/report.go", "jobs/sync.go"}
+
+	r.Report(matches)
+	out := text.String()
+	if !strings.Contains(out, "Possible new patterns (1)") || !strings.Contains(out, "3 files look like a new service pattern") {
+		t.Errorf("text report lacks the candidate:\n%s", out)
+	}
+	for _, file := range wantFiles {
+		if !strings.Contains(out, "    - "+file+"\n") {
+			t.Errorf("text report doesn't list %s:\n%s", file, out)
+		}
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal([]byte(r.ReportJSON(matches, "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.NewPatterns) != 1 || report.NewPatterns[0].PatternType != "service" ||
+		!reflect.DeepEqual(report.NewPatterns[0].Files, wantFiles) {
+		t.Errorf("JSON new_patterns = %+v, want the service candidate", report.NewPatterns)
+	}
+
+	// Without candidates the section is left out
+	text.Reset()
+	r.NewPatterns = nil
+	r.Report(matches)
+	if strings.Contains(text.String(), "Possible new patterns") {
+		t.Errorf("text report has an empty candidates section:\n%s", text.String())
+	}
+	if strings.Contains(r.ReportJSON(matches, "go"), "new_patterns") {
+		t.Error("JSON report has an empty new_patterns")
+	}
+}
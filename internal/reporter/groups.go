@@ -0,0 +1,121 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// deviationGroup collects a file's deviations of one type and element, such
+// as all of its missing imports
+type deviationGroup struct {
+	deviations []patterns.Deviation
+	severity   patterns.Severity // The most severe of the group's
+}
+
+// groupDeviations groups deviations by type and element, in order of first
+// appearance
+func groupDeviations(deviations []patterns.Deviation) []deviationGroup {
+	groups := []deviationGroup{}
+	index := make(map[string]int)
+	for _, dev := range deviations {
+		key := string(dev.Type) + "/" + dev.Element
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, deviationGroup{severity: dev.Severity})
+		}
+		groups[i].deviations = append(groups[i].deviations, dev)
+		if severityRank(dev.Severity) > severityRank(groups[i].severity) {
+			groups[i].severity = dev.Severity
+		}
+	}
+	return groups
+}
+
+// shownDeviations caps deviations at MaxDeviations, keeping the most severe
+// so errors are never hidden behind warnings. It returns those to show and
+// how many were left out.
+func (r *Reporter) shownDeviations(deviations []patterns.Deviation) ([]patterns.Deviation, int) {
+	if r.MaxDeviations <= 0 || len(deviations) <= r.MaxDeviations {
+		return deviations, 0
+	}
+	sorted := append([]patterns.Deviation{}, deviations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severityRank(sorted[i].Severity) > severityRank(sorted[j].Severity)
+	})
+	return sorted[:r.MaxDeviations], len(deviations) - r.MaxDeviations
+}
+
+// moreNote tells how many deviations a capped list left out
+func moreNote(hidden int) string {
+	return fmt.Sprintf("(+%d more)", hidden)
+}
+
+// deviationGroups groups deviations when GroupDeviations is set, and
+// otherwise puts each in a group of its own
+func (r *Reporter) deviationGroups(deviations []patterns.Deviation) []deviationGroup {
+	if r.GroupDeviations {
+		return groupDeviations(deviations)
+	}
+	groups := make([]deviationGroup, 0, len(deviations))
+	for _, dev := range deviations {
+		groups = append(groups, deviationGroup{deviations: []patterns.Deviation{dev}, severity: dev.Severity})
+	}
+	return groups
+}
+
+// severityRank orders severities from info up to error
+func severityRank(s patterns.Severity) int {
+	switch s {
+	case patterns.SeverityError:
+		return 2
+	case patterns.SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// summary describes a group, e.g. "8 missing import(s)"
+func (g deviationGroup) summary() string {
+	first := g.deviations[0]
+	if first.Type == patterns.DeviationMissing {
+		return fmt.Sprintf("%d missing %s(s)", len(g.deviations), first.Element)
+	}
+	return fmt.Sprintf("%d %s deviation(s)", len(g.deviations), first.Element)
+}
+
+// specific describes one deviation of a group by what was expected and
+// found
+func specific(dev patterns.Deviation) string {
+	text := dev.Expected
+	if dev.Actual != "" {
+		if text != "" {
+			text += ", found: "
+		}
+		text += dev.Actual
+	}
+	if text == "" {
+		text = dev.Suggestion
+	}
+	return text
+}
+
+// printGroup prints a group of deviations as one entry listing the
+// specifics, or a lone deviation as usual
+func (r *Reporter) printGroup(g deviationGroup) {
+	if len(g.deviations) == 1 {
+		r.printDeviation(g.deviations[0])
+		return
+	}
+	fmt.Fprintf(r.Out, "    %s %s\n", severityIcon(g.severity), g.summary())
+	for _, dev := range g.deviations {
+		fmt.Fprintf(r.Out, "      - %s", specific(dev))
+		if dev.LineNumber > 0 {
+			fmt.Fprintf(r.Out, " (line %d)", dev.LineNumber)
+		}
+		fmt.Fprintln(r.Out)
+	}
+}
@@ -0,0 +1,150 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// missingImportsMatch is a file missing three imports and with one other
+// deviation
+func missingImportsMatch(t *testing.T) []patterns.PatternMatch {
+	t.Helper()
+	missing := func(imp string, line int, severity patterns.Severity) patterns.Deviation {
+		return patterns.Deviation{Type: patterns.DeviationMissing, Element: "import", Expected: imp, LineNumber: line, Severity: severity}
+	}
+	return []patterns.PatternMatch{{
+		FilePath: linesFile(t, "user.go", 40),
+		Pattern:  &patterns.Pattern{Name: "service", Type: patterns.PatternService},
+		Score:    55,
+		Deviations: []patterns.Deviation{
+			missing("context", 3, patterns.SeverityWarning),
+			{Type: patterns.DeviationDifferent, Element: "error_handling", Expected: "if err != nil", Severity: patterns.SeverityWarning},
+			missing("errors", 3, patterns.SeverityError),
+			missing("fmt", 3, patterns.SeverityWarning),
+		},
+	}}
+}
+
+func TestGroupDeviations(t *testing.T) {
+	groups := groupDeviations(missingImportsMatch(t)[0].Deviations)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want imports and error handling", len(groups))
+	}
+	imports := groups[0]
+	if len(imports.deviations) != 3 || imports.severity != patterns.SeverityError {
+		t.Errorf("import group has %d deviations at severity %s, want 3 at error", len(imports.deviations), imports.severity)
+	}
+	if got := imports.summary(); got != "3 missing import(s)" {
+		t.Errorf("summary = %q, want 3 missing import(s)", got)
+	}
+	if got := groups[1].summary(); got != "1 error_handling deviation(s)" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestGroupedTextOutput(t *testing.T) {
+	matches := missingImportsMatch(t)
+	for _, group := range []bool{true, false} {
+		var out bytes.Buffer
+		r := New(false)
+		r.Out = &out
+		r.GroupDeviations = group
+		r.Report(matches)
+		text := out.String()
+
+		summaries := strings.Count(text, "3 missing import(s)")
+		entries := strings.Count(text, "import (expected: ")
+		if group && (summaries != 1 || entries != 0 || !strings.Contains(text, "      - errors (line 3)")) {
+			t.Errorf("grouped text output doesn't fold the imports into one entry:\n%s", text)
+		}
+		if !group && (summaries != 0 || entries != 3) {
+			t.Errorf("ungrouped text output doesn't list each import:\n%s", text)
+		}
+	}
+}
+
+func TestGroupedGitHubOutput(t *testing.T) {
+	matches := missingImportsMatch(t)
+	for _, group := range []bool{true, false} {
+		r := New(false)
+		r.GroupDeviations = group
+		github := r.FormatForGitHub(matches, "https://github.com/acme/app", "abc123")
+
+		summaries := strings.Count(github, "❌ **3 missing import(s)**")
+		entries := strings.Count(github, "**import** at ")
+		if group && (summaries != 1 || entries != 0 || !strings.Contains(github, "  - `fmt` at ")) {
+			t.Errorf("grouped GitHub output doesn't fold the imports into one entry:\n%s", github)
+		}
+		if !group && (summaries != 0 || entries != 3) {
+			t.Errorf("ungrouped GitHub output doesn't list each import:\n%s", github)
+		}
+	}
+}
+
+func TestJSONKeepsDeviationsUngrouped(t *testing.T) {
+	r := New(false)
+	r.GroupDeviations = true
+	var report JSONReport
+	if err := json.Unmarshal([]byte(r.ReportJSON(missingImportsMatch(t), "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.NeedsReview) != 1 || len(report.NeedsReview[0].Deviations) != 4 {
+		t.Errorf("JSON report = %+v, want the file's 4 deviations listed individually", report.NeedsReview)
+	}
+}
+
+func TestShownDeviations(t *testing.T) {
+	deviations := missingImportsMatch(t)[0].Deviations
+	r := New(false)
+	if shown, hidden := r.shownDeviations(deviations); len(shown) != 4 || hidden != 0 {
+		t.Errorf("uncapped: showing %d, hiding %d; want all 4", len(shown), hidden)
+	}
+
+	r.MaxDeviations = 2
+	shown, hidden := r.shownDeviations(deviations)
+	if hidden != 2 || len(shown) != 2 {
+		t.Fatalf("capped at 2: showing %d, hiding %d", len(shown), hidden)
+	}
+	// The error comes first even though it was reported third
+	if shown[0].Expected != "errors" || shown[1].Expected != "context" {
+		t.Errorf("shown = %+v, want the errors error then the first warning", shown)
+	}
+	if deviations[0].Expected != "context" {
+		t.Error("capping reordered the match's own deviations")
+	}
+}
+
+func TestMaxDeviationsOutput(t *testing.T) {
+	matches := missingImportsMatch(t)
+	r := New(false)
+	r.MaxDeviations = 1
+
+	var out bytes.Buffer
+	r.Out = &out
+	r.Report(matches)
+	if text := out.String(); !strings.Contains(text, "(+3 more)") || strings.Contains(text, "fmt") {
+		t.Errorf("text output doesn't cap to the errors import:\n%s", text)
+	}
+
+	for name, report := range map[string]string{
+		"GitHub":   r.FormatForGitHub(matches, "https://github.com/acme/app", "abc123"),
+		"markdown": r.ReportMarkdown(matches, "go"),
+	} {
+		if !strings.Contains(report, "- _(+3 more)_") || !strings.Contains(report, "errors") || strings.Contains(report, "`fmt`") {
+			t.Errorf("%s output doesn't cap to the errors import:\n%s", name, report)
+		}
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal([]byte(r.ReportJSON(matches, "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	file := report.NeedsReview[0]
+	if len(file.Deviations) != 1 || file.Deviations[0].Expected != "errors" || file.MoreDeviations != 3 {
+		t.Errorf("JSON file report = %+v, want the errors import and 3 more", file)
+	}
+}
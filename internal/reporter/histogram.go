@@ -0,0 +1,96 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// histogramBar is the width of the longest bar in the text histogram
+const histogramBar = 30
+
+// scoreBuckets are the histogram's score ranges, highest first; a score
+// falls in the first bucket whose minimum it reaches
+var scoreBuckets = []struct {
+	label string
+	min   float64
+}{
+	{"95-100", 95},
+	{"80-95", 80},
+	{"60-80", 60},
+	{"<60", 0},
+}
+
+// HistogramReport is the distribution of one pattern type's match scores
+type HistogramReport struct {
+	PatternType string         `json:"pattern_type"` // "unmatched" for files without a pattern
+	Files       int            `json:"files"`
+	Buckets     []BucketReport `json:"buckets"`
+}
+
+// BucketReport counts the files scoring within a range
+type BucketReport struct {
+	Range string `json:"range"`
+	Files int    `json:"files"`
+}
+
+// scoreHistogram buckets the match scores per pattern type, by type name
+func scoreHistogram(matches []patterns.PatternMatch) []HistogramReport {
+	byType := make(map[string]*HistogramReport)
+	for _, match := range matches {
+		patternType := "unmatched"
+		if match.Pattern != nil {
+			patternType = string(match.Pattern.Type)
+		}
+		h, ok := byType[patternType]
+		if !ok {
+			h = &HistogramReport{PatternType: patternType}
+			for _, b := range scoreBuckets {
+				h.Buckets = append(h.Buckets, BucketReport{Range: b.label})
+			}
+			byType[patternType] = h
+		}
+		h.Files++
+		for i, b := range scoreBuckets {
+			if match.Score >= b.min {
+				h.Buckets[i].Files++
+				break
+			}
+		}
+	}
+
+	histogram := make([]HistogramReport, 0, len(byType))
+	for _, h := range byType {
+		histogram = append(histogram, *h)
+	}
+	sort.Slice(histogram, func(i, j int) bool {
+		return histogram[i].PatternType < histogram[j].PatternType
+	})
+	return histogram
+}
+
+// printHistogram draws each pattern type's score distribution as bars
+func (r *Reporter) printHistogram(histogram []HistogramReport) {
+	if len(histogram) == 0 {
+		return
+	}
+	fmt.Fprintln(r.Out, "\nScore distribution:")
+	for _, h := range histogram {
+		most := 0
+		for _, b := range h.Buckets {
+			if b.Files > most {
+				most = b.Files
+			}
+		}
+		fmt.Fprintf(r.Out, "  %s (%d file(s))\n", h.PatternType, h.Files)
+		for _, b := range h.Buckets {
+			bar := ""
+			if b.Files > 0 {
+				bar = strings.Repeat("█", (b.Files*histogramBar+most-1)/most) + " " // A file always gets a sliver
+			}
+			fmt.Fprintf(r.Out, "    %-7s %s%d\n", b.Range, bar, b.Files)
+		}
+	}
+}
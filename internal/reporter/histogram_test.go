@@ -0,0 +1,80 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// scoredMatches are matches of the given pattern scoring scores
+func scoredMatches(pattern *patterns.Pattern, scores ...float64) []patterns.PatternMatch {
+	matches := []patterns.PatternMatch{}
+	for _, score := range scores {
+		matches = append(matches, patterns.PatternMatch{FilePath: "x.go", Pattern: pattern, Score: score, AutoApprove: score >= 95})
+	}
+	return matches
+}
+
+func TestScoreHistogram(t *testing.T) {
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	handler := &patterns.Pattern{Name: "handler", Type: patterns.PatternHTTPHandler}
+	matches := scoredMatches(service, 100, 95, 94.9, 80, 79.9, 60, 59.9, 0)
+	matches = append(matches, scoredMatches(handler, 97, 98, 85)...)
+	matches = append(matches, scoredMatches(nil, 0)...)
+
+	// A bucket holds the scores from its minimum up to the next bucket's
+	bucket := func(files ...int) []BucketReport {
+		return []BucketReport{{"95-100", files[0]}, {"80-95", files[1]}, {"60-80", files[2]}, {"<60", files[3]}}
+	}
+	want := []HistogramReport{
+		{PatternType: "http_handler", Files: 3, Buckets: bucket(2, 1, 0, 0)},
+		{PatternType: "service", Files: 8, Buckets: bucket(2, 2, 2, 2)},
+		{PatternType: "unmatched", Files: 1, Buckets: bucket(0, 0, 0, 1)},
+	}
+	if got := scoreHistogram(matches); !reflect.DeepEqual(got, want) {
+		t.Errorf("scoreHistogram = %+v\nwant %+v", got, want)
+	}
+	if got := scoreHistogram(nil); len(got) != 0 {
+		t.Errorf("scoreHistogram(nil) = %+v", got)
+	}
+}
+
+func TestHistogramOutput(t *testing.T) {
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	matches := scoredMatches(service, 99, 98, 70)
+
+	var text bytes.Buffer
+	r := New(false)
+	r.Out = &text
+	r.Report(matches)
+	if strings.Contains(text.String(), "Score distribution") {
+		t.Errorf("histogram printed without Histogram:\n%s", text.String())
+	}
+
+	r.Histogram = true
+	text.Reset()
+	r.Report(matches)
+	out := text.String()
+	for _, line := range []string{
+		"  service (3 file(s))\n",
+		"    95-100  " + strings.Repeat("█", histogramBar) + " 2\n",
+		"    60-80   " + strings.Repeat("█", histogramBar/2) + " 1\n",
+		"    <60     0\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("text histogram lacks %q:\n%s", line, out)
+		}
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal([]byte(r.ReportJSON(matches, "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Histogram) != 1 || report.Histogram[0].Buckets[0].Files != 2 || report.Histogram[0].Buckets[2].Files != 1 {
+		t.Errorf("JSON histogram = %+v", report.Histogram)
+	}
+}
@@ -0,0 +1,161 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// ReportMarkdown renders a check as portable markdown for wikis, docs or
+// chat: the same sections as the GitHub comment, but with plain relative
+// paths, no links to a code host and no HTML
+func (r *Reporter) ReportMarkdown(matches []patterns.PatternMatch, language string) string {
+	var sb strings.Builder
+
+	approvedFiles := []patterns.PatternMatch{}
+	reviewFiles := []patterns.PatternMatch{}
+	approvedLines := 0
+	for _, match := range matches {
+		if match.AutoApprove {
+			approvedFiles = append(approvedFiles, match)
+			approvedLines += estimateLines(match.FilePath)
+		} else {
+			reviewFiles = append(reviewFiles, match)
+		}
+	}
+
+	sb.WriteString("# Code on Rails Report\n\n")
+	sb.WriteString(fmt.Sprintf("**%d files** analyzed | **%d** auto-approved | **%d** need review",
+		len(matches), len(approvedFiles), len(reviewFiles)))
+	if language != "" {
+		sb.WriteString(fmt.Sprintf(" | Language: %s", language))
+	}
+	sb.WriteString("\n\n")
+	if r.Explicit {
+		sb.WriteString("_Files were explicitly requested, not AI-detected._\n\n")
+	}
+
+	if len(approvedFiles) > 0 {
+		sb.WriteString(fmt.Sprintf("## ✅ Auto-approved (%d files, %d lines)\n\n", len(approvedFiles), approvedLines))
+		sb.WriteString("| File | Pattern | Match |\n")
+		sb.WriteString("|------|---------|-------|\n")
+		for _, match := range approvedFiles {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %.0f%% |\n", r.path(match.FilePath), patternName(match), match.Score))
+		}
+		sb.WriteString("\n")
+	}
+
+	if hits := antiPatternHits(matches); len(hits) > 0 {
+		sb.WriteString("## ⛔ Anti-patterns Detected\n\n")
+		for _, hit := range hits {
+			sb.WriteString(fmt.Sprintf("- `%s` %s\n", r.path(hit.FilePath), r.resemblance(hit, "`%s`")))
+			if hit.AntiPattern.Reason != "" {
+				sb.WriteString(fmt.Sprintf("  - Why: %s\n", hit.AntiPattern.Reason))
+			}
+			if hit.AntiPattern.MigrationGuide != "" {
+				sb.WriteString(fmt.Sprintf("  - Migration guide: %s\n", hit.AntiPattern.MigrationGuide))
+			}
+			if hit.Replacement != "" {
+				sb.WriteString(fmt.Sprintf("  - Migrate towards `%s`\n", hit.Replacement))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if systemic := r.systemicDeviations(matches); len(systemic) > 0 {
+		sb.WriteString("## 🚨 Systemic Deviations\n\n")
+		for _, s := range systemic {
+			sb.WriteString(fmt.Sprintf("- **%s**\n", s.Summary))
+		}
+		sb.WriteString("\n")
+	}
+
+	if drops := r.approvalDrops(matches); len(drops) > 0 {
+		sb.WriteString("## 📉 Approval Rate Drops\n\n")
+		for _, d := range drops {
+			sb.WriteString(fmt.Sprintf("- **%s**\n", d.Summary))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(reviewFiles) > 0 {
+		sb.WriteString("## 🔍 Needs Review\n\n")
+		for _, match := range reviewFiles {
+			sb.WriteString(fmt.Sprintf("### `%s`\n\n", r.path(match.FilePath)))
+			sb.WriteString(fmt.Sprintf("**Pattern:** %s (%.0f%% match)\n\n", patternName(match), match.Score))
+
+			if len(match.Deviations) > 0 {
+				shown, hidden := r.shownDeviations(match.Deviations)
+				for _, group := range r.deviationGroups(shown) {
+					writeMarkdownGroup(&sb, group)
+				}
+				if hidden > 0 {
+					sb.WriteString(fmt.Sprintf("- _%s_\n", moreNote(hidden)))
+				}
+				sb.WriteString("\n")
+			}
+
+			patternType := patterns.PatternUtil
+			if match.Pattern != nil {
+				patternType = match.Pattern.Type
+			}
+			if guide := getPatternReviewGuide(patternType); len(guide) > 0 {
+				sb.WriteString("Review checklist:\n\n")
+				for _, item := range guide {
+					sb.WriteString(fmt.Sprintf("- [ ] %s\n", item))
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		sb.WriteString("No AI-generated code detected.\n\n")
+	}
+
+	sb.WriteString("---\n")
+	sb.WriteString(fmt.Sprintf("_Generated by Code on Rails • Review time saved: ~%d min_\n", r.timeSaved(approvedLines)))
+	return sb.String()
+}
+
+// writeMarkdownGroup writes a deviation, or a group of them with their
+// specifics, as a markdown list item
+func writeMarkdownGroup(sb *strings.Builder, g deviationGroup) {
+	icon := githubIcon(g.severity)
+	if len(g.deviations) > 1 {
+		sb.WriteString(fmt.Sprintf("- %s **%s**\n", icon, g.summary()))
+		for _, dev := range g.deviations {
+			sb.WriteString(fmt.Sprintf("  - `%s`%s\n", specific(dev), lineSuffix(dev)))
+		}
+		return
+	}
+
+	dev := g.deviations[0]
+	sb.WriteString(fmt.Sprintf("- %s **%s**%s\n", icon, dev.Element, lineSuffix(dev)))
+	if dev.Expected != "" {
+		sb.WriteString(fmt.Sprintf("  - Expected: `%s`\n", dev.Expected))
+	}
+	if dev.Actual != "" {
+		sb.WriteString(fmt.Sprintf("  - Found: `%s`\n", dev.Actual))
+	}
+	if dev.Suggestion != "" {
+		sb.WriteString(fmt.Sprintf("  - %s\n", dev.Suggestion))
+	}
+}
+
+// lineSuffix locates a deviation within its file, when it has a line
+func lineSuffix(dev patterns.Deviation) string {
+	if dev.LineNumber > 0 {
+		return fmt.Sprintf(" (line %d)", dev.LineNumber)
+	}
+	return ""
+}
+
+// patternName names the pattern a file matched
+func patternName(match patterns.PatternMatch) string {
+	if match.Pattern != nil {
+		return match.Pattern.Name
+	}
+	return "unknown"
+}
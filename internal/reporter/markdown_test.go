@@ -0,0 +1,60 @@
+package reporter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+func TestReportMarkdownGolden(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string, lines int) string {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x\n", lines)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	handler := &patterns.Pattern{Name: "http_handler", Type: patterns.PatternHTTPHandler}
+	matches := []patterns.PatternMatch{
+		{FilePath: write("services/user.go", 120), Pattern: service, Score: 97, AutoApprove: true},
+		{FilePath: write("handlers/orders.go", 80), Pattern: handler, Score: 62, Deviations: []patterns.Deviation{
+			{Type: patterns.DeviationMissing, Element: "import", Expected: "context", LineNumber: 3, Severity: patterns.SeverityWarning},
+			{Type: patterns.DeviationMissing, Element: "import", Expected: "errors", LineNumber: 3, Severity: patterns.SeverityWarning},
+			{Type: patterns.DeviationDifferent, Element: "error_handling", Expected: "if err != nil", Actual: "_ = err",
+				Suggestion: "Handle the error", LineNumber: 21, Severity: patterns.SeverityError},
+		}},
+	}
+
+	r := New(false)
+	r.Root = root
+	r.GroupDeviations = true
+	got := r.ReportMarkdown(matches, "go")
+	if strings.Contains(got, root) || strings.Contains(got, "](") || strings.Contains(got, "<details>") {
+		t.Errorf("markdown report has host links, HTML or absolute paths:\n%s", got)
+	}
+
+	golden := filepath.Join("testdata", "report.md")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("markdown report differs from %s (rerun with -update to accept):\n%s", golden, got)
+	}
+}
@@ -0,0 +1,201 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// MetricsRecord summarizes one check run for trend tracking, one JSON line
+// per run
+type MetricsRecord struct {
+	Timestamp         time.Time                 `json:"timestamp"`
+	Commit            string                    `json:"commit,omitempty"`
+	Version           string                    `json:"version"`            // cr version that produced the record
+	ConfigFingerprint string                    `json:"config_fingerprint"` // Changes whenever the config does
+	Files             int                       `json:"files"`
+	Approved          int                       `json:"approved"`
+	Review            int                       `json:"review"` // Needing review, with warnings only
+	Errors            int                       `json:"errors"`
+	Patterns          map[string]PatternMetrics `json:"patterns"` // By pattern type; "none" for unmatched files
+}
+
+// PatternMetrics counts the files matched to one pattern type
+type PatternMetrics struct {
+	Files        int     `json:"files"`
+	Approved     int     `json:"approved"`
+	Review       int     `json:"review"`
+	Errors       int     `json:"errors"`
+	ApprovalRate float64 `json:"approval_rate"` // Percentage of files auto-approved
+}
+
+// DefaultApprovalDropThreshold is how many percentage points a pattern's
+// approval rate may fall below the baseline before it is flagged
+const DefaultApprovalDropThreshold = 20.0
+
+// ApprovalDrop is a pattern whose approval rate fell sharply since the
+// baseline run, typically because its golden example was edited or the
+// pattern drifted
+type ApprovalDrop struct {
+	PatternType string  `json:"pattern_type"`
+	Baseline    float64 `json:"baseline_rate"`
+	Current     float64 `json:"current_rate"`
+	Files       int     `json:"files"`
+	Summary     string  `json:"summary"`
+}
+
+// Metrics counts a run's results overall and per pattern type
+func (r *Reporter) Metrics(matches []patterns.PatternMatch) MetricsRecord {
+	record := MetricsRecord{
+		Timestamp: time.Now().UTC(),
+		Files:     len(matches),
+		Patterns:  make(map[string]PatternMetrics),
+	}
+	for _, match := range matches {
+		patternType := "none"
+		if match.Pattern != nil {
+			patternType = string(match.Pattern.Type)
+		}
+		counts := record.Patterns[patternType]
+		counts.Files++
+		switch {
+		case match.AutoApprove:
+			record.Approved++
+			counts.Approved++
+		case hasError(match):
+			record.Errors++
+			counts.Errors++
+		default:
+			record.Review++
+			counts.Review++
+		}
+		record.Patterns[patternType] = counts
+	}
+	for patternType, counts := range record.Patterns {
+		counts.ApprovalRate = approvalRate(counts)
+		record.Patterns[patternType] = counts
+	}
+	return record
+}
+
+// LoadBaselineMetrics reads the most recent record of a metrics JSONL file.
+// Read it before appending this run's record, which would otherwise be its
+// own baseline.
+func LoadBaselineMetrics(path string) (*MetricsRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return nil, fmt.Errorf("%s has no recorded runs", path)
+	}
+	var record MetricsRecord
+	if err := json.Unmarshal([]byte(last), &record); err != nil {
+		return nil, fmt.Errorf("%s: invalid metrics record: %w", path, err)
+	}
+	return &record, nil
+}
+
+// AppendMetrics appends a run's record to a metrics JSONL file, creating it
+// and its directory as needed
+func AppendMetrics(path string, record MetricsRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append metrics: %w", err)
+	}
+	return nil
+}
+
+// approvalRate is the percentage of a pattern's files that were approved
+func approvalRate(counts PatternMetrics) float64 {
+	if counts.Files == 0 {
+		return 0
+	}
+	return float64(counts.Approved) * 100 / float64(counts.Files)
+}
+
+// approvalDrops compares each pattern's approval rate in this run with the
+// reporter's baseline, returning those that fell by more than the drop
+// threshold, steepest first. Patterns missing from either run are skipped.
+func (r *Reporter) approvalDrops(matches []patterns.PatternMatch) []ApprovalDrop {
+	if r.Baseline == nil {
+		return nil
+	}
+	threshold := r.ApprovalDropThreshold
+	if threshold == 0 {
+		threshold = DefaultApprovalDropThreshold
+	}
+
+	drops := []ApprovalDrop{}
+	for patternType, current := range r.Metrics(matches).Patterns {
+		baseline, ok := r.Baseline.Patterns[patternType]
+		if patternType == "none" || !ok || baseline.Files == 0 {
+			continue
+		}
+		// Rates are recomputed so records written before they were stored
+		// still work as a baseline
+		was, now := approvalRate(baseline), approvalRate(current)
+		if was-now <= threshold {
+			continue
+		}
+		drops = append(drops, ApprovalDrop{
+			PatternType: patternType,
+			Baseline:    was,
+			Current:     now,
+			Files:       current.Files,
+			Summary: fmt.Sprintf("%s: %.0f%% of %d file(s) approved, down from %.0f%%",
+				patternType, now, current.Files, was),
+		})
+	}
+	sort.Slice(drops, func(i, j int) bool {
+		di, dj := drops[i].Baseline-drops[i].Current, drops[j].Baseline-drops[j].Current
+		if di != dj {
+			return di > dj
+		}
+		return drops[i].PatternType < drops[j].PatternType
+	})
+	return drops
+}
+
+// printApprovalDrops lists patterns whose approval rate dropped ahead of the
+// per-file results
+func (r *Reporter) printApprovalDrops(drops []ApprovalDrop) {
+	if len(drops) == 0 {
+		return
+	}
+	fmt.Fprintf(r.Out, "⚠ Approval rate drops since baseline (%d)\n", len(drops))
+	for _, d := range drops {
+		fmt.Fprintf(r.Out, "  %s\n", d.Summary)
+	}
+	fmt.Fprintln(r.Out, "  A changed golden example or drifted pattern may be to blame")
+	fmt.Fprintln(r.Out)
+}
+
+// hasError checks if any of a match's deviations is an error
+func hasError(match patterns.PatternMatch) bool {
+	for _, dev := range match.Deviations {
+		if dev.Severity == patterns.SeverityError {
+			return true
+		}
+	}
+	return false
+}
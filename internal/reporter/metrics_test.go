@@ -0,0 +1,134 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestMetrics(t *testing.T) {
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	handler := &patterns.Pattern{Name: "handler", Type: patterns.PatternHTTPHandler}
+	failing := patterns.PatternMatch{FilePath: "x.go", Pattern: handler, Deviations: []patterns.Deviation{
+		{Element: "import", Severity: patterns.SeverityWarning},
+		{Element: "secret", Severity: patterns.SeverityError},
+	}}
+	matches := scoredMatches(service, 100, 97, 96, 70)
+	matches = append(matches, scoredMatches(handler, 98, 60)...)
+	matches = append(matches, failing)
+	matches = append(matches, scoredMatches(nil, 0)...)
+
+	record := New(false).Metrics(matches)
+	if record.Files != 8 || record.Approved != 4 || record.Review != 3 || record.Errors != 1 {
+		t.Errorf("totals = %d files, %d approved, %d review, %d errors; want 8, 4, 3, 1",
+			record.Files, record.Approved, record.Review, record.Errors)
+	}
+	want := map[string]PatternMetrics{
+		"service":      {Files: 4, Approved: 3, Review: 1, ApprovalRate: 75},
+		"http_handler": {Files: 3, Approved: 1, Review: 1, Errors: 1, ApprovalRate: 100.0 / 3},
+		"none":         {Files: 1, Review: 1},
+	}
+	if !reflect.DeepEqual(record.Patterns, want) {
+		t.Errorf("per-type counts = %+v, want %+v", record.Patterns, want)
+	}
+	if record.Timestamp.IsZero() {
+		t.Error("record has no timestamp")
+	}
+
+	if empty := New(false).Metrics(nil); empty.Files != 0 || len(empty.Patterns) != 0 {
+		t.Errorf("metrics of no files = %+v", empty)
+	}
+}
+
+func TestApprovalDrops(t *testing.T) {
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	handler := &patterns.Pattern{Name: "handler", Type: patterns.PatternHTTPHandler}
+	repository := &patterns.Pattern{Name: "repository", Type: patterns.PatternRepository}
+	model := &patterns.Pattern{Name: "model", Type: patterns.PatternModel}
+	matches := scoredMatches(service, 100, 97, 70, 60) // 50% approved
+	matches = append(matches, scoredMatches(handler, 80, 60)...)
+	matches = append(matches, scoredMatches(repository, 90)...)
+	matches = append(matches, scoredMatches(model, 100, 50)...)
+	matches = append(matches, scoredMatches(nil, 0)...)
+
+	baseline := &MetricsRecord{Patterns: map[string]PatternMetrics{
+		"service":      {Files: 10, Approved: 7}, // Down exactly 20 points
+		"http_handler": {Files: 4, Approved: 3},
+		"model":        {Files: 0},
+		"none":         {Files: 5, Approved: 5},
+		// No repository files were checked before
+	}}
+
+	r := New(false)
+	if drops := r.approvalDrops(matches); drops != nil {
+		t.Errorf("drops without a baseline = %+v, want nil", drops)
+	}
+
+	r.Baseline = baseline
+	drops := r.approvalDrops(matches)
+	if len(drops) != 1 || drops[0].PatternType != "http_handler" {
+		t.Fatalf("drops = %+v, want only http_handler", drops)
+	}
+	if d := drops[0]; d.Baseline != 75 || d.Current != 0 || d.Files != 2 {
+		t.Errorf("http_handler drop = %+v, want 75%% to 0%% of 2 files", d)
+	}
+
+	// Just over the threshold is flagged, steepest drop first
+	r.ApprovalDropThreshold = 19.9
+	if drops := r.approvalDrops(matches); len(drops) != 2 ||
+		drops[0].PatternType != "http_handler" || drops[1].PatternType != "service" {
+		t.Errorf("drops over 19.9 points = %+v, want http_handler then service", drops)
+	}
+
+	r.ApprovalDropThreshold = 80
+	if drops := r.approvalDrops(matches); len(drops) != 0 {
+		t.Errorf("drops over 80 points = %+v, want none", drops)
+	}
+}
+
+func TestLoadBaselineMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics", "runs.jsonl")
+	if _, err := LoadBaselineMetrics(path); err == nil {
+		t.Error("loading a missing metrics file succeeded")
+	}
+
+	first := MetricsRecord{Timestamp: time.Unix(1, 0).UTC(), Commit: "aaa", Files: 1}
+	second := MetricsRecord{Timestamp: time.Unix(2, 0).UTC(), Commit: "bbb", Files: 2}
+	for _, record := range []MetricsRecord{first, second} {
+		if err := AppendMetrics(path, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadBaselineMetrics(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Commit != "bbb" || got.Files != 2 {
+		t.Errorf("baseline = %+v, want the last record", got)
+	}
+
+	// The baseline is read before a run appends its own record
+	if err := AppendMetrics(path, MetricsRecord{Commit: "ccc"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Commit != "bbb" {
+		t.Errorf("appending changed the loaded baseline to %+v", got)
+	}
+	if got, err := LoadBaselineMetrics(path); err != nil || got.Commit != "ccc" {
+		t.Errorf("reloaded baseline = %+v, %v; want the appended record", got, err)
+	}
+
+	for name, content := range map[string]string{"empty": "\n\n", "invalid": "{\"files\": 1}\nnot json\n"} {
+		bad := filepath.Join(t.TempDir(), name+".jsonl")
+		if err := os.WriteFile(bad, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBaselineMetrics(bad); err == nil {
+			t.Errorf("loading an %s metrics file succeeded", name)
+		}
+	}
+}
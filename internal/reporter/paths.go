@@ -0,0 +1,43 @@
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NormalizePath converts a file path into repository-root-relative,
+// forward-slash form. Paths outside root are returned unchanged with ok=false.
+func NormalizePath(root, path string) (string, bool) {
+	// Accept Windows separators regardless of the host OS
+	slashed := strings.ReplaceAll(path, `\`, "/")
+
+	if root == "" {
+		return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(slashed)), "./"), true
+	}
+
+	abs, err := filepath.Abs(filepath.FromSlash(slashed))
+	if err != nil {
+		return path, false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// path normalizes a file path for output, warning once about paths that
+// fall outside the repository
+func (r *Reporter) path(p string) string {
+	normalized, ok := NormalizePath(r.Root, p)
+	if !ok && !r.warned[p] {
+		if r.warned == nil {
+			r.warned = make(map[string]bool)
+		}
+		r.warned[p] = true
+		fmt.Fprintf(os.Stderr, "Warning: %s is outside the repository; reporting it as-is\n", p)
+	}
+	return normalized
+}
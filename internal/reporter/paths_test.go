@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestNormalizePath(t *testing.T) {
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(filepath.Dir(root), "elsewhere", "foo.go")
+
+	tests := []struct {
+		name   string
+		root   string
+		path   string
+		want   string
+		inside bool
+	}{
+		{"dot prefix", root, "./internal/foo.go", "internal/foo.go", true},
+		{"plain relative", root, "internal/foo.go", "internal/foo.go", true},
+		{"absolute", root, filepath.Join(root, "internal", "foo.go"), "internal/foo.go", true},
+		{"windows separators", root, `internal\services\foo.go`, "internal/services/foo.go", true},
+		{"windows dot prefix", root, `.\internal\foo.go`, "internal/foo.go", true},
+		{"outside root", root, outside, outside, false},
+		{"parent directory", root, "../foo.go", "../foo.go", false},
+		{"no root", "", "./internal/foo.go", "internal/foo.go", true},
+		{"no root windows", "", `internal\foo.go`, "internal/foo.go", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inside := NormalizePath(tt.root, tt.path)
+			if got != tt.want || inside != tt.inside {
+				t.Errorf("NormalizePath(%q, %q) = %q, %v, want %q, %v", tt.root, tt.path, got, inside, tt.want, tt.inside)
+			}
+		})
+	}
+}
+
+func TestReportedPathsAreRepoRelative(t *testing.T) {
+	root, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New(false)
+	r.Root = root
+	matches := []patterns.PatternMatch{
+		{FilePath: "./internal/a.go", Score: 95, AutoApprove: true},
+		{FilePath: filepath.Join(root, "internal", "b.go"), Score: 95, AutoApprove: true},
+	}
+
+	out := r.FormatForGitHub(matches, "https://github.com/o/r.git", "abc123")
+	for _, want := range []string{"internal/a.go", "internal/b.go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("GitHub report missing %s:\n%s", want, out)
+		}
+	}
+	for _, bad := range []string{"./internal", root} {
+		if strings.Contains(out, bad) {
+			t.Errorf("GitHub report contains %q:\n%s", bad, out)
+		}
+	}
+
+	json := r.ReportJSON(matches, "go")
+	if strings.Contains(json, "./internal") || strings.Contains(json, root) {
+		t.Errorf("JSON report has unnormalized paths:\n%s", json)
+	}
+}
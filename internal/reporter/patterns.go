@@ -0,0 +1,205 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// PatternList is the JSON view of a config's patterns
+type PatternList struct {
+	Language string           `json:"language"`
+	Patterns []PatternSummary `json:"patterns"`
+}
+
+// PatternSummary describes one pattern. Detection, Structure and Examples
+// are only filled in for the full listing.
+type PatternSummary struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Language   string            `json:"language,omitempty"`
+	Version    string            `json:"version,omitempty"`
+	Confidence float64           `json:"confidence"`
+	SeenCount  int               `json:"seen_count"`
+	Declared   bool              `json:"declared,omitempty"`
+	Disabled   bool              `json:"disabled,omitempty"`
+	Remote     bool              `json:"remote,omitempty"` // From the config's patterns_url
+	Detection  *PatternDetection `json:"detection,omitempty"`
+	Structure  *PatternStructure `json:"structure,omitempty"`
+	Examples   *PatternExamples  `json:"examples,omitempty"`
+}
+
+// PatternDetection is a pattern's detection rule
+type PatternDetection struct {
+	FilePattern   string `json:"file_pattern,omitempty"`
+	FuncPattern   string `json:"func_pattern,omitempty"`
+	StructPattern string `json:"struct_pattern,omitempty"`
+	PackagePath   string `json:"package_path,omitempty"`
+}
+
+// PatternStructure is a pattern's expected structure
+type PatternStructure struct {
+	Elements []PatternElement `json:"elements"`
+	Required []string         `json:"required"`
+	Optional []string         `json:"optional"`
+	Ordering []string         `json:"ordering"`
+}
+
+// PatternElement is one structure element
+type PatternElement struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Pattern    string   `json:"pattern,omitempty"`
+	Examples   []string `json:"examples,omitempty"`
+	Importance float64  `json:"importance,omitempty"`
+}
+
+// PatternExamples lists a pattern's example files by tier
+type PatternExamples struct {
+	Golden       []ExampleRef `json:"golden"`
+	Blessed      []ExampleRef `json:"blessed"`
+	Discovered   []ExampleRef `json:"discovered"`
+	AntiPatterns []ExampleRef `json:"anti_patterns"`
+}
+
+// ExampleRef is an example file. Dates are RFC3339.
+type ExampleRef struct {
+	Path       string  `json:"path"`
+	Function   string  `json:"function,omitempty"`
+	Weight     float64 `json:"weight,omitempty"`
+	BlessedBy  string  `json:"blessed_by,omitempty"`
+	Blessed    string  `json:"blessed_date,omitempty"`
+	Deprecated string  `json:"deprecated,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// ReportPatterns prints a one-line summary of each pattern
+func (r *Reporter) ReportPatterns(patternList []patterns.Pattern) {
+	if len(patternList) == 0 {
+		fmt.Fprintln(r.Out, "No patterns learned yet (run 'cr init').")
+		return
+	}
+	for _, p := range patternList {
+		examples := len(p.AnnotatedGolden) + len(p.Blessed()) + len(p.Discovered)
+		fmt.Fprintf(r.Out, "• %s (%s) — %d example(s), %.0f%% confidence", p.ID, p.Type, examples, p.Confidence*100)
+		if p.Language != "" {
+			fmt.Fprintf(r.Out, ", %s", p.Language)
+		}
+		if len(p.AntiPatterns) > 0 {
+			fmt.Fprintf(r.Out, ", %d anti-pattern(s)", len(p.AntiPatterns))
+		}
+		if !p.IsEnabled() {
+			fmt.Fprint(r.Out, " (disabled)")
+		}
+		if p.Remote {
+			fmt.Fprint(r.Out, " (remote)")
+		}
+		fmt.Fprintln(r.Out)
+	}
+}
+
+// FormatPatterns renders the patterns as JSON, with their detection rules,
+// structure and example paths when full is set
+func (r *Reporter) FormatPatterns(patternList []patterns.Pattern, language string, full bool) string {
+	list := PatternList{Language: language, Patterns: []PatternSummary{}}
+	for _, p := range patternList {
+		summary := PatternSummary{
+			ID:         p.ID,
+			Name:       p.Name,
+			Type:       string(p.Type),
+			Language:   p.Language,
+			Version:    p.Version,
+			Confidence: p.Confidence,
+			SeenCount:  p.SeenCount,
+			Declared:   p.IsDeclared(),
+			Disabled:   !p.IsEnabled(),
+			Remote:     p.Remote,
+		}
+		if full {
+			summary.Detection = &PatternDetection{
+				FilePattern:   p.Detection.FilePattern,
+				FuncPattern:   p.Detection.FuncPattern,
+				StructPattern: p.Detection.StructPattern,
+				PackagePath:   p.Detection.PackagePath,
+			}
+			summary.Structure = patternStructure(p.Structure)
+			summary.Examples = patternExamples(p)
+		}
+		list.Patterns = append(list.Patterns, summary)
+	}
+
+	jsonBytes, _ := json.MarshalIndent(list, "", "  ")
+	return string(jsonBytes)
+}
+
+// patternStructure converts a structure, with empty lists rather than null
+func patternStructure(s patterns.CodeStructure) *PatternStructure {
+	structure := &PatternStructure{
+		Elements: []PatternElement{},
+		Required: append([]string{}, s.Required...),
+		Optional: append([]string{}, s.Optional...),
+		Ordering: append([]string{}, s.Ordering...),
+	}
+	for _, elem := range s.Elements {
+		structure.Elements = append(structure.Elements, PatternElement{
+			Name:       elem.Name,
+			Type:       string(elem.Type),
+			Pattern:    elem.Pattern,
+			Examples:   elem.Examples,
+			Importance: elem.Importance,
+		})
+	}
+	return structure
+}
+
+// patternExamples lists a pattern's examples by tier
+func patternExamples(p patterns.Pattern) *PatternExamples {
+	examples := &PatternExamples{
+		Golden:       []ExampleRef{},
+		Blessed:      []ExampleRef{},
+		Discovered:   []ExampleRef{},
+		AntiPatterns: []ExampleRef{},
+	}
+	for _, g := range p.AnnotatedGolden {
+		examples.Golden = append(examples.Golden, ExampleRef{
+			Path:      g.Path,
+			Function:  g.Function,
+			Weight:    g.Weight,
+			BlessedBy: g.BlessedBy,
+			Blessed:   rfc3339(g.BlessedDate),
+			Reason:    g.Reason,
+		})
+	}
+	for _, b := range p.Blessed() {
+		examples.Blessed = append(examples.Blessed, ExampleRef{
+			Path:      b.Path,
+			Function:  b.Function,
+			Weight:    b.Weight,
+			BlessedBy: b.BlessedBy,
+			Blessed:   rfc3339(b.BlessedDate),
+			Reason:    b.Reason,
+		})
+	}
+	for _, d := range p.Discovered {
+		examples.Discovered = append(examples.Discovered, ExampleRef{Path: d.Path, Weight: d.Weight})
+	}
+	for _, a := range p.AntiPatterns {
+		ref := ExampleRef{Path: a.Path, Function: a.Function, Reason: a.Reason}
+		if a.Deprecated != nil {
+			ref.Deprecated = rfc3339(*a.Deprecated)
+		}
+		examples.AntiPatterns = append(examples.AntiPatterns, ref)
+	}
+	return examples
+}
+
+// rfc3339 formats a date, or returns "" for an unset one
+func rfc3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
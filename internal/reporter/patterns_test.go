@@ -0,0 +1,35 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+func TestPatternListMarksDisabled(t *testing.T) {
+	disabled := false
+	list := []patterns.Pattern{
+		{ID: "service_pattern", Type: patterns.PatternService},
+		{ID: "repository_pattern", Type: patterns.PatternRepository, Enabled: &disabled},
+	}
+
+	var out bytes.Buffer
+	r := New(false)
+	r.Out = &out
+	r.ReportPatterns(list)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "(disabled)") || !strings.HasSuffix(lines[1], "(disabled)") {
+		t.Errorf("pattern list doesn't mark just the disabled pattern:\n%s", out.String())
+	}
+
+	var formatted PatternList
+	if err := json.Unmarshal([]byte(r.FormatPatterns(list, "go", false)), &formatted); err != nil {
+		t.Fatal(err)
+	}
+	if formatted.Patterns[0].Disabled || !formatted.Patterns[1].Disabled {
+		t.Errorf("JSON pattern list = %+v, want just repository_pattern disabled", formatted.Patterns)
+	}
+}
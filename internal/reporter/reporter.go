@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/loop-hub/code-on-rails/pkg/patterns"
@@ -13,21 +15,71 @@ import (
 // Reporter formats analysis results
 type Reporter struct {
 	Verbose bool
+	// Quiet prints only files needing attention and a one-line summary
+	Quiet bool
+	// SystemicThreshold is how many files must share a deviation for it to
+	// be escalated: 0 for DefaultSystemicThreshold, negative to never
+	SystemicThreshold int
+	// Baseline is an earlier run's metrics to compare approval rates with
+	Baseline *MetricsRecord
+	// ApprovalDropThreshold is how many percentage points a pattern's
+	// approval rate may drop below Baseline: 0 for DefaultApprovalDropThreshold
+	ApprovalDropThreshold float64
+	// ReviewLinesPerMinute is the review speed time-saved estimates assume:
+	// 0 for DefaultReviewLinesPerMinute
+	ReviewLinesPerMinute int
+	// Explicit marks results for files the user requested rather than AI-detected ones
+	Explicit bool
+	// ScoreBreakdown adds each file's score components to JSON output
+	ScoreBreakdown bool
+	// GroupDeviations folds a file's deviations of the same type and
+	// element, such as several missing imports, into one entry in text and
+	// GitHub output
+	GroupDeviations bool
+	// Compact writes JSON reports on a single line instead of indented
+	Compact bool
+	// MaxDeviations caps the deviations listed per file in text, GitHub,
+	// markdown and JSON reports, most severe first (0 for no cap)
+	MaxDeviations int
+	// Histogram adds the distribution of match scores per pattern type to
+	// text and JSON reports
+	Histogram bool
+	// NewPatterns are groups of unmatched files that look like patterns the
+	// config lacks, reported as possible new patterns in text and JSON
+	NewPatterns []patterns.PatternCandidate
+	// Root is the repository root reported file paths are made relative to
+	Root string
+	// Out receives printed reports (stdout by default)
+	Out io.Writer
+
+	warned map[string]bool // Paths already warned about being outside Root
 }
 
 // New creates a new reporter
 func New(verbose bool) *Reporter {
-	return &Reporter{Verbose: verbose}
+	return &Reporter{Verbose: verbose, Out: os.Stdout}
 }
 
 // Report prints pattern match results
 func (r *Reporter) Report(matches []patterns.PatternMatch) {
 	if len(matches) == 0 {
-		fmt.Println("No files to check.")
+		if !r.Quiet {
+			fmt.Fprintln(r.Out, "No files to check.")
+		}
 		return
 	}
 
-	fmt.Println("\nAnalyzing AI-generated code...\n")
+	if !r.Quiet {
+		if r.Explicit {
+			fmt.Fprint(r.Out, "\nAnalyzing requested files...\n\n")
+		} else {
+			fmt.Fprint(r.Out, "\nAnalyzing AI-generated code...\n\n")
+		}
+	}
+
+	r.printAntiPatternHits(antiPatternHits(matches))
+	r.printSystemic(r.systemicDeviations(matches))
+	r.printApprovalDrops(r.approvalDrops(matches))
 
 	approvedCount := 0
 	approvedLines := 0
@@ -37,7 +89,9 @@ func (r *Reporter) Report(matches []patterns.PatternMatch) {
 	errorLines := 0
 
 	for _, match := range matches {
-		r.printMatch(match)
+		if !r.Quiet || !match.AutoApprove {
+			r.printMatch(match)
+		}
 
 		// Count stats
 		lines := estimateLines(match.FilePath)
@@ -62,53 +116,151 @@ func (r *Reporter) Report(matches []patterns.PatternMatch) {
 		}
 	}
 
+	if r.Quiet {
+		r.printQuietSummary(approvedCount, warningCount, errorCount)
+		return
+	}
+
+	if r.Histogram {
+		r.printHistogram(scoreHistogram(matches))
+	}
+	r.printNewPatterns(r.newPatterns())
+
 	// Print summary
-	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("Summary:")
-	fmt.Printf("  ✓ %d file(s) auto-approved (%d lines)\n", approvedCount, approvedLines)
+	fmt.Fprintln(r.Out, "\n"+strings.Repeat("=", 60))
+	fmt.Fprintln(r.Out, "Summary:")
+	if r.Explicit {
+		fmt.Fprintln(r.Out, "  (explicitly requested files, not AI-detected)")
+	}
+	fmt.Fprintf(r.Out, "  ✓ %d file(s) auto-approved (%d lines)\n", approvedCount, approvedLines)
 	if warningCount > 0 {
-		fmt.Printf("  ⚠ %d file(s) need review (%d lines)\n", warningCount, warningLines)
+		fmt.Fprintf(r.Out, "  ⚠ %d file(s) need review (%d lines)\n", warningCount, warningLines)
 	}
 	if errorCount > 0 {
-		fmt.Printf("  ✗ %d file(s) have errors (%d lines)\n", errorCount, errorLines)
+		fmt.Fprintf(r.Out, "  ✗ %d file(s) have errors (%d lines)\n", errorCount, errorLines)
+	}
+
+	if saved := r.timeSaved(approvedLines); saved > 0 {
+		fmt.Fprintf(r.Out, "\nEstimated review time saved: %d minutes\n", saved)
+	}
+}
+
+// DefaultReviewLinesPerMinute is the assumed review speed
+const DefaultReviewLinesPerMinute = 20
+
+// timeSaved estimates the review minutes saved by auto-approving lines
+func (r *Reporter) timeSaved(lines int) int {
+	rate := r.ReviewLinesPerMinute
+	if rate <= 0 {
+		rate = DefaultReviewLinesPerMinute
+	}
+	return lines / rate
+}
+
+// printQuietSummary prints the file counts on a single line
+func (r *Reporter) printQuietSummary(approved, warnings, errors int) {
+	summary := fmt.Sprintf("✓ %d auto-approved", approved)
+	if warnings > 0 {
+		summary += fmt.Sprintf(", ⚠ %d need review", warnings)
+	}
+	if errors > 0 {
+		summary += fmt.Sprintf(", ✗ %d with errors", errors)
+	}
+	fmt.Fprintln(r.Out, summary)
+}
+
+// ReportExpiredSuppressions lists allow annotations past their expiry date
+func (r *Reporter) ReportExpiredSuppressions(expired []patterns.ExpiredSuppression) {
+	if len(expired) == 0 {
+		fmt.Fprintln(r.Out, "✓ No expired suppressions")
+		return
+	}
+
+	fmt.Fprintf(r.Out, "⚠ Expired suppressions (%d)\n", len(expired))
+	for _, s := range expired {
+		fmt.Fprintf(r.Out, "  %s:%d allow %s", r.path(s.Path), s.LineNumber, s.Element)
+		if s.Function != "" {
+			fmt.Fprintf(r.Out, " in %s", s.Function)
+		}
+		fmt.Fprintf(r.Out, " (expired %s)\n", s.Expires.Format("2006-01-02"))
+		if s.Reason != "" {
+			fmt.Fprintf(r.Out, "    Reason: %s\n", s.Reason)
+		}
+	}
+	fmt.Fprintln(r.Out, "\nFix the deviations or renew the suppressions with a new expires date.")
+}
+
+// printAntiPatternHits lists files resembling anti-patterns ahead of the
+// per-file results, since they are usually the most urgent
+func (r *Reporter) printAntiPatternHits(hits []patterns.AntiPatternMatch) {
+	if len(hits) == 0 {
+		return
+	}
+	fmt.Fprintf(r.Out, "⛔ Anti-patterns detected (%d)\n", len(hits))
+	for _, hit := range hits {
+		fmt.Fprintf(r.Out, "  %s %s\n", r.path(hit.FilePath), r.resemblance(hit, "%s"))
+		if hit.AntiPattern.Reason != "" {
+			fmt.Fprintf(r.Out, "    Why: %s\n", hit.AntiPattern.Reason)
+		}
+		if hit.AntiPattern.MigrationGuide != "" {
+			fmt.Fprintf(r.Out, "    Migration guide: %s\n", hit.AntiPattern.MigrationGuide)
+		}
+	}
+	fmt.Fprintln(r.Out)
+}
+
+// resemblance describes how a file relates to an anti-pattern, quoting the
+// anti-pattern's path with pathFormat
+func (r *Reporter) resemblance(hit patterns.AntiPatternMatch, pathFormat string) string {
+	if r.path(hit.FilePath) == r.path(hit.AntiPattern.Path) {
+		return "is marked as an anti-pattern"
 	}
+	return fmt.Sprintf("resembles "+pathFormat+" (%.0f%%)", hit.AntiPattern.Path, hit.Similarity*100)
+}
 
-	estimatedTime := (warningLines + errorLines) / 20 // Assume 20 lines/minute review
-	if estimatedTime > 0 {
-		fmt.Printf("\nEstimated review time saved: %d minutes\n", approvedLines/20)
+// antiPatternHits gathers the anti-pattern hits across all matches
+func antiPatternHits(matches []patterns.PatternMatch) []patterns.AntiPatternMatch {
+	hits := []patterns.AntiPatternMatch{}
+	for _, match := range matches {
+		hits = append(hits, match.AntiPatternHits...)
 	}
+	return hits
 }
 
 // printMatch prints a single match result
 func (r *Reporter) printMatch(match patterns.PatternMatch) {
 	if match.AutoApprove {
-		fmt.Printf("✓ %s\n", match.FilePath)
+		fmt.Fprintf(r.Out, "✓ %s\n", r.path(match.FilePath))
 		if match.Pattern != nil {
-			fmt.Printf("  Pattern: %s (%.0f%% match)\n", match.Pattern.Name, match.Score)
+			fmt.Fprintf(r.Out, "  Pattern: %s (%.0f%% match)\n", match.Pattern.Name, match.Score)
+			r.printVersion(match)
 
 			// Show reference based on match type
 			switch match.MatchType {
 			case "annotated_golden":
 				if match.GoldenRef != nil {
-					fmt.Printf("  Golden example: %s\n", match.GoldenRef.Path)
+					fmt.Fprintf(r.Out, "  Golden example: %s\n", match.GoldenRef.Path)
 					if match.GoldenRef.BlessedBy != "" {
-						fmt.Printf("  Blessed by: %s\n", match.GoldenRef.BlessedBy)
+						fmt.Fprintf(r.Out, "  Blessed by: %s\n", match.GoldenRef.BlessedBy)
 					}
 					if match.GoldenRef.Reason != "" {
-						fmt.Printf("  Reason: %s\n", match.GoldenRef.Reason)
+						fmt.Fprintf(r.Out, "  Reason: %s\n", match.GoldenRef.Reason)
 					}
 				}
 			case "config_blessed":
 				if match.BlessedRef != nil {
-					fmt.Printf("  Reference: %s (blessed)\n", match.BlessedRef.Path)
+					fmt.Fprintf(r.Out, "  Reference: %s (blessed)\n", match.BlessedRef.Path)
 				}
 			case "discovered":
 				if match.DiscoveredRef != nil {
-					fmt.Printf("  Reference: %s\n", match.DiscoveredRef.Path)
+					fmt.Fprintf(r.Out, "  Reference: %s\n", match.DiscoveredRef.Path)
 				}
+			case "consensus":
+				fmt.Fprintf(r.Out, "  Reference: consensus of %d example(s)\n", len(match.References))
 			}
 		}
-		fmt.Println("  Auto-approved\n")
+		r.printSuppressed(match)
+		fmt.Fprint(r.Out, "  Auto-approved\n\n")
 	} else {
 		// Determine icon based on severity
 		icon := "⚠"
@@ -119,70 +271,104 @@ func (r *Reporter) printMatch(match patterns.PatternMatch) {
 			}
 		}
 
-		fmt.Printf("%s %s\n", icon, match.FilePath)
+		fmt.Fprintf(r.Out, "%s %s\n", icon, r.path(match.FilePath))
 		if match.Pattern != nil {
-			fmt.Printf("  Pattern: %s (%.0f%% match)\n", match.Pattern.Name, match.Score)
+			fmt.Fprintf(r.Out, "  Pattern: %s (%.0f%% match)\n", match.Pattern.Name, match.Score)
+			r.printVersion(match)
 
 			// Show reference based on match type
 			switch match.MatchType {
 			case "annotated_golden":
 				if match.GoldenRef != nil {
-					fmt.Printf("  Golden example: %s\n", match.GoldenRef.Path)
+					fmt.Fprintf(r.Out, "  Golden example: %s\n", match.GoldenRef.Path)
 					if match.GoldenRef.BlessedBy != "" {
-						fmt.Printf("  Blessed by: %s\n", match.GoldenRef.BlessedBy)
+						fmt.Fprintf(r.Out, "  Blessed by: %s\n", match.GoldenRef.BlessedBy)
 					}
 				}
 			case "config_blessed":
 				if match.BlessedRef != nil {
-					fmt.Printf("  Reference: %s (blessed)\n", match.BlessedRef.Path)
+					fmt.Fprintf(r.Out, "  Reference: %s (blessed)\n", match.BlessedRef.Path)
 				}
 			case "discovered":
 				if match.DiscoveredRef != nil {
-					fmt.Printf("  Reference: %s\n", match.DiscoveredRef.Path)
+					fmt.Fprintf(r.Out, "  Reference: %s\n", match.DiscoveredRef.Path)
 				}
+			case "consensus":
+				fmt.Fprintf(r.Out, "  Reference: consensus of %d example(s)\n", len(match.References))
 			}
 		}
 
 		if len(match.Deviations) > 0 {
-			fmt.Println("  Deviations:")
-			for _, dev := range match.Deviations {
-				r.printDeviation(dev)
+			fmt.Fprintln(r.Out, "  Deviations:")
+			shown, hidden := r.shownDeviations(match.Deviations)
+			for _, group := range r.deviationGroups(shown) {
+				r.printGroup(group)
+			}
+			if hidden > 0 {
+				fmt.Fprintf(r.Out, "    %s\n", moreNote(hidden))
 			}
 		}
-		fmt.Println()
+		r.printSuppressed(match)
+		fmt.Fprintln(r.Out)
 	}
 }
 
-// printDeviation prints a single deviation
-func (r *Reporter) printDeviation(dev patterns.Deviation) {
-	icon := "•"
-	switch dev.Severity {
-	case patterns.SeverityError:
-		icon = "✗"
-	case patterns.SeverityWarning:
-		icon = "⚠"
-	case patterns.SeverityInfo:
-		icon = "ℹ"
+// printSuppressed lists deviations silenced by allow annotations in verbose mode
+func (r *Reporter) printSuppressed(match patterns.PatternMatch) {
+	if !r.Verbose || len(match.Suppressed) == 0 {
+		return
+	}
+	fmt.Fprintln(r.Out, "  Suppressed:")
+	for _, s := range match.Suppressed {
+		fmt.Fprintf(r.Out, "    - %s", s.Element)
+		if s.LineNumber > 0 {
+			fmt.Fprintf(r.Out, " (line %d)", s.LineNumber)
+		}
+		fmt.Fprintf(r.Out, ": %s\n", s.Reason)
 	}
+}
 
-	fmt.Printf("    %s %s", icon, dev.Element)
+// printVersion notes when a file matches an older version of its pattern
+func (r *Reporter) printVersion(match patterns.PatternMatch) {
+	if match.IsOutdated() {
+		fmt.Fprintf(r.Out, "  Version: matches %s v%s, current is v%s\n",
+			match.Pattern.Name, match.MatchedVersion, match.Pattern.Version)
+	}
+}
+
+// printDeviation prints a single deviation
+func (r *Reporter) printDeviation(dev patterns.Deviation) {
+	fmt.Fprintf(r.Out, "    %s %s", severityIcon(dev.Severity), dev.Element)
 	if dev.Expected != "" {
-		fmt.Printf(" (expected: %s", dev.Expected)
+		fmt.Fprintf(r.Out, " (expected: %s", dev.Expected)
 		if dev.Actual != "" {
-			fmt.Printf(", found: %s", dev.Actual)
+			fmt.Fprintf(r.Out, ", found: %s", dev.Actual)
 		}
-		fmt.Print(")")
+		fmt.Fprint(r.Out, ")")
 	}
-	fmt.Println()
+	fmt.Fprintln(r.Out)
 
 	if dev.Suggestion != "" {
-		fmt.Printf("      Suggestion: %s\n", dev.Suggestion)
+		fmt.Fprintf(r.Out, "      Suggestion: %s\n", dev.Suggestion)
 	}
 }
 
+// severityIcon marks a severity in text output
+func severityIcon(severity patterns.Severity) string {
+	switch severity {
+	case patterns.SeverityError:
+		return "✗"
+	case patterns.SeverityWarning:
+		return "⚠"
+	case patterns.SeverityInfo:
+		return "ℹ"
+	}
+	return "•"
+}
+
 // ReportInit prints initialization results
 func (r *Reporter) ReportInit(patterns []patterns.Pattern, totalFiles int, language string) {
-	fmt.Println("Scanning codebase...")
+	fmt.Fprintln(r.Out, "Scanning codebase...")
 	lang := "code"
 	switch language {
 	case "go":
@@ -193,42 +379,221 @@ func (r *Reporter) ReportInit(patterns []patterns.Pattern, totalFiles int, langu
 		lang = "JavaScript"
 	case "react":
 		lang = "React/TypeScript"
+	case "csharp":
+		lang = "C#"
 	}
-	fmt.Printf("→ Discovered %d %s files\n", totalFiles, lang)
-	fmt.Println("→ Identified patterns:")
+	fmt.Fprintf(r.Out, "→ Discovered %d %s files\n", totalFiles, lang)
+	fmt.Fprintln(r.Out, "→ Identified patterns:")
 
 	for _, p := range patterns {
-		fmt.Printf("  • %d %s", p.SeenCount, p.Name)
+		fmt.Fprintf(r.Out, "  • %d %s", p.SeenCount, p.Name)
 		if p.SeenCount != 1 {
-			fmt.Print("s")
+			fmt.Fprint(r.Out, "s")
+		}
+		fmt.Fprintln(r.Out)
+	}
+
+	fmt.Fprintln(r.Out, "→ Generated .code-on-rails.yml")
+	fmt.Fprintln(r.Out, "✓ Ready to use!")
+}
+
+// ReportMigration prints a migration plan for files on anti-patterns,
+// grouped by the pattern each anti-pattern belongs to
+func (r *Reporter) ReportMigration(hits []patterns.AntiPatternMatch) {
+	if len(hits) == 0 {
+		fmt.Fprintln(r.Out, "✓ No files match known anti-patterns")
+		return
+	}
+
+	groups, order := groupMigrations(hits)
+	fmt.Fprintf(r.Out, "Migration plan: %d file(s) on anti-patterns\n", len(hits))
+	for _, name := range order {
+		group := groups[name]
+		fmt.Fprintf(r.Out, "\n%s (%d file(s))\n", name, len(group))
+		for _, hit := range group {
+			fmt.Fprintf(r.Out, "  %s", r.path(hit.FilePath))
+			if r.path(hit.FilePath) != r.path(hit.AntiPattern.Path) {
+				fmt.Fprintf(r.Out, " (%.0f%% similar to %s)", hit.Similarity*100, hit.AntiPattern.Path)
+			}
+			fmt.Fprintln(r.Out)
+			if hit.AntiPattern.Reason != "" {
+				fmt.Fprintf(r.Out, "    Why: %s\n", hit.AntiPattern.Reason)
+			}
+			if hit.AntiPattern.Deprecated != nil {
+				fmt.Fprintf(r.Out, "    Deprecated: %s\n", hit.AntiPattern.Deprecated.Format("2006-01-02"))
+			}
+			if hit.AntiPattern.MigrationGuide != "" {
+				fmt.Fprintf(r.Out, "    Migration guide: %s\n", hit.AntiPattern.MigrationGuide)
+			}
+			if hit.Replacement != "" {
+				fmt.Fprintf(r.Out, "    Migrate towards: %s\n", hit.Replacement)
+			}
 		}
-		fmt.Println()
 	}
+}
 
-	fmt.Println("→ Generated .code-on-rails.yml")
-	fmt.Println("✓ Ready to use!")
+// MigrationPlan is the JSON migration output
+type MigrationPlan struct {
+	Tasks []MigrationTask `json:"tasks"`
+}
+
+// MigrationTask is one file's migration step for agents
+type MigrationTask struct {
+	FilePath       string  `json:"file_path"`
+	Pattern        string  `json:"pattern"`
+	AntiPattern    string  `json:"anti_pattern"`
+	Similarity     float64 `json:"similarity"`
+	Reason         string  `json:"reason,omitempty"`
+	Deprecated     string  `json:"deprecated,omitempty"`
+	MigrationGuide string  `json:"migration_guide,omitempty"`
+	Replacement    string  `json:"replacement,omitempty"`
+}
+
+// FormatMigrationJSON outputs the migration plan as a JSON task list
+func (r *Reporter) FormatMigrationJSON(hits []patterns.AntiPatternMatch) string {
+	groups, order := groupMigrations(hits)
+	tasks := []MigrationTask{}
+	for _, name := range order {
+		for _, hit := range groups[name] {
+			tasks = append(tasks, r.migrationTask(hit))
+		}
+	}
+
+	jsonBytes, _ := json.MarshalIndent(MigrationPlan{Tasks: tasks}, "", "  ")
+	return string(jsonBytes)
+}
+
+// ReportSimilarity prints how two files compare structurally
+func (r *Reporter) ReportSimilarity(cmp *patterns.FileComparison) {
+	a, b := r.path(cmp.FileA), r.path(cmp.FileB)
+	fmt.Fprintf(r.Out, "A: %s\nB: %s\n\n", a, b)
+	fmt.Fprintf(r.Out, "Structural similarity: %.1f%%\n", cmp.Structure*100)
+
+	r.printSetDiff("Imports", cmp.SharedImports, cmp.OnlyAImports, cmp.OnlyBImports)
+	r.printSetDiff("Exports", cmp.SharedExports, cmp.OnlyAExports, cmp.OnlyBExports)
+}
+
+// printSetDiff prints the shared and unique members of two sets
+func (r *Reporter) printSetDiff(title string, shared, onlyA, onlyB []string) {
+	fmt.Fprintf(r.Out, "\n%s: %d shared, %d only in A, %d only in B\n", title, len(shared), len(onlyA), len(onlyB))
+	for _, s := range shared {
+		fmt.Fprintf(r.Out, "  = %s\n", s)
+	}
+	for _, s := range onlyA {
+		fmt.Fprintf(r.Out, "  A %s\n", s)
+	}
+	for _, s := range onlyB {
+		fmt.Fprintf(r.Out, "  B %s\n", s)
+	}
+}
+
+// SimilarityReport is the JSON output of cr similarity
+type SimilarityReport struct {
+	FileA         string   `json:"file_a"`
+	FileB         string   `json:"file_b"`
+	Structure     float64  `json:"structural_similarity"`
+	SharedImports []string `json:"shared_imports"`
+	OnlyAImports  []string `json:"only_a_imports"`
+	OnlyBImports  []string `json:"only_b_imports"`
+	SharedExports []string `json:"shared_exports"`
+	OnlyAExports  []string `json:"only_a_exports"`
+	OnlyBExports  []string `json:"only_b_exports"`
+}
+
+// FormatSimilarityJSON outputs a file comparison as JSON
+func (r *Reporter) FormatSimilarityJSON(cmp *patterns.FileComparison) string {
+	report := SimilarityReport{
+		FileA:         r.path(cmp.FileA),
+		FileB:         r.path(cmp.FileB),
+		Structure:     cmp.Structure,
+		SharedImports: cmp.SharedImports,
+		OnlyAImports:  cmp.OnlyAImports,
+		OnlyBImports:  cmp.OnlyBImports,
+		SharedExports: cmp.SharedExports,
+		OnlyAExports:  cmp.OnlyAExports,
+		OnlyBExports:  cmp.OnlyBExports,
+	}
+	jsonBytes, _ := json.MarshalIndent(report, "", "  ")
+	return string(jsonBytes)
+}
+
+// migrationTask describes an anti-pattern hit for JSON output
+func (r *Reporter) migrationTask(hit patterns.AntiPatternMatch) MigrationTask {
+	task := MigrationTask{
+		FilePath:       r.path(hit.FilePath),
+		Pattern:        antiPatternGroup(hit),
+		AntiPattern:    hit.AntiPattern.Path,
+		Similarity:     hit.Similarity,
+		Reason:         hit.AntiPattern.Reason,
+		MigrationGuide: hit.AntiPattern.MigrationGuide,
+		Replacement:    hit.Replacement,
+	}
+	if hit.AntiPattern.Deprecated != nil {
+		task.Deprecated = hit.AntiPattern.Deprecated.Format("2006-01-02")
+	}
+	return task
+}
+
+// antiPatternGroup names the pattern an anti-pattern hit belongs to
+func antiPatternGroup(hit patterns.AntiPatternMatch) string {
+	if hit.AntiPattern.Pattern == "" && hit.Pattern != nil {
+		return hit.Pattern.Name
+	}
+	return hit.AntiPattern.Pattern
+}
+
+// groupMigrations groups anti-pattern hits by pattern in first-seen order
+func groupMigrations(hits []patterns.AntiPatternMatch) (map[string][]patterns.AntiPatternMatch, []string) {
+	groups := make(map[string][]patterns.AntiPatternMatch)
+	order := []string{}
+	for _, hit := range hits {
+		name := antiPatternGroup(hit)
+		if _, ok := groups[name]; !ok {
+			order = append(order, name)
+		}
+		groups[name] = append(groups[name], hit)
+	}
+	return groups, order
 }
 
 // ReportLearn prints learning results
 func (r *Reporter) ReportLearn(newPatterns []patterns.Pattern, updatedPatterns int) {
-	fmt.Println("Analyzing merged code from last week...")
-
 	if len(newPatterns) > 0 {
-		fmt.Printf("→ Discovered %d new pattern(s):\n", len(newPatterns))
+		fmt.Fprintf(r.Out, "→ Discovered %d new pattern(s):\n", len(newPatterns))
 		for _, p := range newPatterns {
-			fmt.Printf("  • %s (%d examples)\n", p.Name, p.SeenCount)
+			fmt.Fprintf(r.Out, "  • %s (%d examples)\n", p.Name, p.SeenCount)
 		}
 	}
 
 	if updatedPatterns > 0 {
-		fmt.Printf("→ Updated %d existing pattern(s)\n", updatedPatterns)
+		fmt.Fprintf(r.Out, "→ Updated %d existing pattern(s)\n", updatedPatterns)
 	}
 
 	if len(newPatterns) == 0 && updatedPatterns == 0 {
-		fmt.Println("→ No new patterns found")
+		fmt.Fprintln(r.Out, "→ No new patterns found")
 	}
 
-	fmt.Println("✓ Configuration updated")
+	fmt.Fprintln(r.Out, "✓ Configuration updated")
+}
+
+// ReportAntiCandidates prints code that may be an anti-pattern, for a human
+// to confirm with cr bless --anti
+func (r *Reporter) ReportAntiCandidates(candidates []patterns.AntiPatternCandidate) {
+	if len(candidates) == 0 {
+		fmt.Fprintln(r.Out, "→ No possible anti-patterns found")
+		return
+	}
+
+	fmt.Fprintf(r.Out, "\n→ Possible anti-patterns (%d), confirm with cr bless --anti <file>:\n", len(candidates))
+	for _, c := range candidates {
+		days := int(c.Replaced.Sub(c.Introduced).Hours() / 24)
+		if c.Removed {
+			fmt.Fprintf(r.Out, "  • %s: removed %d day(s) after it was added\n", c.Path, days)
+		} else {
+			fmt.Fprintf(r.Out, "  • %s: %.0f%% rewritten %d day(s) after it was added\n", c.Path, c.Churn*100, days)
+		}
+		fmt.Fprintf(r.Out, "    original: git show %s:%s\n", c.Commit, c.Path)
+	}
 }
 
 // estimateLines counts the number of lines in a file
@@ -252,32 +617,58 @@ func estimateLines(filePath string) int {
 
 // JSONReport is the structured output for CI/CD systems
 type JSONReport struct {
-	Summary       ReportSummary     `json:"summary"`
-	AutoApproved  []FileReport      `json:"auto_approved"`
-	NeedsReview   []FileReport      `json:"needs_review"`
-	NewPatterns   []string          `json:"new_patterns,omitempty"`
-	Language      string            `json:"language"`
+	Summary         ReportSummary       `json:"summary"`
+	AutoApproved    []FileReport        `json:"auto_approved"`
+	NeedsReview     []FileReport        `json:"needs_review"`
+	AntiPatternHits []MigrationTask     `json:"anti_pattern_hits"`
+	Systemic        []SystemicDeviation `json:"systemic,omitempty"`
+	ApprovalDrops   []ApprovalDrop      `json:"approval_drops,omitempty"`
+	NewPatterns     []NewPatternReport  `json:"new_patterns,omitempty"`
+	Histogram       []HistogramReport   `json:"histogram,omitempty"`
+	Language        string              `json:"language"`
+	Source          string              `json:"source"` // "ai_detected" or "explicit"
 }
 
 // ReportSummary contains aggregate statistics
 type ReportSummary struct {
-	TotalFiles      int `json:"total_files"`
-	ApprovedFiles   int `json:"approved_files"`
-	ReviewFiles     int `json:"review_files"`
-	ApprovedLines   int `json:"approved_lines"`
-	ReviewLines     int `json:"review_lines"`
-	TimeSavedMins   int `json:"time_saved_mins"`
+	TotalFiles    int `json:"total_files"`
+	ApprovedFiles int `json:"approved_files"`
+	ReviewFiles   int `json:"review_files"`
+	ApprovedLines int `json:"approved_lines"`
+	ReviewLines   int `json:"review_lines"`
+	TimeSavedMins int `json:"time_saved_mins"`
 }
 
 // FileReport represents a single file's analysis
 type FileReport struct {
-	FilePath     string            `json:"file_path"`
-	Pattern      string            `json:"pattern"`
-	PatternType  string            `json:"pattern_type"`
-	Score        float64           `json:"score"`
-	Lines        int               `json:"lines"`
-	Deviations   []DeviationReport `json:"deviations,omitempty"`
-	ReviewGuide  []string          `json:"review_guide,omitempty"`
+	FilePath       string            `json:"file_path"`
+	Pattern        string            `json:"pattern"`
+	PatternType    string            `json:"pattern_type"`
+	Score          float64           `json:"score"`
+	MatchedVersion string            `json:"matched_version,omitempty"`
+	Lines          int               `json:"lines"`
+	RunnerUps      []RunnerUpReport  `json:"runner_ups,omitempty"`
+	Breakdown      *BreakdownReport  `json:"score_breakdown,omitempty"`
+	Deviations     []DeviationReport `json:"deviations,omitempty"`
+	MoreDeviations int               `json:"more_deviations,omitempty"` // Left out of Deviations by --max-deviations
+	ReviewGuide    []string          `json:"review_guide,omitempty"`
+}
+
+// BreakdownReport lists the points each component took off a file's score
+// of 100, which the components sum to unless the score bottomed out at 0
+type BreakdownReport struct {
+	Imports             float64 `json:"imports"`
+	ErrorHandling       float64 `json:"error_handling"`
+	Checks              float64 `json:"checks"`
+	Structure           float64 `json:"structure"`
+	StructureSimilarity float64 `json:"structure_similarity"`
+	AntiPatternHits     int     `json:"anti_pattern_hits"` // Block auto-approval without lowering the score
+}
+
+// RunnerUpReport is the best score of a pattern type the file didn't match
+type RunnerUpReport struct {
+	PatternType string  `json:"pattern_type"`
+	Score       float64 `json:"score"`
 }
 
 // DeviationReport represents a single deviation
@@ -293,15 +684,30 @@ type DeviationReport struct {
 // ReportJSON outputs the analysis in JSON format
 func (r *Reporter) ReportJSON(matches []patterns.PatternMatch, language string) string {
 	report := JSONReport{
-		Language:     language,
-		AutoApproved: []FileReport{},
-		NeedsReview:  []FileReport{},
+		Language:        language,
+		AutoApproved:    []FileReport{},
+		NeedsReview:     []FileReport{},
+		Source:          "ai_detected",
+		AntiPatternHits: []MigrationTask{},
+	}
+	if r.Explicit {
+		report.Source = "explicit"
+	}
+
+	for _, hit := range antiPatternHits(matches) {
+		report.AntiPatternHits = append(report.AntiPatternHits, r.migrationTask(hit))
+	}
+	report.Systemic = r.systemicDeviations(matches)
+	report.ApprovalDrops = r.approvalDrops(matches)
+	report.NewPatterns = r.newPatterns()
+	if r.Histogram {
+		report.Histogram = scoreHistogram(matches)
 	}
 
 	for _, match := range matches {
 		lines := estimateLines(match.FilePath)
 		fileReport := FileReport{
-			FilePath: match.FilePath,
+			FilePath: r.path(match.FilePath),
 			Score:    match.Score,
 			Lines:    lines,
 		}
@@ -310,6 +716,23 @@ func (r *Reporter) ReportJSON(matches []patterns.PatternMatch, language string)
 			fileReport.Pattern = match.Pattern.Name
 			fileReport.PatternType = string(match.Pattern.Type)
 		}
+		if match.IsOutdated() {
+			fileReport.MatchedVersion = match.MatchedVersion
+		}
+		if r.ScoreBreakdown && match.Breakdown != nil {
+			b := match.Breakdown
+			fileReport.Breakdown = &BreakdownReport{
+				Imports:             b.Imports,
+				ErrorHandling:       b.ErrorHandling,
+				Checks:              b.Checks,
+				Structure:           b.Structure,
+				StructureSimilarity: b.StructureSimilarity,
+				AntiPatternHits:     b.AntiPatternHits,
+			}
+		}
+		for _, runnerUp := range match.RunnerUps {
+			fileReport.RunnerUps = append(fileReport.RunnerUps, RunnerUpReport{PatternType: string(runnerUp.Type), Score: runnerUp.Score})
+		}
 
 		if match.AutoApprove {
 			report.AutoApproved = append(report.AutoApproved, fileReport)
@@ -317,7 +740,9 @@ func (r *Reporter) ReportJSON(matches []patterns.PatternMatch, language string)
 			report.Summary.ApprovedLines += lines
 		} else {
 			// Add deviations
-			for _, dev := range match.Deviations {
+			shown, hidden := r.shownDeviations(match.Deviations)
+			fileReport.MoreDeviations = hidden
+			for _, dev := range shown {
 				fileReport.Deviations = append(fileReport.Deviations, DeviationReport{
 					Element:    dev.Element,
 					Expected:   dev.Expected,
@@ -340,9 +765,18 @@ func (r *Reporter) ReportJSON(matches []patterns.PatternMatch, language string)
 	}
 
 	report.Summary.TotalFiles = len(matches)
-	report.Summary.TimeSavedMins = report.Summary.ApprovedLines / 20
+	report.Summary.TimeSavedMins = r.timeSaved(report.Summary.ApprovedLines)
 
-	jsonBytes, _ := json.MarshalIndent(report, "", "  ")
+	return r.marshal(report)
+}
+
+// marshal encodes a JSON report, indented unless Compact is set
+func (r *Reporter) marshal(v interface{}) string {
+	if r.Compact {
+		jsonBytes, _ := json.Marshal(v)
+		return string(jsonBytes)
+	}
+	jsonBytes, _ := json.MarshalIndent(v, "", "  ")
 	return string(jsonBytes)
 }
 
@@ -430,6 +864,9 @@ func (r *Reporter) FormatForGitHub(matches []patterns.PatternMatch, repoURL, sha
 
 	sb.WriteString(fmt.Sprintf("**%d files** analyzed | **%d** auto-approved | **%d** need review\n\n",
 		len(matches), len(approvedFiles), len(reviewFiles)))
+	if r.Explicit {
+		sb.WriteString("_Files were explicitly requested, not AI-detected._\n\n")
+	}
 
 	// Approved section (collapsible)
 	if len(approvedFiles) > 0 {
@@ -440,7 +877,7 @@ func (r *Reporter) FormatForGitHub(matches []patterns.PatternMatch, repoURL, sha
 		sb.WriteString("| File | Pattern | Match |\n")
 		sb.WriteString("|------|---------|-------|\n")
 		for _, match := range approvedFiles {
-			fileLink := formatGitHubLink(repoURL, sha, match.FilePath, 0)
+			fileLink := formatGitHubLink(repoURL, sha, r.path(match.FilePath), 0)
 			patternName := "unknown"
 			if match.Pattern != nil {
 				patternName = match.Pattern.Name
@@ -450,6 +887,44 @@ func (r *Reporter) FormatForGitHub(matches []patterns.PatternMatch, repoURL, sha
 		sb.WriteString("\n</details>\n\n")
 	}
 
+	// Anti-patterns first: usually the most urgent
+	if hits := antiPatternHits(matches); len(hits) > 0 {
+		sb.WriteString("### ⛔ Anti-patterns Detected\n\n")
+		sb.WriteString("These files resemble code the team has marked as an anti-pattern:\n\n")
+		for _, hit := range hits {
+			fileLink := formatGitHubLink(repoURL, sha, r.path(hit.FilePath), 0)
+			sb.WriteString(fmt.Sprintf("- %s %s\n", fileLink, r.resemblance(hit, "`%s`")))
+			if hit.AntiPattern.Reason != "" {
+				sb.WriteString(fmt.Sprintf("  - Why: %s\n", hit.AntiPattern.Reason))
+			}
+			if hit.AntiPattern.MigrationGuide != "" {
+				sb.WriteString(fmt.Sprintf("  - Migration guide: %s\n", hit.AntiPattern.MigrationGuide))
+			}
+			if hit.Replacement != "" {
+				sb.WriteString(fmt.Sprintf("  - 💡 Migrate towards `%s`\n", hit.Replacement))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if systemic := r.systemicDeviations(matches); len(systemic) > 0 {
+		sb.WriteString("### 🚨 Systemic Deviations\n\n")
+		sb.WriteString("These deviations recur across many files, pointing at architectural drift:\n\n")
+		for _, s := range systemic {
+			sb.WriteString(fmt.Sprintf("- **%s**\n", s.Summary))
+		}
+		sb.WriteString("\n")
+	}
+
+	if drops := r.approvalDrops(matches); len(drops) > 0 {
+		sb.WriteString("### 📉 Approval Rate Drops\n\n")
+		sb.WriteString("These patterns approve far fewer files than in the baseline run. Check for an edited golden example or pattern drift:\n\n")
+		for _, d := range drops {
+			sb.WriteString(fmt.Sprintf("- **%s**\n", d.Summary))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Review section (expanded)
 	if len(reviewFiles) > 0 {
 		sb.WriteString("### 🔍 Needs Human Review\n\n")
@@ -463,35 +938,32 @@ func (r *Reporter) FormatForGitHub(matches []patterns.PatternMatch, repoURL, sha
 				patternType = match.Pattern.Type
 			}
 
-			fileLink := formatGitHubLink(repoURL, sha, match.FilePath, 0)
+			fileLink := formatGitHubLink(repoURL, sha, r.path(match.FilePath), 0)
 			sb.WriteString(fmt.Sprintf("#### %s\n\n", fileLink))
 			sb.WriteString(fmt.Sprintf("**Pattern:** `%s` (%.0f%% match)\n\n", patternName, match.Score))
 
 			// Deviations with links to specific lines
 			if len(match.Deviations) > 0 {
 				sb.WriteString("**Issues found:**\n\n")
-				for _, dev := range match.Deviations {
-					icon := "⚠️"
-					if dev.Severity == patterns.SeverityError {
-						icon = "❌"
-					} else if dev.Severity == patterns.SeverityInfo {
-						icon = "ℹ️"
+				file := r.path(match.FilePath)
+				shown, hidden := r.shownDeviations(match.Deviations)
+				for _, group := range r.deviationGroups(shown) {
+					if len(group.deviations) == 1 {
+						writeGitHubDeviation(&sb, group.deviations[0], repoURL, sha, file)
+						continue
 					}
-
-					if dev.LineNumber > 0 {
-						lineLink := formatGitHubLink(repoURL, sha, match.FilePath, dev.LineNumber)
-						sb.WriteString(fmt.Sprintf("- %s **%s** at %s\n", icon, dev.Element, lineLink))
-					} else {
-						sb.WriteString(fmt.Sprintf("- %s **%s**\n", icon, dev.Element))
-					}
-
-					if dev.Expected != "" {
-						sb.WriteString(fmt.Sprintf("  - Expected: `%s`\n", dev.Expected))
-					}
-					if dev.Suggestion != "" {
-						sb.WriteString(fmt.Sprintf("  - 💡 %s\n", dev.Suggestion))
+					sb.WriteString(fmt.Sprintf("- %s **%s**\n", githubIcon(group.severity), group.summary()))
+					for _, dev := range group.deviations {
+						sb.WriteString(fmt.Sprintf("  - `%s`", specific(dev)))
+						if dev.LineNumber > 0 {
+							sb.WriteString(" at " + formatGitHubLink(repoURL, sha, file, dev.LineNumber))
+						}
+						sb.WriteString("\n")
 					}
 				}
+				if hidden > 0 {
+					sb.WriteString(fmt.Sprintf("- _%s_\n", moreNote(hidden)))
+				}
 				sb.WriteString("\n")
 			}
 
@@ -516,11 +988,40 @@ func (r *Reporter) FormatForGitHub(matches []patterns.PatternMatch, repoURL, sha
 
 	sb.WriteString("---\n")
 	sb.WriteString("_Generated by [Code on Rails](https://github.com/loop-hub/code-on-rails)_ • ")
-	sb.WriteString(fmt.Sprintf("Review time saved: ~%d min\n", approvedLines/20))
+	sb.WriteString(fmt.Sprintf("Review time saved: ~%d min\n", r.timeSaved(approvedLines)))
 
 	return sb.String()
 }
 
+// writeGitHubDeviation writes a single deviation as a markdown list item
+func writeGitHubDeviation(sb *strings.Builder, dev patterns.Deviation, repoURL, sha, file string) {
+	icon := githubIcon(dev.Severity)
+	if dev.LineNumber > 0 {
+		lineLink := formatGitHubLink(repoURL, sha, file, dev.LineNumber)
+		sb.WriteString(fmt.Sprintf("- %s **%s** at %s\n", icon, dev.Element, lineLink))
+	} else {
+		sb.WriteString(fmt.Sprintf("- %s **%s**\n", icon, dev.Element))
+	}
+
+	if dev.Expected != "" {
+		sb.WriteString(fmt.Sprintf("  - Expected: `%s`\n", dev.Expected))
+	}
+	if dev.Suggestion != "" {
+		sb.WriteString(fmt.Sprintf("  - 💡 %s\n", dev.Suggestion))
+	}
+}
+
+// githubIcon marks a severity in GitHub comments
+func githubIcon(severity patterns.Severity) string {
+	switch severity {
+	case patterns.SeverityError:
+		return "❌"
+	case patterns.SeverityInfo:
+		return "ℹ️"
+	}
+	return "⚠️"
+}
+
 // formatGitHubLink creates a GitHub link to a file or specific line
 func formatGitHubLink(repoURL, sha, filePath string, lineNumber int) string {
 	if repoURL == "" || sha == "" {
@@ -547,25 +1048,26 @@ type AIFeedback struct {
 
 // AIFeedbackSummary provides high-level context
 type AIFeedbackSummary struct {
-	TotalFiles      int    `json:"total_files"`
-	NeedsFixes      int    `json:"needs_fixes"`
-	AutoApproved    int    `json:"auto_approved"`
-	PrimaryLanguage string `json:"primary_language"`
+	TotalFiles      int                 `json:"total_files"`
+	NeedsFixes      int                 `json:"needs_fixes"`
+	AutoApproved    int                 `json:"auto_approved"`
+	PrimaryLanguage string              `json:"primary_language"`
+	Systemic        []SystemicDeviation `json:"systemic,omitempty"`
 }
 
 // AIFileFeedback describes issues in a specific file
 type AIFileFeedback struct {
-	FilePath       string             `json:"file_path"`
-	PatternType    string             `json:"pattern_type"`
-	MatchScore     float64            `json:"match_score"`
-	Issues         []AIIssue          `json:"issues"`
-	ReferenceFile  string             `json:"reference_file,omitempty"`
-	ExpectedImports []string          `json:"expected_imports,omitempty"`
+	FilePath        string    `json:"file_path"`
+	PatternType     string    `json:"pattern_type"`
+	MatchScore      float64   `json:"match_score"`
+	Issues          []AIIssue `json:"issues"`
+	ReferenceFile   string    `json:"reference_file,omitempty"`
+	ExpectedImports []string  `json:"expected_imports,omitempty"`
 }
 
 // AIIssue describes a single issue that needs fixing
 type AIIssue struct {
-	Type       string `json:"type"`        // "missing", "different", "novel"
+	Type       string `json:"type"` // "missing", "different", "novel"
 	Element    string `json:"element"`
 	Expected   string `json:"expected,omitempty"`
 	Actual     string `json:"actual,omitempty"`
@@ -576,10 +1078,11 @@ type AIIssue struct {
 
 // AIPatternRef provides example code for a pattern
 type AIPatternRef struct {
-	PatternType string   `json:"pattern_type"`
-	PatternName string   `json:"pattern_name"`
-	ExampleFile string   `json:"example_file"`
-	KeyElements []string `json:"key_elements"`
+	PatternType string              `json:"pattern_type"`
+	PatternName string              `json:"pattern_name"`
+	ExampleFile string              `json:"example_file"`
+	KeyElements []string            `json:"key_elements"`
+	Examples    map[string][]string `json:"examples,omitempty"` // Element name -> concrete instances
 }
 
 // FormatAIFeedback generates AI-readable feedback that Claude can act upon
@@ -593,6 +1096,7 @@ func (r *Reporter) FormatAIFeedback(matches []patterns.PatternMatch, language st
 		ApprovedFiles:   []string{},
 		PatternExamples: []AIPatternRef{},
 	}
+	feedback.Summary.Systemic = r.systemicDeviations(matches)
 
 	// Collect pattern examples for reference
 	patternExamples := make(map[string]AIPatternRef)
@@ -617,41 +1121,20 @@ func (r *Reporter) FormatAIFeedback(matches []patterns.PatternMatch, language st
 				PatternName: p.Name,
 				ExampleFile: exampleFile,
 				KeyElements: keyElements,
+				Examples:    elementExamples(p.Structure),
 			}
 		}
 	}
 
 	for _, match := range matches {
 		if match.AutoApprove {
-			feedback.ApprovedFiles = append(feedback.ApprovedFiles, match.FilePath)
+			feedback.ApprovedFiles = append(feedback.ApprovedFiles, r.path(match.FilePath))
 			feedback.Summary.AutoApproved++
 		} else {
-			fileFeedback := AIFileFeedback{
-				FilePath:   match.FilePath,
-				MatchScore: match.Score,
-				Issues:     []AIIssue{},
-			}
+			fileFeedback := r.buildFileFeedback(match)
 
+			// Add pattern example if not already present
 			if match.Pattern != nil {
-				fileFeedback.PatternType = string(match.Pattern.Type)
-
-				// Get reference file
-				if match.GoldenRef != nil {
-					fileFeedback.ReferenceFile = match.GoldenRef.Path
-				} else if match.BlessedRef != nil {
-					fileFeedback.ReferenceFile = match.BlessedRef.Path
-				} else if match.DiscoveredRef != nil {
-					fileFeedback.ReferenceFile = match.DiscoveredRef.Path
-				}
-
-				// Add expected imports from structure
-				for _, elem := range match.Pattern.Structure.Elements {
-					if elem.Type == patterns.ElementImport {
-						fileFeedback.ExpectedImports = append(fileFeedback.ExpectedImports, elem.Name)
-					}
-				}
-
-				// Add pattern example if not already present
 				if _, exists := patternExamples[string(match.Pattern.Type)]; !exists && fileFeedback.ReferenceFile != "" {
 					patternExamples[string(match.Pattern.Type)] = AIPatternRef{
 						PatternType: string(match.Pattern.Type),
@@ -661,20 +1144,6 @@ func (r *Reporter) FormatAIFeedback(matches []patterns.PatternMatch, language st
 				}
 			}
 
-			// Convert deviations to issues
-			for _, dev := range match.Deviations {
-				issue := AIIssue{
-					Type:       string(dev.Type),
-					Element:    dev.Element,
-					Expected:   dev.Expected,
-					Actual:     dev.Actual,
-					Suggestion: dev.Suggestion,
-					LineNumber: dev.LineNumber,
-					Severity:   string(dev.Severity),
-				}
-				fileFeedback.Issues = append(fileFeedback.Issues, issue)
-			}
-
 			feedback.FilesToFix = append(feedback.FilesToFix, fileFeedback)
 			feedback.Summary.NeedsFixes++
 		}
@@ -692,6 +1161,94 @@ func (r *Reporter) FormatAIFeedback(matches []patterns.PatternMatch, language st
 	return string(jsonBytes)
 }
 
+// buildFileFeedback converts a match that needs fixes into AI feedback
+func (r *Reporter) buildFileFeedback(match patterns.PatternMatch) AIFileFeedback {
+	fileFeedback := AIFileFeedback{
+		FilePath:   r.path(match.FilePath),
+		MatchScore: match.Score,
+		Issues:     []AIIssue{},
+	}
+
+	if match.Pattern != nil {
+		fileFeedback.PatternType = string(match.Pattern.Type)
+
+		// Get reference file
+		if match.GoldenRef != nil {
+			fileFeedback.ReferenceFile = match.GoldenRef.Path
+		} else if match.BlessedRef != nil {
+			fileFeedback.ReferenceFile = match.BlessedRef.Path
+		} else if match.DiscoveredRef != nil {
+			fileFeedback.ReferenceFile = match.DiscoveredRef.Path
+		} else if len(match.References) > 0 {
+			fileFeedback.ReferenceFile = match.References[0]
+		}
+
+		// Add expected imports from structure
+		for _, elem := range match.Pattern.Structure.Elements {
+			if elem.Type == patterns.ElementImport {
+				fileFeedback.ExpectedImports = append(fileFeedback.ExpectedImports, elem.Name)
+			}
+		}
+	}
+
+	// Convert deviations to issues
+	for _, dev := range match.Deviations {
+		issue := AIIssue{
+			Type:       string(dev.Type),
+			Element:    dev.Element,
+			Expected:   dev.Expected,
+			Actual:     dev.Actual,
+			Suggestion: dev.Suggestion,
+			LineNumber: dev.LineNumber,
+			Severity:   string(dev.Severity),
+		}
+		fileFeedback.Issues = append(fileFeedback.Issues, issue)
+	}
+
+	return fileFeedback
+}
+
+// AgentFileEvent is a single NDJSON line describing a file to fix
+type AgentFileEvent struct {
+	Type string `json:"type"` // "file"
+	AIFileFeedback
+}
+
+// AgentSummaryEvent is the final NDJSON line of an agent stream
+type AgentSummaryEvent struct {
+	Type string `json:"type"` // "summary"
+	AIFeedbackSummary
+}
+
+// FormatAgentFile formats a match as an NDJSON line for streaming to an agent.
+// Returns false if the file was auto-approved and needs no line.
+func (r *Reporter) FormatAgentFile(match patterns.PatternMatch) (string, bool) {
+	if match.AutoApprove {
+		return "", false
+	}
+	event := AgentFileEvent{Type: "file", AIFileFeedback: r.buildFileFeedback(match)}
+	jsonBytes, _ := json.Marshal(event)
+	return string(jsonBytes), true
+}
+
+// FormatAgentSummary formats the closing NDJSON summary line
+func (r *Reporter) FormatAgentSummary(matches []patterns.PatternMatch, language string) string {
+	summary := AIFeedbackSummary{
+		TotalFiles:      len(matches),
+		PrimaryLanguage: language,
+		Systemic:        r.systemicDeviations(matches),
+	}
+	for _, match := range matches {
+		if match.AutoApprove {
+			summary.AutoApproved++
+		} else {
+			summary.NeedsFixes++
+		}
+	}
+	jsonBytes, _ := json.Marshal(AgentSummaryEvent{Type: "summary", AIFeedbackSummary: summary})
+	return string(jsonBytes)
+}
+
 // generateAIInstructions creates clear instructions for Claude/AI to follow
 func generateAIInstructions(feedback AIFeedback) string {
 	if feedback.Summary.NeedsFixes == 0 {
@@ -752,6 +1309,7 @@ func (r *Reporter) FormatSkillFile(patternList []patterns.Pattern, language stri
 			Structure: SkillStructure{
 				Required: p.Structure.Required,
 				Optional: p.Structure.Optional,
+				Examples: elementExamples(p.Structure),
 			},
 			Examples: []string{},
 		}
@@ -779,6 +1337,72 @@ func (r *Reporter) FormatSkillFile(patternList []patterns.Pattern, language stri
 	return string(jsonBytes)
 }
 
+// RulesFile is the enforceable subset of learned patterns, for feeding
+// conventions into other linters and scripts
+type RulesFile struct {
+	Version  string `json:"version"`
+	Language string `json:"language"`
+	Rules    []Rule `json:"rules"`
+}
+
+// Rule is one concrete convention taken from a pattern's detection rules
+// or structure
+type Rule struct {
+	PatternID   string  `json:"pattern_id"`
+	PatternType string  `json:"pattern_type"`
+	Language    string  `json:"language,omitempty"` // Set for patterns learned under a language override
+	Kind        string  `json:"kind"`               // file_name, package_path, func_pattern, struct_pattern, required_import, test_convention
+	Value       string  `json:"value"`              // Glob, regex or import path depending on kind
+	Name        string  `json:"name,omitempty"`     // Test convention name
+	Confidence  float64 `json:"confidence"`
+}
+
+// FormatRules exports each pattern's file naming, package, declaration and
+// required-import conventions as flat rules
+func (r *Reporter) FormatRules(patternList []patterns.Pattern, language string) string {
+	rulesFile := RulesFile{
+		Version:  "1.0",
+		Language: language,
+		Rules:    []Rule{},
+	}
+
+	for _, p := range patternList {
+		add := func(kind, value, name string) {
+			if value == "" {
+				return
+			}
+			rulesFile.Rules = append(rulesFile.Rules, Rule{
+				PatternID:   p.ID,
+				PatternType: string(p.Type),
+				Language:    p.Language,
+				Kind:        kind,
+				Value:       value,
+				Name:        name,
+				Confidence:  p.Confidence,
+			})
+		}
+
+		add("file_name", p.Detection.FilePattern, "")
+		add("package_path", p.Detection.PackagePath, "")
+		add("func_pattern", p.Detection.FuncPattern, "")
+		add("struct_pattern", p.Detection.StructPattern, "")
+
+		required := append([]string{}, p.Structure.Required...)
+		sort.Strings(required)
+		for _, imp := range required {
+			add("required_import", imp, "")
+		}
+		for _, elem := range p.Structure.Elements {
+			if elem.Type == patterns.ElementTestConvention {
+				add("test_convention", elem.Pattern, elem.Name)
+			}
+		}
+	}
+
+	jsonBytes, _ := json.MarshalIndent(rulesFile, "", "  ")
+	return string(jsonBytes)
+}
+
 // SkillFile represents a portable skill definition
 type SkillFile struct {
 	Version  string  `json:"version"`
@@ -806,8 +1430,24 @@ type SkillDetection struct {
 
 // SkillStructure defines what the pattern should contain
 type SkillStructure struct {
-	Required []string `json:"required,omitempty"`
-	Optional []string `json:"optional,omitempty"`
+	Required []string            `json:"required,omitempty"`
+	Optional []string            `json:"optional,omitempty"`
+	Examples map[string][]string `json:"examples,omitempty"` // Element name -> concrete instances
+}
+
+// elementExamples collects the learned examples of each structure element
+func elementExamples(structure patterns.CodeStructure) map[string][]string {
+	var examples map[string][]string
+	for _, elem := range structure.Elements {
+		if len(elem.Examples) == 0 {
+			continue
+		}
+		if examples == nil {
+			examples = make(map[string][]string)
+		}
+		examples[elem.Name] = elem.Examples
+	}
+	return examples
 }
 
 // generateSkillDescription creates a human-readable description of the pattern
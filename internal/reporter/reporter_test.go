@@ -0,0 +1,178 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// linesFile writes a file of n lines and returns its path
+func linesFile(t *testing.T, name string, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.Repeat("x\n", n)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTimeSavedConsistentAcrossFormats(t *testing.T) {
+	pattern := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	matches := []patterns.PatternMatch{
+		{FilePath: linesFile(t, "a.go", 150), Pattern: pattern, Score: 98, AutoApprove: true},
+		{FilePath: linesFile(t, "b.go", 90), Pattern: pattern, Score: 97, AutoApprove: true},
+		{FilePath: linesFile(t, "c.go", 400), Pattern: pattern, Score: 60, Deviations: []patterns.Deviation{
+			{Type: patterns.DeviationMissing, Element: "import", Expected: "context", Severity: patterns.SeverityWarning},
+		}},
+	}
+
+	for _, tt := range []struct {
+		rate int
+		want int
+	}{
+		{0, 12},  // 240 approved lines at the default 20 a minute
+		{15, 16}, // Only the approved lines count, not the 400 to review
+	} {
+		var text bytes.Buffer
+		r := New(false)
+		r.ReviewLinesPerMinute = tt.rate
+		r.Out = &text
+		r.Report(matches)
+		if !strings.Contains(text.String(), fmt.Sprintf("Estimated review time saved: %d minutes", tt.want)) {
+			t.Errorf("rate %d: text report doesn't save %d minutes:\n%s", tt.rate, tt.want, text.String())
+		}
+
+		var report JSONReport
+		if err := json.Unmarshal([]byte(r.ReportJSON(matches, "go")), &report); err != nil {
+			t.Fatal(err)
+		}
+		if got := report.Summary.TimeSavedMins; got != tt.want {
+			t.Errorf("rate %d: JSON time saved = %d, want %d", tt.rate, got, tt.want)
+		}
+
+		if github := r.FormatForGitHub(matches, "", ""); !strings.Contains(github, fmt.Sprintf("Review time saved: ~%d min", tt.want)) {
+			t.Errorf("rate %d: GitHub report doesn't save %d minutes:\n%s", tt.rate, tt.want, github)
+		}
+	}
+}
+
+func TestScoreBreakdownJSON(t *testing.T) {
+	matches := []patterns.PatternMatch{{
+		FilePath: linesFile(t, "a.go", 10),
+		Pattern:  &patterns.Pattern{Name: "service", Type: patterns.PatternService},
+		Score:    72,
+		Breakdown: &patterns.ScoreBreakdown{
+			Imports:             10,
+			Checks:              5,
+			Structure:           13,
+			StructureSimilarity: 0.85,
+		},
+	}}
+
+	r := New(false)
+	if out := r.ReportJSON(matches, "go"); strings.Contains(out, "score_breakdown") {
+		t.Errorf("breakdown in default output:\n%s", out)
+	}
+
+	r.ScoreBreakdown = true
+	var report JSONReport
+	if err := json.Unmarshal([]byte(r.ReportJSON(matches, "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.NeedsReview) != 1 || report.NeedsReview[0].Breakdown == nil {
+		t.Fatalf("no breakdown in %+v", report.NeedsReview)
+	}
+	b := report.NeedsReview[0].Breakdown
+	if got := 100 - b.Imports - b.ErrorHandling - b.Checks - b.Structure; got != report.NeedsReview[0].Score {
+		t.Errorf("breakdown %+v sums to %g, score is %g", b, got, report.NeedsReview[0].Score)
+	}
+	if b.StructureSimilarity != 0.85 {
+		t.Errorf("structure similarity = %g", b.StructureSimilarity)
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	pattern := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	matches := append(missingImportsMatch(t), patterns.PatternMatch{
+		FilePath: linesFile(t, "order.go", 20), Pattern: pattern, Score: 98, AutoApprove: true,
+	})
+
+	r := New(false)
+	pretty := r.ReportJSON(matches, "go")
+	r.Compact = true
+	compact := r.ReportJSON(matches, "go")
+
+	if !strings.Contains(pretty, "\n  ") {
+		t.Errorf("default report isn't indented:\n%s", pretty)
+	}
+	if strings.Contains(compact, "\n") {
+		t.Errorf("compact report spans several lines:\n%s", compact)
+	}
+	var fromPretty, fromCompact interface{}
+	if err := json.Unmarshal([]byte(pretty), &fromPretty); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(compact), &fromCompact); err != nil {
+		t.Fatalf("compact report isn't JSON: %v", err)
+	}
+	if !reflect.DeepEqual(fromPretty, fromCompact) {
+		t.Errorf("compact report differs:\npretty:  %v\ncompact: %v", fromPretty, fromCompact)
+	}
+}
+
+func TestAgentStream(t *testing.T) {
+	service := &patterns.Pattern{Name: "service", Type: patterns.PatternService}
+	matches := []patterns.PatternMatch{
+		{FilePath: "approved.go", Pattern: service, Score: 100, AutoApprove: true},
+		{FilePath: "drifted.go", Pattern: service, Score: 70, Deviations: []patterns.Deviation{
+			{Element: "import", Expected: "errors", Severity: patterns.SeverityWarning, Suggestion: "wrap errors with \"fmt\""},
+		}},
+		{FilePath: "unmatched.go", MatchType: "none"},
+	}
+
+	// Lines are written the way check --format agent streams them
+	r := New(false)
+	var out bytes.Buffer
+	for _, match := range matches {
+		if line, ok := r.FormatAgentFile(match); ok {
+			fmt.Fprintln(&out, line)
+		}
+	}
+	fmt.Fprintln(&out, r.FormatAgentSummary(matches, "go"))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 2 files and a summary:\n%s", len(lines), out.String())
+	}
+	files := []string{}
+	for i, line := range lines {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v\n%s", i+1, err, line)
+		}
+		if i < len(lines)-1 {
+			if event["type"] != "file" {
+				t.Errorf("line %d type = %v, want file", i+1, event["type"])
+			}
+			files = append(files, fmt.Sprint(event["file_path"]))
+		}
+	}
+	if want := []string{"drifted.go", "unmatched.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("streamed files = %v, want %v without the approved file", files, want)
+	}
+
+	var summary AgentSummaryEvent
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Type != "summary" || summary.TotalFiles != 3 || summary.AutoApproved != 1 || summary.NeedsFixes != 2 {
+		t.Errorf("last line = %+v, want the summary of 3 files", summary)
+	}
+}
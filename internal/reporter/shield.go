@@ -0,0 +1,55 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// ShieldReport is a shields.io endpoint badge: https://shields.io/badges/endpoint-badge
+type ShieldReport struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// shieldColors are the badge colors by the conformance they start at,
+// highest first
+var shieldColors = []struct {
+	min   float64
+	color string
+}{
+	{95, "brightgreen"},
+	{85, "green"},
+	{70, "yellowgreen"},
+	{50, "yellow"},
+	{30, "orange"},
+	{0, "red"},
+}
+
+// ReportShield renders a check's conformance, the share of files
+// auto-approved, as a shields.io endpoint badge to commit alongside the
+// README. A check of no files gets a grey "no files" badge.
+func (r *Reporter) ReportShield(matches []patterns.PatternMatch) string {
+	shield := ShieldReport{SchemaVersion: 1, Label: "code on rails", Message: "no files", Color: "lightgrey"}
+	if len(matches) > 0 {
+		approved := 0
+		for _, match := range matches {
+			if match.AutoApprove {
+				approved++
+			}
+		}
+		conformance := float64(approved) * 100 / float64(len(matches))
+		shield.Message = fmt.Sprintf("%.0f%%", conformance)
+		for _, c := range shieldColors {
+			if conformance >= c.min {
+				shield.Color = c.color
+				break
+			}
+		}
+	}
+	data, _ := json.Marshal(shield)
+	return string(data)
+}
@@ -0,0 +1,36 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// approvedMatches are total matches of which approved are auto-approved
+func approvedMatches(approved, total int) []patterns.PatternMatch {
+	matches := make([]patterns.PatternMatch, total)
+	for i := range matches {
+		matches[i] = patterns.PatternMatch{FilePath: "x.go", AutoApprove: i < approved}
+	}
+	return matches
+}
+
+func TestReportShield(t *testing.T) {
+	tests := []struct {
+		matches []patterns.PatternMatch
+		want    string
+	}{
+		{nil, `{"schemaVersion":1,"label":"code on rails","message":"no files","color":"lightgrey"}`},
+		{approvedMatches(20, 20), `{"schemaVersion":1,"label":"code on rails","message":"100%","color":"brightgreen"}`},
+		{approvedMatches(19, 20), `{"schemaVersion":1,"label":"code on rails","message":"95%","color":"brightgreen"}`},
+		{approvedMatches(9, 10), `{"schemaVersion":1,"label":"code on rails","message":"90%","color":"green"}`},
+		{approvedMatches(2, 3), `{"schemaVersion":1,"label":"code on rails","message":"67%","color":"yellow"}`},
+		{approvedMatches(1, 3), `{"schemaVersion":1,"label":"code on rails","message":"33%","color":"orange"}`},
+		{approvedMatches(0, 4), `{"schemaVersion":1,"label":"code on rails","message":"0%","color":"red"}`},
+	}
+	for _, tt := range tests {
+		if got := New(false).ReportShield(tt.matches); got != tt.want {
+			t.Errorf("ReportShield of %d files = %s, want %s", len(tt.matches), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// DefaultSystemicThreshold is how many files must share a deviation before
+// it is escalated as systemic
+const DefaultSystemicThreshold = 5
+
+// SystemicDeviation is a deviation recurring across many files in one run,
+// pointing at architectural drift rather than a one-off mistake. It is
+// reported as an error even when each occurrence is only a warning.
+type SystemicDeviation struct {
+	Element  string   `json:"element"`
+	Expected string   `json:"expected,omitempty"`
+	Severity string   `json:"severity"` // Always "error"
+	Summary  string   `json:"summary"`
+	Files    []string `json:"files"`
+}
+
+// systemicDeviations finds warnings and errors, by element and expected
+// value, found in at least the reporter's systemic threshold of files, most
+// widespread first
+func (r *Reporter) systemicDeviations(matches []patterns.PatternMatch) []SystemicDeviation {
+	threshold := r.SystemicThreshold
+	if threshold == 0 {
+		threshold = DefaultSystemicThreshold
+	}
+	if threshold < 0 {
+		return nil
+	}
+
+	type key struct{ element, expected string }
+	files := make(map[key][]string)
+	kinds := make(map[key]patterns.DeviationType)
+	for _, match := range matches {
+		seen := make(map[key]bool)
+		for _, dev := range match.Deviations {
+			// Informational notes and files without a pattern aren't drift
+			if dev.Severity == patterns.SeverityInfo || dev.Type == patterns.DeviationNovel {
+				continue
+			}
+			k := key{dev.Element, dev.Expected}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			files[k] = append(files[k], r.path(match.FilePath))
+			kinds[k] = dev.Type
+		}
+	}
+
+	systemic := []SystemicDeviation{}
+	for k, paths := range files {
+		if len(paths) < threshold {
+			continue
+		}
+		systemic = append(systemic, SystemicDeviation{
+			Element:  k.element,
+			Expected: k.expected,
+			Severity: string(patterns.SeverityError),
+			Summary:  systemicSummary(len(paths), k.element, k.expected, kinds[k]),
+			Files:    paths,
+		})
+	}
+	sort.Slice(systemic, func(i, j int) bool {
+		if len(systemic[i].Files) != len(systemic[j].Files) {
+			return len(systemic[i].Files) > len(systemic[j].Files)
+		}
+		return systemic[i].Summary < systemic[j].Summary
+	})
+	return systemic
+}
+
+// systemicSummary describes a systemic deviation, e.g. "systemic: 12 files
+// missing import context"
+func systemicSummary(count int, element, expected string, kind patterns.DeviationType) string {
+	if kind == patterns.DeviationMissing {
+		if expected != "" {
+			element += " " + expected
+		}
+		return fmt.Sprintf("systemic: %d files missing %s", count, element)
+	}
+	if expected != "" {
+		element += fmt.Sprintf(" (expected %s)", expected)
+	}
+	return fmt.Sprintf("systemic: %d files deviate on %s", count, element)
+}
+
+// printSystemic lists systemic deviations ahead of the per-file results
+func (r *Reporter) printSystemic(systemic []SystemicDeviation) {
+	if len(systemic) == 0 {
+		return
+	}
+	fmt.Fprintf(r.Out, "✗ Systemic deviations (%d)\n", len(systemic))
+	for _, s := range systemic {
+		fmt.Fprintf(r.Out, "  %s\n", s.Summary)
+	}
+	fmt.Fprintln(r.Out)
+}
@@ -0,0 +1,88 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// systemicMatches has 6 handlers missing the auth import, 4 of them also
+// lacking a doc comment, and an informational note on every file
+func systemicMatches() []patterns.PatternMatch {
+	matches := []patterns.PatternMatch{}
+	for i := 0; i < 6; i++ {
+		devs := []patterns.Deviation{
+			{Type: patterns.DeviationMissing, Element: "import", Expected: "auth", Severity: patterns.SeverityWarning},
+			{Type: patterns.DeviationMissing, Element: "import", Expected: "auth", Severity: patterns.SeverityWarning, LineNumber: 9},
+			{Type: patterns.DeviationDifferent, Element: "naming_convention", Severity: patterns.SeverityInfo},
+		}
+		if i < 4 {
+			devs = append(devs, patterns.Deviation{Type: patterns.DeviationMissing, Element: "doc_comment", Expected: "// Get ...", Severity: patterns.SeverityWarning})
+		}
+		matches = append(matches, patterns.PatternMatch{
+			FilePath:   fmt.Sprintf("handlers/h%d.go", i),
+			Pattern:    &patterns.Pattern{Name: "handler", Type: patterns.PatternHTTPHandler},
+			Score:      70,
+			Deviations: devs,
+		})
+	}
+	return matches
+}
+
+func TestSystemicDeviations(t *testing.T) {
+	r := New(false)
+	systemic := r.systemicDeviations(systemicMatches())
+	if len(systemic) != 1 {
+		t.Fatalf("got %d systemic deviations, want just the auth import: %+v", len(systemic), systemic)
+	}
+	s := systemic[0]
+	if s.Summary != "systemic: 6 files missing import auth" || s.Severity != "error" || len(s.Files) != 6 {
+		t.Errorf("systemic deviation = %+v", s)
+	}
+
+	r.SystemicThreshold = 4
+	if got := r.systemicDeviations(systemicMatches()); len(got) != 2 || got[1].Element != "doc_comment" {
+		t.Errorf("threshold 4: got %+v, want the auth import then the doc comment", got)
+	}
+	r.SystemicThreshold = -1
+	if got := r.systemicDeviations(systemicMatches()); len(got) != 0 {
+		t.Errorf("disabled: got %+v", got)
+	}
+}
+
+func TestSystemicOutputs(t *testing.T) {
+	const summary = "systemic: 6 files missing import auth"
+
+	var text bytes.Buffer
+	r := New(false)
+	r.Out = &text
+	r.Report(systemicMatches())
+	if !strings.Contains(text.String(), "Systemic deviations (1)") || !strings.Contains(text.String(), summary) {
+		t.Errorf("text report lacks the systemic callout:\n%s", text.String())
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal([]byte(New(false).ReportJSON(systemicMatches(), "go")), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Systemic) != 1 || report.Systemic[0].Summary != summary || report.Systemic[0].Severity != "error" {
+		t.Errorf("JSON systemic = %+v", report.Systemic)
+	}
+
+	github := New(false).FormatForGitHub(systemicMatches(), "", "")
+	if !strings.Contains(github, "Systemic Deviations") || !strings.Contains(github, summary) {
+		t.Errorf("GitHub report lacks the systemic callout:\n%s", github)
+	}
+
+	var feedback AIFeedback
+	if err := json.Unmarshal([]byte(New(false).FormatAIFeedback(systemicMatches(), "go", nil)), &feedback); err != nil {
+		t.Fatal(err)
+	}
+	if len(feedback.Summary.Systemic) != 1 || feedback.Summary.Systemic[0].Summary != summary {
+		t.Errorf("AI feedback systemic = %+v", feedback.Summary.Systemic)
+	}
+}
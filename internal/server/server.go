@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+// Server answers editor requests with a minimal JSON-RPC 2.0 protocol: one
+// JSON object per line in each direction. The config is loaded on the first
+// request and reloaded whenever the file changes.
+type Server struct {
+	ConfigPath string
+	NewMatcher func(cfg *config.Config) (*matcher.Matcher, error)
+
+	matcher *matcher.Matcher
+	loaded  time.Time // Modification time of the loaded config
+}
+
+// New creates a server for the config at configPath
+func New(configPath string, newMatcher func(cfg *config.Config) (*matcher.Matcher, error)) *Server {
+	configPath = config.ResolvePath(configPath)
+	return &Server{ConfigPath: configPath, NewMatcher: newMatcher}
+}
+
+// CheckParams are the parameters of the check method. Text, when set, is
+// matched instead of the file on disk so unsaved buffers can be checked.
+type CheckParams struct {
+	Path string  `json:"path"`
+	Text *string `json:"text,omitempty"`
+}
+
+// CheckResult is the outcome of the check method
+type CheckResult struct {
+	Path        string      `json:"path"`
+	Pattern     string      `json:"pattern,omitempty"`
+	PatternType string      `json:"pattern_type,omitempty"`
+	MatchType   string      `json:"match_type"`
+	Score       float64     `json:"score"`
+	AutoApprove bool        `json:"auto_approve"`
+	Reference   string      `json:"reference,omitempty"`
+	Deviations  []Deviation `json:"deviations"`
+}
+
+// Deviation is a deviation as sent to editors
+type Deviation struct {
+	Element    string `json:"element"`
+	Expected   string `json:"expected,omitempty"`
+	Actual     string `json:"actual,omitempty"`
+	Severity   string `json:"severity"`
+	Suggestion string `json:"suggestion"`
+	LineNumber int    `json:"line_number,omitempty"`
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"` // Set, possibly to null, unless Error is
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve handles requests from r until EOF or a shutdown request
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			// The stream can't be resynchronized after malformed JSON
+			enc.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			return fmt.Errorf("invalid request: %w", err)
+		}
+
+		result, rpcErr := s.handle(req)
+		if len(req.ID) > 0 { // Notifications get no response
+			resp := response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+			if rpcErr == nil {
+				data, err := json.Marshal(result)
+				if err != nil {
+					return err
+				}
+				raw := json.RawMessage(data)
+				resp.Result = &raw
+			}
+			if err := enc.Encode(resp); err != nil {
+				return err
+			}
+		}
+		if req.Method == "shutdown" {
+			return nil
+		}
+	}
+}
+
+// handle dispatches a request to its method
+func (s *Server) handle(req request) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{codeInvalidRequest, `jsonrpc must be "2.0"`}
+	}
+
+	switch req.Method {
+	case "check":
+		var params CheckParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Path == "" {
+			return nil, &rpcError{codeInvalidParams, "check needs {path, text?}"}
+		}
+		result, err := s.check(params)
+		if err != nil {
+			return nil, &rpcError{codeServerError, err.Error()}
+		}
+		return result, nil
+	case "shutdown":
+		return nil, nil
+	default:
+		return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+	}
+}
+
+// check matches a file, or its unsaved text, against the patterns
+func (s *Server) check(params CheckParams) (*CheckResult, error) {
+	m, err := s.currentMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	path := repoRelative(params.Path)
+	var match *patterns.PatternMatch
+	if params.Text != nil {
+		match, err = m.MatchSource(path, []byte(*params.Text))
+	} else {
+		match, err = m.MatchFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{
+		Path:        params.Path,
+		MatchType:   match.MatchType,
+		Score:       match.Score,
+		AutoApprove: match.AutoApprove,
+		Deviations:  []Deviation{},
+	}
+	if match.Pattern != nil {
+		result.Pattern = match.Pattern.Name
+		result.PatternType = string(match.Pattern.Type)
+	}
+	switch {
+	case match.GoldenRef != nil:
+		result.Reference = match.GoldenRef.Path
+	case match.BlessedRef != nil:
+		result.Reference = match.BlessedRef.Path
+	case match.DiscoveredRef != nil:
+		result.Reference = match.DiscoveredRef.Path
+	case len(match.References) > 0:
+		result.Reference = match.References[0]
+	}
+	for _, dev := range match.Deviations {
+		result.Deviations = append(result.Deviations, Deviation{
+			Element:    dev.Element,
+			Expected:   dev.Expected,
+			Actual:     dev.Actual,
+			Severity:   string(dev.Severity),
+			Suggestion: dev.Suggestion,
+			LineNumber: dev.LineNumber,
+		})
+	}
+	return result, nil
+}
+
+// currentMatcher returns the matcher, rebuilding it when the config file
+// has changed since it was loaded
+func (s *Server) currentMatcher() (*matcher.Matcher, error) {
+	info, err := os.Stat(s.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if s.matcher != nil && info.ModTime().Equal(s.loaded) {
+		return s.matcher, nil
+	}
+
+	cfg, err := config.Load(s.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.NewMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.matcher, s.loaded = m, info.ModTime()
+	return m, nil
+}
+
+// repoRelative converts an absolute path inside the working directory to
+// the relative form pattern references use
+func repoRelative(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
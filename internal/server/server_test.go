@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loop-hub/code-on-rails/internal/config"
+	"github.com/loop-hub/code-on-rails/internal/matcher"
+)
+
+const refSource = `package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Normalize(name string) (string, error) {
+	_ = fmt.Sprint(name)
+	return strings.ToLower(name), nil
+}
+`
+
+// userSource is refSource missing its strings import
+var userSource = strings.Replace(refSource, "\t\"strings\"\n", "", 1)
+
+// serviceConfig declares a service pattern against ref
+func serviceConfig(ref string) string {
+	return `version: "1.0"
+language: go
+patterns:
+    - id: service
+      name: Service
+      type: service
+      detection:
+        file_pattern: '*.go'
+      reference: ` + ref + `
+      confidence: 0.8
+`
+}
+
+// client drives a server over a pipe
+type client struct {
+	t     *testing.T
+	enc   *json.Encoder
+	dec   *json.Decoder
+	in    *io.PipeWriter
+	done  chan error
+	loads int // Matchers the server built
+}
+
+// serve starts a server for a repository with services/ref.go as the
+// service reference and services/user.go missing one of its imports,
+// returning a client and the repository
+func serve(t *testing.T) (*client, string) {
+	t.Helper()
+	root := t.TempDir()
+	ref := filepath.Join(root, "services", "ref.go")
+	files := map[string]string{
+		"services/ref.go":    refSource,
+		"services/user.go":   userSource,
+		".code-on-rails.yml": serviceConfig(ref),
+	}
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &client{t: t, done: make(chan error, 1)}
+	s := New(filepath.Join(root, ".code-on-rails.yml"), func(cfg *config.Config) (*matcher.Matcher, error) {
+		c.loads++
+		return matcher.New(cfg.Patterns, cfg.Settings.AutoApproveThreshold), nil
+	})
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		err := s.Serve(inR, outW)
+		outW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() { inW.Close() })
+	c.enc, c.dec, c.in = json.NewEncoder(inW), json.NewDecoder(outR), inW
+	return c, root
+}
+
+// send writes one request line
+func (c *client) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// reply reads the next response
+func (c *client) reply() response {
+	c.t.Helper()
+	var resp response
+	if err := c.dec.Decode(&resp); err != nil {
+		c.t.Fatal(err)
+	}
+	return resp
+}
+
+// check sends a check request with params and decodes its result
+func (c *client) check(id int, params CheckParams) CheckResult {
+	c.t.Helper()
+	data, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.send(`{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"check","params":` + string(data) + `}`)
+	resp := c.reply()
+	if resp.Error != nil || resp.Result == nil {
+		c.t.Fatalf("check %s: error %+v", params.Path, resp.Error)
+	}
+	if string(resp.ID) != strconv.Itoa(id) {
+		c.t.Errorf("response id = %s, want %d", resp.ID, id)
+	}
+	var result CheckResult
+	if err := json.Unmarshal(*resp.Result, &result); err != nil {
+		c.t.Fatal(err)
+	}
+	return result
+}
+
+// wait returns Serve's error once it returns
+func (c *client) wait() error {
+	c.t.Helper()
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("server still running")
+		return nil
+	}
+}
+
+func TestServeCheck(t *testing.T) {
+	c, root := serve(t)
+	user := filepath.Join(root, "services", "user.go")
+
+	result := c.check(1, CheckParams{Path: user})
+	if result.Path != user || result.Pattern != "Service" || result.PatternType != "service" {
+		t.Errorf("check = %+v, want services/user.go matched to Service", result)
+	}
+	if len(result.Deviations) != 1 || result.Deviations[0].Element != "import" || result.Deviations[0].Expected != "strings" {
+		t.Errorf("deviations = %+v, want the missing strings import", result.Deviations)
+	}
+	if result.Reference == "" || filepath.Base(result.Reference) != "ref.go" {
+		t.Errorf("reference = %q, want ref.go", result.Reference)
+	}
+
+	// An unsaved buffer is checked instead of the file on disk
+	text := refSource
+	buffer := c.check(2, CheckParams{Path: user, Text: &text})
+	if len(buffer.Deviations) != 0 || buffer.Score <= result.Score {
+		t.Errorf("buffer check = %+v, want no deviations and a better score than %g", buffer, result.Score)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`)
+	var resp map[string]json.RawMessage
+	if err := c.dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if result, ok := resp["result"]; !ok || string(result) != "null" || resp["error"] != nil {
+		t.Errorf("shutdown response = %s, want a null result", resp)
+	}
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve = %v after shutdown", err)
+	}
+	if c.loads != 1 {
+		t.Errorf("built %d matchers, want the config loaded once", c.loads)
+	}
+}
+
+func TestServeNotifications(t *testing.T) {
+	c, root := serve(t)
+	user := filepath.Join(root, "services", "user.go")
+
+	// Notifications, even failing ones, get no response
+	c.send(`{"jsonrpc":"2.0","method":"check","params":{"path":"` + user + `"}}`)
+	c.send(`{"jsonrpc":"2.0","method":"unknown"}`)
+	if result := c.check(1, CheckParams{Path: user}); result.Pattern != "Service" {
+		t.Errorf("check after notifications = %+v", result)
+	}
+
+	// A shutdown notification stops the server silently
+	c.send(`{"jsonrpc":"2.0","method":"shutdown"}`)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve = %v after shutdown", err)
+	}
+	var resp response
+	if err := c.dec.Decode(&resp); err != io.EOF {
+		t.Errorf("after a shutdown notification read %+v, %v; want EOF", resp, err)
+	}
+}
+
+func TestServeReload(t *testing.T) {
+	c, root := serve(t)
+	user := filepath.Join(root, "services", "user.go")
+	if result := c.check(1, CheckParams{Path: user}); len(result.Deviations) != 1 {
+		t.Fatalf("deviations = %+v, want the missing strings import", result.Deviations)
+	}
+
+	// Pointing the pattern at user.go itself leaves nothing missing
+	path := filepath.Join(root, ".code-on-rails.yml")
+	if err := os.WriteFile(path, []byte(serviceConfig(user)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if result := c.check(2, CheckParams{Path: user}); len(result.Deviations) != 0 || filepath.Base(result.Reference) != "user.go" {
+		t.Errorf("check after the config changed = %+v, want user.go as the reference", result)
+	}
+	if c.loads != 2 {
+		t.Errorf("built %d matchers, want one per config", c.loads)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	c, root := serve(t)
+	tests := []struct {
+		line string
+		code int
+	}{
+		{`{"jsonrpc":"1.0","id":1,"method":"check","params":{"path":"a.go"}}`, codeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":2,"method":"format"}`, codeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":3,"method":"check","params":{}}`, codeInvalidParams},
+		{`{"jsonrpc":"2.0","id":4,"method":"check","params":{"path":"` + filepath.Join(root, "services", "missing.go") + `"}}`, codeServerError},
+	}
+	for i, tt := range tests {
+		c.send(tt.line)
+		resp := c.reply()
+		if resp.Error == nil || resp.Error.Code != tt.code || resp.Result != nil {
+			t.Errorf("%s: response %+v, want error %d", tt.line, resp, tt.code)
+		}
+		if string(resp.ID) != strconv.Itoa(i+1) {
+			t.Errorf("%s: response id %s", tt.line, resp.ID)
+		}
+	}
+
+	// Malformed JSON can't be recovered from: a parse error ends the session
+	c.send(`{"jsonrpc":`)
+	c.in.Close()
+	resp := c.reply()
+	if resp.Error == nil || resp.Error.Code != codeParseError || string(resp.ID) != "null" {
+		t.Errorf("malformed request response = %+v, want a parse error with a null id", resp)
+	}
+	if err := c.wait(); err == nil {
+		t.Error("Serve returned no error for malformed JSON")
+	}
+}
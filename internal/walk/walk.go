@@ -0,0 +1,42 @@
+package walk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Files calls fn with each file under root, in lexical order. Symlinks to
+// files are followed, but symlinks to directories never are, so a link
+// cycle (a node_modules package linking back to the repo, a vendored link
+// to the root) can't make a scan loop or cover a tree twice. root itself
+// may be a link to a directory. skipDir, when set, is asked about every
+// directory below root by name.
+func Files(root string, skipDir func(name string) bool, fn func(path string) error) error {
+	// A trailing separator makes the walk resolve a root that is a link
+	start := root
+	if info, err := os.Lstat(root); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		start = root + string(filepath.Separator)
+	}
+
+	return filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != start && skipDir != nil && skipDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// Skip links to directories and broken links
+			if target, err := os.Stat(path); err != nil || !target.Mode().IsRegular() {
+				return nil
+			}
+		} else if !entry.Type().IsRegular() {
+			return nil
+		}
+		return fn(path)
+	})
+}
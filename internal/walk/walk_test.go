@@ -0,0 +1,96 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// symlink creates a link, skipping the test where links aren't supported
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+// tree creates empty files under a temporary directory and returns it
+func tree(t *testing.T, paths ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// files lists what Files finds under root, relative to base
+func files(t *testing.T, base, root string, skipDir func(string) bool) []string {
+	t.Helper()
+	found := []string{}
+	done := make(chan error, 1)
+	go func() {
+		done <- Files(root, skipDir, func(path string) error {
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			found = append(found, filepath.ToSlash(rel))
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("walk didn't terminate")
+	}
+	return found
+}
+
+func TestFilesSymlinkCycle(t *testing.T) {
+	root := tree(t, "main.go", "services/user.go", "web/node_modules/app/index.js")
+	// A package linking back to the repo root and a directory linking to
+	// its own parent
+	symlink(t, root, filepath.Join(root, "web/node_modules/app/root"))
+	symlink(t, "..", filepath.Join(root, "services/parent"))
+
+	got := files(t, root, root, nil)
+	want := []string{"main.go", "services/user.go", "web/node_modules/app/index.js"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Files = %v, want each file once with linked directories skipped", got)
+	}
+}
+
+func TestFilesFollowsFileLinks(t *testing.T) {
+	root := tree(t, "shared/errors.go", "services/user.go")
+	symlink(t, filepath.Join(root, "shared/errors.go"), filepath.Join(root, "services/errors.go"))
+	symlink(t, filepath.Join(root, "missing.go"), filepath.Join(root, "services/broken.go"))
+
+	got := files(t, root, root, nil)
+	want := []string{"services/errors.go", "services/user.go", "shared/errors.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestFilesLinkedRoot(t *testing.T) {
+	root := tree(t, "repo/main.go", "repo/vendor/lib/lib.go")
+	link := filepath.Join(root, "current")
+	symlink(t, filepath.Join(root, "repo"), link)
+
+	got := files(t, link, link, func(name string) bool { return name == "vendor" })
+	if want := []string{"main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Files through a linked root = %v, want %v", got, want)
+	}
+}
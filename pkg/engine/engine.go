@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"github.com/loop-hub/code-on-rails/internal/config"
+	core "github.com/loop-hub/code-on-rails/internal/engine"
+	"github.com/loop-hub/code-on-rails/internal/fixer"
+	"github.com/loop-hub/code-on-rails/internal/reporter"
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+// Config is a loaded .code-on-rails.yml
+type Config struct {
+	cfg *config.Config
+}
+
+// LoadConfig loads a config file; an empty path finds the nearest
+// .code-on-rails.yml like the CLI does
+func LoadConfig(path string) (*Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{cfg: cfg}, nil
+}
+
+// Save writes the config back to path, e.g. after blessing files, keeping
+// its comments and layout; an empty path finds the nearest
+// .code-on-rails.yml like LoadConfig
+func (c *Config) Save(path string) error {
+	return config.Save(c.cfg, path)
+}
+
+// Patterns returns the config's own patterns, leaving out remote ones
+func (c *Config) Patterns() []patterns.Pattern {
+	return c.cfg.Patterns
+}
+
+// Engine checks files against a config's patterns, the way cr check does,
+// for programs embedding Code on Rails. It never prints or exits: results
+// and failures are returned, or passed to the callbacks below.
+type Engine struct {
+	Language      string                 // Language of files to find in directories; "" for every supported one
+	IncludeTests  bool                   // Also check test files found in directories
+	Branch        string                 // Branch name for the branch detection method; "" reads it from CI or git
+	TypeFilter    []patterns.PatternType // Only match files against these pattern types
+	StrictVersion bool                   // Files matching an older pattern version need review
+	StrictImports bool                   // Note imports the reference lacks, warning about forbidden ones (default settings.strict_imports)
+	ReferencePath string                 // Match against this one reference file instead of the patterns
+	Ignore        []string               // Deviation elements to drop on top of settings.ignore_deviations
+
+	ReadReference func(path string) ([]byte, error) // Reads reference files, e.g. from a git revision; nil reads them from disk
+	Progress      func(done, total int)             // Called before each file CheckFiles matches
+	OnMatch       func(match patterns.PatternMatch) // Called with each match as soon as it's made
+	OnError       func(path string, err error)      // Called for files that fail to match, which are then skipped; when nil CheckFiles stops at the first failure
+
+	core *core.Engine
+}
+
+// New creates an engine for cfg. A config the engine can't check with, e.g.
+// one naming an unknown check, is reported by Err and by every check.
+func New(cfg *Config) *Engine {
+	c := core.New(cfg.cfg)
+	return &Engine{
+		Language:      c.Language,
+		StrictImports: c.StrictImports,
+		core:          c,
+	}
+}
+
+// Err returns the error the config was rejected with, if any
+func (e *Engine) Err() error {
+	return e.core.Err()
+}
+
+// Detect lists the AI-generated files in the git repository at dir, using
+// the config's detection method
+func (e *Engine) Detect(dir string) ([]string, error) {
+	return e.sync().Detect(dir)
+}
+
+// Files expands directories in paths to the supported files within them;
+// other paths are kept as they are
+func (e *Engine) Files(paths []string) ([]string, error) {
+	return e.sync().Files(paths)
+}
+
+// CheckFiles matches files, and the files within directories, against the
+// patterns. Files outside TypeFilter are left out of the results.
+func (e *Engine) CheckFiles(paths []string) ([]patterns.PatternMatch, error) {
+	return e.sync().CheckFiles(paths)
+}
+
+// CheckSource matches src as the contents of path, e.g. an unsaved editor
+// buffer or a file from a pull request that isn't checked out
+func (e *Engine) CheckSource(path string, src []byte) (*patterns.PatternMatch, error) {
+	return e.sync().CheckSource(path, src)
+}
+
+// PatternCandidates groups the files among matches that no pattern matched
+// the way cr learn would, returning the groups with enough files to learn a
+// pattern from: a sign the config is missing a pattern
+func (e *Engine) PatternCandidates(matches []patterns.PatternMatch) []patterns.PatternCandidate {
+	return e.sync().PatternCandidates(matches)
+}
+
+// Feedback renders matches as the JSON cr feedback writes for AI assistants
+// to fix them from; explicit marks files that were asked for rather than
+// detected as AI-generated
+func (e *Engine) Feedback(matches []patterns.PatternMatch, explicit bool) string {
+	rep := reporter.New(false)
+	rep.Explicit = explicit
+	return e.sync().Feedback(rep, matches)
+}
+
+// FixResult is the outcome of fixing a single file
+type FixResult struct {
+	Path      string
+	Original  []byte
+	Fixed     []byte
+	Applied   []patterns.Deviation // Deviations fixed automatically
+	Remaining []patterns.Deviation // Deviations that need a human or AI
+}
+
+// Changed reports whether any fix modified the file
+func (r *FixResult) Changed() bool {
+	return r.result().Changed()
+}
+
+// Diff renders the fixes as a unified diff
+func (r *FixResult) Diff() string {
+	return fixer.Diff(r.result())
+}
+
+// Write saves the fixed contents back to disk
+func (r *FixResult) Write() error {
+	return fixer.New().Write(r.result())
+}
+
+// result converts r back to the fixer's result
+func (r *FixResult) result() *fixer.Result {
+	return &fixer.Result{Path: r.Path, Original: r.Original, Fixed: r.Fixed, Applied: r.Applied, Remaining: r.Remaining}
+}
+
+// Fix matches files, and the files within directories, and applies the
+// mechanical fixes for their deviations the way cr fix does, e.g. adding
+// missing imports. Nothing is written until a result's Write is called.
+func (e *Engine) Fix(paths []string) ([]*FixResult, error) {
+	results, err := e.sync().Fix(paths)
+	if err != nil {
+		return nil, err
+	}
+	fixes := make([]*FixResult, len(results))
+	for i, r := range results {
+		fixes[i] = &FixResult{Path: r.Path, Original: r.Original, Fixed: r.Fixed, Applied: r.Applied, Remaining: r.Remaining}
+	}
+	return fixes, nil
+}
+
+// Migrate finds the files, and the files within directories, resembling an
+// anti-pattern, returning the closest anti-pattern of each the way cr
+// migrate plans their migration
+func (e *Engine) Migrate(paths []string) ([]patterns.AntiPatternMatch, error) {
+	return e.sync().Migrate(paths)
+}
+
+// Bless adds example to the pattern its file matches the way cr bless does,
+// returning the updated pattern. A zero weight is settings.weights.blessed.
+// Save the config to keep the blessing.
+func (e *Engine) Bless(example patterns.BlessedExample) (*patterns.Pattern, error) {
+	return e.sync().Bless(example)
+}
+
+// BlessAnti records the file at path as an anti-pattern of the pattern it
+// matches, the way cr bless --anti does, returning the updated pattern.
+// Save the config to keep it.
+func (e *Engine) BlessAnti(path, reason string) (*patterns.Pattern, error) {
+	return e.sync().BlessAnti(path, reason)
+}
+
+// sync applies the engine's settings to the engine it wraps
+func (e *Engine) sync() *core.Engine {
+	c := e.core
+	c.Language = e.Language
+	c.IncludeTests = e.IncludeTests
+	c.Branch = e.Branch
+	c.TypeFilter = e.TypeFilter
+	c.StrictVersion = e.StrictVersion
+	c.StrictImports = e.StrictImports
+	c.ReferencePath = e.ReferencePath
+	c.Ignore = e.Ignore
+	c.Progress = e.Progress
+	c.OnMatch = e.OnMatch
+	c.OnError = e.OnError
+	if m := c.Matcher(); m != nil {
+		m.ReadReference = e.ReadReference
+	}
+	return c
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loop-hub/code-on-rails/pkg/patterns"
+)
+
+const refSource = `package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+func Normalize(name string) (string, error) {
+	_ = fmt.Sprint(name)
+	return strings.ToLower(name), nil
+}
+`
+
+// loadTree writes a Go repository with a service pattern declared against
+// services/ref.go and a service missing its strings import, returning the
+// repository and its loaded config
+func loadTree(t *testing.T) (string, *Config) {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"services/ref.go":  refSource,
+		"services/user.go": strings.Replace(refSource, "\t\"strings\"\n", "", 1),
+		".code-on-rails.yml": `version: "1.0"
+language: go
+patterns:
+    - id: service
+      name: Service
+      type: service
+      detection:
+        file_pattern: '*.go'
+      reference: ` + filepath.Join(root, "services", "ref.go") + `
+      confidence: 0.8
+`,
+	}
+	for path, src := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := LoadConfig(filepath.Join(root, ".code-on-rails.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, cfg
+}
+
+func TestEngineCheck(t *testing.T) {
+	root, cfg := loadTree(t)
+	e := New(cfg)
+	if err := e.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Language != "go" {
+		t.Errorf("Language = %q, want the config's go", e.Language)
+	}
+
+	matches, err := e.CheckFiles([]string{filepath.Join(root, "services")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("CheckFiles matched %d files, want 2", len(matches))
+	}
+
+	match, err := e.CheckSource(filepath.Join(root, "services", "order.go"), []byte(refSource))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Pattern == nil || match.Pattern.ID != "service" {
+		t.Errorf("CheckSource matched %v, want pattern service", match.Pattern)
+	}
+}
+
+func TestEngineReadReference(t *testing.T) {
+	root, cfg := loadTree(t)
+	e := New(cfg)
+	read := []string{}
+	e.ReadReference = func(path string) ([]byte, error) {
+		read = append(read, path)
+		return os.ReadFile(path)
+	}
+
+	if _, err := e.CheckFiles([]string{filepath.Join(root, "services", "user.go")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(read) == 0 || read[0] != filepath.Join(root, "services", "ref.go") {
+		t.Errorf("ReadReference read %v, want services/ref.go", read)
+	}
+}
+
+func TestEngineFix(t *testing.T) {
+	root, cfg := loadTree(t)
+	user := filepath.Join(root, "services", "user.go")
+
+	results, err := New(cfg).Fix([]string{user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Changed() || len(results[0].Applied) != 1 {
+		t.Fatalf("Fix = %+v, want one applied fix", results)
+	}
+	if diff := results[0].Diff(); !strings.Contains(diff, `+	"strings"`) {
+		t.Errorf("Diff doesn't add the import:\n%s", diff)
+	}
+	if err := results[0].Write(); err != nil {
+		t.Fatal(err)
+	}
+	if src, _ := os.ReadFile(user); string(src) != refSource {
+		t.Errorf("written file = %s, want the reference's imports", src)
+	}
+}
+
+func TestEngineBless(t *testing.T) {
+	root, cfg := loadTree(t)
+	user := filepath.Join(root, "services", "user.go")
+
+	p, err := New(cfg).Bless(patterns.BlessedExample{Path: user, Reason: "template"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "service" {
+		t.Errorf("Bless returned pattern %s, want service", p.ID)
+	}
+	path := filepath.Join(root, ".code-on-rails.yml")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blessed := saved.Patterns()[0].ConfigBlessed
+	if len(blessed) != 1 || blessed[0].Path != user || blessed[0].Reason != "template" {
+		t.Errorf("saved config_blessed = %+v, want %s", blessed, user)
+	}
+}
+
+func TestEngineInvalidConfig(t *testing.T) {
+	root, _ := loadTree(t)
+	path := filepath.Join(root, ".code-on-rails.yml")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("settings:\n    match_mode: loudest\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(cfg)
+	if e.Err() == nil {
+		t.Fatal("Err() = nil for an unknown match_mode")
+	}
+	if _, err := e.CheckFiles([]string{filepath.Join(root, "services")}); err == nil {
+		t.Error("CheckFiles succeeded with a rejected config")
+	}
+}
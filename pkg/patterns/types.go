@@ -1,21 +1,69 @@
 package patterns
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Pattern represents a detected code pattern in the codebase
 type Pattern struct {
-	ID                string           `yaml:"id"`
-	Name              string           `yaml:"name"`
-	Type              PatternType      `yaml:"type"`
-	Version           string           `yaml:"version"`
-	Detection         DetectionRule    `yaml:"detection"`
-	Structure         CodeStructure    `yaml:"structure"`
-	AnnotatedGolden   []GoldenExample  `yaml:"annotated_golden,omitempty"`
-	ConfigBlessed     []BlessedExample `yaml:"config_blessed,omitempty"`
-	Discovered        []Example        `yaml:"discovered,omitempty"`
-	AntiPatterns      []AntiPattern    `yaml:"anti_patterns,omitempty"`
-	Confidence        float64          `yaml:"confidence"`
-	SeenCount         int              `yaml:"seen_count"`
+	ID              string           `yaml:"id"`
+	Name            string           `yaml:"name"`
+	Type            PatternType      `yaml:"type"`
+	Version         string           `yaml:"version"`
+	Detection       DetectionRule    `yaml:"detection"`
+	Structure       CodeStructure    `yaml:"structure"`
+	AnnotatedGolden []GoldenExample  `yaml:"annotated_golden,omitempty"`
+	ConfigBlessed   []BlessedExample `yaml:"config_blessed,omitempty"`
+	Discovered      []Example        `yaml:"discovered,omitempty"`
+	AntiPatterns    []AntiPattern    `yaml:"anti_patterns,omitempty"`
+	Confidence      float64          `yaml:"confidence"`
+	SeenCount       int              `yaml:"seen_count"`
+	Fingerprint     string           `yaml:"fingerprint,omitempty"`
+	History         []PatternVersion `yaml:"history,omitempty"`
+	Language        string           `yaml:"language,omitempty"` // Set when a config holds patterns for several languages
+	Enabled         *bool            `yaml:"enabled,omitempty"`  // Nil means enabled; false mutes the pattern but keeps its examples
+	Remote          bool             `yaml:"-"`                  // Read-only, from the config's patterns_url; never saved
+
+	// Reference declares a hand-written pattern: files matching Detection are
+	// checked against this file, treated as a blessed example
+	Reference       string  `yaml:"reference,omitempty"`
+	ReferenceWeight float64 `yaml:"reference_weight,omitempty"` // Defaults to the blessed tier weight
+}
+
+// IsEnabled reports whether files are matched against the pattern
+func (p Pattern) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// IsDeclared reports whether the pattern was written by hand rather than learned
+func (p Pattern) IsDeclared() bool {
+	return p.Reference != ""
+}
+
+// Blessed returns the pattern's blessed examples, including a declared reference
+func (p Pattern) Blessed() []BlessedExample {
+	if !p.IsDeclared() {
+		return p.ConfigBlessed
+	}
+	return append([]BlessedExample{{
+		Path:      p.Reference,
+		BlessedBy: "config",
+		Reason:    "declared pattern reference",
+		Weight:    p.ReferenceWeight,
+	}}, p.ConfigBlessed...)
+}
+
+// PatternVersion records a previous version of a pattern's structure
+type PatternVersion struct {
+	Version     string   `yaml:"version"`
+	Fingerprint string   `yaml:"fingerprint"`
+	Required    []string `yaml:"required"`
 }
 
 // GoldenExample represents an annotated golden example
@@ -24,6 +72,7 @@ type GoldenExample struct {
 	Function     string    `yaml:"function,omitempty"`
 	Pattern      string    `yaml:"pattern"`
 	Version      string    `yaml:"version,omitempty"`
+	Supersedes   string    `yaml:"supersedes,omitempty"`
 	BlessedBy    string    `yaml:"blessed_by"`
 	BlessedDate  time.Time `yaml:"blessed_date"`
 	Reason       string    `yaml:"reason"`
@@ -49,6 +98,32 @@ type Example struct {
 	Weight          float64 `yaml:"weight"`
 }
 
+// TierWeights are the weighted-score multipliers of each kind of reference:
+// annotated golden examples, config-blessed examples and discovered examples
+type TierWeights struct {
+	Golden     float64 `yaml:"golden,omitempty"`
+	Blessed    float64 `yaml:"blessed,omitempty"`
+	Discovered float64 `yaml:"discovered,omitempty"`
+}
+
+// DefaultTierWeights ranks golden examples above blessed ones, and both above
+// discovered examples
+var DefaultTierWeights = TierWeights{Golden: 2.0, Blessed: 1.5, Discovered: 1.0}
+
+// OrDefault fills in unset weights from DefaultTierWeights
+func (w TierWeights) OrDefault() TierWeights {
+	if w.Golden == 0 {
+		w.Golden = DefaultTierWeights.Golden
+	}
+	if w.Blessed == 0 {
+		w.Blessed = DefaultTierWeights.Blessed
+	}
+	if w.Discovered == 0 {
+		w.Discovered = DefaultTierWeights.Discovered
+	}
+	return w
+}
+
 // AntiPattern represents a pattern to avoid
 type AntiPattern struct {
 	Path           string     `yaml:"path"`
@@ -59,6 +134,25 @@ type AntiPattern struct {
 	MigrationGuide string     `yaml:"migration_guide,omitempty"`
 }
 
+// AntiPatternCandidate is code that was introduced and then removed or
+// largely rewritten soon after, suggesting the team moved away from it.
+// Candidates are only suggestions until confirmed with cr bless --anti.
+type AntiPatternCandidate struct {
+	Path       string
+	Commit     string // Commit holding the original version (git show Commit:Path)
+	Introduced time.Time
+	Replaced   time.Time
+	Removed    bool    // Deleted rather than rewritten
+	Churn      float64 // Fraction of the original lines replaced, 0-1
+}
+
+// PatternCandidate is a group of files no pattern matched that share a
+// structure, suggesting a pattern the config doesn't know about yet
+type PatternCandidate struct {
+	Type  PatternType // What cr learn would learn the files as
+	Files []string
+}
+
 // PatternType represents the category of pattern
 type PatternType string
 
@@ -72,18 +166,53 @@ const (
 	PatternUtil        PatternType = "util"
 
 	// TypeScript/React patterns
-	PatternComponent     PatternType = "component"      // React functional/class components
-	PatternHook          PatternType = "hook"           // Custom React hooks
-	PatternContext       PatternType = "context"        // React context providers
-	PatternPage          PatternType = "page"           // Next.js/React Router pages
-	PatternAPI           PatternType = "api"            // API route handlers (Next.js, Express)
-	PatternStore         PatternType = "store"          // State management (Redux, Zustand)
-	PatternTypeDefinition PatternType = "types"          // TypeScript type definitions
-	PatternTest          PatternType = "test"           // Test files
-	PatternStorybook     PatternType = "storybook"      // Storybook stories
-	PatternStyled        PatternType = "styled"         // Styled components
+	PatternComponent      PatternType = "component" // React functional/class components
+	PatternHook           PatternType = "hook"      // Custom React hooks
+	PatternContext        PatternType = "context"   // React context providers
+	PatternPage           PatternType = "page"      // Next.js/React Router pages
+	PatternAPI            PatternType = "api"       // API route handlers (Next.js, Express)
+	PatternStore          PatternType = "store"     // State management (Redux, Zustand)
+	PatternTypeDefinition PatternType = "types"     // TypeScript type definitions
+	PatternTest           PatternType = "test"      // Test files
+	PatternStorybook      PatternType = "storybook" // Storybook stories
+	PatternStyled         PatternType = "styled"    // Styled components
 )
 
+// PatternTypes lists every pattern type the tool understands
+var PatternTypes = []PatternType{
+	PatternHTTPHandler,
+	PatternService,
+	PatternRepository,
+	PatternMiddleware,
+	PatternModel,
+	PatternUtil,
+	PatternComponent,
+	PatternHook,
+	PatternContext,
+	PatternPage,
+	PatternAPI,
+	PatternStore,
+	PatternTypeDefinition,
+	PatternTest,
+	PatternStorybook,
+	PatternStyled,
+}
+
+// ParsePatternType converts a name into a known PatternType
+func ParsePatternType(name string) (PatternType, error) {
+	for _, t := range PatternTypes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+
+	valid := make([]string, 0, len(PatternTypes))
+	for _, t := range PatternTypes {
+		valid = append(valid, string(t))
+	}
+	return "", fmt.Errorf("unknown pattern type %q (valid: %s)", name, strings.Join(valid, ", "))
+}
+
 // DetectionRule defines how to detect this pattern
 type DetectionRule struct {
 	FilePattern   string `yaml:"file_pattern"`
@@ -100,12 +229,21 @@ type CodeStructure struct {
 	Optional []string           `yaml:"optional"`
 }
 
+// Fingerprint returns a stable hash of the required structure
+func (s CodeStructure) Fingerprint() string {
+	required := append([]string{}, s.Required...)
+	sort.Strings(required)
+	sum := sha256.Sum256([]byte(strings.Join(required, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
 // StructureElement represents a component of the code structure
 type StructureElement struct {
-	Name     string      `yaml:"name"`
-	Type     ElementType `yaml:"type"`
-	Pattern  string      `yaml:"pattern"`
-	Examples []string    `yaml:"examples"`
+	Name       string      `yaml:"name"`
+	Type       ElementType `yaml:"type"`
+	Pattern    string      `yaml:"pattern"`
+	Examples   []string    `yaml:"examples"`
+	Importance float64     `yaml:"importance,omitempty"` // Share of the pattern's examples that have it, 0-1; 0 when not recorded
 }
 
 // ElementType categorizes structural elements
@@ -120,43 +258,103 @@ const (
 	ElementErrorHandle ElementType = "error_handling"
 	ElementValidation  ElementType = "validation"
 	ElementTransaction ElementType = "transaction"
+	ElementField       ElementType = "field"     // Struct field shared by models
+	ElementAttribute   ElementType = "attribute" // C# attribute, e.g. ApiController
 
 	// TypeScript/React elements
-	ElementInterface    ElementType = "interface"     // TypeScript interface
-	ElementTypeAlias    ElementType = "type_alias"    // TypeScript type alias
-	ElementEnum         ElementType = "enum"          // TypeScript enum
-	ElementJSXElement   ElementType = "jsx_element"   // JSX/TSX element
-	ElementHookCall     ElementType = "hook_call"     // React hook usage (useState, useEffect, etc.)
-	ElementProp         ElementType = "prop"          // Component prop
-	ElementState        ElementType = "state"         // Component state
-	ElementEffect       ElementType = "effect"        // useEffect/side effects
-	ElementExport       ElementType = "export"        // Export statement
+	ElementInterface     ElementType = "interface"      // TypeScript interface
+	ElementTypeAlias     ElementType = "type_alias"     // TypeScript type alias
+	ElementEnum          ElementType = "enum"           // TypeScript enum
+	ElementJSXElement    ElementType = "jsx_element"    // JSX/TSX element
+	ElementHookCall      ElementType = "hook_call"      // React hook usage (useState, useEffect, etc.)
+	ElementProp          ElementType = "prop"           // Component prop
+	ElementState         ElementType = "state"          // Component state
+	ElementEffect        ElementType = "effect"         // useEffect/side effects
+	ElementExport        ElementType = "export"         // Export statement
 	ElementDefaultExport ElementType = "default_export" // Default export
+
+	// Test elements
+	ElementTestConvention ElementType = "test_convention" // Test style such as subtests or describe blocks
 )
 
 // PatternMatch represents how well code matches a pattern
 type PatternMatch struct {
-	Pattern        *Pattern
-	FilePath       string
-	Score          float64
-	WeightedScore  float64
-	MatchType      string // "annotated_golden", "config_blessed", "discovered"
-	GoldenRef      *GoldenExample
-	BlessedRef     *BlessedExample
-	DiscoveredRef  *Example
-	Deviations     []Deviation
-	AutoApprove    bool
+	Pattern         *Pattern
+	FilePath        string
+	Score           float64
+	WeightedScore   float64
+	MatchType       string // "annotated_golden", "config_blessed", "discovered", "consensus"
+	GoldenRef       *GoldenExample
+	BlessedRef      *BlessedExample
+	DiscoveredRef   *Example
+	Deviations      []Deviation
+	Suppressed      []SuppressedDeviation // Deviations allowed by annotations
+	AutoApprove     bool
+	MatchedVersion  string             // Pattern version the file conforms to
+	AntiPatternHits []AntiPatternMatch // Anti-patterns the file resembles; never auto-approved
+	References      []string           // Reference paths behind a consensus match
+	RunnerUps       []PatternScore     // Best scores of the other pattern types tried, highest first
+	Breakdown       *ScoreBreakdown    // How Score was reached; nil when the reference couldn't be compared
+}
+
+// ScoreBreakdown splits a score into the points each component took off
+// 100: Score = 100 - Imports - ErrorHandling - Checks - Structure, floored
+// at 0. Anti-pattern hits don't lower the score; they block auto-approval.
+type ScoreBreakdown struct {
+	Imports             float64 // Missing reference imports
+	ErrorHandling       float64 // Missing error handling
+	Checks              float64 // Other deviations: checks, conventions, mixed responsibilities
+	Structure           float64 // Lost to structural dissimilarity
+	StructureSimilarity float64 // 0-1
+	AntiPatternHits     int
+}
+
+// PatternScore is the best score a file reached against a pattern type
+type PatternScore struct {
+	Type  PatternType
+	Score float64
+}
+
+// AntiPatternMatch records a file that resembles a known anti-pattern
+type AntiPatternMatch struct {
+	FilePath    string
+	Pattern     *Pattern
+	AntiPattern AntiPattern
+	Similarity  float64 // 0-1
+	Replacement string  // Golden or blessed example to migrate towards
+}
+
+// SuppressedDeviation is a deviation silenced by an allow annotation
+type SuppressedDeviation struct {
+	Deviation
+	Reason string
+}
+
+// ExpiredSuppression is an allow annotation past its expiry date, which no
+// longer silences its deviation
+type ExpiredSuppression struct {
+	Path       string
+	LineNumber int
+	Element    string
+	Function   string // Function the annotation covers, empty for the whole file
+	Reason     string
+	Expires    time.Time
+}
+
+// IsOutdated reports whether the file conforms to an older pattern version
+func (m PatternMatch) IsOutdated() bool {
+	return m.Pattern != nil && m.MatchedVersion != "" && m.MatchedVersion != m.Pattern.Version
 }
 
 // Deviation describes how code differs from pattern
 type Deviation struct {
-	Type        DeviationType
-	Element     string
-	Expected    string
-	Actual      string
-	Severity    Severity
-	Suggestion  string
-	LineNumber  int
+	Type       DeviationType
+	Element    string
+	Expected   string
+	Actual     string
+	Severity   Severity
+	Suggestion string
+	LineNumber int
 }
 
 // DeviationType categorizes deviations
@@ -168,6 +366,9 @@ const (
 	DeviationNovel     DeviationType = "novel"
 )
 
+// DeviationTypes lists every deviation type
+var DeviationTypes = []DeviationType{DeviationMissing, DeviationDifferent, DeviationNovel}
+
 // Severity levels for deviations
 type Severity string
 
@@ -177,27 +378,94 @@ const (
 	SeverityInfo    Severity = "info"
 )
 
+// Severities lists every severity, most severe first
+var Severities = []Severity{SeverityError, SeverityWarning, SeverityInfo}
+
 // FileInfo represents a parsed file
 type FileInfo struct {
-	Path      string
-	Package   string
-	Imports   []string
-	Functions []FunctionInfo
-	Types     []TypeInfo
+	Path          string
+	Package       string
+	Imports       []string
+	ImportLines   map[string]int    // Import path -> line it appears on
+	ImportAliases map[string]string // Import path -> name it is imported under, "." or "_"; Go imports given one only
+	Functions     []FunctionInfo
+	Types         []TypeInfo
+	Attributes    []string // C# attributes, e.g. ApiController or HttpGet
+}
+
+// IsTestFile checks if a path is a Go, TypeScript, JavaScript or C# test file
+func IsTestFile(path string) bool {
+	lower := strings.ToLower(filepath.ToSlash(path))
+	return strings.HasSuffix(lower, "_test.go") ||
+		strings.HasSuffix(path, "Tests.cs") || // Case matters: Latest.cs isn't a test
+		strings.HasSuffix(path, "Test.cs") ||
+		strings.HasSuffix(lower, ".test.ts") ||
+		strings.HasSuffix(lower, ".test.tsx") ||
+		strings.HasSuffix(lower, ".test.js") ||
+		strings.HasSuffix(lower, ".test.jsx") ||
+		strings.HasSuffix(lower, ".spec.ts") ||
+		strings.HasSuffix(lower, ".spec.tsx") ||
+		strings.HasSuffix(lower, ".spec.js") ||
+		strings.HasSuffix(lower, ".spec.jsx") ||
+		strings.Contains(lower, "__tests__/") ||
+		strings.Contains(lower, "__mocks__/")
+}
+
+// FileComparison explains how similar two files are, for diagnosing scores
+type FileComparison struct {
+	FileA         string
+	FileB         string
+	Structure     float64 // Cosine similarity of the files' structure
+	SharedImports []string
+	OnlyAImports  []string
+	OnlyBImports  []string
+	SharedExports []string // Exported functions and types
+	OnlyAExports  []string
+	OnlyBExports  []string
+}
+
+// SupportsFile checks if a file is source code in the given language. An
+// unknown or empty language accepts every supported extension.
+func SupportsFile(language, path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return language == "go" || !isKnownLanguage(language)
+	case ".ts", ".tsx":
+		return language == "typescript" || language == "ts" || language == "react" || !isKnownLanguage(language)
+	case ".js", ".jsx":
+		return language == "javascript" || language == "js" || language == "react" || !isKnownLanguage(language)
+	case ".cs":
+		return language == "csharp" || !isKnownLanguage(language)
+	}
+	return false
+}
+
+// isKnownLanguage checks if a language restricts which files are supported
+func isKnownLanguage(language string) bool {
+	switch language {
+	case "go", "typescript", "ts", "javascript", "js", "react", "csharp":
+		return true
+	}
+	return false
 }
 
 // FunctionInfo represents a function or method
 type FunctionInfo struct {
 	Name       string
 	Receiver   string
-	Parameters []string
-	Returns    []string
-	Body       string
+	Parameters []string // Parameter types, one entry per parameter
+	Returns    []string // Result types, one entry per result
+	Body       string   // Raw body source including braces
+	Line       int      // Line the declaration starts on
+	Exported   bool
 }
 
 // TypeInfo represents a type definition
 type TypeInfo struct {
-	Name   string
-	Kind   string // struct, interface, etc.
-	Fields []string
+	Name     string
+	Kind     string // struct, interface, etc.
+	Fields   []string
+	Embeds   []string // Embedded struct types, e.g. gorm.Model, or C# base types
+	Line     int      // Line the declaration starts on
+	Exported bool
 }